@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"syscall"
 	"time"
 
@@ -28,9 +29,17 @@ func main() {
 	apiToken := getEnv("API_TOKEN", "")
 	templatesPath := getEnv("TEMPLATES_PATH", "./internal/templates")
 	staticPath := getEnv("STATIC_PATH", "./static")
+	attachmentsStorage := getEnv("ATTACHMENTS_STORAGE", "disk")
+	attachmentsPath := getEnv("ATTACHMENTS_PATH", "./attachments")
+	attachmentsMaxSize, err := strconv.ParseInt(getEnv("ATTACHMENTS_MAX_SIZE", "5242880"), 10, 64)
+	if err != nil {
+		log.Fatalf("Invalid ATTACHMENTS_MAX_SIZE: %v", err)
+	}
 
-	// Connect to database
-	db, err := sql.Open("sqlite3", dbPath)
+	// Connect to database. Writes wait for one another rather than failing at once,
+	// since a background import may hold the database while it writes, and WAL
+	// lets reads go ahead meanwhile.
+	db, err := sql.Open("sqlite3", dbPath+"?_busy_timeout=10000&_journal_mode=WAL")
 	if err != nil {
 		log.Fatalf("Failed to connect to database: %v", err)
 	}
@@ -48,12 +57,59 @@ func main() {
 
 	// Initialize dependencies
 	repo := repository.NewSQLiteRepository(db)
-	dictSvc := services.NewDictionaryService()
-	wordSvc := services.NewWordService(repo, dictSvc)
-	handler := api.NewHandler(wordSvc)
+
+	// Set up full-text search (needs a build with -tags sqlite_fts5)
+	ftsEnabled, err := repo.InitSearch(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to initialize search index: %v", err)
+	}
+	if !ftsEnabled {
+		log.Println("FTS5 not available, search falls back to substring matching")
+	}
+
+	// Index words created before fuzzy suggestions existed
+	indexed, err := repo.IndexTrigrams(context.Background())
+	if err != nil {
+		log.Fatalf("Failed to build suggestion index: %v", err)
+	}
+	if indexed > 0 {
+		log.Printf("Indexed %d words for suggestions", indexed)
+	}
+
+	dictSvc := services.NewDictionaryService().WithCache(repo)
+	wordSvc := services.NewWordService(repo, repo, dictSvc)
+	fieldSvc := services.NewFieldService(repo)
+
+	// Attachments are stored on disk by default, or as BLOBs alongside the words
+	var storage services.AttachmentStorage
+	switch attachmentsStorage {
+	case "disk":
+		storage, err = services.NewDiskStorage(attachmentsPath)
+		if err != nil {
+			log.Fatalf("Failed to initialize attachment storage: %v", err)
+		}
+	case "sqlite":
+		storage = services.NewDatabaseStorage(repo)
+	default:
+		log.Fatalf("Invalid ATTACHMENTS_STORAGE %q: must be disk or sqlite", attachmentsStorage)
+	}
+	attachmentSvc := services.NewAttachmentService(repo, repo, storage, attachmentsMaxSize)
+
+	savedSearchSvc := services.NewSavedSearchService(repo, repo)
+	importProfileSvc := services.NewImportProfileService(repo, repo)
+
+	// Run imports in the background, so that large files outlast no request timeout
+	importJobSvc := services.NewImportJobService(repo, wordSvc)
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	if err := importJobSvc.Start(jobsCtx); err != nil {
+		log.Fatalf("Failed to start import jobs: %v", err)
+	}
+
+	handler := api.NewHandler(wordSvc, fieldSvc, attachmentSvc, savedSearchSvc, importProfileSvc, importJobSvc)
 
 	// Initialize web handler
-	webHandler, err := api.NewWebHandler(wordSvc, templatesPath)
+	webHandler, err := api.NewWebHandler(wordSvc, fieldSvc, attachmentSvc, savedSearchSvc, importProfileSvc, importJobSvc, templatesPath)
 	if err != nil {
 		log.Fatalf("Failed to load templates: %v", err)
 	}
@@ -83,6 +139,7 @@ func main() {
 	<-quit
 
 	log.Println("Shutting down server...")
+	stopJobs()
 
 	// Graceful shutdown with timeout
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -0,0 +1,112 @@
+// Package anki reads and writes Anki .apkg packages: a zip holding a SQLite collection
+// in the collection.anki2 (schema 11) format, which desktop Anki and AnkiDroid both import.
+package anki
+
+import (
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Model is a note type with one card template
+type Model struct {
+	ID     int64
+	Name   string
+	Fields []string // field names, in order; the first one is the sort field
+	Front  string   // question template
+	Back   string   // answer template
+	CSS    string
+}
+
+// Note is a note of the deck's model; Fields hold HTML in model field order.
+// ModelID, Created and Modified are filled in by ReadPackage; WritePackage uses
+// the deck's model and the export time instead.
+type Note struct {
+	GUID     string
+	Fields   []string
+	Tags     []string
+	ModelID  int64
+	Created  time.Time
+	Modified time.Time
+}
+
+// Deck is the content of a package: one deck of notes of a single model
+type Deck struct {
+	ID    int64
+	Name  string
+	Model Model
+	Notes []Note
+
+	// Stream, if set, produces the notes instead of Notes: WritePackage calls it
+	// once and it passes each note to add in order, so a large deck is written
+	// without being held in memory
+	Stream func(add func(Note) error) error
+}
+
+// GUID derives a stable note GUID from a key, so that importing a newer export
+// of the same words updates the existing notes instead of duplicating them
+func GUID(key string) string {
+	sum := sha1.Sum([]byte(key))
+	return base64.RawURLEncoding.EncodeToString(sum[:9])
+}
+
+// Tag converts a tag to Anki's form, in which tags are separated by spaces
+func Tag(tag string) string {
+	return strings.Join(strings.Fields(tag), "_")
+}
+
+// htmlTag matches HTML tags, which Anki strips before sorting and duplicate checks
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// sortField returns the text Anki sorts and checks duplicates by for a field
+func sortField(field string) string {
+	return html.UnescapeString(htmlTag.ReplaceAllString(field, ""))
+}
+
+// lineBreak matches the HTML Anki's editor uses to break lines
+var lineBreak = regexp.MustCompile(`(?i)<br\s*/?>|</div>|</p>`)
+
+// soundTag matches Anki's audio references, which have no text equivalent
+var soundTag = regexp.MustCompile(`\[sound:[^\]]*\]`)
+
+// Text converts an HTML field to plain text, keeping line breaks
+func Text(field string) string {
+	text := lineBreak.ReplaceAllString(field, "\n")
+	text = soundTag.ReplaceAllString(htmlTag.ReplaceAllString(text, ""), "")
+	text = strings.ReplaceAll(html.UnescapeString(text), "\u00a0", " ")
+
+	lines := strings.Split(text, "\n")
+	kept := lines[:0]
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// checksum is Anki's duplicate check value: the first 8 hex digits of the SHA-1 of
+// the sort field
+func checksum(field string) int64 {
+	sum := sha1.Sum([]byte(sortField(field)))
+	return int64(binary.BigEndian.Uint32(sum[:4]))
+}
+
+// joinTags formats tags the way Anki stores them, space separated and padded
+func joinTags(tags []string) string {
+	var converted []string
+	for _, tag := range tags {
+		if tag = Tag(tag); tag != "" {
+			converted = append(converted, tag)
+		}
+	}
+	if len(converted) == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" %s ", strings.Join(converted, " "))
+}
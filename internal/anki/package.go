@@ -0,0 +1,251 @@
+package anki
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// schema creates an empty collection.anki2 database
+const schema = `
+CREATE TABLE col (
+    id INTEGER PRIMARY KEY, crt INTEGER NOT NULL, mod INTEGER NOT NULL, scm INTEGER NOT NULL,
+    ver INTEGER NOT NULL, dty INTEGER NOT NULL, usn INTEGER NOT NULL, ls INTEGER NOT NULL,
+    conf TEXT NOT NULL, models TEXT NOT NULL, decks TEXT NOT NULL, dconf TEXT NOT NULL, tags TEXT NOT NULL
+);
+CREATE TABLE notes (
+    id INTEGER PRIMARY KEY, guid TEXT NOT NULL, mid INTEGER NOT NULL, mod INTEGER NOT NULL,
+    usn INTEGER NOT NULL, tags TEXT NOT NULL, flds TEXT NOT NULL, sfld INTEGER NOT NULL,
+    csum INTEGER NOT NULL, flags INTEGER NOT NULL, data TEXT NOT NULL
+);
+CREATE TABLE cards (
+    id INTEGER PRIMARY KEY, nid INTEGER NOT NULL, did INTEGER NOT NULL, ord INTEGER NOT NULL,
+    mod INTEGER NOT NULL, usn INTEGER NOT NULL, type INTEGER NOT NULL, queue INTEGER NOT NULL,
+    due INTEGER NOT NULL, ivl INTEGER NOT NULL, factor INTEGER NOT NULL, reps INTEGER NOT NULL,
+    lapses INTEGER NOT NULL, left INTEGER NOT NULL, odue INTEGER NOT NULL, odid INTEGER NOT NULL,
+    flags INTEGER NOT NULL, data TEXT NOT NULL
+);
+CREATE TABLE revlog (
+    id INTEGER PRIMARY KEY, cid INTEGER NOT NULL, usn INTEGER NOT NULL, ease INTEGER NOT NULL,
+    ivl INTEGER NOT NULL, lastIvl INTEGER NOT NULL, factor INTEGER NOT NULL, time INTEGER NOT NULL,
+    type INTEGER NOT NULL
+);
+CREATE TABLE graves (usn INTEGER NOT NULL, oid INTEGER NOT NULL, type INTEGER NOT NULL);
+CREATE INDEX ix_notes_usn ON notes (usn);
+CREATE INDEX ix_cards_usn ON cards (usn);
+CREATE INDEX ix_revlog_usn ON revlog (usn);
+CREATE INDEX ix_cards_nid ON cards (nid);
+CREATE INDEX ix_cards_sched ON cards (did, queue, due);
+CREATE INDEX ix_revlog_cid ON revlog (cid);
+CREATE INDEX ix_notes_csum ON notes (csum);
+`
+
+// defaultDeckConfig is the deck options group every deck points at
+const defaultDeckConfig = `{"1": {"id": 1, "name": "Default", "mod": 0, "usn": 0, "maxTaken": 60,
+"autoplay": true, "timer": 0, "replayq": true, "dyn": false,
+"new": {"bury": true, "delays": [1, 10], "initialFactor": 2500, "ints": [1, 4, 7], "order": 1, "perDay": 20, "separate": true},
+"lapse": {"delays": [10], "leechAction": 0, "leechFails": 8, "minInt": 1, "mult": 0},
+"rev": {"bury": true, "ease4": 1.3, "fuzz": 0.05, "ivlFct": 1, "maxIvl": 36500, "minSpace": 1, "perDay": 100}}}`
+
+// fieldSeparator separates note fields in the flds column
+const fieldSeparator = "\x1f"
+
+// WritePackage writes the deck as an .apkg file
+func WritePackage(w io.Writer, deck *Deck) error {
+	dir, err := os.MkdirTemp("", "apkg")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "collection.anki2")
+	if err := writeCollection(path, deck); err != nil {
+		return err
+	}
+
+	collection, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open collection: %w", err)
+	}
+	defer collection.Close()
+
+	archive := zip.NewWriter(w)
+	entry, err := archive.Create("collection.anki2")
+	if err != nil {
+		return fmt.Errorf("failed to write package: %w", err)
+	}
+	if _, err := io.Copy(entry, collection); err != nil {
+		return fmt.Errorf("failed to write package: %w", err)
+	}
+
+	// The media map is required even when the package has no media files
+	entry, err = archive.Create("media")
+	if err != nil {
+		return fmt.Errorf("failed to write package: %w", err)
+	}
+	if _, err := io.WriteString(entry, "{}"); err != nil {
+		return fmt.Errorf("failed to write package: %w", err)
+	}
+
+	return archive.Close()
+}
+
+// writeCollection creates the Anki collection database for a deck at path
+func writeCollection(path string, deck *Deck) error {
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(schema); err != nil {
+		return fmt.Errorf("failed to create collection: %w", err)
+	}
+
+	now := time.Now()
+	col, err := collectionRow(deck, now)
+	if err != nil {
+		return err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	_, err = tx.Exec(`INSERT INTO col VALUES (1, ?, ?, ?, 11, 0, 0, 0, ?, ?, ?, ?, '{}')`,
+		dayStart.Unix(), now.UnixMilli(), now.UnixMilli(), col.conf, col.models, col.decks, defaultDeckConfig)
+	if err != nil {
+		return fmt.Errorf("failed to write collection: %w", err)
+	}
+
+	// Note and card IDs are creation times in milliseconds; consecutive values keep
+	// them unique and preserve the export order as the new card order
+	base := now.UnixMilli()
+	i := 0
+	add := func(note Note) error {
+		id := base + int64(i)
+		i++
+		sfld := ""
+		if len(note.Fields) > 0 {
+			sfld = sortField(note.Fields[0])
+		}
+
+		_, err := tx.Exec(`INSERT INTO notes VALUES (?, ?, ?, ?, -1, ?, ?, ?, ?, 0, '')`,
+			id, note.GUID, deck.Model.ID, now.Unix(), joinTags(note.Tags),
+			strings.Join(note.Fields, fieldSeparator), sfld, checksum(sfld))
+		if err != nil {
+			return fmt.Errorf("failed to write note: %w", err)
+		}
+
+		_, err = tx.Exec(`INSERT INTO cards VALUES (?, ?, ?, 0, ?, -1, 0, 0, ?, 0, 0, 0, 0, 0, 0, 0, 0, '')`,
+			id, id, deck.ID, now.Unix(), i)
+		if err != nil {
+			return fmt.Errorf("failed to write card: %w", err)
+		}
+		return nil
+	}
+
+	if deck.Stream != nil {
+		if err := deck.Stream(add); err != nil {
+			return err
+		}
+	} else {
+		for _, note := range deck.Notes {
+			if err := add(note); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit collection: %w", err)
+	}
+	return nil
+}
+
+// colJSON holds the JSON columns of the col row
+type colJSON struct {
+	conf, models, decks string
+}
+
+// collectionRow builds the collection configuration, note type and deck list
+func collectionRow(deck *Deck, now time.Time) (colJSON, error) {
+	fields := make([]map[string]interface{}, len(deck.Model.Fields))
+	for i, name := range deck.Model.Fields {
+		fields[i] = map[string]interface{}{
+			"name": name, "ord": i, "sticky": false, "rtl": false,
+			"font": "Arial", "size": 20, "media": []string{},
+		}
+	}
+
+	model := map[string]interface{}{
+		"id":    deck.Model.ID,
+		"name":  deck.Model.Name,
+		"type":  0,
+		"mod":   now.Unix(),
+		"usn":   -1,
+		"sortf": 0,
+		"did":   deck.ID,
+		"flds":  fields,
+		"tmpls": []map[string]interface{}{{
+			"name": "Card 1", "ord": 0, "qfmt": deck.Model.Front, "afmt": deck.Model.Back,
+			"did": nil, "bqfmt": "", "bafmt": "",
+		}},
+		"css":       deck.Model.CSS,
+		"latexPre":  "\\documentclass[12pt]{article}\n\\special{papersize=3in,5in}\n\\usepackage{amssymb,amsmath}\n\\pagestyle{empty}\n\\setlength{\\parindent}{0in}\n\\begin{document}\n",
+		"latexPost": "\\end{document}",
+		"latexsvg":  false,
+		// The card is generated whenever the first field is filled in
+		"req":  []interface{}{[]interface{}{0, "any", []int{0}}},
+		"tags": []string{},
+		"vers": []int{},
+	}
+
+	decks := map[string]interface{}{
+		"1":                        newDeck(1, "Default", now),
+		fmt.Sprintf("%d", deck.ID): newDeck(deck.ID, deck.Name, now),
+	}
+
+	conf := map[string]interface{}{
+		"activeDecks": []int64{deck.ID}, "curDeck": deck.ID, "curModel": fmt.Sprintf("%d", deck.Model.ID),
+		"newSpread": 0, "collapseTime": 1200, "timeLim": 0, "estTimes": true, "dueCounts": true,
+		"nextPos": len(deck.Notes) + 1, "sortType": "noteFld", "sortBackwards": false, "addToCur": true,
+	}
+
+	var col colJSON
+	for _, part := range []struct {
+		dest  *string
+		value interface{}
+	}{
+		{&col.conf, conf},
+		{&col.models, map[string]interface{}{fmt.Sprintf("%d", deck.Model.ID): model}},
+		{&col.decks, decks},
+	} {
+		data, err := json.Marshal(part.value)
+		if err != nil {
+			return colJSON{}, fmt.Errorf("failed to marshal collection: %w", err)
+		}
+		*part.dest = string(data)
+	}
+	return col, nil
+}
+
+// newDeck returns the JSON form of a regular deck using the default options group
+func newDeck(id int64, name string, now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"id": id, "name": name, "desc": "", "mod": now.Unix(), "usn": -1, "dyn": 0, "conf": 1,
+		"collapsed": false, "browserCollapsed": false, "extendNew": 10, "extendRev": 50,
+		"newToday": []int{0, 0}, "revToday": []int{0, 0}, "lrnToday": []int{0, 0}, "timeToday": []int{0, 0},
+	}
+}
@@ -0,0 +1,282 @@
+package anki
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// openPackage unzips a package and opens its collection
+func openPackage(t *testing.T, data []byte) *sql.DB {
+	t.Helper()
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("package is not a zip: %v", err)
+	}
+
+	var collection []byte
+	for _, file := range archive.File {
+		rc, err := file.Open()
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", file.Name, err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+
+		switch file.Name {
+		case "collection.anki2":
+			collection = content
+		case "media":
+			if string(content) != "{}" {
+				t.Errorf("media = %s, want {}", content)
+			}
+		}
+	}
+	if collection == nil {
+		t.Fatal("package has no collection.anki2")
+	}
+
+	path := filepath.Join(t.TempDir(), "collection.anki2")
+	if err := os.WriteFile(path, collection, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestWritePackage(t *testing.T) {
+	deck := &Deck{
+		ID:   2001,
+		Name: "Vocabulary",
+		Model: Model{
+			ID:     1001,
+			Name:   "Word",
+			Fields: []string{"Word", "Meaning"},
+			Front:  "{{Word}}",
+			Back:   "{{FrontSide}}<hr id=answer>{{Meaning}}",
+		},
+		Notes: []Note{
+			{GUID: GUID("ephemeral"), Fields: []string{"<b>ephemeral</b>", "short-lived"}, Tags: []string{"literature", "word of the day"}},
+			{GUID: GUID("ubiquitous"), Fields: []string{"ubiquitous", "everywhere"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePackage(&buf, deck); err != nil {
+		t.Fatalf("WritePackage() error = %v", err)
+	}
+
+	db := openPackage(t, buf.Bytes())
+
+	var ver int
+	var models, decks string
+	if err := db.QueryRow(`SELECT ver, models, decks FROM col`).Scan(&ver, &models, &decks); err != nil {
+		t.Fatalf("failed to read col: %v", err)
+	}
+	if ver != 11 {
+		t.Errorf("col.ver = %d, want 11", ver)
+	}
+
+	var parsedModels map[string]struct {
+		Name string `json:"name"`
+		Flds []struct {
+			Name string `json:"name"`
+		} `json:"flds"`
+	}
+	if err := json.Unmarshal([]byte(models), &parsedModels); err != nil {
+		t.Fatalf("col.models is not JSON: %v", err)
+	}
+	if m := parsedModels["1001"]; m.Name != "Word" || len(m.Flds) != 2 {
+		t.Errorf("col.models = %s, want note type Word with 2 fields", models)
+	}
+	if !strings.Contains(decks, `"name":"Vocabulary"`) {
+		t.Errorf("col.decks = %s, want Vocabulary deck", decks)
+	}
+
+	rows, err := db.Query(`SELECT n.guid, n.mid, n.tags, n.flds, n.sfld, c.did FROM notes n JOIN cards c ON c.nid = n.id ORDER BY c.due`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+
+	var got []string
+	for rows.Next() {
+		var guid, tags, flds, sfld string
+		var mid, did int64
+		if err := rows.Scan(&guid, &mid, &tags, &flds, &sfld, &did); err != nil {
+			t.Fatal(err)
+		}
+		if mid != 1001 || did != 2001 {
+			t.Errorf("note %s mid, did = %d, %d, want 1001, 2001", sfld, mid, did)
+		}
+		got = append(got, sfld+"|"+tags+"|"+strings.ReplaceAll(flds, fieldSeparator, ";"))
+	}
+
+	want := []string{
+		"ephemeral| literature word_of_the_day |<b>ephemeral</b>;short-lived",
+		"ubiquitous||ubiquitous;everywhere",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("notes =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestWritePackage_Stream(t *testing.T) {
+	words := []string{"ephemeral", "ubiquitous", "laconic"}
+	deck := &Deck{
+		ID:    2001,
+		Name:  "Vocabulary",
+		Model: Model{ID: 1001, Name: "Word", Fields: []string{"Word"}, Front: "{{Word}}", Back: "{{Word}}"},
+		Stream: func(add func(Note) error) error {
+			for _, word := range words {
+				if err := add(Note{GUID: GUID(word), Fields: []string{word}}); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePackage(&buf, deck); err != nil {
+		t.Fatalf("WritePackage() error = %v", err)
+	}
+	db := openPackage(t, buf.Bytes())
+	var got []string
+	rows, err := db.Query(`SELECT n.flds FROM notes n JOIN cards c ON c.nid = n.id ORDER BY c.due`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var flds string
+		if err := rows.Scan(&flds); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, flds)
+	}
+	if strings.Join(got, ",") != strings.Join(words, ",") {
+		t.Errorf("notes = %v, want %v in order", got, words)
+	}
+
+	// An error producing the notes fails the package
+	failed := errors.New("read failed")
+	deck.Stream = func(add func(Note) error) error { return failed }
+	if err := WritePackage(&bytes.Buffer{}, deck); !errors.Is(err, failed) {
+		t.Errorf("WritePackage() error = %v, want %v", err, failed)
+	}
+}
+
+func TestGUID(t *testing.T) {
+	if GUID("ephemeral") != GUID("ephemeral") {
+		t.Error("GUID() should be stable")
+	}
+	if GUID("ephemeral") == GUID("ubiquitous") {
+		t.Error("GUID() should differ between keys")
+	}
+}
+
+func TestReadPackage(t *testing.T) {
+	deck := &Deck{
+		ID:   2001,
+		Name: "Vocabulary",
+		Model: Model{
+			ID:     1001,
+			Name:   "Word",
+			Fields: []string{"Word", "Meaning"},
+			Front:  "{{Word}}",
+			Back:   "{{Meaning}}",
+		},
+		Notes: []Note{
+			{GUID: "a", Fields: []string{"ephemeral", "short-lived"}, Tags: []string{"literature"}},
+			{GUID: "b", Fields: []string{"ubiquitous", "everywhere"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePackage(&buf, deck); err != nil {
+		t.Fatalf("WritePackage() error = %v", err)
+	}
+
+	collection, err := ReadPackage(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ReadPackage() error = %v", err)
+	}
+
+	model, ok := collection.Model(1001)
+	if !ok || model.Name != "Word" || strings.Join(model.Fields, ",") != "Word,Meaning" {
+		t.Errorf("ReadPackage() models = %+v, want Word with fields Word, Meaning", collection.Models)
+	}
+	if len(collection.Notes) != 2 {
+		t.Fatalf("ReadPackage() notes = %d, want 2", len(collection.Notes))
+	}
+
+	note := collection.Notes[0]
+	if note.GUID != "a" || note.ModelID != 1001 || note.Fields[1] != "short-lived" ||
+		strings.Join(note.Tags, ",") != "literature" || note.Created.IsZero() {
+		t.Errorf("ReadPackage() note = %+v", note)
+	}
+}
+
+func TestReadPackage_Invalid(t *testing.T) {
+	newFormat := func() []byte {
+		var buf bytes.Buffer
+		archive := zip.NewWriter(&buf)
+		archive.Create("collection.anki21b")
+		archive.Create("collection.anki2")
+		archive.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr error
+	}{
+		{name: "not a zip", data: []byte("word,source")},
+		{name: "new format only", data: newFormat(), wantErr: ErrNewFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ReadPackage(bytes.NewReader(tt.data), int64(len(tt.data)))
+			if err == nil {
+				t.Fatal("ReadPackage() should return error")
+			}
+			if tt.wantErr != nil && err != tt.wantErr {
+				t.Errorf("ReadPackage() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		field string
+		want  string
+	}{
+		{field: "ephemeral", want: "ephemeral"},
+		{field: "<b>short</b>-lived&nbsp;thing", want: "short-lived thing"},
+		{field: "<div>line one</div><div>line two<br></div>", want: "line one\nline two"},
+		{field: "rapport [sound:rapport.mp3]", want: "rapport"},
+		{field: "Tom &amp; Jerry", want: "Tom & Jerry"},
+	}
+
+	for _, tt := range tests {
+		if got := Text(tt.field); got != tt.want {
+			t.Errorf("Text(%q) = %q, want %q", tt.field, got, tt.want)
+		}
+	}
+}
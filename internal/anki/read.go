@@ -0,0 +1,207 @@
+package anki
+
+import (
+	"archive/zip"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ErrNewFormat is returned for packages exported only in the compressed format of
+// Anki 2.1.50 and later, which this package cannot read
+var ErrNewFormat = errors.New("package uses the Anki 2.1.50+ format: export it again with \"Support older Anki versions\" checked")
+
+// Collection is the content of a package: its note types and notes
+type Collection struct {
+	Models []Model // ordered by name; only ID, Name and Fields are filled in
+	Notes  []Note  // ordered by creation
+}
+
+// Model returns the note type with the given ID
+func (c *Collection) Model(id int64) (Model, bool) {
+	for _, model := range c.Models {
+		if model.ID == id {
+			return model, true
+		}
+	}
+	return Model{}, false
+}
+
+// ReadPackage reads the note types and notes of an .apkg file
+func ReadPackage(r io.ReaderAt, size int64) (*Collection, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("not an Anki package: %w", err)
+	}
+
+	// collection.anki21 is preferred over the anki2 file written beside it for
+	// older clients; when the package only has the new format, anki2 holds a
+	// single note asking the user to upgrade
+	files := make(map[string]*zip.File)
+	for _, file := range archive.File {
+		files[file.Name] = file
+	}
+	file := files["collection.anki21"]
+	if file == nil {
+		if files["collection.anki21b"] != nil {
+			return nil, ErrNewFormat
+		}
+		file = files["collection.anki2"]
+	}
+	if file == nil {
+		return nil, fmt.Errorf("not an Anki package: no collection found")
+	}
+
+	dir, err := os.MkdirTemp("", "apkg")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "collection.anki2")
+	if err := extract(file, path); err != nil {
+		return nil, err
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open collection: %w", err)
+	}
+	defer db.Close()
+
+	models, err := readModels(db)
+	if err != nil {
+		return nil, err
+	}
+
+	notes, err := readNotes(db)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Collection{Models: models, Notes: notes}, nil
+}
+
+// extract copies a zip entry to a file
+func extract(file *zip.File, path string) error {
+	src, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read collection: %w", err)
+	}
+	defer src.Close()
+
+	dst, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to extract collection: %w", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return fmt.Errorf("failed to extract collection: %w", err)
+	}
+	return dst.Close()
+}
+
+// readModels reads the note types from the col row, or from the notetypes and
+// fields tables of collections in the newer schema
+func readModels(db *sql.DB) ([]Model, error) {
+	var modelsJSON string
+	if err := db.QueryRow(`SELECT models FROM col`).Scan(&modelsJSON); err != nil {
+		return nil, fmt.Errorf("not an Anki collection: %w", err)
+	}
+
+	var stored map[string]struct {
+		ID   json.Number `json:"id"`
+		Name string      `json:"name"`
+		Flds []struct {
+			Name string `json:"name"`
+			Ord  int    `json:"ord"`
+		} `json:"flds"`
+	}
+	if modelsJSON != "" {
+		if err := json.Unmarshal([]byte(modelsJSON), &stored); err != nil {
+			return nil, fmt.Errorf("failed to read note types: %w", err)
+		}
+	}
+	if len(stored) == 0 {
+		return readNotetypes(db)
+	}
+
+	models := make([]Model, 0, len(stored))
+	for _, m := range stored {
+		id, err := m.ID.Int64()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read note types: %w", err)
+		}
+		fields := make([]string, len(m.Flds))
+		for _, field := range m.Flds {
+			if field.Ord >= 0 && field.Ord < len(fields) {
+				fields[field.Ord] = field.Name
+			}
+		}
+		models = append(models, Model{ID: id, Name: m.Name, Fields: fields})
+	}
+
+	sort.Slice(models, func(i, j int) bool { return models[i].Name < models[j].Name })
+	return models, nil
+}
+
+// readNotetypes reads note types stored in their own tables
+func readNotetypes(db *sql.DB) ([]Model, error) {
+	rows, err := db.Query(`SELECT n.id, n.name, f.name FROM notetypes n
+		JOIN fields f ON f.ntid = n.id ORDER BY n.name, n.id, f.ord`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read note types: %w", err)
+	}
+	defer rows.Close()
+
+	var models []Model
+	for rows.Next() {
+		var id int64
+		var name, field string
+		if err := rows.Scan(&id, &name, &field); err != nil {
+			return nil, fmt.Errorf("failed to read note types: %w", err)
+		}
+		if len(models) == 0 || models[len(models)-1].ID != id {
+			models = append(models, Model{ID: id, Name: name})
+		}
+		last := &models[len(models)-1]
+		last.Fields = append(last.Fields, field)
+	}
+	return models, rows.Err()
+}
+
+// readNotes reads every note with its fields and tags
+func readNotes(db *sql.DB) ([]Note, error) {
+	rows, err := db.Query(`SELECT id, guid, mid, mod, tags, flds FROM notes ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notes: %w", err)
+	}
+	defer rows.Close()
+
+	var notes []Note
+	for rows.Next() {
+		var id, mid, mod int64
+		var guid, tags, flds string
+		if err := rows.Scan(&id, &guid, &mid, &mod, &tags, &flds); err != nil {
+			return nil, fmt.Errorf("failed to read notes: %w", err)
+		}
+
+		// Note IDs are creation times in milliseconds
+		notes = append(notes, Note{
+			GUID:     guid,
+			Fields:   strings.Split(flds, fieldSeparator),
+			Tags:     strings.Fields(tags),
+			ModelID:  mid,
+			Created:  time.UnixMilli(id),
+			Modified: time.Unix(mod, 0),
+		})
+	}
+	return notes, rows.Err()
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// UploadAttachment handles POST /api/words/{id}/attachments
+func (h *Handler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid word ID")
+		return
+	}
+
+	// Allow some room for the multipart envelope on top of the file limit
+	r.Body = http.MaxBytesReader(w, r.Body, h.attachmentService.MaxSize()+(1<<20))
+	if err := r.ParseMultipartForm(h.attachmentService.MaxSize()); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, "file is too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "failed to parse form")
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "file is required")
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.attachmentService.Upload(r.Context(), id, header.Filename, file)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "word not found")
+			return
+		}
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, attachment)
+}
+
+// ListAttachments handles GET /api/words/{id}/attachments
+func (h *Handler) ListAttachments(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid word ID")
+		return
+	}
+
+	attachments, err := h.attachmentService.List(r.Context(), id)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list attachments")
+		return
+	}
+
+	if attachments == nil {
+		attachments = []*models.Attachment{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"attachments": attachments})
+}
+
+// GetAttachmentFile handles GET /api/attachments/{id}
+func (h *Handler) GetAttachmentFile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid attachment ID")
+		return
+	}
+
+	attachment, data, err := h.attachmentService.Open(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "attachment not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to read attachment")
+		return
+	}
+
+	w.Header().Set("Content-Disposition", fmt.Sprintf("inline; filename=%q", attachment.Filename))
+	serveAttachment(w, r, attachment, attachment.ContentType, `"`+attachment.Checksum+`"`, data)
+}
+
+// GetAttachmentThumbnail handles GET /api/attachments/{id}/thumbnail
+func (h *Handler) GetAttachmentThumbnail(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid attachment ID")
+		return
+	}
+
+	attachment, data, err := h.attachmentService.Thumbnail(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "attachment not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to read thumbnail")
+		return
+	}
+
+	serveAttachment(w, r, attachment, "image/jpeg", `"`+attachment.Checksum+`-thumb"`, data)
+}
+
+// DeleteAttachment handles DELETE /api/attachments/{id}
+func (h *Handler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid attachment ID")
+		return
+	}
+
+	err = h.attachmentService.Delete(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "attachment not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to delete attachment")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveAttachment writes file bytes with long-lived caching headers. Attachments are
+// immutable once uploaded, so the content checksum serves as a strong ETag and
+// ServeContent answers conditional and range requests.
+func serveAttachment(w http.ResponseWriter, r *http.Request, a *models.Attachment, contentType, etag string, data []byte) {
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	w.Header().Set("ETag", etag)
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	http.ServeContent(w, r, "", a.CreatedAt, bytes.NewReader(data))
+}
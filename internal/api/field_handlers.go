@@ -0,0 +1,114 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// ListFields handles GET /api/fields
+func (h *Handler) ListFields(w http.ResponseWriter, r *http.Request) {
+	fields, err := h.fieldService.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list fields")
+		return
+	}
+
+	if fields == nil {
+		fields = []*models.CustomField{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"fields": fields})
+}
+
+// GetField handles GET /api/fields/{id}
+func (h *Handler) GetField(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid field ID")
+		return
+	}
+
+	field, err := h.fieldService.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "field not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to get field")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, field)
+}
+
+// CreateField handles POST /api/fields
+func (h *Handler) CreateField(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateFieldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	field, err := h.fieldService.Create(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, field)
+}
+
+// UpdateField handles PUT /api/fields/{id}
+func (h *Handler) UpdateField(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid field ID")
+		return
+	}
+
+	var req models.UpdateFieldRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	field, err := h.fieldService.Update(r.Context(), id, &req)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "field not found")
+			return
+		}
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, field)
+}
+
+// DeleteField handles DELETE /api/fields/{id}
+func (h *Handler) DeleteField(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid field ID")
+		return
+	}
+
+	err = h.fieldService.Delete(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "field not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to delete field")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
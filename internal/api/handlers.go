@@ -4,24 +4,39 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
 
 	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/querylang"
 	"github.com/lehmann314159/vocabulator/internal/services"
+	"github.com/lehmann314159/vocabulator/internal/validation"
 )
 
 // Handler contains all HTTP handlers
 type Handler struct {
-	wordService *services.WordService
+	wordService          *services.WordService
+	fieldService         *services.FieldService
+	attachmentService    *services.AttachmentService
+	savedSearchService   *services.SavedSearchService
+	importProfileService *services.ImportProfileService
+	importJobService     *services.ImportJobService
 }
 
 // NewHandler creates a new handler
-func NewHandler(wordService *services.WordService) *Handler {
+func NewHandler(wordService *services.WordService, fieldService *services.FieldService, attachmentService *services.AttachmentService, savedSearchService *services.SavedSearchService, importProfileService *services.ImportProfileService, importJobService *services.ImportJobService) *Handler {
 	return &Handler{
-		wordService: wordService,
+		wordService:          wordService,
+		fieldService:         fieldService,
+		attachmentService:    attachmentService,
+		savedSearchService:   savedSearchService,
+		importProfileService: importProfileService,
+		importJobService:     importJobService,
 	}
 }
 
@@ -30,6 +45,11 @@ type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
+// ValidationErrorResponse represents a response listing field-level errors
+type ValidationErrorResponse struct {
+	Errors validation.Errors `json:"errors"`
+}
+
 // writeJSON writes a JSON response
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
 	w.WriteHeader(status)
@@ -41,16 +61,77 @@ func writeError(w http.ResponseWriter, status int, message string) {
 	writeJSON(w, status, ErrorResponse{Error: message})
 }
 
-// ListWords handles GET /api/words
-func (h *Handler) ListWords(w http.ResponseWriter, r *http.Request) {
+// writeServiceError writes field errors as a structured response and anything else as a plain error
+func writeServiceError(w http.ResponseWriter, status int, err error) {
+	var verrs validation.Errors
+	if errors.As(err, &verrs) {
+		writeJSON(w, status, ValidationErrorResponse{Errors: verrs})
+		return
+	}
+	writeError(w, status, err.Error())
+}
+
+// parseWordFilter reads the list filters from the query string. Tag and source
+// parameters may repeat: tag requires all, tag_any at least one and tag_none none
+// of the given tags; source matches any and source_none none of the given sources.
+// Custom fields are matched with field.<name>=value parameters.
+func parseWordFilter(r *http.Request) models.WordFilter {
+	query := r.URL.Query()
 	filter := models.WordFilter{
-		Search:   r.URL.Query().Get("search"),
-		Source:   r.URL.Query().Get("source"),
-		Tag:      r.URL.Query().Get("tag"),
-		FromDate: r.URL.Query().Get("from_date"),
-		ToDate:   r.URL.Query().Get("to_date"),
+		Search:         query.Get("search"),
+		Sources:        queryValues(query["source"]),
+		ExcludeSources: queryValues(query["source_none"]),
+		Tags:           validation.NormalizeTags(query["tag"]),
+		AnyTags:        validation.NormalizeTags(query["tag_any"]),
+		ExcludeTags:    validation.NormalizeTags(query["tag_none"]),
+		FromDate:       query.Get("from_date"),
+		ToDate:         query.Get("to_date"),
+		Sort:           query.Get("sort"),
+	}
+
+	for key, values := range query {
+		name, ok := strings.CutPrefix(key, "field.")
+		if !ok || name == "" || len(values) == 0 {
+			continue
+		}
+		if filter.Fields == nil {
+			filter.Fields = make(map[string]string)
+		}
+		filter.Fields[name] = values[0]
 	}
 
+	return filter
+}
+
+// applySearchQuery compiles the search query language in filter.Search into the
+// filter, reporting syntax errors as a field error on search
+func applySearchQuery(filter *models.WordFilter) error {
+	err := querylang.Apply(filter)
+	var qerr *querylang.Error
+	if errors.As(err, &qerr) {
+		return validation.Errors{{
+			Field:    "search",
+			Code:     validation.CodeInvalidFormat,
+			Message:  qerr.Msg,
+			Position: qerr.Pos,
+		}}
+	}
+	return err
+}
+
+// queryValues trims repeated query parameter values and drops empty ones
+func queryValues(values []string) []string {
+	var result []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			result = append(result, v)
+		}
+	}
+	return result
+}
+
+// parsePaging reads limit, offset and cursor from the query string into filter
+func parsePaging(r *http.Request, filter *models.WordFilter) {
 	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		if limit, err := strconv.Atoi(limitStr); err == nil {
 			filter.Limit = limit
@@ -63,8 +144,37 @@ func (h *Handler) ListWords(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	words, err := h.wordService.List(r.Context(), filter)
+	filter.Cursor = r.URL.Query().Get("cursor")
+}
+
+// ListWords handles GET /api/words
+func (h *Handler) ListWords(w http.ResponseWriter, r *http.Request) {
+	filter := parseWordFilter(r)
+	parsePaging(r, &filter)
+
+	if err := applySearchQuery(&filter); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	h.writeWordPage(w, r, filter)
+}
+
+// writeWordPage validates a compiled filter and writes the matching page of words
+// with the total count and, for empty searches, "did you mean" suggestions
+func (h *Handler) writeWordPage(w http.ResponseWriter, r *http.Request, filter models.WordFilter) {
+	if err := validation.ValidateFilter(filter); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	page, err := h.wordService.ListPage(r.Context(), filter)
 	if err != nil {
+		var verrs validation.Errors
+		if errors.As(err, &verrs) {
+			writeServiceError(w, http.StatusBadRequest, err)
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "failed to list words")
 		return
 	}
@@ -73,17 +183,72 @@ func (h *Handler) ListWords(w http.ResponseWriter, r *http.Request) {
 	count, _ := h.wordService.Count(r.Context(), filter)
 
 	response := map[string]interface{}{
-		"words": words,
+		"words": page.Words,
 		"total": count,
 	}
 
-	if words == nil {
+	if page.Words == nil {
 		response["words"] = []interface{}{}
 	}
 
+	if page.NextCursor != "" {
+		response["next_cursor"] = page.NextCursor
+	}
+
+	// Offer close matches when a search finds nothing
+	if filter.Search != "" && count == 0 {
+		if suggestions, err := h.wordService.Suggest(r.Context(), filter.Search, didYouMeanLimit); err == nil {
+			response["did_you_mean"] = suggestions
+		}
+	}
+
 	writeJSON(w, http.StatusOK, response)
 }
 
+// GetFacets handles GET /api/words/facets; it accepts the ListWords filters and a
+// facet_limit capping the values returned per facet
+func (h *Handler) GetFacets(w http.ResponseWriter, r *http.Request) {
+	filter := parseWordFilter(r)
+
+	if err := applySearchQuery(&filter); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if err := validation.ValidateFilter(filter); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("facet_limit"))
+
+	facets, err := h.wordService.Facets(r.Context(), filter, limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to count facets")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, facets)
+}
+
+// didYouMeanLimit is how many suggestions accompany a search with no results
+const didYouMeanLimit = 3
+
+// SuggestWords handles GET /api/words/suggest
+func (h *Handler) SuggestWords(w http.ResponseWriter, r *http.Request) {
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+	suggestions, err := h.wordService.Suggest(r.Context(), r.URL.Query().Get("q"), limit)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to suggest words")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"suggestions": suggestions,
+	})
+}
+
 // GetWord handles GET /api/words/{id}
 func (h *Handler) GetWord(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
@@ -115,7 +280,12 @@ func (h *Handler) CreateWord(w http.ResponseWriter, r *http.Request) {
 
 	word, err := h.wordService.Create(r.Context(), &req)
 	if err != nil {
-		writeError(w, http.StatusBadRequest, err.Error())
+		var verrs validation.Errors
+		if errors.As(err, &verrs) {
+			writeServiceError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to create word")
 		return
 	}
 
@@ -142,7 +312,12 @@ func (h *Handler) UpdateWord(w http.ResponseWriter, r *http.Request) {
 			writeError(w, http.StatusNotFound, "word not found")
 			return
 		}
-		writeError(w, http.StatusBadRequest, err.Error())
+		var verrs validation.Errors
+		if errors.As(err, &verrs) {
+			writeServiceError(w, http.StatusUnprocessableEntity, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to update word")
 		return
 	}
 
@@ -157,7 +332,7 @@ func (h *Handler) DeleteWord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.wordService.Delete(r.Context(), id)
+	err = h.attachmentService.DeleteWord(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			writeError(w, http.StatusNotFound, "word not found")
@@ -170,8 +345,40 @@ func (h *Handler) DeleteWord(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-// GetRandomWord handles GET /api/words/random
+// ReviewWord handles POST /api/words/{id}/review
+func (h *Handler) ReviewWord(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid word ID")
+		return
+	}
+
+	var req models.ReviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	err = h.wordService.Review(r.Context(), id, req.Remembered)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "word not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to record review")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetRandomWord handles GET /api/words/random; ?list=<id> draws from a saved search
 func (h *Handler) GetRandomWord(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Query().Get("list") != "" {
+		h.getRandomListWord(w, r)
+		return
+	}
+
 	word, err := h.wordService.GetRandom(r.Context())
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
@@ -185,6 +392,42 @@ func (h *Handler) GetRandomWord(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, word)
 }
 
+// getRandomListWord writes a random word from the saved search named by ?list=
+func (h *Handler) getRandomListWord(w http.ResponseWriter, r *http.Request) {
+	listID, err := strconv.ParseInt(r.URL.Query().Get("list"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid list ID")
+		return
+	}
+
+	filter, err := h.savedSearchService.Filter(r.Context(), listID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "list not found")
+			return
+		}
+		var verrs validation.Errors
+		if errors.As(err, &verrs) {
+			writeServiceError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to load list")
+		return
+	}
+
+	word, err := h.wordService.GetRandomMatching(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "no words found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to get random word")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, word)
+}
+
 // GetWordDefinition handles GET /api/words/{id}/definition
 func (h *Handler) GetWordDefinition(w http.ResponseWriter, r *http.Request) {
 	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
@@ -210,7 +453,15 @@ func (h *Handler) GetWordDefinition(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, definition)
 }
 
-// ImportWords handles POST /api/words/import
+// ImportWords handles POST /api/words/import. The format form value picks csv (the
+// default), tsv, json, ndjson, apkg, kindle, kobo or markdown (a zipped vault),
+// falling back to the file extension;
+// conflict picks how imports treat words that already exist, and profile the ID of
+// the import profile a CSV or TSV file is read with. Anki imports map note
+// fields with map.<Anki field>=<word field> values and take a default source. With
+// dry_run=true nothing is written: the response previews each row and carries a
+// token for CommitImport. With async=true the file is imported in the background:
+// the response is 202 Accepted with the import job, polled at its Location.
 func (h *Handler) ImportWords(w http.ResponseWriter, r *http.Request) {
 	// Parse multipart form
 	err := r.ParseMultipartForm(10 << 20) // 10 MB max
@@ -219,36 +470,395 @@ func (h *Handler) ImportWords(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	file, _, err := r.FormFile("file")
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "file is required")
+		return
+	}
+	defer file.Close()
+
+	opts, err := importOptions(r, h.importProfileService)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format := importFormat(r.FormValue("format"), header.Filename)
+
+	if dryRun, _ := strconv.ParseBool(r.FormValue("dry_run")); dryRun {
+		var preview *services.ImportPreview
+		if format == "apkg" {
+			preview, err = h.wordService.PreviewAnki(r.Context(), file, header.Size, ankiImportOptions(r, opts))
+		} else {
+			preview, err = h.wordService.PreviewImport(r.Context(), file, format, opts)
+		}
+		if err != nil {
+			writeServiceError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, preview)
+		return
+	}
+
+	if async, _ := strconv.ParseBool(r.FormValue("async")); async {
+		job, err := h.importJobService.EnqueueImport(r.Context(), file, header.Filename, format, ankiImportOptions(r, opts))
+		if err != nil {
+			writeImportJobError(w, err)
+			return
+		}
+		writeImportJob(w, job)
+		return
+	}
+
+	var result *services.ImportResult
+	if format == "apkg" {
+		result, err = h.wordService.ImportAnki(r.Context(), file, header.Size, ankiImportOptions(r, opts))
+	} else {
+		result, err = h.wordService.Import(r.Context(), file, format, opts)
+	}
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// CommitImport handles POST /api/words/import/commit: it writes the rows of the
+// import previewed under the token form value, in the background with async=true
+func (h *Handler) CommitImport(w http.ResponseWriter, r *http.Request) {
+	token := r.FormValue("token")
+	if token == "" {
+		writeError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	if async, _ := strconv.ParseBool(r.FormValue("async")); async {
+		job, err := h.importJobService.EnqueueCommit(r.Context(), token)
+		if err != nil {
+			if errors.Is(err, services.ErrImportNotStaged) {
+				writeError(w, http.StatusNotFound, err.Error())
+				return
+			}
+			writeImportJobError(w, err)
+			return
+		}
+		writeImportJob(w, job)
+		return
+	}
+
+	result, err := h.wordService.CommitImport(r.Context(), token)
+	if err != nil {
+		if errors.Is(err, services.ErrImportNotStaged) {
+			writeError(w, http.StatusNotFound, err.Error())
+			return
+		}
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// importOptions reads the conflict strategy, the all_or_nothing flag and the CSV
+// import profile of an import from its form values
+func importOptions(r *http.Request, profiles *services.ImportProfileService) (services.ImportOptions, error) {
+	strategy, err := services.ParseConflictStrategy(r.FormValue("conflict"))
+	if err != nil {
+		return services.ImportOptions{}, err
+	}
+
+	opts := services.ImportOptions{Conflict: strategy}
+	if value := r.FormValue("all_or_nothing"); value != "" {
+		if opts.AllOrNothing, err = strconv.ParseBool(value); err != nil {
+			return services.ImportOptions{}, fmt.Errorf("invalid all_or_nothing value %q", value)
+		}
+	}
+
+	if opts.Profile, err = importProfile(r, profiles); err != nil {
+		return services.ImportOptions{}, err
+	}
+	return opts, nil
+}
+
+// ankiImportOptions reads the field mapping and default source of an Anki import
+// from a parsed multipart form
+func ankiImportOptions(r *http.Request, opts services.ImportOptions) services.AnkiImportOptions {
+	return services.AnkiImportOptions{
+		ImportOptions: opts,
+		Mapping:       ankiMapping(r.MultipartForm.Value),
+		Source:        r.FormValue("source"),
+	}
+}
+
+// InspectAnkiPackage handles POST /api/words/import/anki/fields: it lists the note
+// types and fields of an uploaded .apkg file and suggests a field mapping
+func (h *Handler) InspectAnkiPackage(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, "failed to parse form")
+		return
+	}
+
+	file, header, err := r.FormFile("file")
 	if err != nil {
 		writeError(w, http.StatusBadRequest, "file is required")
 		return
 	}
 	defer file.Close()
 
-	result, err := h.wordService.ImportCSV(r.Context(), file)
+	info, err := h.wordService.InspectAnki(r.Context(), file, header.Size)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
+	writeJSON(w, http.StatusOK, info)
+}
+
+// ClippingCandidates handles POST /api/words/import/clippings/candidates: it reads
+// an uploaded "My Clippings.txt" and returns its short highlights for review
+func (h *Handler) ClippingCandidates(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseMultipartForm(10 << 20); err != nil {
+		writeError(w, http.StatusBadRequest, "failed to parse form")
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "file is required")
+		return
+	}
+	defer file.Close()
+
+	candidates, err := h.wordService.ClippingCandidates(r.Context(), file, clippingWords(r.FormValue("max_words")))
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, candidates)
+}
+
+// ImportClippings handles POST /api/words/import/clippings: it imports the
+// reviewed candidates returned by ClippingCandidates
+func (h *Handler) ImportClippings(w http.ResponseWriter, r *http.Request) {
+	var candidates []*services.ClippingCandidate
+	if err := json.NewDecoder(r.Body).Decode(&candidates); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	result, err := h.wordService.ImportClippings(r.Context(), candidates)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// ScanText handles POST /api/words/scan: it lists the uncommon words of a pasted
+// text or an uploaded .txt or .epub file that are not in the collection yet
+func (h *Handler) ScanText(w http.ResponseWriter, r *http.Request) {
+	result, err := scanForm(r, h.wordService)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+// ImportScanned handles POST /api/words/scan/import: it adds the words picked
+// from the candidates returned by ScanText
+func (h *Handler) ImportScanned(w http.ResponseWriter, r *http.Request) {
+	var scanned services.ScannedWords
+	if err := json.NewDecoder(r.Body).Decode(&scanned); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	result, err := h.wordService.ImportScanned(r.Context(), &scanned)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
 	writeJSON(w, http.StatusOK, result)
 }
 
-// ExportWords handles GET /api/words/export
+// scanForm scans the file, or else the text, of a form with optional common and
+// limit values
+func scanForm(r *http.Request, svc *services.WordService) (*services.ScanResult, error) {
+	if err := r.ParseMultipartForm(32 << 20); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+		return nil, errors.New("failed to parse form")
+	}
+	opts := services.ScanOptions{Common: scanNumber(r.FormValue("common")), Limit: scanNumber(r.FormValue("limit"))}
+
+	file, header, err := r.FormFile("file")
+	if err == nil {
+		defer file.Close()
+		return svc.ScanFile(r.Context(), file, header.Size, header.Filename, opts)
+	}
+	return svc.ScanText(r.Context(), r.FormValue("text"), opts)
+}
+
+// scanNumber reads a scan option, where 0 means the default. An unreadable value
+// is passed on as -1 so that it is reported as out of range.
+func scanNumber(value string) int {
+	if value == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return -1
+	}
+	return n
+}
+
+// clippingWords reads the max_words parameter, defaulting when it is empty. An
+// unreadable value is passed on as 0 so that it is reported as out of range.
+func clippingWords(value string) int {
+	if value == "" {
+		return services.DefaultClippingWords
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// ankiMapping collects map.<Anki field>=<word field> form values; empty values
+// leave the field out of the import
+func ankiMapping(values map[string][]string) map[string]string {
+	mapping := make(map[string]string)
+	for key, vals := range values {
+		name, ok := strings.CutPrefix(key, "map.")
+		if !ok || name == "" || len(vals) == 0 {
+			continue
+		}
+		mapping[name] = strings.TrimSpace(vals[0])
+	}
+	return mapping
+}
+
+// importFormat returns the requested import format, or guesses it from the
+// uploaded file's extension
+func importFormat(format, filename string) string {
+	if format != "" {
+		return strings.ToLower(format)
+	}
+
+	switch strings.ToLower(filepath.Ext(filename)) {
+	case ".json":
+		return "json"
+	case ".ndjson", ".jsonl":
+		return "ndjson"
+	case ".apkg":
+		return "apkg"
+	case ".db":
+		return "kindle"
+	case ".sqlite":
+		return "kobo"
+	case ".tsv", ".tab":
+		return "tsv"
+	case ".zip":
+		return "markdown"
+	}
+	return "csv"
+}
+
+// exportTypes maps export formats to their content type and file name
+var exportTypes = map[string]struct{ contentType, filename string }{
+	"csv":      {"text/csv", "words.csv"},
+	"tsv":      {"text/tab-separated-values", "words.tsv"},
+	"json":     {"application/json", "words.json"},
+	"ndjson":   {"application/x-ndjson", "words.ndjson"},
+	"apkg":     {"application/apkg", "vocabulator.apkg"},
+	"markdown": {"application/zip", "vocabulator-vault.zip"},
+	"stardict": {"application/zip", "vocabulator-stardict.zip"},
+}
+
+// ExportWords handles GET /api/words/export. The format parameter picks csv (the
+// default), tsv, json, ndjson, apkg, markdown or stardict, and the usual list
+// filters pick the words. For the text formats, fields lists the columns to write,
+// comma-separated or repeated; without it the JSON formats keep IDs, timestamps and
+// custom field definitions so they can be imported back without loss. An apkg export is an
+// Anki deck, a markdown export a zipped Obsidian vault and a stardict export a
+// zipped StarDict dictionary. Rows are streamed as they are read from the database.
 func (h *Handler) ExportWords(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "text/csv")
-	w.Header().Set("Content-Disposition", "attachment; filename=words.csv")
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	exportType, ok := exportTypes[format]
+	if !ok {
+		writeError(w, http.StatusBadRequest, "format must be csv, tsv, json, ndjson, apkg, markdown or stardict")
+		return
+	}
 
-	err := h.wordService.ExportCSV(r.Context(), w)
+	filter := parseWordFilter(r)
+	if err := applySearchQuery(&filter); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := validation.ValidateFilter(filter); err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	var fields []string
+	for _, value := range r.URL.Query()["fields"] {
+		fields = append(fields, queryValues(strings.Split(value, ","))...)
+	}
+
+	w.Header().Set("Content-Type", exportType.contentType)
+	w.Header().Set("Content-Disposition", "attachment; filename="+exportType.filename)
+
+	out := &sentWriter{ResponseWriter: w}
+	var err error
+	switch format {
+	case "apkg":
+		err = h.wordService.ExportAnki(r.Context(), out, filter)
+	case "markdown":
+		err = h.wordService.ExportMarkdown(r.Context(), out, filter)
+	case "stardict":
+		err = h.wordService.ExportStarDict(r.Context(), out, filter)
+	default:
+		err = h.wordService.Export(r.Context(), out, format, services.ExportOptions{Filter: filter, Fields: fields})
+	}
 	if err != nil {
+		// Once part of the file is sent its 200 status cannot change, so the
+		// connection is cut instead, for the client to see an incomplete download
+		if out.sent {
+			panic(http.ErrAbortHandler)
+		}
+
 		// Reset headers since we already set them
 		w.Header().Set("Content-Type", "application/json")
+		w.Header().Del("Content-Disposition")
+		var verrs validation.Errors
+		if errors.As(err, &verrs) {
+			writeServiceError(w, http.StatusBadRequest, err)
+			return
+		}
 		writeError(w, http.StatusInternalServerError, "failed to export words")
 		return
 	}
 }
 
+// sentWriter records whether any of the response body has been written
+type sentWriter struct {
+	http.ResponseWriter
+	sent bool
+}
+
+func (w *sentWriter) Write(p []byte) (int, error) {
+	w.sent = true
+	return w.ResponseWriter.Write(p)
+}
+
 // HealthCheck handles GET /health
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
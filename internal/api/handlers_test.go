@@ -1,17 +1,27 @@
 package api
 
 import (
+	"archive/zip"
 	"bytes"
+	"context"
 	"database/sql"
 	"encoding/json"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
 	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	_ "github.com/mattn/go-sqlite3"
 
+	"github.com/lehmann314159/vocabulator/internal/anki"
 	"github.com/lehmann314159/vocabulator/internal/models"
 	"github.com/lehmann314159/vocabulator/internal/repository"
 	"github.com/lehmann314159/vocabulator/internal/services"
@@ -24,6 +34,9 @@ func setupTestHandler(t *testing.T) (*Handler, *chi.Mux, func()) {
 	if err != nil {
 		t.Fatalf("failed to open test db: %v", err)
 	}
+	// Each connection would open its own empty database, and imports run in the
+	// background on another goroutine
+	db.SetMaxOpenConns(1)
 
 	_, err = db.Exec(`
 		CREATE TABLE words (
@@ -35,8 +48,91 @@ func setupTestHandler(t *testing.T) (*Handler, *chi.Mux, func()) {
 			example_sentence TEXT,
 			tags TEXT DEFAULT '[]',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			reviews INTEGER NOT NULL DEFAULT 0,
+			lapses INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE TABLE custom_fields (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			label TEXT NOT NULL,
+			type TEXT NOT NULL,
+			options TEXT DEFAULT '[]',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE word_field_values (
+			word_id INTEGER NOT NULL,
+			field_id INTEGER NOT NULL,
+			value TEXT NOT NULL,
+			PRIMARY KEY (word_id, field_id)
+		);
+		CREATE TABLE attachments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			word_id INTEGER NOT NULL,
+			filename TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			checksum TEXT NOT NULL,
+			data BLOB,
+			thumbnail BLOB NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE word_trigrams (
+			trigram TEXT NOT NULL,
+			word_id INTEGER NOT NULL,
+			PRIMARY KEY (trigram, word_id)
+		);
+
+		CREATE TABLE saved_searches (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			query TEXT NOT NULL DEFAULT '',
+			filter TEXT NOT NULL DEFAULT '{}',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE import_profiles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			columns TEXT NOT NULL DEFAULT '{}',
+			delimiter TEXT NOT NULL DEFAULT '',
+			quote TEXT NOT NULL DEFAULT '',
+			encoding TEXT NOT NULL DEFAULT '',
+			date_format TEXT NOT NULL DEFAULT '',
+			defaults TEXT NOT NULL DEFAULT '{}',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)
+		);
+
+		CREATE TABLE definitions (
+			word TEXT PRIMARY KEY COLLATE NOCASE,
+			data TEXT NOT NULL,
+			fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE imported_items (
+			origin TEXT NOT NULL,
+			item_key TEXT NOT NULL,
+			imported_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (origin, item_key)
+		);
+
+		CREATE TABLE import_jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			filename TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'queued',
+			total INTEGER NOT NULL DEFAULT 0,
+			processed INTEGER NOT NULL DEFAULT 0,
+			result TEXT,
+			error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			finished_at DATETIME
+		);
 	`)
 	if err != nil {
 		t.Fatalf("failed to create table: %v", err)
@@ -44,11 +140,20 @@ func setupTestHandler(t *testing.T) (*Handler, *chi.Mux, func()) {
 
 	repo := repository.NewSQLiteRepository(db)
 	dictSvc := services.NewDictionaryService()
-	wordSvc := services.NewWordService(repo, dictSvc)
-	handler := NewHandler(wordSvc)
+	wordSvc := services.NewWordService(repo, repo, dictSvc)
+	fieldSvc := services.NewFieldService(repo)
+	attachmentSvc := services.NewAttachmentService(repo, repo, services.NewDatabaseStorage(repo), 0)
+	importJobSvc := services.NewImportJobService(repo, wordSvc)
+	jobsCtx, stopJobs := context.WithCancel(context.Background())
+	if err := importJobSvc.Start(jobsCtx); err != nil {
+		t.Fatalf("failed to start import jobs: %v", err)
+	}
+	handler := NewHandler(wordSvc, fieldSvc, attachmentSvc, services.NewSavedSearchService(repo, repo),
+		services.NewImportProfileService(repo, repo), importJobSvc)
 	router := NewRouter(handler, "")
 
 	cleanup := func() {
+		stopJobs()
 		db.Close()
 	}
 
@@ -72,7 +177,7 @@ func TestHandler_CreateWord(t *testing.T) {
 		{
 			name:       "missing word",
 			body:       `{"source":"Book","date_learned":"2024-01-15"}`,
-			wantStatus: http.StatusBadRequest,
+			wantStatus: http.StatusUnprocessableEntity,
 		},
 		{
 			name:       "invalid JSON",
@@ -96,6 +201,38 @@ func TestHandler_CreateWord(t *testing.T) {
 	}
 }
 
+func TestHandler_CreateWord_ValidationErrors(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	body := `{"word":"ephemeral","source":"Book","date_learned":"2024/01/15","part_of_speech":"gerundive"}`
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/words", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("CreateWord() status = %v, want %v", rec.Code, http.StatusUnprocessableEntity)
+	}
+
+	var response ValidationErrorResponse
+	if err := json.NewDecoder(rec.Body).Decode(&response); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+
+	codes := make(map[string]string)
+	for _, fe := range response.Errors {
+		codes[fe.Field] = fe.Code
+	}
+	if codes["date_learned"] != "invalid_format" {
+		t.Errorf("date_learned code = %q, want invalid_format", codes["date_learned"])
+	}
+	if codes["part_of_speech"] != "invalid_value" {
+		t.Errorf("part_of_speech code = %q, want invalid_value", codes["part_of_speech"])
+	}
+}
+
 func TestHandler_GetWord(t *testing.T) {
 	_, router, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -168,6 +305,7 @@ func TestHandler_ListWords(t *testing.T) {
 		query      string
 		wantStatus int
 		wantCount  int
+		wantFirst  string
 	}{
 		{
 			name:       "list all",
@@ -199,6 +337,46 @@ func TestHandler_ListWords(t *testing.T) {
 			wantStatus: http.StatusOK,
 			wantCount:  1,
 		},
+		{
+			name:       "repeated sources match any",
+			query:      "?source=Book&source=Article",
+			wantStatus: http.StatusOK,
+			wantCount:  2,
+		},
+		{
+			name:       "repeated tags match all",
+			query:      "?tag=literature&tag=technology",
+			wantStatus: http.StatusOK,
+			wantCount:  0,
+		},
+		{
+			name:       "tag_any and tag_none",
+			query:      "?tag_any=literature&tag_any=technology&tag_none=Technology",
+			wantStatus: http.StatusOK,
+			wantCount:  1,
+			wantFirst:  "ephemeral",
+		},
+		{
+			name:       "source_none",
+			query:      "?source_none=Book",
+			wantStatus: http.StatusOK,
+			wantCount:  1,
+			wantFirst:  "ubiquitous",
+		},
+		{
+			name:       "sort by word descending",
+			query:      "?sort=-word",
+			wantStatus: http.StatusOK,
+			wantCount:  2,
+			wantFirst:  "ubiquitous",
+		},
+		{
+			name:       "sort by date ascending",
+			query:      "?sort=date_learned",
+			wantStatus: http.StatusOK,
+			wantCount:  2,
+			wantFirst:  "ephemeral",
+		},
 	}
 
 	for _, tt := range tests {
@@ -219,8 +397,117 @@ func TestHandler_ListWords(t *testing.T) {
 			if len(words) != tt.wantCount {
 				t.Errorf("ListWords() count = %v, want %v", len(words), tt.wantCount)
 			}
+			if tt.wantFirst != "" && len(words) > 0 {
+				if first := words[0].(map[string]interface{})["word"]; first != tt.wantFirst {
+					t.Errorf("ListWords() first word = %v, want %v", first, tt.wantFirst)
+				}
+			}
 		})
 	}
+
+	// Unknown sort keys are rejected
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words?sort=popularity", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("ListWords() with unknown sort status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandler_ListWords_Cursor(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	for _, word := range []string{"apt", "bold", "calm", "deft", "eager"} {
+		body := `{"word":"` + word + `","source":"Book","date_learned":"2024-01-15"}`
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/words", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var words []string
+	cursor := ""
+	for pages := 0; pages < 5; pages++ {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/words?sort=word&limit=2&cursor="+cursor, nil)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("ListWords() status = %v, body: %s", rec.Code, rec.Body.String())
+		}
+
+		var response struct {
+			Total      int           `json:"total"`
+			Words      []models.Word `json:"words"`
+			NextCursor string        `json:"next_cursor"`
+		}
+		json.NewDecoder(rec.Body).Decode(&response)
+		if response.Total != 5 {
+			t.Errorf("ListWords() total = %d, want 5", response.Total)
+		}
+		for _, w := range response.Words {
+			words = append(words, w.Word)
+		}
+		if response.NextCursor == "" {
+			break
+		}
+		cursor = response.NextCursor
+	}
+
+	if got := strings.Join(words, ","); got != "apt,bold,calm,deft,eager" {
+		t.Errorf("paged words = %s, want apt,bold,calm,deft,eager", got)
+	}
+
+	// A cursor from a different sort is rejected
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words?sort=-word&limit=2&cursor="+cursor, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("ListWords() with mismatched cursor status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandler_ListWords_QueryLanguage(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	for _, body := range []string{
+		`{"word":"ephemeral","source":"The Economist","date_learned":"2024-01-15","part_of_speech":"adjective","tags":["legal"]}`,
+		`{"word":"ephemera","source":"The Economist","date_learned":"2024-02-15","part_of_speech":"noun","tags":["legal","easy"]}`,
+		`{"word":"eloquent","source":"Book","date_learned":"2024-03-10","part_of_speech":"adjective","tags":["legal"]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/words", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	search := url.QueryEscape(`tag:legal source:"The Economist" pos:adjective after:2024-01-01 -tag:easy ephemer*`)
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words?search="+search, nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var response struct {
+		Total int           `json:"total"`
+		Words []models.Word `json:"words"`
+	}
+	json.NewDecoder(rec.Body).Decode(&response)
+	if rec.Code != http.StatusOK || response.Total != 1 || response.Words[0].Word != "ephemeral" {
+		t.Errorf("ListWords() status = %v, total = %d, words = %v", rec.Code, response.Total, response.Words)
+	}
+
+	// Syntax errors carry their position
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/words?search="+url.QueryEscape(`tag:legal pos:gerund`), nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var errResp ValidationErrorResponse
+	json.NewDecoder(rec.Body).Decode(&errResp)
+	if rec.Code != http.StatusBadRequest || len(errResp.Errors) != 1 ||
+		errResp.Errors[0].Field != "search" || errResp.Errors[0].Position != 15 {
+		t.Errorf("ListWords() with bad query status = %v, errors = %+v", rec.Code, errResp.Errors)
+	}
 }
 
 func TestHandler_UpdateWord(t *testing.T) {
@@ -246,6 +533,12 @@ func TestHandler_UpdateWord(t *testing.T) {
 			body:       `{"source":"Updated Book"}`,
 			wantStatus: http.StatusOK,
 		},
+		{
+			name:       "invalid date",
+			id:         "1",
+			body:       `{"date_learned":"2024/01/15"}`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
 		{
 			name:       "non-existent word",
 			id:         "9999",
@@ -317,6 +610,59 @@ func TestHandler_DeleteWord(t *testing.T) {
 	}
 }
 
+func TestHandler_ReviewWord(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	for _, body := range []string{
+		`{"word":"ephemeral","source":"Book","date_learned":"2024-01-15"}`,
+		`{"word":"laconic","source":"Book","date_learned":"2024-01-16"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/words", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		body       string
+		wantStatus int
+	}{
+		{name: "forgotten", id: "1", body: `{"remembered":false}`, wantStatus: http.StatusNoContent},
+		{name: "remembered", id: "2", body: `{"remembered":true}`, wantStatus: http.StatusNoContent},
+		{name: "invalid body", id: "1", body: `remembered`, wantStatus: http.StatusBadRequest},
+		{name: "non-existent", id: "9999", body: `{"remembered":true}`, wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/words/"+tt.id+"/review", bytes.NewBufferString(tt.body))
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("ReviewWord() status = %v, want %v", rec.Code, tt.wantStatus)
+			}
+		})
+	}
+
+	// The forgotten word sorts first by difficulty
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words?sort=-difficulty", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp struct {
+		Words []models.Word `json:"words"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if len(resp.Words) != 2 || resp.Words[0].Word != "ephemeral" {
+		t.Errorf("ListWords(sort=-difficulty) = %+v, want ephemeral first", resp.Words)
+	}
+}
+
 func TestHandler_GetRandomWord(t *testing.T) {
 	_, router, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -380,6 +726,120 @@ ubiquitous,Article,2024-02-20,,,"technology"`
 	}
 }
 
+func TestHandler_ImportWords_DryRun(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	csvContent := `word,source,date_learned
+ephemeral,Book,2024-01-15
+laconic,Book,someday`
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("file", "words.csv")
+	part.Write([]byte(csvContent))
+	writer.WriteField("dry_run", "true")
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/words/import", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ImportWords() dry run status = %v, body: %s", rec.Code, rec.Body.String())
+	}
+	var preview services.ImportPreview
+	json.NewDecoder(rec.Body).Decode(&preview)
+	if preview.New != 1 || preview.Invalid != 1 || len(preview.Rows) != 2 || preview.Rows[1].Status != services.RowInvalid {
+		t.Errorf("ImportWords() dry run = %+v", preview)
+	}
+
+	commit := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/words/import/commit", strings.NewReader("token="+token))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec = commit(preview.Token)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("CommitImport() status = %v, body: %s", rec.Code, rec.Body.String())
+	}
+	var result services.ImportResult
+	json.NewDecoder(rec.Body).Decode(&result)
+	if result.Imported != 1 || result.Skipped != 1 {
+		t.Errorf("CommitImport() = %+v, want 1 imported, 1 skipped", result)
+	}
+
+	if rec = commit(preview.Token); rec.Code != http.StatusNotFound {
+		t.Errorf("CommitImport() again status = %v, want %v", rec.Code, http.StatusNotFound)
+	}
+}
+
+func TestHandler_ImportWords_Async(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	csvContent := `word,source,date_learned
+ephemeral,Book,2024-01-15
+laconic,Book,someday`
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("file", "words.csv")
+	part.Write([]byte(csvContent))
+	writer.WriteField("async", "true")
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/words/import", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusAccepted {
+		t.Fatalf("ImportWords() async status = %v, body: %s", rec.Code, rec.Body.String())
+	}
+	var job models.ImportJob
+	json.NewDecoder(rec.Body).Decode(&job)
+	location := rec.Header().Get("Location")
+	if location != fmt.Sprintf("/api/v1/jobs/%d", job.ID) || job.Filename != "words.csv" {
+		t.Fatalf("ImportWords() async = %+v at %q", job, location)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !job.Finished() {
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		rec = httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, location, nil))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GetImportJob() status = %v, body: %s", rec.Code, rec.Body.String())
+		}
+		job = models.ImportJob{}
+		json.NewDecoder(rec.Body).Decode(&job)
+	}
+
+	if job.Status != models.ImportJobDone || job.Total != 2 || job.Processed != 2 {
+		t.Errorf("GetImportJob() = %+v, want done with 2 rows", job)
+	}
+	var result services.ImportResult
+	json.Unmarshal(job.Result, &result)
+	if result.Imported != 1 || result.Skipped != 1 || len(result.Errors) != 1 {
+		t.Errorf("GetImportJob() result = %+v, want 1 imported, 1 skipped", result)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/jobs/999", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GetImportJob() missing status = %v, want %v", rec.Code, http.StatusNotFound)
+	}
+}
+
 func TestHandler_ExportWords(t *testing.T) {
 	_, router, cleanup := setupTestHandler(t)
 	defer cleanup()
@@ -412,16 +872,1044 @@ func TestHandler_ExportWords(t *testing.T) {
 	}
 }
 
-func TestHandler_HealthCheck(t *testing.T) {
+// cancelOnWrite cancels a request's context as soon as the response body is written
+type cancelOnWrite struct {
+	*httptest.ResponseRecorder
+	cancel context.CancelFunc
+}
+
+func (w *cancelOnWrite) Write(p []byte) (int, error) {
+	w.cancel()
+	return w.ResponseRecorder.Write(p)
+}
+
+func TestHandler_ExportWords_FailsMidStream(t *testing.T) {
+	h, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	// More words than an export reads at once, so it reads a second batch
+	for i := 0; i < 600; i++ {
+		req := &models.CreateWordRequest{Word: fmt.Sprintf("word%03d", i), Source: "Book", DateLearned: "2024-01-15"}
+		if _, err := h.wordService.Create(context.Background(), req); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	for _, format := range []string{"csv", "json"} {
+		t.Run(format, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/words/export?format="+format, nil).WithContext(ctx)
+			rec := &cancelOnWrite{ResponseRecorder: httptest.NewRecorder(), cancel: cancel}
+
+			// The failed second batch aborts the response rather than appending an error
+			defer func() {
+				if p := recover(); p != http.ErrAbortHandler {
+					t.Errorf("ExportWords() panic = %v, want http.ErrAbortHandler", p)
+				}
+				if strings.Contains(rec.Body.String(), "failed to export") {
+					t.Errorf("ExportWords() appended an error to the export: %q", rec.Body.String())
+				}
+			}()
+			router.ServeHTTP(rec, req)
+		})
+	}
+}
+
+func TestHandler_ExportWords_Filtered(t *testing.T) {
 	_, router, cleanup := setupTestHandler(t)
 	defer cleanup()
 
-	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	for _, body := range []string{
+		`{"word":"ephemeral","source":"Book","date_learned":"2024-01-15","tags":["literature"]}`,
+		`{"word":"laconic","source":"Book","date_learned":"2024-03-01"}`,
+		`{"word":"ubiquitous","source":"Article","date_learned":"2024-02-20","tags":["literature"]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/words", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req := httptest.NewRequest(http.MethodGet,
+		"/api/v1/words/export?format=tsv&tag=literature&from_date=2024-02-01&fields=word,source&fields=tags", nil)
 	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "text/tab-separated-values" {
+		t.Fatalf("ExportWords() tsv = %v %q, want 200 text/tab-separated-values", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if want := "word\tsource\ttags\nubiquitous\tArticle\tliterature\n"; rec.Body.String() != want {
+		t.Errorf("ExportWords() tsv body = %q, want %q", rec.Body.String(), want)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/words/export?format=ndjson&search=source:Book&fields=word", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n"); len(lines) != 3 || lines[1] != `{"word":"ephemeral"}` {
+		t.Errorf("ExportWords() ndjson = %q, want the header and two words", rec.Body.String())
+	}
 
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/words/export?fields=word,meaning", nil)
+	rec = httptest.NewRecorder()
 	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || rec.Header().Get("Content-Disposition") != "" ||
+		!strings.Contains(rec.Body.String(), `"field":"fields"`) {
+		t.Errorf("ExportWords() unknown field = %v %q, want 400 with an error for fields", rec.Code, rec.Body.String())
+	}
+}
 
-	if rec.Code != http.StatusOK {
-		t.Errorf("HealthCheck() status = %v, want %v", rec.Code, http.StatusOK)
+func TestHandler_ExportImportJSON(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/api/v1/words",
+		bytes.NewBufferString(`{"word":"ephemeral","source":"Book","date_learned":"2024-01-15","tags":["literature"]}`))
+	createReq.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), createReq)
+
+	tests := []struct {
+		format      string
+		contentType string
+		filename    string
+	}{
+		{format: "json", contentType: "application/json", filename: "words.json"},
+		{format: "ndjson", contentType: "application/x-ndjson", filename: "words.ndjson"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/words/export?format="+tt.format, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != http.StatusOK {
+				t.Fatalf("ExportWords() status = %v, want %v", rec.Code, http.StatusOK)
+			}
+			if got := rec.Header().Get("Content-Type"); got != tt.contentType {
+				t.Errorf("ExportWords() Content-Type = %v, want %v", got, tt.contentType)
+			}
+			if !strings.Contains(rec.Body.String(), `"schema_version":`) {
+				t.Errorf("ExportWords() body missing schema_version: %s", rec.Body.String())
+			}
+
+			// Import the export back; the format comes from the file extension
+			var buf bytes.Buffer
+			writer := multipart.NewWriter(&buf)
+			part, _ := writer.CreateFormFile("file", tt.filename)
+			part.Write(rec.Body.Bytes())
+			writer.WriteField("conflict", "overwrite")
+			writer.Close()
+
+			importReq := httptest.NewRequest(http.MethodPost, "/api/v1/words/import", &buf)
+			importReq.Header.Set("Content-Type", writer.FormDataContentType())
+			importRec := httptest.NewRecorder()
+			router.ServeHTTP(importRec, importReq)
+
+			if importRec.Code != http.StatusOK {
+				t.Fatalf("ImportWords() status = %v, body: %s", importRec.Code, importRec.Body.String())
+			}
+
+			var result services.ImportResult
+			json.NewDecoder(importRec.Body).Decode(&result)
+			if result.Updated != 1 || result.Imported != 0 {
+				t.Errorf("ImportWords() = %+v, want 1 updated", result)
+			}
+		})
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words/export?format=xml", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("ExportWords() unknown format status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandler_ExportImportMarkdown(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/api/v1/words",
+		bytes.NewBufferString(`{"word":"ephemeral","source":"Book","date_learned":"2024-01-15","tags":["literature"]}`))
+	createReq.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), createReq)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words/export?format=markdown&search=tag:literature", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "application/zip" {
+		t.Fatalf("ExportWords() = %v %q, want 200 application/zip", rec.Code, rec.Header().Get("Content-Type"))
+	}
+
+	// A zip is read back as a vault
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("file", "vault.zip")
+	part.Write(rec.Body.Bytes())
+	writer.WriteField("conflict", "overwrite")
+	writer.Close()
+
+	importReq := httptest.NewRequest(http.MethodPost, "/api/v1/words/import", &buf)
+	importReq.Header.Set("Content-Type", writer.FormDataContentType())
+	importRec := httptest.NewRecorder()
+	router.ServeHTTP(importRec, importReq)
+
+	var result services.ImportResult
+	json.NewDecoder(importRec.Body).Decode(&result)
+	if importRec.Code != http.StatusOK || result.Updated != 1 {
+		t.Errorf("ImportWords() = %v %+v, want 1 updated", importRec.Code, result)
+	}
+}
+
+func TestHandler_ExportStarDict(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/api/v1/words",
+		bytes.NewBufferString(`{"word":"ephemeral","source":"Book","date_learned":"2024-01-15","example_sentence":"An ephemeral joy"}`))
+	createReq.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), createReq)
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words/export?format=stardict&source=Book", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Disposition") != "attachment; filename=vocabulator-stardict.zip" {
+		t.Fatalf("ExportWords() = %v %q, want 200 with vocabulator-stardict.zip", rec.Code, rec.Header().Get("Content-Disposition"))
+	}
+	archive, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatalf("ExportWords() did not write a zip: %v", err)
+	}
+	if len(archive.File) != 3 || archive.File[0].Name != "Vocabulator/Vocabulator.ifo" {
+		t.Errorf("ExportWords() zip holds %d files starting with %s, want .ifo, .idx and .dict", len(archive.File),
+			archive.File[0].Name)
+	}
+}
+
+func TestHandler_ExportAnki(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/api/v1/words",
+		bytes.NewBufferString(`{"word":"ephemeral","source":"Book","date_learned":"2024-01-15","tags":["literature"]}`))
+	createReq.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), createReq)
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{name: "all words", query: "format=apkg", wantStatus: http.StatusOK},
+		{name: "filtered", query: "format=apkg&search=tag:literature", wantStatus: http.StatusOK},
+		{name: "invalid filter", query: "format=apkg&sort=popularity", wantStatus: http.StatusBadRequest},
+		{name: "invalid query", query: "format=apkg&search=pos:gerundive", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/words/export?"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("ExportWords() status = %v, want %v, body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := rec.Header().Get("Content-Type"); got != "application/apkg" {
+				t.Errorf("ExportWords() Content-Type = %v, want application/apkg", got)
+			}
+			if !bytes.HasPrefix(rec.Body.Bytes(), []byte("PK")) {
+				t.Error("ExportWords() body should be a zip archive")
+			}
+		})
+	}
+}
+
+func TestHandler_ImportAnki(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	var pkg bytes.Buffer
+	anki.WritePackage(&pkg, &anki.Deck{
+		ID:    1,
+		Name:  "GRE",
+		Model: anki.Model{ID: 10, Name: "Basic", Fields: []string{"Front", "Back"}},
+		Notes: []anki.Note{{GUID: "a", Fields: []string{"laconic", "Her laconic reply"}, Tags: []string{"gre"}}},
+	})
+
+	upload := func(path string, values map[string]string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		part, _ := writer.CreateFormFile("file", "gre.apkg")
+		part.Write(pkg.Bytes())
+		for key, value := range values {
+			writer.WriteField(key, value)
+		}
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, path, &buf)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := upload("/api/v1/words/import/anki/fields", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("InspectAnkiPackage() status = %v, body: %s", rec.Code, rec.Body.String())
+	}
+	var info services.AnkiPackageInfo
+	json.NewDecoder(rec.Body).Decode(&info)
+	if len(info.NoteTypes) != 1 || info.Mapping["Front"] != "word" {
+		t.Errorf("InspectAnkiPackage() = %+v", info)
+	}
+
+	rec = upload("/api/v1/words/import", map[string]string{"map.Front": "word", "map.Back": "meaning"})
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"field":"map.Back"`) {
+		t.Errorf("ImportWords() bad mapping = %v %s, want 400 on map.Back", rec.Code, rec.Body.String())
+	}
+
+	rec = upload("/api/v1/words/import", map[string]string{
+		"map.Front": "word", "map.Back": "example_sentence", "source": "GRE deck",
+	})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ImportWords() status = %v, body: %s", rec.Code, rec.Body.String())
+	}
+	var result services.ImportResult
+	json.NewDecoder(rec.Body).Decode(&result)
+	if result.Imported != 1 {
+		t.Errorf("ImportWords() = %+v, want 1 imported", result)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words?source=GRE+deck", nil)
+	listRec := httptest.NewRecorder()
+	router.ServeHTTP(listRec, req)
+	if !strings.Contains(listRec.Body.String(), `"example_sentence":"Her laconic reply"`) {
+		t.Errorf("imported word = %s", listRec.Body.String())
+	}
+}
+
+func TestHandler_ImportClippings(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	clippings := "Dune (Frank Herbert)\r\n" +
+		"- Your Highlight on page 5 | Location 77 | Added on Tuesday, March 5, 2024 08:00:00 PM\r\n\r\n" +
+		"sietch\r\n==========\r\n"
+
+	upload := func(maxWords string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		part, _ := writer.CreateFormFile("file", "My Clippings.txt")
+		part.Write([]byte(clippings))
+		writer.WriteField("max_words", maxWords)
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/words/import/clippings/candidates", &buf)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := upload("many")
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"field":"max_words"`) {
+		t.Errorf("ClippingCandidates() bad max_words = %v %s, want 400 on max_words", rec.Code, rec.Body.String())
+	}
+
+	rec = upload("")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ClippingCandidates() status = %v, body: %s", rec.Code, rec.Body.String())
+	}
+	var candidates []*services.ClippingCandidate
+	json.NewDecoder(rec.Body).Decode(&candidates)
+	if len(candidates) != 1 || candidates[0].Word != "sietch" || candidates[0].Location != "77" {
+		t.Fatalf("ClippingCandidates() = %+v", candidates)
+	}
+
+	body, _ := json.Marshal(candidates)
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/words/import/clippings", bytes.NewReader(body))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ImportClippings() status = %v, body: %s", rec.Code, rec.Body.String())
+	}
+	var result services.ImportResult
+	json.NewDecoder(rec.Body).Decode(&result)
+	if result.Imported != 1 {
+		t.Errorf("ImportClippings() = %+v, want 1 imported", result)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/words?source=Dune", nil)
+	listRec := httptest.NewRecorder()
+	router.ServeHTTP(listRec, req)
+	if !strings.Contains(listRec.Body.String(), `"location":"77"`) {
+		t.Errorf("imported word = %s", listRec.Body.String())
+	}
+}
+
+func TestHandler_ScanText(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	form := url.Values{"text": {"The butler obfuscated the quarrel. Obfuscating was his habit."}, "limit": {"many"}}
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/words/scan", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), `"field":"limit"`) {
+		t.Errorf("ScanText() bad limit = %v %s, want 400 on limit", rec.Code, rec.Body.String())
+	}
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("file", "chapter.txt")
+	part.Write([]byte(form.Get("text")))
+	writer.Close()
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/words/scan", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ScanText() status = %v, body: %s", rec.Code, rec.Body.String())
+	}
+	var scan services.ScanResult
+	json.NewDecoder(rec.Body).Decode(&scan)
+	if scan.Words != 9 || len(scan.Candidates) != 2 || scan.Candidates[0].Word != "obfuscate" {
+		t.Fatalf("ScanText() = %+v", scan)
+	}
+
+	scanned := services.ScannedWords{Source: "Bleak House", DateLearned: "2024-05-01"}
+	for _, c := range scan.Candidates {
+		scanned.Words = append(scanned.Words, services.ScannedWord{Word: c.Word, Context: c.Context})
+	}
+	body, _ := json.Marshal(scanned)
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/words/scan/import", bytes.NewReader(body))
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ImportScanned() status = %v, body: %s", rec.Code, rec.Body.String())
+	}
+	var result services.ImportResult
+	json.NewDecoder(rec.Body).Decode(&result)
+	if result.Imported != 2 {
+		t.Errorf("ImportScanned() = %+v, want 2 imported", result)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/words?source=Bleak+House&search=obfuscate", nil)
+	listRec := httptest.NewRecorder()
+	router.ServeHTTP(listRec, req)
+	if !strings.Contains(listRec.Body.String(), `"example_sentence":"The butler obfuscated the quarrel."`) {
+		t.Errorf("imported word = %s", listRec.Body.String())
+	}
+}
+
+func TestHandler_Fields(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "create field",
+			method:     http.MethodPost,
+			path:       "/api/v1/fields",
+			body:       `{"name":"cefr","label":"CEFR Level","type":"enum","options":["C1","C2"]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "create invalid field",
+			method:     http.MethodPost,
+			path:       "/api/v1/fields",
+			body:       `{"name":"level","type":"enum"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "list fields",
+			method:     http.MethodGet,
+			path:       "/api/v1/fields",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "update field",
+			method:     http.MethodPut,
+			path:       "/api/v1/fields/1",
+			body:       `{"options":["B2","C1","C2"]}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "create word with custom field",
+			method:     http.MethodPost,
+			path:       "/api/v1/words",
+			body:       `{"word":"ephemeral","source":"Book","date_learned":"2024-01-15","custom_fields":{"cefr":"B2"}}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "create word with invalid custom field",
+			method:     http.MethodPost,
+			path:       "/api/v1/words",
+			body:       `{"word":"ubiquitous","source":"Book","date_learned":"2024-01-15","custom_fields":{"cefr":"A1"}}`,
+			wantStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:       "delete non-existent field",
+			method:     http.MethodDelete,
+			path:       "/api/v1/fields/9999",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("%s %s status = %v, want %v, body: %s", tt.method, tt.path, rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+
+	// Filter by the custom field value
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words?field.cefr=B2", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var response map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&response)
+	if words := response["words"].([]interface{}); len(words) != 1 {
+		t.Errorf("ListWords() with field filter count = %v, want 1", len(words))
+	}
+}
+
+func TestHandler_Facets(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	for _, body := range []string{
+		`{"word":"habeas","source":"Law Review","date_learned":"2024-01-15","tags":["latin","legal"],"part_of_speech":"noun"}`,
+		`{"word":"mens rea","source":"Law Review","date_learned":"2024-02-03","tags":["latin","legal"],"part_of_speech":"noun"}`,
+		`{"word":"ad hoc","source":"Article","date_learned":"2024-02-10","tags":["latin"],"part_of_speech":"adjective"}`,
+		`{"word":"thee","source":"Poetry","date_learned":"2024-02-11"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/words", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		want       string // "total tags sources parts_of_speech months" as value=count lists
+	}{
+		{
+			name:       "all words",
+			wantStatus: http.StatusOK,
+			want:       "4 latin=3,legal=2 Law Review=2,Article=1,Poetry=1 noun=2,adjective=1 2024-02=3,2024-01=1",
+		},
+		{
+			name:       "filter parameters and facet limit",
+			query:      "?tag=legal&facet_limit=1",
+			wantStatus: http.StatusOK,
+			want:       "2 latin=2 Law Review=2 noun=2 2024-02=1",
+		},
+		{
+			name:       "search query language",
+			query:      "?search=" + url.QueryEscape("pos:adjective after:2024-02-01"),
+			wantStatus: http.StatusOK,
+			want:       "1 latin=1 Article=1 adjective=1 2024-02=1",
+		},
+		{
+			name:       "invalid search",
+			query:      "?search=pos:gerundive",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/words/facets"+tt.query, nil)
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("GetFacets() status = %v, want %v, body: %s", rec.Code, tt.wantStatus, rec.Body.String())
+			}
+			if tt.want == "" {
+				return
+			}
+
+			var facets models.Facets
+			json.NewDecoder(rec.Body).Decode(&facets)
+			join := func(counts []models.FacetCount) string {
+				parts := make([]string, len(counts))
+				for i, fc := range counts {
+					parts[i] = fmt.Sprintf("%s=%d", fc.Value, fc.Count)
+				}
+				return strings.Join(parts, ",")
+			}
+			got := fmt.Sprintf("%d %s %s %s %s", facets.Total, join(facets.Tags), join(facets.Sources),
+				join(facets.PartsOfSpeech), join(facets.Months))
+			if got != tt.want {
+				t.Errorf("GetFacets() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandler_SavedSearches(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "create word",
+			method:     http.MethodPost,
+			path:       "/api/v1/words",
+			body:       `{"word":"rapport","source":"Interview","date_learned":"2024-01-15","tags":["interview"]}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "create word with example",
+			method:     http.MethodPost,
+			path:       "/api/v1/words",
+			body:       `{"word":"synergy","source":"Interview","date_learned":"2024-01-16","tags":["interview"],"example_sentence":"Real synergy"}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "create list",
+			method:     http.MethodPost,
+			path:       "/api/v1/lists",
+			body:       `{"name":"Needs examples","query":"-has:example","filter":{"tags":["interview"],"sort":"word"}}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "create list with bad query",
+			method:     http.MethodPost,
+			path:       "/api/v1/lists",
+			body:       `{"name":"Broken","query":"pos:gerundive"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "create duplicate list",
+			method:     http.MethodPost,
+			path:       "/api/v1/lists",
+			body:       `{"name":"needs examples"}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "random from list",
+			method:     http.MethodGet,
+			path:       "/api/v1/words/random?list=1",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "random from missing list",
+			method:     http.MethodGet,
+			path:       "/api/v1/words/random?list=9999",
+			wantStatus: http.StatusNotFound,
+		},
+		{
+			name:       "update list",
+			method:     http.MethodPut,
+			path:       "/api/v1/lists/1",
+			body:       `{"name":"Interview words","filter":{"tags":["interview"],"sort":"-word"}}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "get missing list",
+			method:     http.MethodGet,
+			path:       "/api/v1/lists/9999",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("%s %s status = %v, want %v, body: %s", tt.method, tt.path, rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+
+	// The list endpoint reports live counts
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/lists", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var lists struct {
+		Lists []models.SavedSearch `json:"lists"`
+	}
+	json.NewDecoder(rec.Body).Decode(&lists)
+	if len(lists.Lists) != 1 || lists.Lists[0].Name != "Interview words" || lists.Lists[0].Count != 2 {
+		t.Errorf("ListSavedSearches() = %+v, want Interview words with 2 words", lists.Lists)
+	}
+
+	// The list's words use its stored sort
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/lists/1/words?limit=1", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var page struct {
+		Total      int           `json:"total"`
+		Words      []models.Word `json:"words"`
+		NextCursor string        `json:"next_cursor"`
+	}
+	json.NewDecoder(rec.Body).Decode(&page)
+	if page.Total != 2 || len(page.Words) != 1 || page.Words[0].Word != "synergy" || page.NextCursor == "" {
+		t.Errorf("ListSavedSearchWords() = %+v, want synergy first of 2 with a next cursor", page)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/lists/1", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("DeleteSavedSearch() status = %v, want %v", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestHandler_ImportProfiles(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "create profile",
+			method:     http.MethodPost,
+			path:       "/api/v1/import-profiles",
+			body:       `{"name":"Team sheet","columns":{"Term":"word"},"delimiter":";","date_format":"MM/DD/YYYY","defaults":{"source":"Team sheet"}}`,
+			wantStatus: http.StatusCreated,
+		},
+		{
+			name:       "create profile with unknown column target",
+			method:     http.MethodPost,
+			path:       "/api/v1/import-profiles",
+			body:       `{"name":"Broken","columns":{"Level":"level"}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "update profile",
+			method:     http.MethodPut,
+			path:       "/api/v1/import-profiles/1",
+			body:       `{"name":"Team sheet","columns":{"Term":"word","Learned":"date_learned"},"delimiter":";","date_format":"MM/DD/YYYY","defaults":{"source":"Team sheet"}}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "get missing profile",
+			method:     http.MethodGet,
+			path:       "/api/v1/import-profiles/9999",
+			wantStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+
+			router.ServeHTTP(rec, req)
+
+			if rec.Code != tt.wantStatus {
+				t.Errorf("%s %s status = %v, want %v, body: %s", tt.method, tt.path, rec.Code, tt.wantStatus, rec.Body.String())
+			}
+		})
+	}
+
+	importCSV := func(profile string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		part, _ := writer.CreateFormFile("file", "sheet.csv")
+		part.Write([]byte("Term;Learned\nephemeral;03/15/2024\n"))
+		writer.WriteField("profile", profile)
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/words/import", &buf)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := importCSV("9999"); rec.Code != http.StatusBadRequest {
+		t.Errorf("ImportWords() with missing profile status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+
+	rec := importCSV("1")
+	var result services.ImportResult
+	json.NewDecoder(rec.Body).Decode(&result)
+	if rec.Code != http.StatusOK || result.Imported != 1 {
+		t.Errorf("ImportWords() with profile = %v %+v, want 1 imported", rec.Code, result)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/import-profiles", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var profiles struct {
+		Profiles []models.ImportProfile `json:"profiles"`
+	}
+	json.NewDecoder(rec.Body).Decode(&profiles)
+	if len(profiles.Profiles) != 1 || profiles.Profiles[0].Columns["Learned"] != "date_learned" {
+		t.Errorf("ListImportProfiles() = %+v, want the updated Team sheet", profiles.Profiles)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/v1/import-profiles/1", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("DeleteImportProfile() status = %v, want %v", rec.Code, http.StatusNoContent)
+	}
+}
+
+func TestHandler_Attachments(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/api/v1/words",
+		bytes.NewBufferString(`{"word":"ephemeral","source":"Book","date_learned":"2024-01-15"}`))
+	createReq.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(httptest.NewRecorder(), createReq)
+
+	// Upload a small PNG
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	var pngData bytes.Buffer
+	png.Encode(&pngData, img)
+
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+	part, _ := writer.CreateFormFile("file", "mnemonic.png")
+	part.Write(pngData.Bytes())
+	writer.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/words/1/attachments", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("UploadAttachment() status = %v, want %v, body: %s", rec.Code, http.StatusCreated, rec.Body.String())
+	}
+
+	var attachment models.Attachment
+	json.NewDecoder(rec.Body).Decode(&attachment)
+
+	// Fetch the file and check caching headers
+	req = httptest.NewRequest(http.MethodGet, attachment.URL, nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GetAttachmentFile() status = %v, want %v", rec.Code, http.StatusOK)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "image/png" {
+		t.Errorf("GetAttachmentFile() Content-Type = %v, want image/png", ct)
+	}
+	etag := rec.Header().Get("ETag")
+	if etag == "" || !strings.HasPrefix(rec.Header().Get("Cache-Control"), "private") {
+		t.Errorf("GetAttachmentFile() missing private caching headers: %v", rec.Header())
+	}
+
+	// A matching ETag is answered with 304
+	req = httptest.NewRequest(http.MethodGet, attachment.URL, nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusNotModified {
+		t.Errorf("GetAttachmentFile() with If-None-Match status = %v, want %v", rec.Code, http.StatusNotModified)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, attachment.ThumbnailURL, nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if ct := rec.Header().Get("Content-Type"); rec.Code != http.StatusOK || ct != "image/jpeg" {
+		t.Errorf("GetAttachmentThumbnail() status = %v, Content-Type = %v", rec.Code, ct)
+	}
+
+	// Text files are rejected
+	buf.Reset()
+	writer = multipart.NewWriter(&buf)
+	part, _ = writer.CreateFormFile("file", "notes.txt")
+	part.Write([]byte("not an image"))
+	writer.Close()
+
+	req = httptest.NewRequest(http.MethodPost, "/api/v1/words/1/attachments", &buf)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("UploadAttachment() with text file status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+}
+
+func TestHandler_SuggestWords(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	for _, body := range []string{
+		`{"word":"obsequious","source":"Book","date_learned":"2024-01-15"}`,
+		`{"word":"ephemeral","source":"Book","date_learned":"2024-01-15"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/words", bytes.NewBufferString(body))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words/suggest?q=obsequeous", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("SuggestWords() status = %v, want %v", rec.Code, http.StatusOK)
+	}
+
+	var suggestResp struct {
+		Suggestions []models.Suggestion `json:"suggestions"`
+	}
+	json.NewDecoder(rec.Body).Decode(&suggestResp)
+	if len(suggestResp.Suggestions) == 0 || suggestResp.Suggestions[0].Word != "obsequious" {
+		t.Errorf("SuggestWords() = %v, want obsequious first", suggestResp.Suggestions)
+	}
+
+	// A search with no results carries "did you mean" suggestions
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/words?search=ephmeral", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var listResp struct {
+		Total      int                 `json:"total"`
+		DidYouMean []models.Suggestion `json:"did_you_mean"`
+	}
+	json.NewDecoder(rec.Body).Decode(&listResp)
+	if listResp.Total != 0 || len(listResp.DidYouMean) == 0 || listResp.DidYouMean[0].Word != "ephemeral" {
+		t.Errorf("ListWords() total = %d, did_you_mean = %v", listResp.Total, listResp.DidYouMean)
+	}
+}
+
+func TestHandler_HealthCheck(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	req := httptest.NewRequest(http.MethodGet, "/health", nil)
+	rec := httptest.NewRecorder()
+
+	router.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusOK {
+		t.Errorf("HealthCheck() status = %v, want %v", rec.Code, http.StatusOK)
+	}
+}
+
+func TestHandler_ImportWords_AllOrNothing(t *testing.T) {
+	_, router, cleanup := setupTestHandler(t)
+	defer cleanup()
+
+	importCSV := func(allOrNothing string) *httptest.ResponseRecorder {
+		var buf bytes.Buffer
+		writer := multipart.NewWriter(&buf)
+		part, _ := writer.CreateFormFile("file", "words.csv")
+		part.Write([]byte("word,source,date_learned\nephemeral,Book,2024-01-15\nlaconic,Book,someday"))
+		writer.WriteField("all_or_nothing", allOrNothing)
+		writer.Close()
+
+		req := httptest.NewRequest(http.MethodPost, "/api/v1/words/import", &buf)
+		req.Header.Set("Content-Type", writer.FormDataContentType())
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := importCSV("maybe"); rec.Code != http.StatusBadRequest {
+		t.Errorf("ImportWords() invalid all_or_nothing status = %v, want %v", rec.Code, http.StatusBadRequest)
+	}
+
+	rec := importCSV("true")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("ImportWords() status = %v, body: %s", rec.Code, rec.Body.String())
+	}
+	var result services.ImportResult
+	json.NewDecoder(rec.Body).Decode(&result)
+	if !result.RolledBack || result.Imported != 0 {
+		t.Errorf("ImportWords() = %+v, want a rollback", result)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/words", nil)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var response map[string]interface{}
+	json.NewDecoder(rec.Body).Decode(&response)
+	if words := response["words"].([]interface{}); len(words) != 0 {
+		t.Errorf("rolled back import left %d words", len(words))
+	}
+}
+
+func TestUploadTimeout(t *testing.T) {
+	handler := Logger(UploadTimeout(5 * time.Second)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.Copy(io.Discard, r.Body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})))
+
+	// The upload takes longer than the server's read timeout allows
+	server := httptest.NewUnstartedServer(handler)
+	server.Config.ReadTimeout = 50 * time.Millisecond
+	server.Start()
+	defer server.Close()
+
+	body, pw := io.Pipe()
+	go func() {
+		for i := 0; i < 3; i++ {
+			pw.Write([]byte("row\n"))
+			time.Sleep(50 * time.Millisecond)
+		}
+		pw.Close()
+	}()
+
+	resp, err := http.Post(server.URL, "text/plain", body)
+	if err != nil {
+		t.Fatalf("Post() error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		t.Errorf("Post() status = %v, want %v", resp.StatusCode, http.StatusNoContent)
 	}
 }
@@ -0,0 +1,52 @@
+package api
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/services"
+)
+
+// GetImportJob handles GET /api/jobs/{id}: it reports the progress of a background
+// import and, once it is done, its result
+func (h *Handler) GetImportJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid job ID")
+		return
+	}
+
+	job, err := h.importJobService.Get(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "job not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to get job")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, job)
+}
+
+// writeImportJob responds to a queued import with its job and where to poll it
+func writeImportJob(w http.ResponseWriter, job *models.ImportJob) {
+	w.Header().Set("Location", fmt.Sprintf("/api/v1/jobs/%d", job.ID))
+	writeJSON(w, http.StatusAccepted, job)
+}
+
+// writeImportJobError responds to an import that could not be queued
+func writeImportJobError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrImportQueueFull) {
+		w.Header().Set("Retry-After", "60")
+		writeError(w, http.StatusServiceUnavailable, err.Error())
+		return
+	}
+	writeError(w, http.StatusInternalServerError, "failed to queue import")
+}
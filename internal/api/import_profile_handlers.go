@@ -0,0 +1,136 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/services"
+)
+
+// ListImportProfiles handles GET /api/import-profiles
+func (h *Handler) ListImportProfiles(w http.ResponseWriter, r *http.Request) {
+	profiles, err := h.importProfileService.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list import profiles")
+		return
+	}
+
+	if profiles == nil {
+		profiles = []*models.ImportProfile{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"profiles": profiles})
+}
+
+// GetImportProfile handles GET /api/import-profiles/{id}
+func (h *Handler) GetImportProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid import profile ID")
+		return
+	}
+
+	profile, err := h.importProfileService.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "import profile not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to get import profile")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// CreateImportProfile handles POST /api/import-profiles
+func (h *Handler) CreateImportProfile(w http.ResponseWriter, r *http.Request) {
+	var req models.ImportProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	profile, err := h.importProfileService.Create(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, profile)
+}
+
+// UpdateImportProfile handles PUT /api/import-profiles/{id}
+func (h *Handler) UpdateImportProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid import profile ID")
+		return
+	}
+
+	var req models.ImportProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	profile, err := h.importProfileService.Update(r.Context(), id, &req)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "import profile not found")
+			return
+		}
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, profile)
+}
+
+// DeleteImportProfile handles DELETE /api/import-profiles/{id}
+func (h *Handler) DeleteImportProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid import profile ID")
+		return
+	}
+
+	err = h.importProfileService.Delete(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "import profile not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to delete import profile")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// importProfile loads the import profile whose ID is the profile form value, or
+// returns nil when there is none
+func importProfile(r *http.Request, profiles *services.ImportProfileService) (*models.ImportProfile, error) {
+	value := r.FormValue("profile")
+	if value == "" {
+		return nil, nil
+	}
+
+	id, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid import profile ID %q", value)
+	}
+
+	profile, err := profiles.GetByID(r.Context(), id)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("import profile %d not found", id)
+	}
+	return profile, err
+}
@@ -46,11 +46,37 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.ResponseWriter.WriteHeader(code)
 }
 
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// UploadTimeout returns middleware that gives a request d to upload its body and be
+// answered, in place of the server's read and write timeouts, which are too short
+// for large files
+func UploadTimeout(d time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Writers without deadlines, such as in tests, have no timeouts to extend
+			rc := http.NewResponseController(w)
+			deadline := time.Now().Add(d)
+			rc.SetReadDeadline(deadline)
+			rc.SetWriteDeadline(deadline)
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
 // Recoverer recovers from panics and returns a 500 error
 func Recoverer(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		defer func() {
 			if err := recover(); err != nil {
+				// Deliberate aborts cut the connection, as net/http does without us
+				if err == http.ErrAbortHandler {
+					panic(err)
+				}
 				log.Printf("panic recovered: %v", err)
 				http.Error(w, `{"error":"internal server error"}`, http.StatusInternalServerError)
 			}
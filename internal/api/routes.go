@@ -2,11 +2,15 @@ package api
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 )
 
+// uploadTimeout bounds the requests that upload files to import, which may be large
+const uploadTimeout = 10 * time.Minute
+
 // NewRouter creates and configures the Chi router
 func NewRouter(h *Handler, apiToken string) *chi.Mux {
 	r := chi.NewRouter()
@@ -21,27 +25,7 @@ func NewRouter(h *Handler, apiToken string) *chi.Mux {
 	r.Get("/health", h.HealthCheck)
 
 	// API v1 routes
-	r.Route("/api/v1", func(r chi.Router) {
-		r.Use(JSONContentType)
-		r.Use(BearerAuth(apiToken))
-
-		r.Route("/words", func(r chi.Router) {
-			r.Get("/", h.ListWords)
-			r.Post("/", h.CreateWord)
-
-			// Special routes before /{id} to avoid conflicts
-			r.Get("/random", h.GetRandomWord)
-			r.Post("/import", h.ImportWords)
-			r.Get("/export", h.ExportWords)
-
-			r.Route("/{id}", func(r chi.Router) {
-				r.Get("/", h.GetWord)
-				r.Put("/", h.UpdateWord)
-				r.Delete("/", h.DeleteWord)
-				r.Get("/definition", h.GetWordDefinition)
-			})
-		})
-	})
+	r.Route("/api/v1", apiRoutes(h, apiToken))
 
 	return r
 }
@@ -66,19 +50,44 @@ func NewWebRouter(h *Handler, wh *WebHandler, apiToken string, staticPath string
 	// Web routes (HTML pages)
 	r.Get("/", wh.Index)
 	r.Get("/words/new", wh.NewWordForm)
+	r.Get("/words/suggest", wh.Suggest)
+	r.Get("/words/facets", wh.Facets)
 	r.Post("/words", wh.CreateWord)
 	r.Get("/words/{id}", wh.ShowWord)
 	r.Get("/words/{id}/edit", wh.EditWordForm)
 	r.Put("/words/{id}", wh.UpdateWord)
 	r.Delete("/words/{id}", wh.DeleteWord)
 	r.Get("/words/{id}/definition", wh.GetDefinition)
+	r.Post("/words/{id}/review", wh.ReviewWord)
+	r.Get("/words/{id}/attachments", wh.Attachments)
+	r.Post("/words/{id}/attachments", wh.UploadAttachment)
+	r.Delete("/attachments/{id}", wh.DeleteAttachment)
 	r.Get("/random", wh.Random)
 	r.Get("/import", wh.ImportPage)
-	r.Post("/import", wh.HandleImport)
+	r.With(UploadTimeout(uploadTimeout)).Post("/import", wh.HandleImport)
+	r.Post("/import/commit", wh.CommitImport)
+	r.Get("/import/jobs/{id}", wh.ImportJob)
+	r.With(UploadTimeout(uploadTimeout)).Post("/import/anki/fields", wh.AnkiFields)
+	r.With(UploadTimeout(uploadTimeout)).Post("/import/clippings", wh.ClippingsReview)
+	r.Post("/import/clippings/confirm", wh.ImportClippings)
+	r.With(UploadTimeout(uploadTimeout)).Post("/import/scan", wh.ScanReview)
+	r.Post("/import/scan/confirm", wh.ImportScanned)
+	r.Post("/import/profiles", wh.CreateImportProfile)
+	r.Delete("/import/profiles/{id}", wh.DeleteImportProfile)
 	r.Get("/settings", wh.Settings)
+	r.Get("/lists", wh.SmartLists)
+	r.Post("/lists", wh.CreateSmartList)
+	r.Delete("/lists/{id}", wh.DeleteSmartList)
 
 	// API v1 routes
-	r.Route("/api/v1", func(r chi.Router) {
+	r.Route("/api/v1", apiRoutes(h, apiToken))
+
+	return r
+}
+
+// apiRoutes mounts the /api/v1 routes shared by NewRouter and NewWebRouter
+func apiRoutes(h *Handler, apiToken string) func(r chi.Router) {
+	return func(r chi.Router) {
 		r.Use(JSONContentType)
 		r.Use(BearerAuth(apiToken))
 
@@ -88,7 +97,15 @@ func NewWebRouter(h *Handler, wh *WebHandler, apiToken string, staticPath string
 
 			// Special routes before /{id} to avoid conflicts
 			r.Get("/random", h.GetRandomWord)
-			r.Post("/import", h.ImportWords)
+			r.Get("/suggest", h.SuggestWords)
+			r.Get("/facets", h.GetFacets)
+			r.With(UploadTimeout(uploadTimeout)).Post("/import", h.ImportWords)
+			r.Post("/import/commit", h.CommitImport)
+			r.With(UploadTimeout(uploadTimeout)).Post("/import/anki/fields", h.InspectAnkiPackage)
+			r.With(UploadTimeout(uploadTimeout)).Post("/import/clippings/candidates", h.ClippingCandidates)
+			r.With(UploadTimeout(uploadTimeout)).Post("/import/clippings", h.ImportClippings)
+			r.With(UploadTimeout(uploadTimeout)).Post("/scan", h.ScanText)
+			r.Post("/scan/import", h.ImportScanned)
 			r.Get("/export", h.ExportWords)
 
 			r.Route("/{id}", func(r chi.Router) {
@@ -96,9 +113,52 @@ func NewWebRouter(h *Handler, wh *WebHandler, apiToken string, staticPath string
 				r.Put("/", h.UpdateWord)
 				r.Delete("/", h.DeleteWord)
 				r.Get("/definition", h.GetWordDefinition)
+				r.Post("/review", h.ReviewWord)
+				r.Get("/attachments", h.ListAttachments)
+				r.Post("/attachments", h.UploadAttachment)
 			})
 		})
-	})
 
-	return r
+		r.Route("/attachments/{id}", func(r chi.Router) {
+			r.Get("/", h.GetAttachmentFile)
+			r.Get("/thumbnail", h.GetAttachmentThumbnail)
+			r.Delete("/", h.DeleteAttachment)
+		})
+
+		r.Route("/lists", func(r chi.Router) {
+			r.Get("/", h.ListSavedSearches)
+			r.Post("/", h.CreateSavedSearch)
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", h.GetSavedSearch)
+				r.Put("/", h.UpdateSavedSearch)
+				r.Delete("/", h.DeleteSavedSearch)
+				r.Get("/words", h.ListSavedSearchWords)
+			})
+		})
+
+		r.Get("/jobs/{id}", h.GetImportJob)
+
+		r.Route("/import-profiles", func(r chi.Router) {
+			r.Get("/", h.ListImportProfiles)
+			r.Post("/", h.CreateImportProfile)
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", h.GetImportProfile)
+				r.Put("/", h.UpdateImportProfile)
+				r.Delete("/", h.DeleteImportProfile)
+			})
+		})
+
+		r.Route("/fields", func(r chi.Router) {
+			r.Get("/", h.ListFields)
+			r.Post("/", h.CreateField)
+
+			r.Route("/{id}", func(r chi.Router) {
+				r.Get("/", h.GetField)
+				r.Put("/", h.UpdateField)
+				r.Delete("/", h.DeleteField)
+			})
+		})
+	}
 }
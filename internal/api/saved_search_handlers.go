@@ -0,0 +1,147 @@
+package api
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// ListSavedSearches handles GET /api/lists
+func (h *Handler) ListSavedSearches(w http.ResponseWriter, r *http.Request) {
+	searches, err := h.savedSearchService.List(r.Context())
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "failed to list saved searches")
+		return
+	}
+
+	if searches == nil {
+		searches = []*models.SavedSearch{}
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{"lists": searches})
+}
+
+// GetSavedSearch handles GET /api/lists/{id}
+func (h *Handler) GetSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid list ID")
+		return
+	}
+
+	search, err := h.savedSearchService.GetByID(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "list not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to get saved search")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, search)
+}
+
+// CreateSavedSearch handles POST /api/lists
+func (h *Handler) CreateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	var req models.SavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	search, err := h.savedSearchService.Create(r.Context(), &req)
+	if err != nil {
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusCreated, search)
+}
+
+// UpdateSavedSearch handles PUT /api/lists/{id}
+func (h *Handler) UpdateSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid list ID")
+		return
+	}
+
+	var req models.SavedSearchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	search, err := h.savedSearchService.Update(r.Context(), id, &req)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "list not found")
+			return
+		}
+		writeServiceError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, search)
+}
+
+// DeleteSavedSearch handles DELETE /api/lists/{id}
+func (h *Handler) DeleteSavedSearch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid list ID")
+		return
+	}
+
+	err = h.savedSearchService.Delete(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "list not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to delete saved search")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListSavedSearchWords handles GET /api/lists/{id}/words; it pages like GET /api/words
+// and accepts a sort parameter that overrides the list's own
+func (h *Handler) ListSavedSearchWords(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid list ID")
+		return
+	}
+
+	filter, err := h.savedSearchService.Filter(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			writeError(w, http.StatusNotFound, "list not found")
+			return
+		}
+		var verrs validation.Errors
+		if errors.As(err, &verrs) {
+			writeServiceError(w, http.StatusBadRequest, err)
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "failed to load list")
+		return
+	}
+
+	if sort := r.URL.Query().Get("sort"); sort != "" {
+		filter.Sort = sort
+	}
+	parsePaging(r, &filter)
+
+	h.writeWordPage(w, r, filter)
+}
@@ -1,27 +1,41 @@
 package api
 
 import (
+	"bytes"
+	"database/sql"
+	"errors"
 	"html/template"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
+	"unicode"
 
 	"github.com/go-chi/chi/v5"
 
+	"github.com/lehmann314159/vocabulator/internal/dsv"
 	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/querylang"
 	"github.com/lehmann314159/vocabulator/internal/services"
+	"github.com/lehmann314159/vocabulator/internal/validation"
 )
 
 // WebHandler handles HTML template rendering
 type WebHandler struct {
-	wordSvc   *services.WordService
-	templates map[string]*template.Template
-	partials  *template.Template
+	wordSvc          *services.WordService
+	fieldSvc         *services.FieldService
+	attachmentSvc    *services.AttachmentService
+	savedSearchSvc   *services.SavedSearchService
+	importProfileSvc *services.ImportProfileService
+	importJobSvc     *services.ImportJobService
+	templates        map[string]*template.Template
+	partials         *template.Template
 }
 
 // NewWebHandler creates a new WebHandler with parsed templates
-func NewWebHandler(wordSvc *services.WordService, templatesPath string) (*WebHandler, error) {
+func NewWebHandler(wordSvc *services.WordService, fieldSvc *services.FieldService, attachmentSvc *services.AttachmentService, savedSearchSvc *services.SavedSearchService, importProfileSvc *services.ImportProfileService, importJobSvc *services.ImportJobService, templatesPath string) (*WebHandler, error) {
 	funcMap := template.FuncMap{
 		"add": func(a, b int) int {
 			return a + b
@@ -35,6 +49,13 @@ func NewWebHandler(wordSvc *services.WordService, templatesPath string) (*WebHan
 			}
 			return *s
 		},
+		"sortOptions": func() []SortOption {
+			return sortOptions
+		},
+		// highlight marks a search snippet, which the repository has already escaped, as safe HTML
+		"highlight": func(s string) template.HTML {
+			return template.HTML(s)
+		},
 	}
 
 	// Parse layout template first
@@ -74,47 +95,129 @@ func NewWebHandler(wordSvc *services.WordService, templatesPath string) (*WebHan
 	partials, err := template.New("").Funcs(funcMap).ParseFiles(
 		templatesPath+"/definition.html",
 		templatesPath+"/import_result.html",
+		templatesPath+"/import_job.html",
+		templatesPath+"/attachments.html",
+		templatesPath+"/suggestions.html",
+		templatesPath+"/smart_lists.html",
+		templatesPath+"/facets.html",
+		templatesPath+"/anki_fields.html",
+		templatesPath+"/clippings.html",
+		templatesPath+"/scan.html",
 	)
 	if err != nil {
 		return nil, err
 	}
 
 	return &WebHandler{
-		wordSvc:   wordSvc,
-		templates: templates,
-		partials:  partials,
+		wordSvc:          wordSvc,
+		fieldSvc:         fieldSvc,
+		attachmentSvc:    attachmentSvc,
+		savedSearchSvc:   savedSearchSvc,
+		importProfileSvc: importProfileSvc,
+		importJobSvc:     importJobSvc,
+		templates:        templates,
+		partials:         partials,
 	}, nil
 }
 
+// indexPageSize is how many words the index loads at a time
+const indexPageSize = 20
+
 // IndexData contains data for the index page
 type IndexData struct {
-	Title      string
-	Words      []*models.Word
-	TotalWords int64
-	Page       int
-	TotalPages int
-	Search     string
+	Title       string
+	Words       []*models.Word
+	TotalWords  int64
+	NextCursor  string // loads the next rows when the end of the list scrolls into view
+	Search      string
+	Sort        string
+	SearchError string
+	Suggestions []models.Suggestion // "did you mean" hints when a search finds nothing
+	List        *models.SavedSearch // smart list being viewed; Search narrows it further
 }
 
-// Index handles the home page / word list
-func (h *WebHandler) Index(w http.ResponseWriter, r *http.Request) {
-	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
-	if page < 1 {
-		page = 1
+// SortOption is an entry in the index page's sort menu
+type SortOption struct {
+	Value string
+	Label string
+}
+
+// sortOptions lists the sort menu entries after the default
+var sortOptions = []SortOption{
+	{Value: "-date_learned", Label: "Date learned (newest)"},
+	{Value: "date_learned", Label: "Date learned (oldest)"},
+	{Value: "word", Label: "Word (A-Z)"},
+	{Value: "-word", Label: "Word (Z-A)"},
+	{Value: "-created_at", Label: "Recently added"},
+	{Value: "created_at", Label: "First added"},
+	{Value: "-updated_at", Label: "Recently updated"},
+	{Value: "length", Label: "Shortest words"},
+	{Value: "-length", Label: "Longest words"},
+	{Value: "-difficulty", Label: "Hardest words"},
+	{Value: "difficulty", Label: "Easiest words"},
+}
+
+// SortParam returns the sort value a column header links to: ascending on first
+// click, then toggling direction. Date learned starts descending, matching the default.
+func (d IndexData) SortParam(field string) string {
+	current := d.Sort
+	if current == "" && d.Search == "" {
+		current = "-date_learned"
+	}
+
+	switch current {
+	case field:
+		return "-" + field
+	case "-" + field:
+		return field
+	}
+	if field == "date_learned" {
+		return "-" + field
+	}
+	return field
+}
+
+// SortIndicator returns an arrow for the column the list is currently sorted by
+func (d IndexData) SortIndicator(field string) string {
+	current := d.Sort
+	if current == "" && d.Search == "" {
+		current = "-date_learned"
 	}
-	limit := 20
-	offset := (page - 1) * limit
 
-	search := r.URL.Query().Get("search")
+	switch current {
+	case field:
+		return "▲"
+	case "-" + field:
+		return "▼"
+	}
+	return ""
+}
+
+// Index handles the home page / word list; ?list=<id> shows a smart list
+func (h *WebHandler) Index(w http.ResponseWriter, r *http.Request) {
+	var data IndexData
+	filter, ok := h.wordListFilter(w, r, &data)
+	if !ok {
+		return
+	}
 
-	filter := models.WordFilter{
-		Limit:  limit,
-		Offset: offset,
-		Search: search,
+	// Show search syntax errors above an empty list
+	if data.SearchError != "" {
+		h.render(w, "index.html", data)
+		return
 	}
 
-	words, err := h.wordSvc.List(r.Context(), filter)
+	filter.Limit = indexPageSize
+	filter.Cursor = r.URL.Query().Get("cursor")
+
+	page, err := h.wordSvc.ListPage(r.Context(), filter)
 	if err != nil {
+		// A stale or tampered cursor is the request's fault, not a failure to load
+		var verrs validation.Errors
+		if errors.As(err, &verrs) {
+			h.renderError(w, verrs.Error(), http.StatusBadRequest)
+			return
+		}
 		h.renderError(w, "Failed to load words", http.StatusInternalServerError)
 		return
 	}
@@ -124,37 +227,205 @@ func (h *WebHandler) Index(w http.ResponseWriter, r *http.Request) {
 		total = 0
 	}
 
-	totalPages := int(total) / limit
-	if int(total)%limit > 0 {
-		totalPages++
-	}
+	data.Words = page.Words
+	data.TotalWords = total
+	data.NextCursor = page.NextCursor
 
-	data := IndexData{
-		Words:      words,
-		TotalWords: total,
-		Page:       page,
-		TotalPages: totalPages,
-		Search:     search,
+	if filter.Search != "" && total == 0 {
+		data.Suggestions, _ = h.wordSvc.Suggest(r.Context(), filter.Search, didYouMeanLimit)
 	}
 
 	h.render(w, "index.html", data)
 }
 
+// wordListFilter reads the search, sort and smart list of the word list into data
+// and compiles them into a filter. It returns false after rendering an error page
+// for a missing list; search syntax errors are left in data.SearchError.
+func (h *WebHandler) wordListFilter(w http.ResponseWriter, r *http.Request, data *IndexData) (models.WordFilter, bool) {
+	data.Search = r.URL.Query().Get("search")
+
+	// Ignore unknown sort keys rather than failing the page
+	data.Sort = r.URL.Query().Get("sort")
+	if !validation.IsSortField(data.Sort) {
+		data.Sort = ""
+	}
+
+	var filter models.WordFilter
+	if listID, err := strconv.ParseInt(r.URL.Query().Get("list"), 10, 64); err == nil {
+		var ok bool
+		if data.List, filter, ok = h.loadSmartList(w, r, listID); !ok {
+			return filter, false
+		}
+		data.Title = data.List.Name
+		if data.Sort == "" {
+			data.Sort = filter.Sort
+		}
+	}
+
+	// The search narrows the smart list rather than being parsed together with it
+	filter.Sort = data.Sort
+	if err := querylang.Narrow(&filter, data.Search); err != nil {
+		data.SearchError = err.Error()
+	}
+	return filter, true
+}
+
+// Suggest renders datalist options for the search box
+func (h *WebHandler) Suggest(w http.ResponseWriter, r *http.Request) {
+	suggestions, err := h.wordSvc.Suggest(r.Context(), r.URL.Query().Get("search"), 0)
+	if err != nil {
+		http.Error(w, "Failed to load suggestions", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderPartial(w, "suggestions.html", suggestions)
+}
+
+// indexFacetLimit is how many chips the index shows per facet
+const indexFacetLimit = 8
+
+// FacetGroup is a row of filter chips on the index page
+type FacetGroup struct {
+	Name  string
+	Chips []FacetChip
+}
+
+// FacetChip links to the word list narrowed to one facet value
+type FacetChip struct {
+	Label string
+	Count int64
+	Href  string
+}
+
+// QueryString encodes the search, sort and smart list of the word list
+func (d IndexData) QueryString() string {
+	return d.queryWith(d.Search)
+}
+
+// queryWith encodes the word list's sort and smart list with the given search
+func (d IndexData) queryWith(search string) string {
+	values := url.Values{}
+	if search != "" {
+		values.Set("search", search)
+	}
+	if d.Sort != "" {
+		values.Set("sort", d.Sort)
+	}
+	if d.List != nil {
+		values.Set("list", strconv.FormatInt(d.List.ID, 10))
+	}
+	return values.Encode()
+}
+
+// Facets renders filter chips with counts for the current word list
+func (h *WebHandler) Facets(w http.ResponseWriter, r *http.Request) {
+	var data IndexData
+	filter, ok := h.wordListFilter(w, r, &data)
+	if !ok || data.SearchError != "" {
+		return
+	}
+
+	facets, err := h.wordSvc.Facets(r.Context(), filter, indexFacetLimit)
+	if err != nil {
+		http.Error(w, "Failed to load facets", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderPartial(w, "facets.html", facetGroups(data, filter, facets))
+}
+
+// facetGroups turns facet counts into chips that add a search qualifier, leaving
+// out values the filter already requires
+func facetGroups(data IndexData, filter models.WordFilter, facets *models.Facets) []FacetGroup {
+	chip := func(label string, count int64, qualifier string) FacetChip {
+		search := strings.TrimSpace(data.Search + " " + qualifier)
+		return FacetChip{Label: label, Count: count, Href: "/?" + data.queryWith(search)}
+	}
+
+	var groups []FacetGroup
+	add := func(name string, chips []FacetChip) {
+		if len(chips) > 0 {
+			groups = append(groups, FacetGroup{Name: name, Chips: chips})
+		}
+	}
+
+	var chips []FacetChip
+	for _, fc := range facets.Tags {
+		if q, ok := qualifier("tag", fc.Value); ok && !slices.Contains(filter.Tags, fc.Value) {
+			chips = append(chips, chip(fc.Value, fc.Count, q))
+		}
+	}
+	add("Tags", chips)
+
+	chips = nil
+	for _, fc := range facets.Sources {
+		if q, ok := qualifier("source", fc.Value); ok && !slices.Contains(filter.Sources, fc.Value) {
+			chips = append(chips, chip(fc.Value, fc.Count, q))
+		}
+	}
+	add("Sources", chips)
+
+	chips = nil
+	for _, fc := range facets.PartsOfSpeech {
+		if !slices.Contains(filter.PartsOfSpeech, fc.Value) {
+			chips = append(chips, chip(fc.Value, fc.Count, "pos:"+fc.Value))
+		}
+	}
+	add("Part of speech", chips)
+
+	// A month replaces any date range, so only offer months when none is set
+	chips = nil
+	if filter.FromDate == "" && filter.ToDate == "" {
+		for _, fc := range facets.Months {
+			month, err := time.Parse("2006-01", fc.Value)
+			if err != nil {
+				continue
+			}
+			q := "after:" + month.Format(validation.DateFormat) + " before:" + month.AddDate(0, 1, 0).Format(validation.DateFormat)
+			chips = append(chips, chip(month.Format("Jan 2006"), fc.Count, q))
+		}
+	}
+	add("Learned", chips)
+
+	return groups
+}
+
+// qualifier builds a key:value search qualifier, quoting values with spaces; values
+// containing a double quote cannot be expressed and are skipped
+func qualifier(key, value string) (string, bool) {
+	if strings.Contains(value, `"`) {
+		return "", false
+	}
+	if strings.ContainsFunc(value, unicode.IsSpace) {
+		return key + `:"` + value + `"`, true
+	}
+	return key + ":" + value, true
+}
+
 // WordFormData contains data for the word form
 type WordFormData struct {
 	Title      string
 	Word       *models.Word
 	TagsString string
+	Fields     []*models.CustomField
+	Errors     validation.Errors
 }
 
 // NewWordForm shows the form to add a new word
 func (h *WebHandler) NewWordForm(w http.ResponseWriter, r *http.Request) {
+	fields, err := h.fieldSvc.List(r.Context())
+	if err != nil {
+		h.renderError(w, "Failed to load custom fields", http.StatusInternalServerError)
+		return
+	}
+
 	today := time.Now().Format("2006-01-02")
 	data := WordFormData{
 		Title: "Add Word",
 		Word: &models.Word{
 			DateLearned: today,
 		},
+		Fields: fields,
 	}
 	h.render(w, "word_form.html", data)
 }
@@ -166,13 +437,20 @@ func (h *WebHandler) CreateWord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fields, err := h.fieldSvc.List(r.Context())
+	if err != nil {
+		h.renderError(w, "Failed to load custom fields", http.StatusInternalServerError)
+		return
+	}
+
 	tags := parseTags(r.FormValue("tags"))
 
 	req := models.CreateWordRequest{
-		Word:        r.FormValue("word"),
-		Source:      r.FormValue("source"),
-		DateLearned: r.FormValue("date_learned"),
-		Tags:        tags,
+		Word:         r.FormValue("word"),
+		Source:       r.FormValue("source"),
+		DateLearned:  r.FormValue("date_learned"),
+		Tags:         tags,
+		CustomFields: customFieldValues(r, fields),
 	}
 
 	if pos := r.FormValue("part_of_speech"); pos != "" {
@@ -182,8 +460,26 @@ func (h *WebHandler) CreateWord(w http.ResponseWriter, r *http.Request) {
 		req.ExampleSentence = &ex
 	}
 
-	_, err := h.wordSvc.Create(r.Context(), &req)
+	_, err = h.wordSvc.Create(r.Context(), &req)
 	if err != nil {
+		var verrs validation.Errors
+		if errors.As(err, &verrs) {
+			h.renderStatus(w, "word_form.html", WordFormData{
+				Title: "Add Word",
+				Word: &models.Word{
+					Word:            req.Word,
+					Source:          req.Source,
+					DateLearned:     req.DateLearned,
+					PartOfSpeech:    req.PartOfSpeech,
+					ExampleSentence: req.ExampleSentence,
+					CustomFields:    req.CustomFields,
+				},
+				TagsString: r.FormValue("tags"),
+				Fields:     fields,
+				Errors:     verrs,
+			}, http.StatusUnprocessableEntity)
+			return
+		}
 		h.renderError(w, "Failed to create word: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -194,8 +490,9 @@ func (h *WebHandler) CreateWord(w http.ResponseWriter, r *http.Request) {
 
 // WordDetailData contains data for the word detail page
 type WordDetailData struct {
-	Title string
-	Word  *models.Word
+	Title  string
+	Word   *models.Word
+	Fields []*models.CustomField
 }
 
 // ShowWord displays a single word
@@ -212,9 +509,16 @@ func (h *WebHandler) ShowWord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fields, err := h.fieldSvc.List(r.Context())
+	if err != nil {
+		h.renderError(w, "Failed to load custom fields", http.StatusInternalServerError)
+		return
+	}
+
 	data := WordDetailData{
-		Title: word.Word,
-		Word:  word,
+		Title:  word.Word,
+		Word:   word,
+		Fields: fields,
 	}
 	h.render(w, "word_detail.html", data)
 }
@@ -233,10 +537,17 @@ func (h *WebHandler) EditWordForm(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fields, err := h.fieldSvc.List(r.Context())
+	if err != nil {
+		h.renderError(w, "Failed to load custom fields", http.StatusInternalServerError)
+		return
+	}
+
 	data := WordFormData{
 		Title:      "Edit Word",
 		Word:       word,
 		TagsString: strings.Join(word.Tags, ", "),
+		Fields:     fields,
 	}
 	h.render(w, "word_form.html", data)
 }
@@ -254,6 +565,12 @@ func (h *WebHandler) UpdateWord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	fields, err := h.fieldSvc.List(r.Context())
+	if err != nil {
+		h.renderError(w, "Failed to load custom fields", http.StatusInternalServerError)
+		return
+	}
+
 	tags := parseTags(r.FormValue("tags"))
 
 	source := r.FormValue("source")
@@ -267,10 +584,30 @@ func (h *WebHandler) UpdateWord(w http.ResponseWriter, r *http.Request) {
 		PartOfSpeech:    &partOfSpeech,
 		ExampleSentence: &exampleSentence,
 		Tags:            tags,
+		CustomFields:    customFieldValues(r, fields),
 	}
 
 	_, err = h.wordSvc.Update(r.Context(), id, &req)
 	if err != nil {
+		var verrs validation.Errors
+		if errors.As(err, &verrs) {
+			h.renderStatus(w, "word_form.html", WordFormData{
+				Title: "Edit Word",
+				Word: &models.Word{
+					ID:              id,
+					Word:            r.FormValue("word"),
+					Source:          source,
+					DateLearned:     dateLearned,
+					PartOfSpeech:    &partOfSpeech,
+					ExampleSentence: &exampleSentence,
+					CustomFields:    req.CustomFields,
+				},
+				TagsString: r.FormValue("tags"),
+				Fields:     fields,
+				Errors:     verrs,
+			}, http.StatusUnprocessableEntity)
+			return
+		}
 		h.renderError(w, "Failed to update word: "+err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -287,7 +624,7 @@ func (h *WebHandler) DeleteWord(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.wordSvc.Delete(r.Context(), id)
+	err = h.attachmentSvc.DeleteWord(r.Context(), id)
 	if err != nil {
 		h.renderError(w, "Failed to delete word", http.StatusInternalServerError)
 		return
@@ -301,23 +638,66 @@ func (h *WebHandler) DeleteWord(w http.ResponseWriter, r *http.Request) {
 type RandomData struct {
 	Title string
 	Word  *models.Word
+	List  *models.SavedSearch // smart list the word was drawn from, if any
 }
 
-// Random shows a random word
+// Random shows a random word, drawn from a smart list when ?list=<id> is given
 func (h *WebHandler) Random(w http.ResponseWriter, r *http.Request) {
-	word, err := h.wordSvc.GetRandom(r.Context())
+	data := RandomData{Title: "Random Word"}
+
+	// A missing word leaves the card empty
+	if listID, err := strconv.ParseInt(r.URL.Query().Get("list"), 10, 64); err == nil {
+		list, filter, ok := h.loadSmartList(w, r, listID)
+		if !ok {
+			return
+		}
+		data.List = list
+		data.Word, _ = h.wordSvc.GetRandomMatching(r.Context(), filter)
+	} else {
+		data.Word, _ = h.wordSvc.GetRandom(r.Context())
+	}
+
+	h.render(w, "random.html", data)
+}
+
+// ReviewWord records a flash-card review from the random word page and shows the
+// next word, from the same smart list when ?list=<id> is given
+func (h *WebHandler) ReviewWord(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
 	if err != nil {
-		// No words available
-		data := RandomData{Title: "Random Word", Word: nil}
-		h.render(w, "random.html", data)
+		h.renderError(w, "Invalid word ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.wordSvc.Review(r.Context(), id, r.FormValue("remembered") == "true"); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			h.renderError(w, "Word not found", http.StatusNotFound)
+			return
+		}
+		h.renderError(w, "Failed to record review", http.StatusInternalServerError)
 		return
 	}
 
-	data := RandomData{
-		Title: "Random Word",
-		Word:  word,
+	h.Random(w, r)
+}
+
+// loadSmartList fetches a smart list and compiles its filter, rendering an error
+// page and returning false if that fails
+func (h *WebHandler) loadSmartList(w http.ResponseWriter, r *http.Request, id int64) (*models.SavedSearch, models.WordFilter, bool) {
+	list, err := h.savedSearchSvc.GetByID(r.Context(), id)
+	if err != nil {
+		h.renderError(w, "Smart list not found", http.StatusNotFound)
+		return nil, models.WordFilter{}, false
 	}
-	h.render(w, "random.html", data)
+
+	// A stored query that no longer parses is a bad request, as on the list words endpoint
+	filter, err := h.savedSearchSvc.Compile(list)
+	if err != nil {
+		h.renderError(w, "Invalid smart list: "+err.Error(), http.StatusBadRequest)
+		return nil, models.WordFilter{}, false
+	}
+
+	return list, filter, true
 }
 
 // DefinitionData contains data for the definition partial
@@ -343,52 +723,467 @@ func (h *WebHandler) GetDefinition(w http.ResponseWriter, r *http.Request) {
 	h.renderPartial(w, "definition.html", DefinitionData{Definition: def})
 }
 
+// AttachmentsData contains data for the attachments partial
+type AttachmentsData struct {
+	WordID      int64
+	Attachments []*models.Attachment
+	Compact     bool // flash card view: thumbnails only, without upload or delete
+	Error       string
+}
+
+// Attachments renders the image gallery for a word
+func (h *WebHandler) Attachments(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.renderPartial(w, "attachments.html", AttachmentsData{Error: "Invalid word ID"})
+		return
+	}
+
+	h.renderAttachments(w, r, id, r.URL.Query().Get("compact") != "", "")
+}
+
+// UploadAttachment handles an image upload from the word detail page
+func (h *WebHandler) UploadAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.renderPartial(w, "attachments.html", AttachmentsData{Error: "Invalid word ID"})
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.attachmentSvc.MaxSize()+(1<<20))
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.renderAttachments(w, r, id, false, "No file uploaded, or the file is too large")
+		return
+	}
+	defer file.Close()
+
+	message := ""
+	if _, err := h.attachmentSvc.Upload(r.Context(), id, header.Filename, file); err != nil {
+		message = "Upload failed: " + err.Error()
+	}
+
+	h.renderAttachments(w, r, id, false, message)
+}
+
+// DeleteAttachment removes an image from a word
+func (h *WebHandler) DeleteAttachment(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.renderError(w, "Invalid attachment ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.attachmentSvc.Delete(r.Context(), id); err != nil {
+		h.renderError(w, "Failed to delete attachment", http.StatusInternalServerError)
+		return
+	}
+
+	// Return empty response for HTMX to remove the figure
+	w.WriteHeader(http.StatusOK)
+}
+
+// renderAttachments renders the attachments partial for a word
+func (h *WebHandler) renderAttachments(w http.ResponseWriter, r *http.Request, wordID int64, compact bool, message string) {
+	attachments, err := h.attachmentSvc.List(r.Context(), wordID)
+	if err != nil {
+		h.renderPartial(w, "attachments.html", AttachmentsData{WordID: wordID, Error: "Failed to load images"})
+		return
+	}
+
+	h.renderPartial(w, "attachments.html", AttachmentsData{
+		WordID:      wordID,
+		Attachments: attachments,
+		Compact:     compact,
+		Error:       message,
+	})
+}
+
 // ImportData contains data for the import page
 type ImportData struct {
-	Title string
+	Title     string
+	Profiles  []*models.ImportProfile
+	Profile   int64    // profile selected for CSV files
+	Encodings []string // encodings a profile can read
 }
 
-// ImportPage shows the CSV import form
+// ImportPage shows the import form; ?profile=<id> selects an import profile
 func (h *WebHandler) ImportPage(w http.ResponseWriter, r *http.Request) {
-	data := ImportData{Title: "Import Words"}
+	profiles, err := h.importProfileSvc.List(r.Context())
+	if err != nil {
+		h.renderError(w, "Failed to load import profiles", http.StatusInternalServerError)
+		return
+	}
+
+	data := ImportData{Title: "Import Words", Profiles: profiles, Encodings: dsv.Encodings}
+	data.Profile, _ = strconv.ParseInt(r.URL.Query().Get("profile"), 10, 64)
 	h.render(w, "import.html", data)
 }
 
-// ImportResultData contains data for the import result
+// CreateImportProfile saves the CSV import profile described by the form and
+// selects it; validation errors are shown next to the form. Columns and defaults
+// are written one "name = value" pair per line.
+func (h *WebHandler) CreateImportProfile(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.renderError(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	req := models.ImportProfileRequest{
+		Name:       r.FormValue("name"),
+		Columns:    formPairs(r.FormValue("columns")),
+		Delimiter:  r.FormValue("delimiter"),
+		Quote:      r.FormValue("quote"),
+		Encoding:   r.FormValue("encoding"),
+		DateFormat: r.FormValue("date_format"),
+		Defaults:   formPairs(r.FormValue("defaults")),
+	}
+
+	profile, err := h.importProfileSvc.Create(r.Context(), &req)
+	if err != nil {
+		h.renderPartial(w, "import_profile_error", err.Error())
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/import?profile="+strconv.FormatInt(profile.ID, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteImportProfile deletes an import profile and reloads the import page
+func (h *WebHandler) DeleteImportProfile(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.renderError(w, "Invalid import profile ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.importProfileSvc.Delete(r.Context(), id); err != nil {
+		h.renderError(w, "Failed to delete import profile", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/import")
+	w.WriteHeader(http.StatusOK)
+}
+
+// formPairs reads "name = value" lines into a map, leaving out lines without "="
+func formPairs(text string) map[string]string {
+	pairs := make(map[string]string)
+	for _, line := range strings.Split(text, "\n") {
+		if name, value, ok := strings.Cut(line, "="); ok {
+			pairs[strings.TrimSpace(name)] = strings.TrimSpace(value)
+		}
+	}
+	return pairs
+}
+
+// ImportResultData contains data for the import result, or for the preview of an
+// import awaiting confirmation
 type ImportResultData struct {
-	Imported int
-	Skipped  int
-	Errors   []string
-	Error    string
+	Imported   int
+	Updated    int
+	Skipped    int
+	Errors     []string
+	Error      string
+	RolledBack bool
+	Preview    *services.ImportPreview
 }
 
-// HandleImport processes a CSV file upload
+// HandleImport processes an uploaded CSV, TSV, JSON, NDJSON, Anki, Kindle or Kobo file
 func (h *WebHandler) HandleImport(w http.ResponseWriter, r *http.Request) {
-	file, _, err := r.FormFile("file")
+	file, header, err := r.FormFile("file")
 	if err != nil {
 		h.renderPartial(w, "import_result.html", ImportResultData{Error: "No file uploaded"})
 		return
 	}
 	defer file.Close()
 
-	result, err := h.wordSvc.ImportCSV(r.Context(), file)
+	opts, err := importOptions(r, h.importProfileSvc)
+	if err != nil {
+		h.renderPartial(w, "import_result.html", ImportResultData{Error: err.Error()})
+		return
+	}
+
+	format := importFormat("", header.Filename)
+
+	if r.FormValue("dry_run") != "" {
+		var preview *services.ImportPreview
+		if format == "apkg" {
+			preview, err = h.wordSvc.PreviewAnki(r.Context(), file, header.Size, ankiImportOptions(r, opts))
+		} else {
+			preview, err = h.wordSvc.PreviewImport(r.Context(), file, format, opts)
+		}
+		if err != nil {
+			h.renderPartial(w, "import_result.html", ImportResultData{Error: err.Error()})
+			return
+		}
+		h.renderPartial(w, "import_result.html", ImportResultData{Preview: preview})
+		return
+	}
+
+	// Large files take longer than a request may, so they are imported in the
+	// background while the page polls for progress
+	job, err := h.importJobSvc.EnqueueImport(r.Context(), file, header.Filename, format, ankiImportOptions(r, opts))
 	if err != nil {
 		h.renderPartial(w, "import_result.html", ImportResultData{Error: err.Error()})
 		return
 	}
 
+	h.renderPartial(w, "import_job.html", job)
+}
+
+// CommitImport queues the rows of a previewed import to be written when the user
+// confirms it
+func (h *WebHandler) CommitImport(w http.ResponseWriter, r *http.Request) {
+	job, err := h.importJobSvc.EnqueueCommit(r.Context(), r.FormValue("token"))
+	if errors.Is(err, services.ErrImportNotStaged) {
+		h.renderPartial(w, "import_result.html", ImportResultData{Error: "This preview has expired or was already imported. Upload the file again."})
+		return
+	}
+	if err != nil {
+		h.renderPartial(w, "import_result.html", ImportResultData{Error: err.Error()})
+		return
+	}
+
+	h.renderPartial(w, "import_job.html", job)
+}
+
+// ImportJob renders the progress of a background import, which polls itself until
+// the import finishes and is replaced by its result
+func (h *WebHandler) ImportJob(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	var job *models.ImportJob
+	if err == nil {
+		job, err = h.importJobSvc.Get(r.Context(), id)
+	}
+	if err != nil {
+		h.renderPartial(w, "import_result.html", ImportResultData{Error: "This import could not be found."})
+		return
+	}
+
+	switch job.Status {
+	case models.ImportJobFailed:
+		h.renderPartial(w, "import_result.html", ImportResultData{Error: job.Error})
+	case models.ImportJobDone:
+		result, err := services.ImportJobResult(job)
+		if err != nil {
+			h.renderPartial(w, "import_result.html", ImportResultData{Error: err.Error()})
+			return
+		}
+		h.renderImportResult(w, result)
+	default:
+		h.renderPartial(w, "import_job.html", job)
+	}
+}
+
+// renderImportResult renders the outcome of an import
+func (h *WebHandler) renderImportResult(w http.ResponseWriter, result *services.ImportResult) {
 	h.renderPartial(w, "import_result.html", ImportResultData{
-		Imported: result.Imported,
-		Skipped:  result.Skipped,
-		Errors:   result.Errors,
+		Imported:   result.Imported,
+		Updated:    result.Updated,
+		Skipped:    result.Skipped,
+		Errors:     result.Errors,
+		RolledBack: result.RolledBack,
 	})
 }
 
+// AnkiFieldsData contains data for the Anki field mapping form
+type AnkiFieldsData struct {
+	Package *services.AnkiPackageInfo
+	Targets []string // word fields and custom field names a field can be mapped to
+	Error   string
+}
+
+// AnkiFields renders the field mapping form for an .apkg file chosen on the import
+// page; other files get an empty response
+func (h *WebHandler) AnkiFields(w http.ResponseWriter, r *http.Request) {
+	file, header, err := r.FormFile("file")
+	if err != nil || importFormat("", header.Filename) != "apkg" {
+		return
+	}
+	defer file.Close()
+
+	info, err := h.wordSvc.InspectAnki(r.Context(), file, header.Size)
+	if err != nil {
+		h.renderPartial(w, "anki_fields.html", AnkiFieldsData{Error: err.Error()})
+		return
+	}
+
+	targets := slices.Clone(services.AnkiTargets)
+	if fields, err := h.fieldSvc.List(r.Context()); err == nil {
+		for _, field := range fields {
+			targets = append(targets, field.Name)
+		}
+	}
+
+	h.renderPartial(w, "anki_fields.html", AnkiFieldsData{Package: info, Targets: targets})
+}
+
+// ClippingsData contains data for the Kindle highlights review form
+type ClippingsData struct {
+	Candidates []*services.ClippingCandidate
+	Error      string
+}
+
+// ClippingsReview lists the short highlights of an uploaded "My Clippings.txt" for
+// the user to pick and correct before importing them
+func (h *WebHandler) ClippingsReview(w http.ResponseWriter, r *http.Request) {
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		h.renderPartial(w, "clippings.html", ClippingsData{Error: "No file uploaded"})
+		return
+	}
+	defer file.Close()
+
+	candidates, err := h.wordSvc.ClippingCandidates(r.Context(), file, clippingWords(r.FormValue("max_words")))
+	if err != nil {
+		h.renderPartial(w, "clippings.html", ClippingsData{Error: err.Error()})
+		return
+	}
+
+	h.renderPartial(w, "clippings.html", ClippingsData{Candidates: candidates})
+}
+
+// ImportClippings imports the highlights checked on the review form. Each row's
+// fields are suffixed with its index, and include lists the checked indexes.
+func (h *WebHandler) ImportClippings(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.renderPartial(w, "import_result.html", ImportResultData{Error: "Failed to parse form"})
+		return
+	}
+
+	var candidates []*services.ClippingCandidate
+	for _, i := range r.PostForm["include"] {
+		candidates = append(candidates, &services.ClippingCandidate{
+			Key:         r.PostFormValue("key." + i),
+			Word:        r.PostFormValue("word." + i),
+			Source:      r.PostFormValue("source." + i),
+			Location:    r.PostFormValue("location." + i),
+			DateLearned: r.PostFormValue("date_learned." + i),
+		})
+	}
+	if len(candidates) == 0 {
+		h.renderPartial(w, "import_result.html", ImportResultData{Error: "No highlights selected"})
+		return
+	}
+
+	result, err := h.wordSvc.ImportClippings(r.Context(), candidates)
+	if err != nil {
+		h.renderPartial(w, "import_result.html", ImportResultData{Error: err.Error()})
+		return
+	}
+
+	h.renderImportResult(w, result)
+}
+
+// ScanData contains data for the scanned words review form
+type ScanData struct {
+	Result *services.ScanResult
+	Source string
+	Error  string
+}
+
+// ScanReview lists the uncommon words of a pasted text or an uploaded .txt or
+// .epub file for the user to pick and correct before adding them
+func (h *WebHandler) ScanReview(w http.ResponseWriter, r *http.Request) {
+	result, err := scanForm(r, h.wordSvc)
+	if err != nil {
+		h.renderPartial(w, "scan.html", ScanData{Error: err.Error()})
+		return
+	}
+
+	h.renderPartial(w, "scan.html", ScanData{Result: result, Source: strings.TrimSpace(r.FormValue("source"))})
+}
+
+// ImportScanned adds the words checked on the scan review form. Each row's
+// fields are suffixed with its index, and include lists the checked indexes.
+func (h *WebHandler) ImportScanned(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.renderPartial(w, "import_result.html", ImportResultData{Error: "Failed to parse form"})
+		return
+	}
+
+	scanned := &services.ScannedWords{
+		Source: r.PostFormValue("source"),
+		Tags:   parseTags(r.PostFormValue("tags")),
+	}
+	for _, i := range r.PostForm["include"] {
+		scanned.Words = append(scanned.Words, services.ScannedWord{
+			Word:    r.PostFormValue("word." + i),
+			Context: r.PostFormValue("context." + i),
+		})
+	}
+	if len(scanned.Words) == 0 {
+		h.renderPartial(w, "import_result.html", ImportResultData{Error: "No words selected"})
+		return
+	}
+
+	result, err := h.wordSvc.ImportScanned(r.Context(), scanned)
+	if err != nil {
+		h.renderPartial(w, "import_result.html", ImportResultData{Error: err.Error()})
+		return
+	}
+
+	h.renderImportResult(w, result)
+}
+
 // SettingsData contains data for the settings page
 type SettingsData struct {
 	Title string
 }
 
+// SmartLists renders the smart list sidebar with current word counts
+func (h *WebHandler) SmartLists(w http.ResponseWriter, r *http.Request) {
+	lists, err := h.savedSearchSvc.List(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load smart lists", http.StatusInternalServerError)
+		return
+	}
+
+	h.renderPartial(w, "smart_lists.html", lists)
+}
+
+// CreateSmartList saves the current search from the word list as a smart list and
+// opens it; validation errors are shown next to the form
+func (h *WebHandler) CreateSmartList(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.renderError(w, "Invalid form data", http.StatusBadRequest)
+		return
+	}
+
+	req := models.SavedSearchRequest{
+		Name:   r.FormValue("name"),
+		Query:  r.FormValue("search"),
+		Filter: models.WordFilter{Sort: r.FormValue("sort")},
+	}
+
+	list, err := h.savedSearchSvc.Create(r.Context(), &req)
+	if err != nil {
+		h.renderPartial(w, "smart_list_error", err.Error())
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/?list="+strconv.FormatInt(list.ID, 10))
+	w.WriteHeader(http.StatusOK)
+}
+
+// DeleteSmartList deletes a smart list and returns to the full word list
+func (h *WebHandler) DeleteSmartList(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		h.renderError(w, "Invalid list ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.savedSearchSvc.Delete(r.Context(), id); err != nil {
+		h.renderError(w, "Failed to delete smart list", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("HX-Redirect", "/")
+	w.WriteHeader(http.StatusOK)
+}
+
 // Settings shows the settings page
 func (h *WebHandler) Settings(w http.ResponseWriter, r *http.Request) {
 	data := SettingsData{Title: "Settings"}
@@ -397,8 +1192,13 @@ func (h *WebHandler) Settings(w http.ResponseWriter, r *http.Request) {
 
 // render renders a full page with layout
 func (h *WebHandler) render(w http.ResponseWriter, content string, data interface{}) {
-	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	h.renderStatus(w, content, data, http.StatusOK)
+}
 
+// renderStatus renders a page with the given status, such as a rejected form with
+// 422. The page is rendered before the status is sent, so template errors still
+// answer 500.
+func (h *WebHandler) renderStatus(w http.ResponseWriter, content string, data interface{}, status int) {
 	tmpl, ok := h.templates[content]
 	if !ok {
 		http.Error(w, "Template not found: "+content, http.StatusInternalServerError)
@@ -406,10 +1206,15 @@ func (h *WebHandler) render(w http.ResponseWriter, content string, data interfac
 	}
 
 	// Execute the layout template (which includes the content)
-	err := tmpl.ExecuteTemplate(w, "layout.html", data)
-	if err != nil {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, "layout.html", data); err != nil {
 		http.Error(w, "Template error: "+err.Error(), http.StatusInternalServerError)
+		return
 	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(status)
+	buf.WriteTo(w)
 }
 
 // renderPartial renders just a partial template (for HTMX)
@@ -444,3 +1249,13 @@ func parseTags(s string) []string {
 	}
 	return tags
 }
+
+// customFieldValues reads the field.<name> inputs for every defined custom field;
+// blank values are kept so that an update clears them
+func customFieldValues(r *http.Request, fields []*models.CustomField) map[string]string {
+	values := make(map[string]string, len(fields))
+	for _, field := range fields {
+		values[field.Name] = r.FormValue("field." + field.Name)
+	}
+	return values
+}
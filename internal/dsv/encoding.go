@@ -0,0 +1,111 @@
+package dsv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Text encodings accepted by Decode
+const (
+	EncodingAuto        = "auto"
+	EncodingUTF8        = "utf-8"
+	EncodingUTF16       = "utf-16"
+	EncodingLatin1      = "latin-1"
+	EncodingWindows1252 = "windows-1252"
+)
+
+// Encodings lists the encoding names accepted by Decode
+var Encodings = []string{EncodingAuto, EncodingUTF8, EncodingUTF16, EncodingLatin1, EncodingWindows1252}
+
+// Byte order marks
+var (
+	bomUTF8    = []byte{0xef, 0xbb, 0xbf}
+	bomUTF16LE = []byte{0xff, 0xfe}
+	bomUTF16BE = []byte{0xfe, 0xff}
+)
+
+// windows1252 maps the bytes 0x80-0x9f, where Windows-1252 differs from
+// Latin-1, to runes; unassigned bytes keep their Latin-1 control characters
+var windows1252 = [32]rune{
+	'€', 0x81, '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', 0x8d, 'Ž', 0x8f,
+	0x90, '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', 0x9d, 'ž', 'Ÿ',
+}
+
+// Detect guesses the encoding of text: a byte order mark decides, text that is
+// valid UTF-8 is taken as UTF-8, and anything else as Windows-1252, which
+// spreadsheets on Windows save in and which reads Latin-1 text the same way.
+func Detect(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return EncodingUTF8
+	case bytes.HasPrefix(data, bomUTF16LE), bytes.HasPrefix(data, bomUTF16BE):
+		return EncodingUTF16
+	case utf8.Valid(data):
+		return EncodingUTF8
+	}
+	return EncodingWindows1252
+}
+
+// Decode converts text in the named encoding to UTF-8, dropping any byte order
+// mark. An empty name or "auto" detects the encoding.
+func Decode(data []byte, encoding string) ([]byte, error) {
+	encoding = strings.ToLower(encoding)
+	if encoding == "" || encoding == EncodingAuto {
+		encoding = Detect(data)
+	}
+
+	switch encoding {
+	case EncodingUTF8:
+		data = bytes.TrimPrefix(data, bomUTF8)
+		if !utf8.Valid(data) {
+			return nil, fmt.Errorf("file is not valid UTF-8")
+		}
+		return data, nil
+	case EncodingUTF16:
+		return decodeUTF16(data)
+	case EncodingLatin1:
+		return decodeSingleByte(data, false), nil
+	case EncodingWindows1252:
+		return decodeSingleByte(data, true), nil
+	}
+	return nil, fmt.Errorf("unknown encoding %q: must be %s", encoding, strings.Join(Encodings, ", "))
+}
+
+// decodeUTF16 decodes UTF-16 text, which is little-endian unless a byte order
+// mark says otherwise
+func decodeUTF16(data []byte) ([]byte, error) {
+	var order binary.ByteOrder = binary.LittleEndian
+	switch {
+	case bytes.HasPrefix(data, bomUTF16LE):
+		data = data[2:]
+	case bytes.HasPrefix(data, bomUTF16BE):
+		data, order = data[2:], binary.BigEndian
+	}
+	if len(data)%2 != 0 {
+		return nil, fmt.Errorf("file is not valid UTF-16: odd number of bytes")
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+	return []byte(string(utf16.Decode(units))), nil
+}
+
+// decodeSingleByte decodes Latin-1 text, or Windows-1252 text when cp1252 is set
+func decodeSingleByte(data []byte, cp1252 bool) []byte {
+	var out bytes.Buffer
+	out.Grow(len(data))
+	for _, b := range data {
+		r := rune(b)
+		if cp1252 && b >= 0x80 && b <= 0x9f {
+			r = windows1252[b-0x80]
+		}
+		out.WriteRune(r)
+	}
+	return out.Bytes()
+}
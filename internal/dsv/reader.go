@@ -0,0 +1,143 @@
+// Package dsv reads delimiter-separated values: CSV files with a configurable
+// delimiter and quote character, in any of the text encodings spreadsheets save in.
+package dsv
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Errors wrapped in a ParseError
+var (
+	ErrFieldCount = errors.New("wrong number of fields")
+	ErrQuote      = errors.New("extraneous or missing quote in quoted field")
+)
+
+// ParseError reports the line a record starts on along with what is wrong with it
+type ParseError struct {
+	Line int
+	Err  error
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("record on line %d: %v", e.Line, e.Err)
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// Reader reads records from delimited text. As with encoding/csv, empty lines
+// are skipped, a field that starts with the quote character may hold delimiters,
+// line breaks and doubled quotes, and every record must have as many fields as
+// the first one.
+type Reader struct {
+	Comma rune // field delimiter; defaults to ','
+	Quote rune // quote character; 0 reads quotes as ordinary text
+
+	r      *bufio.Reader
+	line   int
+	fields int
+}
+
+// NewReader returns a Reader for comma-separated values with double quotes
+func NewReader(r io.Reader) *Reader {
+	return &Reader{Comma: ',', Quote: '"', r: bufio.NewReader(r)}
+}
+
+// Read reads the next record. A record with the wrong number of fields is
+// returned along with an ErrFieldCount error, so that reading can go on.
+func (r *Reader) Read() ([]string, error) {
+	var line string
+	for line == "" {
+		var err error
+		if line, err = r.readLine(); err != nil {
+			return nil, err
+		}
+	}
+	start := r.line
+
+	record, err := r.parse(line)
+	if err != nil {
+		return nil, &ParseError{Line: start, Err: err}
+	}
+
+	if r.fields == 0 {
+		r.fields = len(record)
+	} else if len(record) != r.fields {
+		return record, &ParseError{Line: start, Err: ErrFieldCount}
+	}
+	return record, nil
+}
+
+// readLine reads a line without its line ending; it returns io.EOF only when
+// there is nothing left
+func (r *Reader) readLine() (string, error) {
+	line, err := r.r.ReadString('\n')
+	if err == io.EOF && line == "" {
+		return "", io.EOF
+	}
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	r.line++
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"), nil
+}
+
+// parse splits a line into fields, reading further lines while a quoted field
+// is open
+func (r *Reader) parse(line string) ([]string, error) {
+	comma := r.Comma
+	if comma == 0 {
+		comma = ','
+	}
+
+	var record []string
+	var field strings.Builder
+	quoted, atStart, closed := false, true, false
+
+	for {
+		for _, c := range line {
+			switch {
+			case quoted && c == r.Quote:
+				quoted, closed = false, true
+			case quoted:
+				field.WriteRune(c)
+			case closed && c == r.Quote:
+				// A doubled quote inside a quoted field
+				field.WriteRune(c)
+				quoted, closed = true, false
+			case c == comma:
+				record = append(record, field.String())
+				field.Reset()
+				atStart, closed = true, false
+			case closed:
+				return nil, ErrQuote
+			case atStart && r.Quote != 0 && c == r.Quote:
+				quoted, atStart = true, false
+			default:
+				field.WriteRune(c)
+				atStart = false
+			}
+		}
+
+		if !quoted {
+			break
+		}
+
+		next, err := r.readLine()
+		if err == io.EOF {
+			return nil, ErrQuote
+		}
+		if err != nil {
+			return nil, err
+		}
+		field.WriteRune('\n')
+		line = next
+	}
+
+	return append(record, field.String()), nil
+}
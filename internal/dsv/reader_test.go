@@ -0,0 +1,132 @@
+package dsv
+
+import (
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReader(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		comma rune
+		quote rune
+		want  [][]string
+	}{
+		{
+			name:  "comma and double quotes",
+			input: "word,example\r\nephemeral,\"Fame, \"\"they say\"\", is ephemeral.\"\r\n\r\nlaconic,\n",
+			comma: ',', quote: '"',
+			want: [][]string{{"word", "example"}, {"ephemeral", `Fame, "they say", is ephemeral.`}, {"laconic", ""}},
+		},
+		{
+			name:  "semicolon and single quotes",
+			input: "Term;Notes\nverre;'un; deux'\n",
+			comma: ';', quote: '\'',
+			want: [][]string{{"Term", "Notes"}, {"verre", "un; deux"}},
+		},
+		{
+			name:  "line break in quoted field",
+			input: "word\tnote\nephemeral\t\"first\nsecond\"\n",
+			comma: '\t', quote: '"',
+			want: [][]string{{"word", "note"}, {"ephemeral", "first\nsecond"}},
+		},
+		{
+			name:  "no quoting",
+			input: "word,note\n\"ephemeral\",it's \"short\"",
+			comma: ',', quote: 0,
+			want: [][]string{{"word", "note"}, {`"ephemeral"`, `it's "short"`}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewReader(strings.NewReader(tt.input))
+			r.Comma, r.Quote = tt.comma, tt.quote
+
+			var got [][]string
+			for {
+				record, err := r.Read()
+				if err == io.EOF {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Read() error = %v", err)
+				}
+				got = append(got, record)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Read() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReader_Errors(t *testing.T) {
+	r := NewReader(strings.NewReader("word,source\nephemeral\n\"bad\"x,Book\nlaconic,Book\n\"open,Book\n"))
+
+	if _, err := r.Read(); err != nil {
+		t.Fatalf("Read() header error = %v", err)
+	}
+
+	record, err := r.Read()
+	var perr *ParseError
+	if !errors.As(err, &perr) || !errors.Is(err, ErrFieldCount) || perr.Line != 2 || len(record) != 1 {
+		t.Errorf("Read() short record = %q, %v, want ErrFieldCount on line 2", record, err)
+	}
+	if _, err := r.Read(); !errors.Is(err, ErrQuote) {
+		t.Errorf("Read() text after quote error = %v, want ErrQuote", err)
+	}
+	if record, err := r.Read(); err != nil || record[0] != "laconic" {
+		t.Errorf("Read() after errors = %q, %v, want the next record", record, err)
+	}
+	if _, err := r.Read(); !errors.Is(err, ErrQuote) {
+		t.Errorf("Read() unterminated quote error = %v, want ErrQuote", err)
+	}
+	if _, err := r.Read(); err != io.EOF {
+		t.Errorf("Read() at end error = %v, want io.EOF", err)
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		encoding string
+		want     string
+		detected string
+	}{
+		{"utf-8", []byte("café"), "", "café", EncodingUTF8},
+		{"utf-8 with bom", []byte("\xef\xbb\xbfcafé"), "auto", "café", EncodingUTF8},
+		{"latin-1", []byte("caf\xe9"), EncodingLatin1, "café", EncodingWindows1252},
+		{"windows-1252", []byte("\x93caf\xe9\x94 \x80"), "", "“café” €", EncodingWindows1252},
+		{"utf-16le with bom", []byte("\xff\xfec\x00a\x00f\x00\xe9\x00"), "", "café", EncodingUTF16},
+		{"utf-16be with bom", []byte("\xfe\xff\x00c\x00a\x00f\x00\xe9"), "", "café", EncodingUTF16},
+		{"utf-16le", []byte("c\x00a\x00f\x00\xe9\x00"), EncodingUTF16, "café", EncodingWindows1252},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Detect(tt.data); got != tt.detected {
+				t.Errorf("Detect() = %q, want %q", got, tt.detected)
+			}
+			got, err := Decode(tt.data, tt.encoding)
+			if err != nil {
+				t.Fatalf("Decode() error = %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("Decode() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Decode([]byte("caf\xe9"), EncodingUTF8); err == nil {
+		t.Error("Decode() should reject invalid UTF-8")
+	}
+	if _, err := Decode([]byte("x"), "ebcdic"); err == nil {
+		t.Error("Decode() should reject unknown encodings")
+	}
+}
@@ -0,0 +1,111 @@
+// Package fuzzy implements the string similarity measures used for typo-tolerant
+// word suggestions: trigram sets for candidate lookup and Damerau-Levenshtein
+// distance for ranking.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Normalize lowercases s and drops everything except letters, digits and inner spaces
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(s)) {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) || r == ' ' {
+			b.WriteRune(r)
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// Trigrams returns the sorted, distinct three-rune sequences of the normalized
+// string, padded with two leading spaces and one trailing space so that short
+// strings and word starts carry weight
+func Trigrams(s string) []string {
+	s = Normalize(s)
+	if s == "" {
+		return nil
+	}
+
+	runes := []rune("  " + s + " ")
+	seen := make(map[string]bool)
+	trigrams := make([]string, 0, len(runes))
+	for i := 0; i+3 <= len(runes); i++ {
+		t := string(runes[i : i+3])
+		if !seen[t] {
+			seen[t] = true
+			trigrams = append(trigrams, t)
+		}
+	}
+	sort.Strings(trigrams)
+	return trigrams
+}
+
+// Similarity returns the Jaccard similarity of the trigram sets of a and b, from 0 to 1
+func Similarity(a, b string) float64 {
+	ta, tb := Trigrams(a), Trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+
+	set := make(map[string]bool, len(ta))
+	for _, t := range ta {
+		set[t] = true
+	}
+	shared := 0
+	for _, t := range tb {
+		if set[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// Distance returns the Damerau-Levenshtein distance (optimal string alignment)
+// between the normalized forms of a and b: the number of insertions, deletions,
+// substitutions and adjacent transpositions needed to turn one into the other
+func Distance(a, b string) int {
+	ra, rb := []rune(Normalize(a)), []rune(Normalize(b))
+
+	// Three rolling rows: two back (for transpositions), previous and current
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				curr[j] = min(curr[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, curr = prev, curr, prev2
+	}
+
+	return prev[len(rb)]
+}
+
+// MaxDistance is the largest edit distance still considered a plausible typo for a
+// query of the given length in runes
+func MaxDistance(length int) int {
+	switch {
+	case length <= 2:
+		return 0
+	case length <= 5:
+		return 1
+	case length <= 9:
+		return 2
+	default:
+		return 3
+	}
+}
@@ -0,0 +1,67 @@
+package fuzzy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{a: "", b: "", want: 0},
+		{a: "word", b: "", want: 4},
+		{a: "obsequious", b: "obsequious", want: 0},
+		{a: "obsequeous", b: "obsequious", want: 1},
+		{a: "recieve", b: "receive", want: 1},
+		{a: "ephmeeral", b: "ephemeral", want: 1},
+		{a: "kitten", b: "sitting", want: 3},
+		{a: "Naïve", b: "naïve", want: 0},
+		{a: "ca", b: "abc", want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			if got := Distance(tt.a, tt.b); got != tt.want {
+				t.Errorf("Distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := Distance(tt.b, tt.a); got != tt.want {
+				t.Errorf("Distance(%q, %q) = %d, want %d", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrigrams(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{s: "", want: nil},
+		{s: "a", want: []string{"  a", " a "}},
+		{s: "Cat!", want: []string{"  c", " ca", "at ", "cat"}},
+		{s: "aaaa", want: []string{"  a", " aa", "aa ", "aaa"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			if got := Trigrams(tt.s); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Trigrams(%q) = %q, want %q", tt.s, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	if got := Similarity("ephemeral", "Ephemeral"); got != 1 {
+		t.Errorf("Similarity() of equal words = %v, want 1", got)
+	}
+	if got := Similarity("ephemeral", "xyz"); got != 0 {
+		t.Errorf("Similarity() of unrelated words = %v, want 0", got)
+	}
+	close, far := Similarity("obsequeous", "obsequious"), Similarity("obsequeous", "obvious")
+	if close <= far {
+		t.Errorf("Similarity() close = %v, far = %v; want close > far", close, far)
+	}
+}
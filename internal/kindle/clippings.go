@@ -0,0 +1,235 @@
+package kindle
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Clipping kinds
+const (
+	KindHighlight = "highlight"
+	KindNote      = "note"
+	KindBookmark  = "bookmark"
+)
+
+// Clipping is one entry of a "My Clippings.txt" file
+type Clipping struct {
+	Book     string
+	Author   string
+	Kind     string
+	Page     string
+	Location string    // such as "170-171"
+	Added    time.Time // zero when the date could not be read
+	Text     string
+}
+
+// clippingSeparator ends every entry of the file
+const clippingSeparator = "=========="
+
+// Words that identify the kind of a clipping in the languages Kindle writes the
+// file in. Highlights are checked first, since some note words are substrings of
+// other languages' text.
+var (
+	highlightWords = []string{"highlight", "markierung", "surlignement", "subrayado", "evidenziazione",
+		"destaque", "markering", "ハイライト", "标注", "하이라이트"}
+	noteWords     = []string{"note", "notiz", "nota", "notitie", "メモ", "笔记", "메모"}
+	bookmarkWords = []string{"bookmark", "lesezeichen", "signet", "marcador", "segnalibro", "bladwijzer",
+		"ブックマーク", "书签", "북마크"}
+)
+
+var (
+	// titleAuthor splits "Title (Author)"
+	titleAuthor = regexp.MustCompile(`^(.*?)\s*\(([^()]*)\)$`)
+
+	locationPattern = regexp.MustCompile(`(?i)(?:location|loc\.|position|emplacement|posici[oó]n|posizione|posi[cç][aã]o|positie|位置(?:no\.)?|위치)\s*#?\s*(\d+(?:-\d+)?)`)
+	pagePattern     = regexp.MustCompile(`(?i)(?:page|seite|p[aá]gina|pagina|pag\.)\s*(\d+(?:-\d+)?)|(\d+(?:-\d+)?)\s*ページ|第\s*(\d+(?:-\d+)?)\s*页|(\d+(?:-\d+)?)\s*페이지`)
+
+	cjkDatePattern = regexp.MustCompile(`(\d{4})\s*[年년]\s*(\d{1,2})\s*[月월]\s*(\d{1,2})\s*[日일]`)
+	timePattern    = regexp.MustCompile(`(\d{1,2}):(\d{2})(?::(\d{2}))?(\s*[AaPp]\.?\s?[Mm]\.?)?`)
+	yearPattern    = regexp.MustCompile(`\b(\d{4})\b`)
+	dayPattern     = regexp.MustCompile(`\b(\d{1,2})\b`)
+)
+
+// monthNames maps month names in the languages Kindle uses to months
+var monthNames = map[string]time.Month{}
+
+func init() {
+	for _, names := range [][]string{
+		{"january", "february", "march", "april", "may", "june", "july", "august", "september", "october", "november", "december"},
+		{"januar", "februar", "märz", "april", "mai", "juni", "juli", "august", "september", "oktober", "november", "dezember"},
+		{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"},
+		{"enero", "febrero", "marzo", "abril", "mayo", "junio", "julio", "agosto", "septiembre", "octubre", "noviembre", "diciembre"},
+		{"gennaio", "febbraio", "marzo", "aprile", "maggio", "giugno", "luglio", "agosto", "settembre", "ottobre", "novembre", "dicembre"},
+		{"janeiro", "fevereiro", "março", "abril", "maio", "junho", "julho", "agosto", "setembro", "outubro", "novembro", "dezembro"},
+		{"januari", "februari", "maart", "april", "mei", "juni", "juli", "augustus", "september", "oktober", "november", "december"},
+	} {
+		for i, name := range names {
+			monthNames[name] = time.Month(i + 1)
+		}
+	}
+	monthNames["setiembre"] = time.September
+}
+
+// ParseClippings reads the entries of a "My Clippings.txt" file in any of the
+// languages Kindle writes it in. Entries that cannot be read are left out.
+func ParseClippings(r io.Reader) ([]Clipping, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+
+	var clippings []Clipping
+	var entry []string
+	for scanner.Scan() {
+		line := strings.TrimRight(strings.TrimPrefix(scanner.Text(), "\ufeff"), "\r")
+		if strings.TrimSpace(line) == clippingSeparator {
+			if clipping, ok := parseClipping(entry); ok {
+				clippings = append(clippings, clipping)
+			}
+			entry = entry[:0]
+			continue
+		}
+		entry = append(entry, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read clippings: %w", err)
+	}
+
+	// The last entry may lack its separator
+	if clipping, ok := parseClipping(entry); ok {
+		clippings = append(clippings, clipping)
+	}
+	return clippings, nil
+}
+
+// parseClipping reads one entry: the title line, the metadata line, a blank line
+// and the clipped text
+func parseClipping(lines []string) (Clipping, bool) {
+	for len(lines) > 0 && strings.TrimSpace(lines[0]) == "" {
+		lines = lines[1:]
+	}
+	if len(lines) < 2 {
+		return Clipping{}, false
+	}
+
+	var clipping Clipping
+	title := strings.TrimSpace(strings.TrimPrefix(lines[0], "\ufeff"))
+	if m := titleAuthor.FindStringSubmatch(title); m != nil && m[1] != "" {
+		clipping.Book, clipping.Author = m[1], strings.TrimSpace(m[2])
+	} else {
+		clipping.Book = title
+	}
+
+	meta := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(lines[1]), "-"))
+	clipping.Kind = clippingKind(meta)
+
+	// The date comes after the last separator; page and location before it
+	details, date := meta, ""
+	if i := strings.LastIndexAny(meta, "|｜"); i >= 0 {
+		details, date = meta[:i], meta[i:]
+	}
+	if m := locationPattern.FindStringSubmatch(details); m != nil {
+		clipping.Location = m[1]
+	}
+	if m := pagePattern.FindStringSubmatch(details); m != nil {
+		clipping.Page = firstNonEmpty(m[1:])
+	}
+	clipping.Added, _ = parseClippingDate(date)
+
+	var text []string
+	for _, line := range lines[2:] {
+		if line = strings.TrimSpace(line); line != "" {
+			text = append(text, line)
+		}
+	}
+	clipping.Text = strings.Join(text, "\n")
+
+	return clipping, true
+}
+
+// clippingKind classifies the metadata line of an entry
+func clippingKind(meta string) string {
+	lower := strings.ToLower(meta)
+	for _, kind := range []struct {
+		name  string
+		words []string
+	}{
+		{KindHighlight, highlightWords},
+		{KindBookmark, bookmarkWords},
+		{KindNote, noteWords},
+	} {
+		for _, word := range kind.words {
+			if strings.Contains(lower, word) {
+				return kind.name
+			}
+		}
+	}
+	return KindHighlight
+}
+
+// parseClippingDate reads the "Added on" part of a metadata line, such as
+// "Added on Sunday, March 3, 2024 10:15:32 PM", "Hinzugefügt am Sonntag, 3. März
+// 2024 22:15:32" or "作成日: 2024年3月3日日曜日 22:15:32"
+func parseClippingDate(s string) (time.Time, bool) {
+	hour, minute, second := 0, 0, 0
+	if m := timePattern.FindStringSubmatch(s); m != nil {
+		hour, _ = strconv.Atoi(m[1])
+		minute, _ = strconv.Atoi(m[2])
+		second, _ = strconv.Atoi(m[3])
+
+		marker := strings.ToLower(strings.NewReplacer(".", "", " ", "").Replace(m[4]))
+		pm := marker == "pm" || strings.Contains(s, "下午") || strings.Contains(s, "오후")
+		am := marker == "am" || strings.Contains(s, "上午") || strings.Contains(s, "오전")
+		if pm && hour < 12 {
+			hour += 12
+		} else if am && hour == 12 {
+			hour = 0
+		}
+		s = strings.Replace(s, m[0], " ", 1)
+	}
+
+	var year, day int
+	var month time.Month
+	if m := cjkDatePattern.FindStringSubmatch(s); m != nil {
+		year, _ = strconv.Atoi(m[1])
+		monthNum, _ := strconv.Atoi(m[2])
+		month = time.Month(monthNum)
+		day, _ = strconv.Atoi(m[3])
+	} else {
+		m := yearPattern.FindStringSubmatch(s)
+		if m == nil {
+			return time.Time{}, false
+		}
+		year, _ = strconv.Atoi(m[1])
+		s = strings.Replace(s, m[0], " ", 1)
+
+		for _, word := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !unicode.IsLetter(r) }) {
+			if found, ok := monthNames[word]; ok {
+				month = found
+				break
+			}
+		}
+		if d := dayPattern.FindStringSubmatch(s); d != nil {
+			day, _ = strconv.Atoi(d[1])
+		}
+	}
+
+	if month < time.January || month > time.December || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+	return time.Date(year, month, day, hour, minute, second, 0, time.Local), true
+}
+
+// firstNonEmpty returns the first non-empty string
+func firstNonEmpty(values []string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,157 @@
+package kindle
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseClippings(t *testing.T) {
+	input := "\ufeffMoby-Dick (Herman Melville)\r\n" +
+		"- Your Highlight on page 12 | Location 170-171 | Added on Sunday, March 3, 2024 10:15:32 PM\r\n" +
+		"\r\n" +
+		"leviathan\r\n" +
+		"==========\r\n" +
+		"Moby-Dick (Herman Melville)\r\n" +
+		"- Your Bookmark on page 13 | Location 180 | Added on Sunday, March 3, 2024 10:20:00 PM\r\n" +
+		"\r\n" +
+		"\r\n" +
+		"==========\r\n" +
+		"Der Steppenwolf (Hesse, Hermann)\r\n" +
+		"- Ihre Notiz auf Seite 5 | Position 77 | Hinzugefügt am Montag, 4. März 2024 08:01:02\r\n" +
+		"\r\n" +
+		"Look this up\r\n" +
+		"==========\r\n" +
+		"Untitled\r\n" +
+		"- Your Highlight Loc. 12-14 | Added on Tuesday, March 1, 2011, 07:27 PM\r\n" +
+		"\r\n" +
+		"ephemeral,\r\n"
+
+	clippings, err := ParseClippings(strings.NewReader(input))
+	if err != nil {
+		t.Fatalf("ParseClippings() error = %v", err)
+	}
+	if len(clippings) != 4 {
+		t.Fatalf("ParseClippings() = %d clippings, want 4", len(clippings))
+	}
+
+	want := Clipping{
+		Book: "Moby-Dick", Author: "Herman Melville", Kind: KindHighlight, Page: "12", Location: "170-171",
+		Added: time.Date(2024, time.March, 3, 22, 15, 32, 0, time.Local), Text: "leviathan",
+	}
+	if clippings[0] != want {
+		t.Errorf("ParseClippings()[0] = %+v, want %+v", clippings[0], want)
+	}
+	if clippings[1].Kind != KindBookmark || clippings[1].Text != "" {
+		t.Errorf("ParseClippings()[1] = %+v, want an empty bookmark", clippings[1])
+	}
+	if c := clippings[2]; c.Kind != KindNote || c.Author != "Hesse, Hermann" || c.Page != "5" || c.Location != "77" {
+		t.Errorf("ParseClippings()[2] = %+v, want a note at page 5, location 77", c)
+	}
+	if c := clippings[3]; c.Book != "Untitled" || c.Author != "" || c.Location != "12-14" || c.Text != "ephemeral," {
+		t.Errorf("ParseClippings()[3] = %+v, want an untitled highlight at 12-14", c)
+	}
+}
+
+func TestParseClippingMeta(t *testing.T) {
+	tests := []struct {
+		name     string
+		meta     string
+		kind     string
+		page     string
+		location string
+		added    time.Time
+	}{
+		{
+			name:     "english",
+			meta:     "- Your Highlight at location 170-171 | Added on Sunday, 3 March 2024 22:15:32",
+			kind:     KindHighlight,
+			location: "170-171",
+			added:    time.Date(2024, time.March, 3, 22, 15, 32, 0, time.Local),
+		},
+		{
+			name:     "english 12 am",
+			meta:     "- Your Highlight on Location 9 | Added on Friday, January 5, 2024 12:05:00 AM",
+			kind:     KindHighlight,
+			location: "9",
+			added:    time.Date(2024, time.January, 5, 0, 5, 0, 0, time.Local),
+		},
+		{
+			name:     "german",
+			meta:     "- Ihre Markierung auf Seite 12 | Position 170-171 | Hinzugefügt am Sonntag, 3. März 2024 22:15:32",
+			kind:     KindHighlight,
+			page:     "12",
+			location: "170-171",
+			added:    time.Date(2024, time.March, 3, 22, 15, 32, 0, time.Local),
+		},
+		{
+			name:     "french",
+			meta:     "- Votre surlignement sur la page 12 | emplacement 170-171 | Ajouté le dimanche 3 mars 2024 22:15:32",
+			kind:     KindHighlight,
+			page:     "12",
+			location: "170-171",
+			added:    time.Date(2024, time.March, 3, 22, 15, 32, 0, time.Local),
+		},
+		{
+			name:     "spanish",
+			meta:     "- Tu subrayado en la página 12 | posición 170-171 | Añadido el domingo, 3 de marzo de 2024 22:15:32",
+			kind:     KindHighlight,
+			page:     "12",
+			location: "170-171",
+			added:    time.Date(2024, time.March, 3, 22, 15, 32, 0, time.Local),
+		},
+		{
+			name:     "italian",
+			meta:     "- La tua evidenziazione a pagina 12 | posizione 170-171 | Aggiunto in data domenica 3 marzo 2024 22:15:32",
+			kind:     KindHighlight,
+			page:     "12",
+			location: "170-171",
+			added:    time.Date(2024, time.March, 3, 22, 15, 32, 0, time.Local),
+		},
+		{
+			name:     "portuguese",
+			meta:     "- Seu destaque na posição 170-171 | Adicionado: domingo, 3 de março de 2024 22:15:32",
+			kind:     KindHighlight,
+			location: "170-171",
+			added:    time.Date(2024, time.March, 3, 22, 15, 32, 0, time.Local),
+		},
+		{
+			name:     "japanese",
+			meta:     "- 12ページ|位置No. 170-171のハイライト |作成日: 2024年3月3日日曜日 22:15:32",
+			kind:     KindHighlight,
+			page:     "12",
+			location: "170-171",
+			added:    time.Date(2024, time.March, 3, 22, 15, 32, 0, time.Local),
+		},
+		{
+			name:     "chinese",
+			meta:     "- 您在第 12 页（位置 #170-171）的标注 | 添加于 2024年3月3日星期日 下午10:15:32",
+			kind:     KindHighlight,
+			page:     "12",
+			location: "170-171",
+			added:    time.Date(2024, time.March, 3, 22, 15, 32, 0, time.Local),
+		},
+		{
+			name: "unreadable date",
+			meta: "- Your Note on page 3 | Added on someday",
+			kind: KindNote,
+			page: "3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clipping, ok := parseClipping([]string{"Book", tt.meta, "", "text"})
+			if !ok {
+				t.Fatal("parseClipping() failed")
+			}
+			if clipping.Kind != tt.kind || clipping.Page != tt.page || clipping.Location != tt.location {
+				t.Errorf("parseClipping() = kind %q, page %q, location %q; want %q, %q, %q",
+					clipping.Kind, clipping.Page, clipping.Location, tt.kind, tt.page, tt.location)
+			}
+			if !clipping.Added.Equal(tt.added) {
+				t.Errorf("parseClipping() added = %v, want %v", clipping.Added, tt.added)
+			}
+		})
+	}
+}
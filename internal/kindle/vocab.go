@@ -0,0 +1,94 @@
+// Package kindle reads the vocabulary a Kindle collects: the Vocabulary Builder
+// database (vocab.db) of dictionary lookups and the highlights in "My
+// Clippings.txt".
+package kindle
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ErrNotVocabDB is returned for files that are not a Vocabulary Builder database
+var ErrNotVocabDB = errors.New("not a Kindle vocab.db file")
+
+// Lookup is a looked-up word with the first time it was looked up
+type Lookup struct {
+	Key     string // WORDS.id, such as "en:ephemeral"; stable across devices
+	Word    string // the word as it appeared in the book
+	Stem    string // its dictionary form
+	Lang    string
+	Book    string
+	Authors string
+	Usage   string // the sentence the word was looked up in
+	Time    time.Time
+}
+
+// ReadVocab reads every looked-up word from a vocab.db file, oldest first. A word
+// looked up several times is returned once, with its earliest lookup.
+func ReadVocab(r io.Reader) ([]Lookup, error) {
+	dir, err := os.MkdirTemp("", "kindle")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "vocab.db")
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy vocab.db: %w", err)
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to copy vocab.db: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to copy vocab.db: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocab.db: %w", err)
+	}
+	defer db.Close()
+
+	var tables int
+	err = db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name IN ('WORDS', 'LOOKUPS', 'BOOK_INFO')`).Scan(&tables)
+	if err != nil || tables != 3 {
+		return nil, ErrNotVocabDB
+	}
+
+	// The earliest lookup of each word; ties go to the lowest lookup ID
+	rows, err := db.Query(`
+		SELECT w.id, coalesce(w.word, ''), coalesce(w.stem, ''), coalesce(w.lang, ''),
+			coalesce(b.title, ''), coalesce(b.authors, ''), coalesce(l.usage, ''), l.timestamp
+		FROM WORDS w
+		JOIN LOOKUPS l ON l.id = (
+			SELECT id FROM LOOKUPS WHERE word_key = w.id ORDER BY timestamp, id LIMIT 1
+		)
+		LEFT JOIN BOOK_INFO b ON b.id = l.book_key
+		ORDER BY l.timestamp, w.id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lookups: %w", err)
+	}
+	defer rows.Close()
+
+	var lookups []Lookup
+	for rows.Next() {
+		var lookup Lookup
+		var millis int64
+		if err := rows.Scan(&lookup.Key, &lookup.Word, &lookup.Stem, &lookup.Lang,
+			&lookup.Book, &lookup.Authors, &lookup.Usage, &millis); err != nil {
+			return nil, fmt.Errorf("failed to read lookups: %w", err)
+		}
+		lookup.Time = time.UnixMilli(millis)
+		lookups = append(lookups, lookup)
+	}
+	return lookups, rows.Err()
+}
@@ -0,0 +1,92 @@
+package kindle
+
+import (
+	"bytes"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// vocabSchema is the schema of the Vocabulary Builder database
+const vocabSchema = `
+CREATE TABLE WORDS (id TEXT PRIMARY KEY NOT NULL, word TEXT, stem TEXT, lang TEXT,
+	category INTEGER DEFAULT 0, timestamp INTEGER DEFAULT 0, profileid TEXT);
+CREATE TABLE LOOKUPS (id TEXT PRIMARY KEY NOT NULL, word_key TEXT, book_key TEXT, dict_key TEXT,
+	pos TEXT, usage TEXT, timestamp INTEGER DEFAULT 0);
+CREATE TABLE BOOK_INFO (id TEXT PRIMARY KEY NOT NULL, asin TEXT, guid TEXT, lang TEXT,
+	title TEXT, authors TEXT);
+CREATE TABLE DICT_INFO (id TEXT PRIMARY KEY NOT NULL, asin TEXT, langin TEXT, langout TEXT);
+`
+
+// writeVocabDB creates a vocab.db file from SQL statements and returns its contents
+func writeVocabDB(t *testing.T, statements string) []byte {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "vocab.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(statements); err != nil {
+		t.Fatalf("failed to create vocab.db: %v", err)
+	}
+	db.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestReadVocab(t *testing.T) {
+	data := writeVocabDB(t, vocabSchema+`
+		INSERT INTO BOOK_INFO (id, title, authors) VALUES ('b1', 'Moby-Dick', 'Herman Melville'), ('b2', 'Dune', 'Frank Herbert');
+		INSERT INTO WORDS (id, word, stem, lang) VALUES ('en:ephemeral', 'ephemeral', 'ephemeral', 'en'),
+			('en:leviathans', 'leviathans', 'leviathan', 'en');
+		INSERT INTO LOOKUPS (id, word_key, book_key, usage, timestamp) VALUES
+			('l1', 'en:leviathans', 'b1', 'The leviathans swam past.', 1700000000000),
+			('l2', 'en:ephemeral', 'b2', 'An ephemeral peace.', 1710000000000),
+			('l3', 'en:leviathans', 'b2', 'Later lookup.', 1720000000000);
+	`)
+
+	lookups, err := ReadVocab(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadVocab() error = %v", err)
+	}
+	if len(lookups) != 2 {
+		t.Fatalf("ReadVocab() = %d lookups, want 2", len(lookups))
+	}
+
+	want := Lookup{
+		Key: "en:leviathans", Word: "leviathans", Stem: "leviathan", Lang: "en",
+		Book: "Moby-Dick", Authors: "Herman Melville", Usage: "The leviathans swam past.",
+		Time: time.UnixMilli(1700000000000),
+	}
+	if lookups[0] != want {
+		t.Errorf("ReadVocab()[0] = %+v, want %+v", lookups[0], want)
+	}
+	if lookups[1].Key != "en:ephemeral" || lookups[1].Book != "Dune" {
+		t.Errorf("ReadVocab()[1] = %+v, want ephemeral from Dune", lookups[1])
+	}
+}
+
+func TestReadVocab_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "not sqlite", data: []byte("word,source,date_learned")},
+		{name: "other database", data: writeVocabDB(t, `CREATE TABLE words (id INTEGER PRIMARY KEY)`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadVocab(bytes.NewReader(tt.data)); err == nil {
+				t.Error("ReadVocab() should return error")
+			}
+		})
+	}
+}
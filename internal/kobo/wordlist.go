@@ -0,0 +1,125 @@
+// Package kobo reads the words looked up on a Kobo e-reader from its
+// KoboReader.sqlite database.
+package kobo
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+// ErrNotKoboDB is returned for files that are not a Kobo reader database
+var ErrNotKoboDB = errors.New("not a KoboReader.sqlite file")
+
+// bookContentType is the content.ContentType of whole books, as opposed to their
+// chapters
+const bookContentType = 6
+
+// Entry is a word saved to the Kobo word list
+type Entry struct {
+	Word    string
+	Lang    string // the dictionary's language, such as "en"
+	Book    string // empty when the word was not looked up in a book
+	Author  string
+	Created time.Time // zero when the date could not be read
+}
+
+// createdLayouts are the formats Kobo firmware versions write WordList.DateCreated in
+var createdLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.000",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05.000-07:00",
+	"2006-01-02 15:04:05",
+}
+
+// ReadWordList reads every word in the word list of a KoboReader.sqlite file,
+// oldest first, with the title of the book it was looked up in
+func ReadWordList(r io.Reader) ([]Entry, error) {
+	dir, err := os.MkdirTemp("", "kobo")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	dbPath := filepath.Join(dir, "KoboReader.sqlite")
+	file, err := os.Create(dbPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to copy KoboReader.sqlite: %w", err)
+	}
+	if _, err := io.Copy(file, r); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to copy KoboReader.sqlite: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return nil, fmt.Errorf("failed to copy KoboReader.sqlite: %w", err)
+	}
+
+	db, err := sql.Open("sqlite3", "file:"+dbPath+"?mode=ro")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open KoboReader.sqlite: %w", err)
+	}
+	defer db.Close()
+
+	var tables int
+	err = db.QueryRow(`SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name IN ('WordList', 'content')`).Scan(&tables)
+	if err != nil || tables != 2 {
+		return nil, ErrNotKoboDB
+	}
+
+	rows, err := db.Query(`
+		SELECT w.Text, coalesce(w.DictSuffix, ''), coalesce(w.VolumeId, ''), coalesce(w.DateCreated, ''),
+			coalesce(c.Title, ''), coalesce(c.Attribution, '')
+		FROM WordList w
+		LEFT JOIN content c ON c.ContentID = w.VolumeId AND c.ContentType = ?
+		ORDER BY w.DateCreated, w.Text`, bookContentType)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read word list: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Entry
+	for rows.Next() {
+		var entry Entry
+		var volume, created string
+		if err := rows.Scan(&entry.Word, &entry.Lang, &volume, &created, &entry.Book, &entry.Author); err != nil {
+			return nil, fmt.Errorf("failed to read word list: %w", err)
+		}
+		entry.Lang = strings.TrimPrefix(entry.Lang, "-")
+		if entry.Book == "" {
+			entry.Book = volumeTitle(volume)
+		}
+		entry.Created = parseCreated(created)
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+// volumeTitle names a book missing from the content table after its file, so that
+// "file:///mnt/onboard/Moby-Dick.epub" becomes "Moby-Dick". Store books are
+// identified by a UUID, which is no title at all.
+func volumeTitle(volume string) string {
+	if !strings.HasPrefix(volume, "file://") {
+		return ""
+	}
+	name := path.Base(volume)
+	return strings.TrimSuffix(strings.TrimSuffix(name, path.Ext(name)), ".kepub")
+}
+
+// parseCreated reads a DateCreated value, which Kobo writes in UTC
+func parseCreated(value string) time.Time {
+	for _, layout := range createdLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,90 @@
+package kobo
+
+import (
+	"bytes"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// koboSchema is the part of the KoboReader.sqlite schema the word list uses
+const koboSchema = `
+CREATE TABLE content (ContentID TEXT NOT NULL, ContentType TEXT NOT NULL, MimeType TEXT NOT NULL,
+	BookID TEXT, BookTitle TEXT, Title TEXT, Attribution TEXT, PRIMARY KEY (ContentID));
+CREATE TABLE WordList (Text TEXT NOT NULL, VolumeId TEXT NULL, DictSuffix TEXT NULL,
+	DateCreated TEXT NULL, PRIMARY KEY (Text));
+`
+
+// writeKoboDB creates a KoboReader.sqlite file from SQL statements and returns its
+// contents
+func writeKoboDB(t *testing.T, statements string) []byte {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "KoboReader.sqlite")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec(statements); err != nil {
+		t.Fatalf("failed to create KoboReader.sqlite: %v", err)
+	}
+	db.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestReadWordList(t *testing.T) {
+	data := writeKoboDB(t, koboSchema+`
+		INSERT INTO content (ContentID, ContentType, MimeType, Title, Attribution) VALUES
+			('1f0e2b3c-uuid', '6', 'application/x-kobo-epub+zip', 'Dune', 'Frank Herbert'),
+			('1f0e2b3c-uuid!OEBPS!ch01.html', '9', 'application/xhtml+xml', 'Chapter 1', '');
+		INSERT INTO WordList (Text, VolumeId, DictSuffix, DateCreated) VALUES
+			('sietch', '1f0e2b3c-uuid', '-en', '2024-03-05T20:00:00Z'),
+			('leviathan', 'file:///mnt/onboard/Melville/Moby-Dick.kepub.epub', '-en', '2024-03-01T08:30:00.000'),
+			('gom jabbar', NULL, '', 'soon');
+	`)
+
+	entries, err := ReadWordList(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("ReadWordList() error = %v", err)
+	}
+	if len(entries) != 3 {
+		t.Fatalf("ReadWordList() = %d entries, want 3", len(entries))
+	}
+
+	want := []Entry{
+		{Word: "leviathan", Lang: "en", Book: "Moby-Dick", Created: time.Date(2024, time.March, 1, 8, 30, 0, 0, time.UTC)},
+		{Word: "sietch", Lang: "en", Book: "Dune", Author: "Frank Herbert", Created: time.Date(2024, time.March, 5, 20, 0, 0, 0, time.UTC)},
+		{Word: "gom jabbar"},
+	}
+	for i, entry := range entries {
+		if entry.Word != want[i].Word || entry.Lang != want[i].Lang || entry.Book != want[i].Book ||
+			entry.Author != want[i].Author || !entry.Created.Equal(want[i].Created) {
+			t.Errorf("ReadWordList()[%d] = %+v, want %+v", i, entry, want[i])
+		}
+	}
+}
+
+func TestReadWordList_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "not sqlite", data: []byte("word,source,date_learned")},
+		{name: "other database", data: writeKoboDB(t, `CREATE TABLE WordList (Text TEXT)`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := ReadWordList(bytes.NewReader(tt.data)); err == nil {
+				t.Error("ReadWordList() should return error")
+			}
+		})
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// Attachment is an image uploaded for a word, such as a visual mnemonic
+type Attachment struct {
+	ID           int64     `json:"id"`
+	WordID       int64     `json:"word_id"`
+	Filename     string    `json:"filename"`
+	ContentType  string    `json:"content_type"`
+	Size         int64     `json:"size"`
+	Width        int       `json:"width"`
+	Height       int       `json:"height"`
+	Checksum     string    `json:"checksum"` // hex SHA-256 of the file, used as the ETag
+	URL          string    `json:"url"`
+	ThumbnailURL string    `json:"thumbnail_url"`
+	CreatedAt    time.Time `json:"created_at"`
+}
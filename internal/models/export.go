@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+)
+
+// ExportSchemaVersion is the version of the JSON export format written by this build
+const ExportSchemaVersion = 1
+
+// ExportHeader describes a JSON or NDJSON export. In NDJSON it is the first line,
+// followed by one word per line.
+type ExportHeader struct {
+	SchemaVersion int            `json:"schema_version"`
+	ExportedAt    time.Time      `json:"exported_at"`
+	Fields        []*CustomField `json:"fields"` // custom field definitions the words' values refer to
+}
+
+// ExportDocument is a full-fidelity JSON export: every word with its ID, timestamps
+// and custom field values
+type ExportDocument struct {
+	ExportHeader
+	Words []*Word `json:"words"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+)
+
+// FieldType is the value type of a custom field
+type FieldType string
+
+// Supported custom field types
+const (
+	FieldTypeText   FieldType = "text"
+	FieldTypeNumber FieldType = "number"
+	FieldTypeEnum   FieldType = "enum"
+	FieldTypeDate   FieldType = "date"
+)
+
+// CustomField is a user-defined attribute that can be set on every word
+type CustomField struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Label     string    `json:"label"`
+	Type      FieldType `json:"type"`
+	Options   []string  `json:"options,omitempty"` // allowed values for enum fields
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// CreateFieldRequest represents the request body for defining a custom field
+type CreateFieldRequest struct {
+	Name    string    `json:"name"`
+	Label   string    `json:"label,omitempty"`
+	Type    FieldType `json:"type"`
+	Options []string  `json:"options,omitempty"`
+}
+
+// UpdateFieldRequest represents the request body for updating a custom field;
+// the name and type are fixed once values exist
+type UpdateFieldRequest struct {
+	Label   *string  `json:"label,omitempty"`
+	Options []string `json:"options,omitempty"`
+}
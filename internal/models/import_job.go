@@ -0,0 +1,37 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// ImportJobStatus is how far a background import has got
+type ImportJobStatus string
+
+// Import job statuses
+const (
+	ImportJobQueued  ImportJobStatus = "queued"  // waiting for earlier imports to finish
+	ImportJobRunning ImportJobStatus = "running" // reading the file or writing its rows
+	ImportJobDone    ImportJobStatus = "done"    // finished; Result holds the outcome
+	ImportJobFailed  ImportJobStatus = "failed"  // stopped without importing; Error says why
+)
+
+// ImportJob is an import run in the background, so that large files are not
+// bound by the server's request timeouts
+type ImportJob struct {
+	ID         int64           `json:"id"`
+	Filename   string          `json:"filename,omitempty"` // the uploaded file; empty when confirming a preview
+	Status     ImportJobStatus `json:"status"`
+	Total      int             `json:"total"`            // rows to write; 0 until the file has been read
+	Processed  int             `json:"processed"`        // rows written or skipped so far
+	Result     json.RawMessage `json:"result,omitempty"` // the import result so far, as JSON, and in full once done
+	Error      string          `json:"error,omitempty"`
+	CreatedAt  time.Time       `json:"created_at"`
+	UpdatedAt  time.Time       `json:"updated_at"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+}
+
+// Finished reports whether the job is done or has failed
+func (j *ImportJob) Finished() bool {
+	return j.Status == ImportJobDone || j.Status == ImportJobFailed
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// ImportProfile describes how to read a CSV file laid out differently from the
+// export format, such as a spreadsheet with its own headers, delimiter, encoding
+// and date format. Empty settings keep the export format's.
+type ImportProfile struct {
+	ID         int64             `json:"id"`
+	Name       string            `json:"name"`
+	Columns    map[string]string `json:"columns,omitempty"`     // file header to word field or custom field name; "" skips the column
+	Delimiter  string            `json:"delimiter,omitempty"`   // a single character; defaults to ","
+	Quote      string            `json:"quote,omitempty"`       // a single character or "none"; defaults to `"`
+	Encoding   string            `json:"encoding,omitempty"`    // defaults to detecting it
+	DateFormat string            `json:"date_format,omitempty"` // such as MM/DD/YYYY; defaults to YYYY-MM-DD
+	Defaults   map[string]string `json:"defaults,omitempty"`    // values for columns that are missing or empty
+	CreatedAt  time.Time         `json:"created_at"`
+	UpdatedAt  time.Time         `json:"updated_at"`
+}
+
+// ImportProfileRequest represents the request body for creating or replacing an import profile
+type ImportProfileRequest struct {
+	Name       string            `json:"name"`
+	Columns    map[string]string `json:"columns,omitempty"`
+	Delimiter  string            `json:"delimiter,omitempty"`
+	Quote      string            `json:"quote,omitempty"`
+	Encoding   string            `json:"encoding,omitempty"`
+	DateFormat string            `json:"date_format,omitempty"`
+	Defaults   map[string]string `json:"defaults,omitempty"`
+}
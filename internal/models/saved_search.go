@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// SavedSearch is a named smart list: a search query and filter that are run again
+// every time the list is opened, so its words follow the collection
+type SavedSearch struct {
+	ID        int64      `json:"id"`
+	Name      string     `json:"name"`
+	Query     string     `json:"query"`  // search query language, combined with Filter.Search
+	Filter    WordFilter `json:"filter"` // structured filters, as accepted by the word list
+	Count     int64      `json:"count"`  // words currently matching, filled in when read
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// SavedSearchRequest represents the request body for creating or replacing a saved search
+type SavedSearchRequest struct {
+	Name   string     `json:"name"`
+	Query  string     `json:"query,omitempty"`
+	Filter WordFilter `json:"filter,omitempty"`
+}
@@ -6,46 +6,100 @@ import (
 
 // Word represents a vocabulary word entity
 type Word struct {
-	ID              int64     `json:"id"`
-	Word            string    `json:"word"`
-	Source          string    `json:"source"`
-	DateLearned     string    `json:"date_learned"` // YYYY-MM-DD format
-	PartOfSpeech    *string   `json:"part_of_speech,omitempty"`
-	ExampleSentence *string   `json:"example_sentence,omitempty"`
-	Tags            []string  `json:"tags"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	ID              int64             `json:"id"`
+	Word            string            `json:"word"`
+	Source          string            `json:"source"`
+	DateLearned     string            `json:"date_learned"` // YYYY-MM-DD format
+	PartOfSpeech    *string           `json:"part_of_speech,omitempty"`
+	ExampleSentence *string           `json:"example_sentence,omitempty"`
+	Tags            []string          `json:"tags"`
+	CustomFields    map[string]string `json:"custom_fields,omitempty"`
+	Snippet         string            `json:"snippet,omitempty"` // search match as HTML with <mark> highlights
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
 }
 
 // CreateWordRequest represents the request body for creating a word
 type CreateWordRequest struct {
-	Word            string   `json:"word"`
-	Source          string   `json:"source"`
-	DateLearned     string   `json:"date_learned"`
-	PartOfSpeech    *string  `json:"part_of_speech,omitempty"`
-	ExampleSentence *string  `json:"example_sentence,omitempty"`
-	Tags            []string `json:"tags,omitempty"`
+	Word            string            `json:"word"`
+	Source          string            `json:"source"`
+	DateLearned     string            `json:"date_learned"`
+	PartOfSpeech    *string           `json:"part_of_speech,omitempty"`
+	ExampleSentence *string           `json:"example_sentence,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	CustomFields    map[string]string `json:"custom_fields,omitempty"`
 }
 
 // UpdateWordRequest represents the request body for updating a word
+// CustomFields is merged into the existing values; an empty value removes the field
 type UpdateWordRequest struct {
-	Word            *string  `json:"word,omitempty"`
-	Source          *string  `json:"source,omitempty"`
-	DateLearned     *string  `json:"date_learned,omitempty"`
-	PartOfSpeech    *string  `json:"part_of_speech,omitempty"`
-	ExampleSentence *string  `json:"example_sentence,omitempty"`
-	Tags            []string `json:"tags,omitempty"`
+	Word            *string           `json:"word,omitempty"`
+	Source          *string           `json:"source,omitempty"`
+	DateLearned     *string           `json:"date_learned,omitempty"`
+	PartOfSpeech    *string           `json:"part_of_speech,omitempty"`
+	ExampleSentence *string           `json:"example_sentence,omitempty"`
+	Tags            []string          `json:"tags,omitempty"`
+	CustomFields    map[string]string `json:"custom_fields,omitempty"`
+}
+
+// ReviewRequest represents the request body for recording a flash-card review
+type ReviewRequest struct {
+	Remembered bool `json:"remembered"`
 }
 
 // WordFilter represents query parameters for filtering words
+// Tag and Source are single-value forms of Tags and Sources. The JSON form, used by
+// saved searches, leaves out the shorthands and the paging fields.
 type WordFilter struct {
-	Search   string
-	Source   string
-	Tag      string
-	FromDate string
-	ToDate   string
-	Limit    int
-	Offset   int
+	Search               string            `json:"search,omitempty"`
+	Source               string            `json:"-"`
+	Sources              []string          `json:"sources,omitempty"`         // word comes from any of these sources
+	ExcludeSources       []string          `json:"exclude_sources,omitempty"` // word comes from none of these sources
+	Tag                  string            `json:"-"`
+	Tags                 []string          `json:"tags,omitempty"`                    // word has all of these tags
+	AnyTags              []string          `json:"any_tags,omitempty"`                // word has at least one of these tags
+	ExcludeTags          []string          `json:"exclude_tags,omitempty"`            // word has none of these tags
+	PartsOfSpeech        []string          `json:"parts_of_speech,omitempty"`         // word is any of these parts of speech
+	ExcludePartsOfSpeech []string          `json:"exclude_parts_of_speech,omitempty"` // word is none of these parts of speech
+	Has                  []string          `json:"has,omitempty"`                     // word has a value for each of these: example, pos, tags
+	Missing              []string          `json:"missing,omitempty"`                 // word has no value for any of these
+	FromDate             string            `json:"from_date,omitempty"`
+	ToDate               string            `json:"to_date,omitempty"`
+	Fields               map[string]string `json:"fields,omitempty"` // custom field name -> exact value
+	Sort                 string            `json:"sort,omitempty"`   // sort key, "-" prefix for descending; empty means newest first
+	Limit                int               `json:"-"`
+	Offset               int               `json:"-"`
+	Cursor               string            `json:"-"` // next_cursor from a previous page; takes precedence over Offset
+}
+
+// WordPage is one page of a word list
+type WordPage struct {
+	Words      []*Word `json:"words"`
+	NextCursor string  `json:"next_cursor,omitempty"` // empty on the last page
+}
+
+// FacetCount is the number of matching words sharing one value of a facet
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// Facets groups the words matching a filter by tag, source, part of speech and
+// month learned; each group is ordered by count, except months which run newest first
+type Facets struct {
+	Total         int64        `json:"total"`
+	Tags          []FacetCount `json:"tags"`
+	Sources       []FacetCount `json:"sources"`
+	PartsOfSpeech []FacetCount `json:"parts_of_speech"`
+	Months        []FacetCount `json:"months"` // YYYY-MM
+}
+
+// Suggestion is a word close to a fuzzy query
+type Suggestion struct {
+	ID         int64   `json:"id"`
+	Word       string  `json:"word"`
+	Distance   int     `json:"distance"`   // Damerau-Levenshtein edits from the query
+	Similarity float64 `json:"similarity"` // trigram similarity from 0 to 1
 }
 
 // DictionaryEntry represents a response from the dictionary API
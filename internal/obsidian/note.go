@@ -0,0 +1,441 @@
+// Package obsidian writes and reads Markdown notes as kept in an Obsidian vault:
+// a body of Markdown with properties in YAML front matter, linked to each other
+// with wiki-links.
+package obsidian
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrFrontMatter is returned for front matter this package cannot read
+var ErrFrontMatter = errors.New("invalid front matter")
+
+// Property is a front matter property: a text value, or a list of them
+type Property struct {
+	Key    string
+	Value  string
+	List   []string
+	IsList bool
+}
+
+// Note is a Markdown note with its front matter properties in order
+type Note struct {
+	Properties []Property
+	Body       string
+}
+
+// Set adds a text property, or replaces the value of an existing one
+func (n *Note) Set(key, value string) {
+	n.set(Property{Key: key, Value: value})
+}
+
+// SetList adds a list property, or replaces the values of an existing one
+func (n *Note) SetList(key string, values []string) {
+	n.set(Property{Key: key, List: values, IsList: true})
+}
+
+func (n *Note) set(p Property) {
+	for i := range n.Properties {
+		if n.Properties[i].Key == p.Key {
+			n.Properties[i] = p
+			return
+		}
+	}
+	n.Properties = append(n.Properties, p)
+}
+
+// Get returns a property by key
+func (n *Note) Get(key string) (Property, bool) {
+	for _, p := range n.Properties {
+		if p.Key == key {
+			return p, true
+		}
+	}
+	return Property{}, false
+}
+
+// Value returns a text property, or a list property's values joined with ", "
+func (n *Note) Value(key string) string {
+	p, _ := n.Get(key)
+	if p.IsList {
+		return strings.Join(p.List, ", ")
+	}
+	return p.Value
+}
+
+// Values returns a list property, or the comma-separated items of a text property
+func (n *Note) Values(key string) []string {
+	p, _ := n.Get(key)
+	if p.IsList {
+		return p.List
+	}
+	var values []string
+	for _, v := range strings.Split(p.Value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// Format returns the note as Markdown, its properties as front matter. Empty
+// text properties and lists are left out.
+func Format(n *Note) []byte {
+	var b strings.Builder
+	var props []Property
+	for _, p := range n.Properties {
+		if (p.IsList && len(p.List) > 0) || (!p.IsList && p.Value != "") {
+			props = append(props, p)
+		}
+	}
+
+	if len(props) > 0 {
+		b.WriteString("---\n")
+		for _, p := range props {
+			b.WriteString(formatKey(p.Key))
+			if !p.IsList {
+				b.WriteString(": " + formatScalar(p.Value) + "\n")
+				continue
+			}
+			b.WriteString(":\n")
+			for _, v := range p.List {
+				b.WriteString("  - " + formatScalar(v) + "\n")
+			}
+		}
+		b.WriteString("---\n")
+	}
+	b.WriteString(n.Body)
+	return []byte(b.String())
+}
+
+// plainKey matches property keys written without quotes
+var plainKey = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_ .-]*$`)
+
+func formatKey(key string) string {
+	if plainKey.MatchString(key) && !strings.HasSuffix(key, " ") {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// yamlKeywords are plain scalars YAML reads as something other than text
+var yamlKeywords = map[string]bool{
+	"~": true, "null": true, "true": true, "false": true, "yes": true, "no": true, "on": true, "off": true,
+}
+
+// formatScalar writes a value plainly when YAML reads it back as the same text
+// (or number, or date), and double-quoted otherwise
+func formatScalar(v string) string {
+	plain := v != "" && !yamlKeywords[strings.ToLower(v)] &&
+		!strings.ContainsAny(v[:1], "-?:,[]{}#&*!|>'\"%@` \t") &&
+		!strings.HasSuffix(v, " ") && !strings.HasSuffix(v, ":") &&
+		!strings.Contains(v, ": ") && !strings.Contains(v, " #")
+	for _, r := range v {
+		if r < ' ' || r == 0x7f || r == '\ufeff' {
+			plain = false
+		}
+	}
+	if plain {
+		return v
+	}
+	return strconv.Quote(v)
+}
+
+// Parse reads a note. Front matter is read as the YAML Obsidian writes: text,
+// numbers and dates as key: value, lists as indented "- " items or in brackets,
+// and | or > blocks.
+func Parse(data []byte) (*Note, error) {
+	text := strings.TrimPrefix(strings.ReplaceAll(string(data), "\r\n", "\n"), "\ufeff")
+	note := &Note{}
+
+	rest, ok := strings.CutPrefix(text, "---\n")
+	if !ok {
+		note.Body = text
+		return note, nil
+	}
+
+	var lines []string
+	closed := false
+	for rest != "" {
+		var line string
+		line, rest, _ = strings.Cut(rest, "\n")
+		if line == "---" || line == "..." {
+			closed = true
+			break
+		}
+		lines = append(lines, line)
+	}
+	if !closed {
+		return nil, fmt.Errorf("%w: missing closing ---", ErrFrontMatter)
+	}
+	note.Body = rest
+
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '\t' {
+			return nil, fmt.Errorf("%w: unexpected indentation on line %d", ErrFrontMatter, i+2)
+		}
+
+		key, value, err := splitProperty(line)
+		if err != nil {
+			return nil, fmt.Errorf("%w on line %d: %v", ErrFrontMatter, i+2, err)
+		}
+
+		// Indented lines that follow belong to the property
+		var block []string
+		for i+1 < len(lines) && (lines[i+1] == "" || lines[i+1][0] == ' ' || lines[i+1][0] == '\t' ||
+			strings.HasPrefix(lines[i+1], "- ") || lines[i+1] == "-") {
+			i++
+			block = append(block, lines[i])
+		}
+
+		p, err := parseValue(key, value, block)
+		if err != nil {
+			return nil, fmt.Errorf("%w in %s: %v", ErrFrontMatter, key, err)
+		}
+		note.set(p)
+	}
+	return note, nil
+}
+
+// splitProperty splits a key: value line
+func splitProperty(line string) (string, string, error) {
+	var key, rest string
+	if line[0] == '"' || line[0] == '\'' {
+		end := closingQuote(line)
+		if end < 0 {
+			return "", "", errors.New("unterminated quoted key")
+		}
+		k, err := unquote(line[:end+1])
+		if err != nil {
+			return "", "", err
+		}
+		key, rest = k, line[end+1:]
+		if !strings.HasPrefix(rest, ":") {
+			return "", "", errors.New("expected : after key")
+		}
+		rest = rest[1:]
+	} else {
+		i := strings.Index(line, ":")
+		for i >= 0 && i+1 < len(line) && line[i+1] != ' ' && line[i+1] != '\t' {
+			next := strings.Index(line[i+1:], ":")
+			if next < 0 {
+				i = -1
+				break
+			}
+			i += next + 1
+		}
+		if i < 0 {
+			return "", "", errors.New("expected key: value")
+		}
+		key, rest = strings.TrimSpace(line[:i]), line[i+1:]
+	}
+	return key, strings.TrimSpace(rest), nil
+}
+
+// parseValue reads the value of a property from the rest of its line and the
+// indented lines below it
+func parseValue(key, value string, block []string) (Property, error) {
+	p := Property{Key: key}
+
+	switch {
+	case value == "" || strings.HasPrefix(value, "#"):
+		var items []string
+		for _, line := range block {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			item, ok := strings.CutPrefix(line, "-")
+			if !ok || (item != "" && item[0] != ' ') {
+				return p, errors.New("nested values are not supported")
+			}
+			v, err := parseScalar(strings.TrimSpace(item))
+			if err != nil {
+				return p, err
+			}
+			items = append(items, v)
+		}
+		if items != nil {
+			p.List, p.IsList = items, true
+		}
+	case value[0] == '[':
+		end := strings.LastIndex(value, "]")
+		if end < 0 {
+			return p, errors.New("unterminated list")
+		}
+		items, err := splitFlow(value[1:end])
+		if err != nil {
+			return p, err
+		}
+		p.List, p.IsList = items, true
+	case value[0] == '|' || value[0] == '>':
+		p.Value = blockScalar(value, block)
+	default:
+		v, err := parseScalar(value)
+		if err != nil {
+			return p, err
+		}
+		p.Value = v
+		// Plain text may continue on indented lines
+		for _, line := range block {
+			if line = strings.TrimSpace(line); line != "" {
+				p.Value += " " + line
+			}
+		}
+	}
+	return p, nil
+}
+
+// parseScalar reads a quoted or plain value, dropping a trailing comment
+func parseScalar(v string) (string, error) {
+	if v == "" {
+		return "", nil
+	}
+	if v[0] == '"' || v[0] == '\'' {
+		end := closingQuote(v)
+		if end < 0 {
+			return "", errors.New("unterminated quoted value")
+		}
+		return unquote(v[:end+1])
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		v = v[:i]
+	}
+	v = strings.TrimSpace(v)
+	if v == "~" || v == "null" {
+		return "", nil
+	}
+	return v, nil
+}
+
+// splitFlow splits the items of a [a, "b", c] list
+func splitFlow(s string) ([]string, error) {
+	items := []string{}
+	for s = strings.TrimSpace(s); s != ""; {
+		var item string
+		if s[0] == '"' || s[0] == '\'' {
+			end := closingQuote(s)
+			if end < 0 {
+				return nil, errors.New("unterminated quoted value")
+			}
+			v, err := unquote(s[:end+1])
+			if err != nil {
+				return nil, err
+			}
+			item, s = v, strings.TrimSpace(s[end+1:])
+			s = strings.TrimSpace(strings.TrimPrefix(s, ","))
+		} else {
+			raw, rest, _ := strings.Cut(s, ",")
+			item, s = strings.TrimSpace(raw), strings.TrimSpace(rest)
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+// closingQuote returns the index of the quote closing the value s starts with
+func closingQuote(s string) int {
+	q := s[0]
+	for i := 1; i < len(s); i++ {
+		switch {
+		case q == '"' && s[i] == '\\':
+			i++
+		case s[i] == q && q == '\'' && i+1 < len(s) && s[i+1] == '\'':
+			i++
+		case s[i] == q:
+			return i
+		}
+	}
+	return -1
+}
+
+// unquote reads a double-quoted value with YAML escapes or a single-quoted one
+func unquote(s string) (string, error) {
+	if s[0] == '\'' {
+		return strings.ReplaceAll(s[1:len(s)-1], "''", "'"), nil
+	}
+	// YAML escapes are a superset of Go's apart from \/ and \e
+	s = strings.NewReplacer(`\\`, `\\`, `\/`, `/`, `\e`, `\x1b`).Replace(s)
+	v, err := strconv.Unquote(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid quoted value %s", s)
+	}
+	return v, nil
+}
+
+// blockScalar reads a | (literal) or > (folded) block of indented lines
+func blockScalar(indicator string, block []string) string {
+	indent := -1
+	var lines []string
+	for _, line := range block {
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if indent < 0 {
+			indent = len(line) - len(strings.TrimLeft(line, " \t"))
+		}
+		if len(line) >= indent {
+			line = line[indent:]
+		}
+		lines = append(lines, line)
+	}
+
+	var value string
+	if indicator[0] == '|' {
+		value = strings.Join(lines, "\n")
+	} else {
+		var b strings.Builder
+		for i, line := range lines {
+			switch {
+			case i == 0:
+			case line == "" || lines[i-1] == "":
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+			b.WriteString(line)
+		}
+		value = b.String()
+	}
+
+	value = strings.TrimRight(value, "\n")
+	if strings.Contains(indicator, "+") {
+		return value + "\n"
+	}
+	if strings.Contains(indicator, "-") {
+		return value
+	}
+	return value + "\n"
+}
+
+// unsafeName matches characters file systems or wiki-links do not allow in note names
+var unsafeName = regexp.MustCompile(`[*"\\/<>:|?#^\[\]\x00-\x1f]+`)
+
+// FileName returns a note name for a title, replacing characters file systems or
+// wiki-links do not allow
+func FileName(title string) string {
+	name := strings.Trim(unsafeName.ReplaceAllString(title, "-"), " .")
+	if name == "" {
+		return "Untitled"
+	}
+	return name
+}
+
+// Link returns a wiki-link to the note at path, without its .md extension, shown
+// as text
+func Link(path, text string) string {
+	text = strings.NewReplacer("|", "-", "[", "(", "]", ")").Replace(text)
+	if text == "" || text == path {
+		return "[[" + path + "]]"
+	}
+	return "[[" + path + "|" + text + "]]"
+}
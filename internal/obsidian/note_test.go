@@ -0,0 +1,120 @@
+package obsidian
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestFormat_RoundTrip(t *testing.T) {
+	note := &Note{Body: "# ephemeral\n\nLasting a very short time.\n"}
+	note.Set("word", "ephemeral")
+	note.Set("source", "Book: a novel")
+	note.Set("date_learned", "2024-01-15")
+	note.Set("part_of_speech", "")
+	note.SetList("tags", []string{"literature", "#gre", "yes"})
+	note.Set("difficulty", "3")
+	note.Set("gloss", "line one\nline \"two\"")
+	note.Set("cefr level", "- C1")
+
+	data := Format(note)
+	want := "---\n" +
+		"word: ephemeral\n" +
+		"source: \"Book: a novel\"\n" +
+		"date_learned: 2024-01-15\n" +
+		"tags:\n  - literature\n  - \"#gre\"\n  - \"yes\"\n" +
+		"difficulty: 3\n" +
+		"gloss: \"line one\\nline \\\"two\\\"\"\n" +
+		"cefr level: \"- C1\"\n" +
+		"---\n# ephemeral\n\nLasting a very short time.\n"
+	if string(data) != want {
+		t.Errorf("Format() = %q, want %q", data, want)
+	}
+
+	got, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	for _, key := range []string{"word", "source", "date_learned", "difficulty", "gloss", "cefr level"} {
+		if got.Value(key) != note.Value(key) {
+			t.Errorf("Parse() %s = %q, want %q", key, got.Value(key), note.Value(key))
+		}
+	}
+	if !reflect.DeepEqual(got.Values("tags"), []string{"literature", "#gre", "yes"}) {
+		t.Errorf("Parse() tags = %q", got.Values("tags"))
+	}
+	if got.Body != note.Body {
+		t.Errorf("Parse() body = %q, want %q", got.Body, note.Body)
+	}
+}
+
+func TestParse(t *testing.T) {
+	data := "---\r\n" +
+		"word: 'it''s'\r\n" +
+		"tags: [gre, \"a, b\"]\r\n" +
+		"aliases:\r\n- first\r\n- second # comment\r\n" +
+		"notes: |\r\n  Line one\r\n\r\n  Line two\r\n" +
+		"summary: >-\r\n  folded\r\n  text\r\n" +
+		"source: long plain\r\n  continued\r\n" +
+		"empty:\r\n" +
+		"---\r\nBody\r\n"
+
+	note, err := Parse([]byte(data))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	tests := map[string]string{
+		"word":    "it's",
+		"tags":    "gre, a, b",
+		"aliases": "first, second",
+		"notes":   "Line one\n\nLine two\n",
+		"summary": "folded text",
+		"source":  "long plain continued",
+		"empty":   "",
+	}
+	for key, want := range tests {
+		if got := note.Value(key); got != want {
+			t.Errorf("Parse() %s = %q, want %q", key, got, want)
+		}
+	}
+	if note.Body != "Body\n" {
+		t.Errorf("Parse() body = %q, want %q", note.Body, "Body\n")
+	}
+
+	if note, err := Parse([]byte("# No properties\n")); err != nil || note.Body != "# No properties\n" || len(note.Properties) != 0 {
+		t.Errorf("Parse() without front matter = %+v, %v", note, err)
+	}
+	if _, err := Parse([]byte("---\nword: x\n")); !errors.Is(err, ErrFrontMatter) {
+		t.Errorf("Parse() unclosed front matter error = %v, want ErrFrontMatter", err)
+	}
+	if _, err := Parse([]byte("---\nmeta:\n  key: value\n---\n")); !errors.Is(err, ErrFrontMatter) {
+		t.Errorf("Parse() nested map error = %v, want ErrFrontMatter", err)
+	}
+}
+
+func TestFileNameAndLink(t *testing.T) {
+	tests := []struct{ title, want string }{
+		{"ephemeral", "ephemeral"},
+		{"either/or", "either-or"},
+		{"what? #1 [draft]", "what- -1 -draft-"},
+		{".hidden.", "hidden"},
+		{"???", "-"},
+		{"", "Untitled"},
+	}
+	for _, tt := range tests {
+		if got := FileName(tt.title); got != tt.want {
+			t.Errorf("FileName(%q) = %q, want %q", tt.title, got, tt.want)
+		}
+	}
+
+	if got := Link("Words/either-or", "either/or"); got != "[[Words/either-or|either/or]]" {
+		t.Errorf("Link() = %q", got)
+	}
+	if got := Link("Sources/Book", "Book"); got != "[[Sources/Book|Book]]" {
+		t.Errorf("Link() = %q", got)
+	}
+	if got := Link("Book", "Book"); got != "[[Book]]" {
+		t.Errorf("Link() = %q", got)
+	}
+}
@@ -0,0 +1,316 @@
+// Package querylang parses the search box syntax into a WordFilter.
+//
+// A query is a list of space-separated terms. Plain words, "quoted phrases" and
+// prefix* terms are full-text search terms. Qualifiers narrow the result:
+//
+//	tag:legal              word has the tag (repeat to require several)
+//	source:"The Economist" word comes from the source (repeat to allow several)
+//	pos:adjective          part of speech (repeat to allow several)
+//	has:example            word has an example sentence (also pos, tags)
+//	after:2024-01-01       learned on or after the date
+//	before:2024-02-01      learned before the date
+//
+// Dates may also be relative to today: today, or -30d, -2w, -3m, -1y for that many
+// days, weeks, months or years ago, so a saved query keeps a moving window.
+//
+// A leading - negates tag:, source:, pos: and has: qualifiers. Other words followed
+// by a colon, such as in a URL, are plain search terms.
+package querylang
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// Error is a syntax or value error at a 1-based rune position in the query
+type Error struct {
+	Pos int
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("position %d: %s", e.Pos, e.Msg)
+}
+
+// qualifiers are the keys lexed as qualifiers rather than search terms
+var qualifiers = map[string]bool{
+	"tag":    true,
+	"source": true,
+	"pos":    true,
+	"has":    true,
+	"after":  true,
+	"before": true,
+}
+
+// term is a single lexed term of a query
+type term struct {
+	pos     int    // 1-based position of the term's first rune
+	negated bool   // leading -
+	key     string // qualifier name, empty for search terms
+	value   string
+	quoted  bool
+	valPos  int // 1-based position of the value
+}
+
+// Parse compiles a query into a filter holding the free-text search and the qualifiers
+func Parse(input string) (models.WordFilter, error) {
+	var filter models.WordFilter
+
+	terms, err := lex(input)
+	if err != nil {
+		return filter, err
+	}
+
+	var search []string
+	for _, t := range terms {
+		if t.key == "" {
+			if t.negated {
+				return filter, &Error{Pos: t.pos, Msg: "negated search terms are not supported; use -tag:, -source:, -pos: or -has:"}
+			}
+			if t.quoted {
+				search = append(search, `"`+t.value+`"`)
+			} else {
+				search = append(search, t.value)
+			}
+			continue
+		}
+
+		if err := applyQualifier(&filter, t); err != nil {
+			return filter, err
+		}
+	}
+
+	filter.Search = strings.Join(search, " ")
+	return filter, nil
+}
+
+// Apply parses filter.Search and merges the result into filter: the qualifiers are
+// added to the existing filters and Search is replaced by the remaining free text
+func Apply(filter *models.WordFilter) error {
+	if filter.Search == "" {
+		return nil
+	}
+
+	parsed, err := Parse(filter.Search)
+	if err != nil {
+		return err
+	}
+
+	filter.Search = ""
+	merge(filter, parsed)
+	return nil
+}
+
+// Narrow parses a query and merges it into a filter whose Search was already
+// applied, such as a saved search's: the qualifiers narrow the filter and the free
+// text is added to Search. Parsing the query on its own lets it repeat after: or
+// before: to narrow the dates already set.
+func Narrow(filter *models.WordFilter, query string) error {
+	parsed, err := Parse(query)
+	if err != nil {
+		return err
+	}
+
+	merge(filter, parsed)
+	return nil
+}
+
+// merge adds the free text and qualifiers of a parsed query to a filter
+func merge(filter *models.WordFilter, parsed models.WordFilter) {
+	filter.Search = strings.TrimSpace(filter.Search + " " + parsed.Search)
+	filter.Tags = append(filter.Tags, parsed.Tags...)
+	filter.ExcludeTags = append(filter.ExcludeTags, parsed.ExcludeTags...)
+	filter.Sources = append(filter.Sources, parsed.Sources...)
+	filter.ExcludeSources = append(filter.ExcludeSources, parsed.ExcludeSources...)
+	filter.PartsOfSpeech = append(filter.PartsOfSpeech, parsed.PartsOfSpeech...)
+	filter.ExcludePartsOfSpeech = append(filter.ExcludePartsOfSpeech, parsed.ExcludePartsOfSpeech...)
+	filter.Has = append(filter.Has, parsed.Has...)
+	filter.Missing = append(filter.Missing, parsed.Missing...)
+
+	// Date bounds narrow whichever range is already set
+	if parsed.FromDate != "" && parsed.FromDate > filter.FromDate {
+		filter.FromDate = parsed.FromDate
+	}
+	if parsed.ToDate != "" && (filter.ToDate == "" || parsed.ToDate < filter.ToDate) {
+		filter.ToDate = parsed.ToDate
+	}
+}
+
+// applyQualifier adds a single key:value term to the filter
+func applyQualifier(filter *models.WordFilter, t term) error {
+	if t.value == "" {
+		return &Error{Pos: t.valPos, Msg: fmt.Sprintf("%s: needs a value", t.key)}
+	}
+
+	switch t.key {
+	case "tag":
+		tag := strings.ToLower(t.value)
+		if t.negated {
+			filter.ExcludeTags = append(filter.ExcludeTags, tag)
+		} else {
+			filter.Tags = append(filter.Tags, tag)
+		}
+
+	case "source":
+		if t.negated {
+			filter.ExcludeSources = append(filter.ExcludeSources, t.value)
+		} else {
+			filter.Sources = append(filter.Sources, t.value)
+		}
+
+	case "pos":
+		pos := strings.ToLower(t.value)
+		if !validation.IsPartOfSpeech(pos) {
+			return &Error{Pos: t.valPos, Msg: fmt.Sprintf("unknown part of speech '%s'; expected one of: %s",
+				t.value, strings.Join(validation.PartsOfSpeech, ", "))}
+		}
+		if t.negated {
+			filter.ExcludePartsOfSpeech = append(filter.ExcludePartsOfSpeech, pos)
+		} else {
+			filter.PartsOfSpeech = append(filter.PartsOfSpeech, pos)
+		}
+
+	case "has":
+		name := strings.ToLower(t.value)
+		if !validation.IsPresenceField(name) {
+			return &Error{Pos: t.valPos, Msg: fmt.Sprintf("unknown attribute '%s'; expected one of: %s",
+				t.value, strings.Join(validation.PresenceFields, ", "))}
+		}
+		if t.negated {
+			filter.Missing = append(filter.Missing, name)
+		} else {
+			filter.Has = append(filter.Has, name)
+		}
+
+	case "after", "before":
+		if t.negated {
+			return &Error{Pos: t.pos, Msg: fmt.Sprintf("%s: cannot be negated", t.key)}
+		}
+		date, ok := parseDate(t.value)
+		if !ok {
+			return &Error{Pos: t.valPos, Msg: fmt.Sprintf("%s: needs a date in YYYY-MM-DD format or a relative date such as -30d", t.key)}
+		}
+		if t.key == "after" {
+			if filter.FromDate != "" {
+				return &Error{Pos: t.pos, Msg: "after: given more than once"}
+			}
+			filter.FromDate = date.Format(validation.DateFormat)
+		} else {
+			if filter.ToDate != "" {
+				return &Error{Pos: t.pos, Msg: "before: given more than once"}
+			}
+			// before: is exclusive while ToDate is inclusive
+			filter.ToDate = date.AddDate(0, 0, -1).Format(validation.DateFormat)
+		}
+	}
+
+	return nil
+}
+
+// today returns the date relative dates count back from; tests replace it
+var today = validation.Today
+
+// parseDate reads a YYYY-MM-DD date, "today", or a relative date of the form -N
+// followed by d, w, m or y
+func parseDate(value string) (time.Time, bool) {
+	if date, err := time.Parse(validation.DateFormat, value); err == nil {
+		return date, true
+	}
+
+	value = strings.ToLower(value)
+	if value == "today" {
+		return today(), true
+	}
+
+	if len(value) < 3 || value[0] != '-' {
+		return time.Time{}, false
+	}
+	n, err := strconv.Atoi(value[1 : len(value)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, false
+	}
+
+	switch value[len(value)-1] {
+	case 'd':
+		return today().AddDate(0, 0, -n), true
+	case 'w':
+		return today().AddDate(0, 0, -7*n), true
+	case 'm':
+		return today().AddDate(0, -n, 0), true
+	case 'y':
+		return today().AddDate(-n, 0, 0), true
+	}
+	return time.Time{}, false
+}
+
+// lex splits a query into terms
+func lex(input string) ([]term, error) {
+	runes := []rune(input)
+	var terms []term
+
+	i := 0
+	for i < len(runes) {
+		if unicode.IsSpace(runes[i]) {
+			i++
+			continue
+		}
+
+		t := term{pos: i + 1}
+		if runes[i] == '-' {
+			t.negated = true
+			i++
+			if i == len(runes) || unicode.IsSpace(runes[i]) {
+				return nil, &Error{Pos: t.pos, Msg: "- must be followed by a qualifier such as tag:"}
+			}
+		}
+
+		// A qualifier key is a known name ending in a colon
+		start := i
+		for i < len(runes) && unicode.IsLetter(runes[i]) {
+			i++
+		}
+		if key := strings.ToLower(string(runes[start:i])); i < len(runes) && runes[i] == ':' && qualifiers[key] {
+			t.key = key
+			i++
+		} else {
+			i = start
+		}
+
+		t.valPos = i + 1
+		if i < len(runes) && runes[i] == '"' {
+			end := i + 1
+			for end < len(runes) && runes[end] != '"' {
+				end++
+			}
+			if end == len(runes) {
+				return nil, &Error{Pos: i + 1, Msg: "unterminated quote"}
+			}
+			t.value = strings.TrimSpace(string(runes[i+1 : end]))
+			t.quoted = true
+			i = end + 1
+			if i < len(runes) && !unicode.IsSpace(runes[i]) {
+				return nil, &Error{Pos: i + 1, Msg: "expected a space after closing quote"}
+			}
+		} else {
+			// Unquoted values run to the next space
+			start = i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) {
+				if runes[i] == '"' {
+					return nil, &Error{Pos: i + 1, Msg: "unexpected quote inside a term"}
+				}
+				i++
+			}
+			t.value = string(runes[start:i])
+		}
+
+		terms = append(terms, t)
+	}
+
+	return terms, nil
+}
@@ -0,0 +1,215 @@
+package querylang
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  models.WordFilter
+	}{
+		{
+			name:  "empty",
+			input: "   ",
+			want:  models.WordFilter{},
+		},
+		{
+			name:  "plain search",
+			input: `ephemer* "cherry blossoms"`,
+			want:  models.WordFilter{Search: `ephemer* "cherry blossoms"`},
+		},
+		{
+			name:  "full example",
+			input: `tag:legal source:"The Economist" pos:adjective after:2024-01-01 -tag:easy ephemer*`,
+			want: models.WordFilter{
+				Search:        "ephemer*",
+				Tags:          []string{"legal"},
+				ExcludeTags:   []string{"easy"},
+				Sources:       []string{"The Economist"},
+				PartsOfSpeech: []string{"adjective"},
+				FromDate:      "2024-01-01",
+			},
+		},
+		{
+			name:  "before is exclusive",
+			input: "before:2024-03-01",
+			want:  models.WordFilter{ToDate: "2024-02-29"},
+		},
+		{
+			name:  "qualifiers are case insensitive",
+			input: "TAG:Latin Pos:NOUN -POS:verb -source:Blog",
+			want: models.WordFilter{
+				Tags:                 []string{"latin"},
+				PartsOfSpeech:        []string{"noun"},
+				ExcludePartsOfSpeech: []string{"verb"},
+				ExcludeSources:       []string{"Blog"},
+			},
+		},
+		{
+			name:  "repeated qualifiers",
+			input: "tag:latin tag:legal source:A source:B",
+			want: models.WordFilter{
+				Tags:    []string{"latin", "legal"},
+				Sources: []string{"A", "B"},
+			},
+		},
+		{
+			name:  "presence",
+			input: "tag:interview -has:example has:POS",
+			want: models.WordFilter{
+				Tags:    []string{"interview"},
+				Has:     []string{"pos"},
+				Missing: []string{"example"},
+			},
+		},
+		{
+			name:  "colon after digits is text",
+			input: "12:30",
+			want:  models.WordFilter{Search: "12:30"},
+		},
+		{
+			name:  "unknown keys are text",
+			input: "https://example.com note: colour:red tag:legal",
+			want:  models.WordFilter{Search: "https://example.com note: colour:red", Tags: []string{"legal"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantPos int
+	}{
+		{name: "unterminated quote", input: `tag:legal "cherry`, wantPos: 11},
+		{name: "unterminated quoted value", input: `source:"The Economist`, wantPos: 8},
+		{name: "missing value", input: "ephemeral tag:", wantPos: 15},
+		{name: "bad date", input: "after:01/02/2024", wantPos: 7},
+		{name: "unknown part of speech", input: "pos:gerundive", wantPos: 5},
+		{name: "unknown presence attribute", input: "has:audio", wantPos: 5},
+		{name: "bad relative date", input: "after:-3q", wantPos: 7},
+		{name: "relative date without number", input: "before:-d", wantPos: 8},
+		{name: "negated search term", input: "ephemeral -easy", wantPos: 11},
+		{name: "negated date", input: "-after:2024-01-01", wantPos: 1},
+		{name: "lone dash", input: "tag:legal -", wantPos: 11},
+		{name: "text after quote", input: `"cherry"blossom`, wantPos: 9},
+		{name: "repeated after", input: "after:2024-01-01 after:2024-02-01", wantPos: 18},
+		{name: "position counts runes", input: `naïve tag:`, wantPos: 11},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var qerr *Error
+			if !errors.As(err, &qerr) {
+				t.Fatalf("Parse(%q) error = %v, want *Error", tt.input, err)
+			}
+			if qerr.Pos != tt.wantPos {
+				t.Errorf("Parse(%q) error at %d (%s), want position %d", tt.input, qerr.Pos, qerr.Msg, tt.wantPos)
+			}
+		})
+	}
+}
+
+func TestParse_RelativeDates(t *testing.T) {
+	today = func() time.Time { return time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC) }
+	defer func() { today = validation.Today }()
+
+	tests := []struct {
+		input    string
+		wantFrom string
+		wantTo   string
+	}{
+		{input: "after:today", wantFrom: "2024-05-15"},
+		{input: "after:-30d", wantFrom: "2024-04-15"},
+		{input: "after:-2w", wantFrom: "2024-05-01"},
+		{input: "after:-3M", wantFrom: "2024-02-15"},
+		{input: "after:-1y before:today", wantFrom: "2023-05-15", wantTo: "2024-05-14"},
+		{input: "before:-0d", wantTo: "2024-05-14"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", tt.input, err)
+			}
+			if got.FromDate != tt.wantFrom || got.ToDate != tt.wantTo {
+				t.Errorf("Parse(%q) = from %q to %q, want from %q to %q",
+					tt.input, got.FromDate, got.ToDate, tt.wantFrom, tt.wantTo)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	filter := models.WordFilter{
+		Search:   "tag:legal after:2024-02-01 before:2024-12-01 habeas",
+		Tags:     []string{"latin"},
+		FromDate: "2024-01-01",
+		ToDate:   "2024-06-30",
+		Limit:    10,
+	}
+
+	if err := Apply(&filter); err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	want := models.WordFilter{
+		Search:   "habeas",
+		Tags:     []string{"latin", "legal"},
+		FromDate: "2024-02-01",
+		ToDate:   "2024-06-30",
+		Limit:    10,
+	}
+	if !reflect.DeepEqual(filter, want) {
+		t.Errorf("Apply() = %+v, want %+v", filter, want)
+	}
+}
+
+func TestNarrow(t *testing.T) {
+	// A smart list's compiled filter, narrowed by a search that repeats after:
+	filter := models.WordFilter{
+		Search:   "habeas",
+		Tags:     []string{"legal"},
+		FromDate: "2024-01-01",
+	}
+
+	if err := Narrow(&filter, "after:2024-03-01 tag:latin corpus"); err != nil {
+		t.Fatalf("Narrow() error = %v", err)
+	}
+
+	want := models.WordFilter{
+		Search:   "habeas corpus",
+		Tags:     []string{"legal", "latin"},
+		FromDate: "2024-03-01",
+	}
+	if !reflect.DeepEqual(filter, want) {
+		t.Errorf("Narrow() = %+v, want %+v", filter, want)
+	}
+
+	// A later bound does not widen the range
+	if err := Narrow(&filter, "after:2023-01-01"); err != nil || filter.FromDate != "2024-03-01" {
+		t.Errorf("Narrow() = %+v, %v, want from date kept", filter, err)
+	}
+}
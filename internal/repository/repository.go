@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/lehmann314159/vocabulator/internal/models"
 )
@@ -20,15 +21,170 @@ type WordRepository interface {
 	// List retrieves words with optional filtering
 	List(ctx context.Context, filter models.WordFilter) ([]*models.Word, error)
 
+	// ListPage retrieves a page of words along with a cursor for the next page;
+	// it returns ErrInvalidCursor if filter.Cursor does not belong to the filter
+	ListPage(ctx context.Context, filter models.WordFilter) (*models.WordPage, error)
+
 	// Update modifies an existing word
 	Update(ctx context.Context, word *models.Word) (*models.Word, error)
 
+	// Restore inserts a word, or replaces the word with its ID when set, keeping the
+	// word's own created_at and updated_at
+	Restore(ctx context.Context, word *models.Word) (*models.Word, error)
+
 	// Delete removes a word by ID
 	Delete(ctx context.Context, id int64) error
 
+	// RecordReview counts a flash-card review of a word, and a lapse when it was
+	// not remembered
+	RecordReview(ctx context.Context, id int64, remembered bool) error
+
 	// GetRandom retrieves a random word
 	GetRandom(ctx context.Context) (*models.Word, error)
 
 	// Count returns the total number of words matching the filter
 	Count(ctx context.Context, filter models.WordFilter) (int64, error)
+
+	// Facets counts the words matching the filter per tag, source, part of speech and
+	// month learned, with at most limit values per facet
+	Facets(ctx context.Context, filter models.WordFilter, limit int) (*models.Facets, error)
+
+	// SuggestCandidates returns up to limit words sharing the most trigrams with the given set
+	SuggestCandidates(ctx context.Context, trigrams []string, limit int) ([]*models.Word, error)
+
+	// ImportedKeys returns the keys of the items already imported from an origin,
+	// such as the Kindle lookups imported from vocab.db files
+	ImportedKeys(ctx context.Context, origin string) (map[string]bool, error)
+
+	// MarkImported records items from an origin as imported
+	MarkImported(ctx context.Context, origin string, keys []string) error
+
+	// WithTx runs fn with repositories bound to a single transaction, committing it
+	// when fn returns nil and rolling it back otherwise
+	WithTx(ctx context.Context, fn func(tx Tx) error) error
+}
+
+// Tx is a word and field repository bound to a transaction
+type Tx interface {
+	WordRepository
+	FieldRepository
+}
+
+// FieldRepository defines the interface for custom field definitions
+type FieldRepository interface {
+	// CreateField inserts a new custom field definition
+	CreateField(ctx context.Context, field *models.CustomField) (*models.CustomField, error)
+
+	// GetField retrieves a custom field by its ID
+	GetField(ctx context.Context, id int64) (*models.CustomField, error)
+
+	// ListFields retrieves all custom fields ordered by ID
+	ListFields(ctx context.Context) ([]*models.CustomField, error)
+
+	// UpdateField modifies the label and options of a custom field
+	UpdateField(ctx context.Context, field *models.CustomField) (*models.CustomField, error)
+
+	// DeleteField removes a custom field and every value stored for it
+	DeleteField(ctx context.Context, id int64) error
+
+	// CountFieldValues counts the words storing each value of a custom field
+	CountFieldValues(ctx context.Context, id int64) (map[string]int64, error)
+}
+
+// AttachmentRepository defines the interface for word attachment persistence
+type AttachmentRepository interface {
+	// CreateAttachment inserts attachment metadata and its thumbnail
+	CreateAttachment(ctx context.Context, attachment *models.Attachment, thumbnail []byte) (*models.Attachment, error)
+
+	// GetAttachment retrieves attachment metadata by ID
+	GetAttachment(ctx context.Context, id int64) (*models.Attachment, error)
+
+	// ListAttachments retrieves the attachments of a word, oldest first
+	ListAttachments(ctx context.Context, wordID int64) ([]*models.Attachment, error)
+
+	// GetAttachmentThumbnail retrieves the thumbnail image of an attachment
+	GetAttachmentThumbnail(ctx context.Context, id int64) ([]byte, error)
+
+	// SetAttachmentData stores the original file bytes in the database
+	SetAttachmentData(ctx context.Context, id int64, data []byte) error
+
+	// GetAttachmentData retrieves original file bytes stored in the database
+	GetAttachmentData(ctx context.Context, id int64) ([]byte, error)
+
+	// DeleteAttachment removes an attachment by ID
+	DeleteAttachment(ctx context.Context, id int64) error
+}
+
+// SavedSearchRepository defines the interface for saved search persistence
+type SavedSearchRepository interface {
+	// CreateSavedSearch inserts a new saved search
+	CreateSavedSearch(ctx context.Context, search *models.SavedSearch) (*models.SavedSearch, error)
+
+	// GetSavedSearch retrieves a saved search by its ID
+	GetSavedSearch(ctx context.Context, id int64) (*models.SavedSearch, error)
+
+	// GetSavedSearchByName retrieves a saved search by name, ignoring case
+	GetSavedSearchByName(ctx context.Context, name string) (*models.SavedSearch, error)
+
+	// ListSavedSearches retrieves all saved searches ordered by name
+	ListSavedSearches(ctx context.Context) ([]*models.SavedSearch, error)
+
+	// UpdateSavedSearch replaces the name, query and filter of a saved search
+	UpdateSavedSearch(ctx context.Context, search *models.SavedSearch) (*models.SavedSearch, error)
+
+	// DeleteSavedSearch removes a saved search by ID
+	DeleteSavedSearch(ctx context.Context, id int64) error
+}
+
+// ImportProfileRepository defines the interface for import profile persistence
+type ImportProfileRepository interface {
+	// CreateImportProfile inserts a new import profile
+	CreateImportProfile(ctx context.Context, profile *models.ImportProfile) (*models.ImportProfile, error)
+
+	// GetImportProfile retrieves an import profile by its ID
+	GetImportProfile(ctx context.Context, id int64) (*models.ImportProfile, error)
+
+	// GetImportProfileByName retrieves an import profile by name, ignoring case
+	GetImportProfileByName(ctx context.Context, name string) (*models.ImportProfile, error)
+
+	// ListImportProfiles retrieves all import profiles ordered by name
+	ListImportProfiles(ctx context.Context) ([]*models.ImportProfile, error)
+
+	// UpdateImportProfile replaces the name and settings of an import profile
+	UpdateImportProfile(ctx context.Context, profile *models.ImportProfile) (*models.ImportProfile, error)
+
+	// DeleteImportProfile removes an import profile by ID
+	DeleteImportProfile(ctx context.Context, id int64) error
+}
+
+// ImportJobRepository defines the interface for background import persistence
+type ImportJobRepository interface {
+	// CreateImportJob inserts a new import job
+	CreateImportJob(ctx context.Context, job *models.ImportJob) (*models.ImportJob, error)
+
+	// GetImportJob retrieves an import job by its ID
+	GetImportJob(ctx context.Context, id int64) (*models.ImportJob, error)
+
+	// UpdateImportJob saves the status, progress and outcome of an import job
+	UpdateImportJob(ctx context.Context, job *models.ImportJob) error
+
+	// FailUnfinishedImportJobs marks the jobs that are queued or running as failed
+	// with the given error, returning how many there were
+	FailUnfinishedImportJobs(ctx context.Context, reason string) (int64, error)
+
+	// DeleteImportJobsBefore removes the jobs that finished before a time
+	DeleteImportJobsBefore(ctx context.Context, before time.Time) error
+}
+
+// DefinitionRepository defines the interface for cached dictionary lookups
+type DefinitionRepository interface {
+	// GetDefinition retrieves the cached definition of a word, ignoring case;
+	// it returns sql.ErrNoRows when the word has not been looked up
+	GetDefinition(ctx context.Context, word string) (*models.DictionaryResponse, error)
+
+	// SaveDefinition caches the definition of a word, replacing any earlier lookup
+	SaveDefinition(ctx context.Context, word string, definition *models.DictionaryResponse) error
+
+	// ListDefinitions retrieves every cached definition keyed by lowercased word
+	ListDefinitions(ctx context.Context) (map[string]*models.DictionaryResponse, error)
 }
@@ -0,0 +1,221 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// Snippet highlight markers; control characters cannot appear in stored text, so
+// they survive HTML escaping and are swapped for <mark> tags afterwards
+const (
+	snippetStart = "\x02"
+	snippetEnd   = "\x03"
+)
+
+// definitionTexts joins each cached definition to its individual definition texts,
+// exposed as def.value; callers filter on definitions.word
+const definitionTexts = `definitions, json_each(definitions.data, '$.meanings') AS meaning,
+	json_each(meaning.value, '$.definitions') AS def`
+
+// ftsDefinitions returns the indexed definition text of the word given by the expression
+func ftsDefinitions(word string) string {
+	return `coalesce((SELECT group_concat(json_extract(def.value, '$.definition'), ' ') FROM ` +
+		definitionTexts + ` WHERE definitions.word = ` + word + `), '')`
+}
+
+// ftsTriggers keep words_fts in sync with words, word_field_values and definitions
+var ftsTriggers = []string{
+	`CREATE TRIGGER IF NOT EXISTS words_fts_insert AFTER INSERT ON words BEGIN
+		INSERT INTO words_fts (rowid, word, example_sentence, source, tags, custom, definitions)
+		VALUES (NEW.id, NEW.word, NEW.example_sentence, NEW.source, NEW.tags, '', ` + ftsDefinitions("NEW.word") + `);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS words_fts_update AFTER UPDATE OF word, example_sentence, source, tags ON words BEGIN
+		UPDATE words_fts SET word = NEW.word, example_sentence = NEW.example_sentence,
+			source = NEW.source, tags = NEW.tags, definitions = ` + ftsDefinitions("NEW.word") + `
+		WHERE rowid = NEW.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS words_fts_delete AFTER DELETE ON words BEGIN
+		DELETE FROM words_fts WHERE rowid = OLD.id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS words_fts_values_insert AFTER INSERT ON word_field_values BEGIN
+		UPDATE words_fts SET custom = (SELECT group_concat(value, ' ') FROM word_field_values WHERE word_id = NEW.word_id)
+		WHERE rowid = NEW.word_id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS words_fts_values_update AFTER UPDATE ON word_field_values BEGIN
+		UPDATE words_fts SET custom = (SELECT group_concat(value, ' ') FROM word_field_values WHERE word_id = NEW.word_id)
+		WHERE rowid = NEW.word_id;
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS words_fts_values_delete AFTER DELETE ON word_field_values BEGIN
+		UPDATE words_fts SET custom = coalesce((SELECT group_concat(value, ' ') FROM word_field_values WHERE word_id = OLD.word_id), '')
+		WHERE rowid = OLD.word_id;
+	END`,
+	// Definitions are cached by word text, ignoring case, and may arrive before or after the word
+	`CREATE TRIGGER IF NOT EXISTS words_fts_definitions_insert AFTER INSERT ON definitions BEGIN
+		UPDATE words_fts SET definitions = ` + ftsDefinitions("NEW.word") + `
+		WHERE rowid IN (SELECT id FROM words WHERE word = NEW.word COLLATE NOCASE);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS words_fts_definitions_update AFTER UPDATE ON definitions BEGIN
+		UPDATE words_fts SET definitions = ` + ftsDefinitions("NEW.word") + `
+		WHERE rowid IN (SELECT id FROM words WHERE word = NEW.word COLLATE NOCASE);
+	END`,
+	`CREATE TRIGGER IF NOT EXISTS words_fts_definitions_delete AFTER DELETE ON definitions BEGIN
+		UPDATE words_fts SET definitions = ''
+		WHERE rowid IN (SELECT id FROM words WHERE word = OLD.word COLLATE NOCASE);
+	END`,
+}
+
+// InitSearch sets up the FTS5 index used for the search filter. It reports whether
+// full-text search is available; without FTS5 support in the SQLite build, search
+// falls back to LIKE matching.
+//
+// The index lives outside the migrations because FTS5 is a compile-time option.
+// When it is unavailable the sync triggers are dropped so that writes keep working,
+// and the index is rebuilt the next time the server starts with FTS5 enabled.
+func (r *SQLiteRepository) InitSearch(ctx context.Context) (bool, error) {
+	var enabled bool
+	if err := r.db.QueryRowContext(ctx, `SELECT sqlite_compileoption_used('ENABLE_FTS5')`).Scan(&enabled); err != nil {
+		return false, fmt.Errorf("failed to check for fts5: %w", err)
+	}
+
+	if !enabled {
+		for _, name := range ftsTriggerNames() {
+			if _, err := r.db.ExecContext(ctx, `DROP TRIGGER IF EXISTS `+name); err != nil {
+				return false, fmt.Errorf("failed to drop search trigger: %w", err)
+			}
+		}
+		r.fts = false
+		return false, nil
+	}
+
+	// Triggers missing means the index is new, was left stale by a build without FTS5,
+	// or predates a column added since
+	var triggers int
+	if err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM sqlite_master WHERE type = 'trigger' AND name LIKE 'words_fts_%'`,
+	).Scan(&triggers); err != nil {
+		return false, fmt.Errorf("failed to check search triggers: %w", err)
+	}
+
+	if triggers < len(ftsTriggers) {
+		if err := r.rebuildSearchIndex(ctx); err != nil {
+			return false, err
+		}
+	}
+
+	r.fts = true
+	return true, nil
+}
+
+// rebuildSearchIndex recreates words_fts from the current rows and installs the triggers.
+// The table is dropped first so that an index with an older set of columns is replaced.
+func (r *SQLiteRepository) rebuildSearchIndex(ctx context.Context) error {
+	var statements []string
+	for _, name := range ftsTriggerNames() {
+		statements = append(statements, `DROP TRIGGER IF EXISTS `+name)
+	}
+	statements = append(statements,
+		`DROP TABLE IF EXISTS words_fts`,
+		`CREATE VIRTUAL TABLE words_fts USING fts5(
+			word, example_sentence, source, tags, custom, definitions,
+			tokenize = 'unicode61 remove_diacritics 2'
+		)`,
+		`INSERT INTO words_fts (rowid, word, example_sentence, source, tags, custom, definitions)
+		 SELECT id, word, example_sentence, source, tags,
+			coalesce((SELECT group_concat(value, ' ') FROM word_field_values WHERE word_id = words.id), ''),
+			`+ftsDefinitions("words.word")+`
+		 FROM words`,
+	)
+	statements = append(statements, ftsTriggers...)
+
+	return r.inTx(ctx, func(tx *SQLiteRepository) error {
+		for _, stmt := range statements {
+			if _, err := tx.db.ExecContext(ctx, stmt); err != nil {
+				return fmt.Errorf("failed to build search index: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// ftsTriggerNames lists the names of the sync triggers
+func ftsTriggerNames() []string {
+	return []string{
+		"words_fts_insert", "words_fts_update", "words_fts_delete",
+		"words_fts_values_insert", "words_fts_values_update", "words_fts_values_delete",
+		"words_fts_definitions_insert", "words_fts_definitions_update", "words_fts_definitions_delete",
+	}
+}
+
+// searchTerm is a single word or quoted phrase from a search string
+type searchTerm struct {
+	text   string
+	phrase bool
+	prefix bool
+}
+
+// parseSearch splits a search string into terms. Double quotes group a phrase and a
+// trailing * marks a prefix term; all other punctuation separates words. The last
+// unquoted word is always a prefix term so results keep up with search-as-you-type.
+func parseSearch(search string) []searchTerm {
+	var terms []searchTerm
+
+	for i, part := range strings.Split(search, `"`) {
+		// Odd-numbered parts sit between quotes
+		if i%2 == 1 {
+			words := strings.FieldsFunc(part, isSearchSeparator)
+			if len(words) > 0 {
+				terms = append(terms, searchTerm{text: strings.Join(words, " "), phrase: true})
+			}
+			continue
+		}
+
+		for _, field := range strings.Fields(part) {
+			prefix := strings.HasSuffix(field, "*")
+			words := strings.FieldsFunc(field, isSearchSeparator)
+			for j, word := range words {
+				terms = append(terms, searchTerm{text: word, prefix: prefix && j == len(words)-1})
+			}
+		}
+	}
+
+	if n := len(terms); n > 0 && !terms[n-1].phrase {
+		terms[n-1].prefix = true
+	}
+
+	return terms
+}
+
+// isSearchSeparator reports whether r splits search words
+func isSearchSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsNumber(r) && r != '\''
+}
+
+// likeSearch matches one search term as a substring of the columns words_fts indexes,
+// for builds without FTS5. Each ? takes the same LIKE pattern.
+const likeSearch = `(words.word LIKE ? OR words.example_sentence LIKE ? OR words.source LIKE ? OR words.tags LIKE ?
+	OR EXISTS (SELECT 1 FROM word_field_values WHERE word_field_values.word_id = words.id AND word_field_values.value LIKE ?)
+	OR EXISTS (SELECT 1 FROM ` + definitionTexts + `
+		WHERE definitions.word = words.word AND json_extract(def.value, '$.definition') LIKE ?))`
+
+// ftsQuery builds an FTS5 MATCH expression requiring every term. Terms are quoted
+// so user input is never interpreted as FTS5 syntax.
+func ftsQuery(terms []searchTerm) string {
+	parts := make([]string, len(terms))
+	for i, term := range terms {
+		parts[i] = `"` + strings.ReplaceAll(term.text, `"`, `""`) + `"`
+		if term.prefix {
+			parts[i] += "*"
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatSnippet escapes a raw FTS5 snippet and converts its markers to <mark> tags
+func formatSnippet(raw string) string {
+	escaped := html.EscapeString(raw)
+	escaped = strings.ReplaceAll(escaped, snippetStart, "<mark>")
+	return strings.ReplaceAll(escaped, snippetEnd, "</mark>")
+}
@@ -0,0 +1,269 @@
+//go:build sqlite_fts5
+
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+func setupSearchRepo(t *testing.T) (*SQLiteRepository, func()) {
+	t.Helper()
+	db := setupTestDB(t)
+	// Keep a single connection so every query sees the same in-memory database
+	db.SetMaxOpenConns(1)
+
+	repo := NewSQLiteRepository(db)
+	enabled, err := repo.InitSearch(context.Background())
+	if err != nil {
+		t.Fatalf("InitSearch() error = %v", err)
+	}
+	if !enabled {
+		t.Fatal("InitSearch() = false, want FTS5 with the sqlite_fts5 tag")
+	}
+
+	return repo, func() { db.Close() }
+}
+
+func TestSQLiteRepository_FullTextSearch(t *testing.T) {
+	repo, cleanup := setupSearchRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	repo.Create(ctx, &models.Word{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15",
+		ExampleSentence: strPtr("The ephemeral beauty of cherry blossoms"), Tags: []string{"nature"}})
+	repo.Create(ctx, &models.Word{Word: "blossom", Source: "Article", DateLearned: "2024-02-20",
+		ExampleSentence: strPtr("Trees blossom in spring")})
+	repo.Create(ctx, &models.Word{Word: "eloquent", Source: "Speech", DateLearned: "2024-03-10",
+		ExampleSentence: strPtr("An eloquent plea for cherry trees"), Tags: []string{"rhetoric"}})
+
+	tests := []struct {
+		name      string
+		search    string
+		wantWords []string
+	}{
+		{name: "word match ranks first", search: "blossom", wantWords: []string{"blossom", "ephemeral"}},
+		{name: "example sentence", search: "spring", wantWords: []string{"blossom"}},
+		{name: "prefix", search: "elo", wantWords: []string{"eloquent"}},
+		{name: "phrase", search: `"cherry blossoms"`, wantWords: []string{"ephemeral"}},
+		{name: "phrase in wrong order", search: `"blossoms cherry"`, wantWords: nil},
+		{name: "tags", search: "rhetoric", wantWords: []string{"eloquent"}},
+		{name: "source", search: "speech", wantWords: []string{"eloquent"}},
+		{name: "all terms required", search: "cherry plea", wantWords: []string{"eloquent"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.List(ctx, models.WordFilter{Search: tt.search})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var words []string
+			for _, w := range got {
+				words = append(words, w.Word)
+			}
+			if strings.Join(words, ",") != strings.Join(tt.wantWords, ",") {
+				t.Errorf("List(%q) = %v, want %v", tt.search, words, tt.wantWords)
+			}
+
+			count, err := repo.Count(ctx, models.WordFilter{Search: tt.search})
+			if err != nil || int(count) != len(tt.wantWords) {
+				t.Errorf("Count(%q) = %d, %v, want %d", tt.search, count, err, len(tt.wantWords))
+			}
+		})
+	}
+}
+
+func TestSQLiteRepository_FullTextSearchSnippet(t *testing.T) {
+	repo, cleanup := setupSearchRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	repo.Create(ctx, &models.Word{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15",
+		ExampleSentence: strPtr("Fleeting <beauty> of cherry blossoms")})
+
+	got, err := repo.List(ctx, models.WordFilter{Search: "cherry"})
+	if err != nil || len(got) != 1 {
+		t.Fatalf("List() = %v, %v", got, err)
+	}
+	want := "Fleeting &lt;beauty&gt; of <mark>cherry</mark> blossoms"
+	if got[0].Snippet != want {
+		t.Errorf("Snippet = %q, want %q", got[0].Snippet, want)
+	}
+}
+
+func TestSQLiteRepository_FullTextSearchSync(t *testing.T) {
+	repo, cleanup := setupSearchRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	search := func(q string) int {
+		t.Helper()
+		count, err := repo.Count(ctx, models.WordFilter{Search: q})
+		if err != nil {
+			t.Fatalf("Count(%q) error = %v", q, err)
+		}
+		return int(count)
+	}
+
+	word, _ := repo.Create(ctx, &models.Word{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15"})
+
+	word.ExampleSentence = strPtr("Mayflies are short lived")
+	repo.Update(ctx, word)
+	if search("mayflies") != 1 {
+		t.Error("search did not see updated example sentence")
+	}
+
+	field, _ := repo.CreateField(ctx, &models.CustomField{Name: "notes", Label: "Notes", Type: models.FieldTypeText})
+	word.CustomFields = map[string]string{field.Name: "from the Greek ephemeros"}
+	repo.Update(ctx, word)
+	if search("ephemeros") != 1 {
+		t.Error("search did not see custom field value")
+	}
+
+	word.CustomFields = nil
+	repo.Update(ctx, word)
+	if search("ephemeros") != 0 {
+		t.Error("search still matches removed custom field value")
+	}
+
+	repo.Delete(ctx, word.ID)
+	if search("ephemeral") != 0 {
+		t.Error("search still matches deleted word")
+	}
+}
+
+func TestSQLiteRepository_FullTextSearchDefinitions(t *testing.T) {
+	repo, cleanup := setupSearchRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	search := func(q string) int {
+		t.Helper()
+		count, err := repo.Count(ctx, models.WordFilter{Search: q})
+		if err != nil {
+			t.Fatalf("Count(%q) error = %v", q, err)
+		}
+		return int(count)
+	}
+	definition := func(text string) *models.DictionaryResponse {
+		return &models.DictionaryResponse{Meanings: []models.Meaning{
+			{PartOfSpeech: "adjective", Definitions: []models.Definition{{Definition: text}}},
+		}}
+	}
+
+	// A definition cached before the word is added is indexed with it
+	repo.SaveDefinition(ctx, "Ephemeral", definition("Lasting a very short time"))
+	repo.Create(ctx, &models.Word{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15"})
+	if search("lasting") != 1 {
+		t.Error("search did not see definition cached before the word")
+	}
+
+	// One cached or refreshed afterwards replaces it
+	repo.SaveDefinition(ctx, "ephemeral", definition("Fleeting"))
+	if search("lasting") != 0 || search("fleeting") != 1 {
+		t.Error("search did not follow the refreshed definition")
+	}
+
+	// Definitions rank below the word itself, and the JSON keys are not indexed
+	repo.Create(ctx, &models.Word{Word: "fleeting", Source: "Book", DateLearned: "2024-01-15"})
+	got, _ := repo.List(ctx, models.WordFilter{Search: "fleeting"})
+	if len(got) != 2 || got[0].Word != "fleeting" {
+		t.Errorf("List(fleeting) = %v, want fleeting ranked first of 2", got)
+	}
+	if search("meanings") != 0 {
+		t.Error("search matched definition JSON keys")
+	}
+}
+
+func TestSQLiteRepository_InitSearchUpgrade(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+	ctx := context.Background()
+
+	// An index from before the definitions column, with its triggers
+	for _, stmt := range []string{
+		`CREATE VIRTUAL TABLE words_fts USING fts5(word, example_sentence, source, tags, custom)`,
+		`CREATE TRIGGER words_fts_insert AFTER INSERT ON words BEGIN
+			INSERT INTO words_fts (rowid, word, example_sentence, source, tags, custom)
+			VALUES (NEW.id, NEW.word, NEW.example_sentence, NEW.source, NEW.tags, '');
+		END`,
+	} {
+		if _, err := db.Exec(stmt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	db.Exec(`INSERT INTO words (word, source, date_learned) VALUES ('ephemeral', 'Book', '2024-01-15')`)
+	db.Exec(`INSERT INTO definitions (word, data) VALUES ('ephemeral', '{"meanings":[{"definitions":[{"definition":"Fleeting"}]}]}')`)
+
+	repo := NewSQLiteRepository(db)
+	if _, err := repo.InitSearch(ctx); err != nil {
+		t.Fatalf("InitSearch() error = %v", err)
+	}
+
+	got, err := repo.List(ctx, models.WordFilter{Search: "fleeting"})
+	if err != nil || len(got) != 1 {
+		t.Errorf("List() after upgrade = %d words, %v, want 1", len(got), err)
+	}
+}
+
+func TestSQLiteRepository_InitSearchRebuild(t *testing.T) {
+	repo, cleanup := setupSearchRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	// Simulate rows written by a build without FTS5: triggers gone, index stale
+	for _, name := range ftsTriggerNames() {
+		repo.conn.Exec(`DROP TRIGGER ` + name)
+	}
+	repo.Create(ctx, &models.Word{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15"})
+
+	if _, err := repo.InitSearch(ctx); err != nil {
+		t.Fatalf("InitSearch() error = %v", err)
+	}
+
+	got, err := repo.List(ctx, models.WordFilter{Search: "ephemeral"})
+	if err != nil || len(got) != 1 {
+		t.Errorf("List() after rebuild = %d words, %v, want 1", len(got), err)
+	}
+}
+
+func TestSQLiteRepository_FullTextSearchPaging(t *testing.T) {
+	repo, cleanup := setupSearchRepo(t)
+	defer cleanup()
+	ctx := context.Background()
+
+	for _, word := range []string{"blossom", "blossoming", "blossomed", "reblossom", "bloom"} {
+		repo.Create(ctx, &models.Word{Word: word, Source: "Book", DateLearned: "2024-01-15",
+			ExampleSentence: strPtr("Trees blossom in spring")})
+	}
+
+	want, _ := repo.List(ctx, models.WordFilter{Search: "blossom"})
+
+	var got []*models.Word
+	filter := models.WordFilter{Search: "blossom", Limit: 2}
+	for {
+		page, err := repo.ListPage(ctx, filter)
+		if err != nil {
+			t.Fatalf("ListPage() error = %v", err)
+		}
+		got = append(got, page.Words...)
+		if page.NextCursor == "" {
+			break
+		}
+		filter.Cursor = page.NextCursor
+	}
+
+	if len(got) != len(want) {
+		t.Fatalf("paged through %d words, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i].ID != want[i].ID {
+			t.Errorf("word %d = %s, want %s", i, got[i].Word, want[i].Word)
+		}
+	}
+}
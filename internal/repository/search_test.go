@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+func TestParseSearch(t *testing.T) {
+	tests := []struct {
+		name   string
+		search string
+		want   []searchTerm
+	}{
+		{
+			name:   "single word is a prefix",
+			search: "eph",
+			want:   []searchTerm{{text: "eph", prefix: true}},
+		},
+		{
+			name:   "explicit prefix",
+			search: "cherry* blossom",
+			want:   []searchTerm{{text: "cherry", prefix: true}, {text: "blossom", prefix: true}},
+		},
+		{
+			name:   "phrase",
+			search: `"cherry blossoms" beauty`,
+			want:   []searchTerm{{text: "cherry blossoms", phrase: true}, {text: "beauty", prefix: true}},
+		},
+		{
+			name:   "trailing phrase is exact",
+			search: `the "ephemeral beauty"`,
+			want:   []searchTerm{{text: "the"}, {text: "ephemeral beauty", phrase: true}},
+		},
+		{
+			name:   "fts syntax is treated as text",
+			search: "word:NEAR(a) -b",
+			want:   []searchTerm{{text: "word"}, {text: "NEAR"}, {text: "a"}, {text: "b", prefix: true}},
+		},
+		{
+			name:   "only punctuation",
+			search: `** "" -`,
+			want:   nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseSearch(tt.search)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSearch(%q) = %+v, want %+v", tt.search, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFTSQuery(t *testing.T) {
+	got := ftsQuery(parseSearch(`"cherry blossoms" ephem`))
+	want := `"cherry blossoms" "ephem"*`
+	if got != want {
+		t.Errorf("ftsQuery() = %s, want %s", got, want)
+	}
+}
+
+func TestFormatSnippet(t *testing.T) {
+	got := formatSnippet("a <b> " + snippetStart + "match" + snippetEnd + " & more")
+	want := "a &lt;b&gt; <mark>match</mark> &amp; more"
+	if got != want {
+		t.Errorf("formatSnippet() = %s, want %s", got, want)
+	}
+}
+
+func TestSQLiteRepository_SearchFallback(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	enabled, err := repo.InitSearch(ctx)
+	if err != nil {
+		t.Fatalf("InitSearch() error = %v", err)
+	}
+	if enabled {
+		t.Skip("FTS5 is available; covered by the sqlite_fts5 tests")
+	}
+
+	repo.CreateField(ctx, &models.CustomField{Name: "notes", Label: "Notes", Type: models.FieldTypeText})
+	repo.Create(ctx, &models.Word{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15",
+		ExampleSentence: strPtr("The beauty of cherry blossoms"), Tags: []string{"nature"},
+		CustomFields: map[string]string{"notes": "from the Greek ephemeros"}})
+	repo.Create(ctx, &models.Word{Word: "eloquent", Source: "Speech", DateLearned: "2024-03-10"})
+	repo.SaveDefinition(ctx, "Eloquent", &models.DictionaryResponse{Meanings: []models.Meaning{
+		{PartOfSpeech: "adjective", Definitions: []models.Definition{{Definition: "Fluent or persuasive"}}},
+	}})
+
+	// The fallback searches the same columns as the full-text index
+	tests := []struct {
+		search    string
+		wantCount int
+	}{
+		{search: "eph", wantCount: 1},
+		{search: "cherry", wantCount: 1},
+		{search: `"cherry blossoms"`, wantCount: 1},
+		{search: "e* beauty", wantCount: 1},
+		{search: "speech", wantCount: 1},
+		{search: "nature", wantCount: 1},
+		{search: "ephemeros", wantCount: 1},
+		{search: "persuasive", wantCount: 1},
+		{search: "meanings", wantCount: 0},
+		{search: "missing", wantCount: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.search, func(t *testing.T) {
+			got, err := repo.List(ctx, models.WordFilter{Search: tt.search})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(got) != tt.wantCount {
+				t.Errorf("List(%q) returned %d words, want %d", tt.search, len(got), tt.wantCount)
+			}
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -13,15 +14,53 @@ import (
 
 // SQLiteRepository implements WordRepository using SQLite
 type SQLiteRepository struct {
-	db *sql.DB
+	db   dbtx
+	conn *sql.DB // the database; nil for a repository bound to a transaction
+	fts  bool    // full-text search index available; set by InitSearch
+}
+
+// dbtx is what queries run on: the database or a transaction
+type dbtx interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
 }
 
 // NewSQLiteRepository creates a new SQLite repository
 func NewSQLiteRepository(db *sql.DB) *SQLiteRepository {
-	return &SQLiteRepository{db: db}
+	return &SQLiteRepository{db: db, conn: db}
+}
+
+// WithTx runs fn with a repository bound to a single transaction, committing it
+// when fn returns nil and rolling it back otherwise. Within a transaction, fn runs
+// on the same one.
+func (r *SQLiteRepository) WithTx(ctx context.Context, fn func(tx Tx) error) error {
+	return r.inTx(ctx, func(tx *SQLiteRepository) error {
+		return fn(tx)
+	})
+}
+
+// inTx runs fn on a repository bound to a new transaction, or to the transaction
+// r is already bound to
+func (r *SQLiteRepository) inTx(ctx context.Context, fn func(tx *SQLiteRepository) error) error {
+	if r.conn == nil {
+		return fn(r)
+	}
+
+	tx, err := r.conn.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(&SQLiteRepository{db: tx, fts: r.fts}); err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
-// Create inserts a new word and returns the created word with ID
+// Create inserts a new word and returns the created word with ID. The word row,
+// its custom field values and its trigrams are written in one transaction.
 func (r *SQLiteRepository) Create(ctx context.Context, word *models.Word) (*models.Word, error) {
 	tagsJSON, err := json.Marshal(word.Tags)
 	if err != nil {
@@ -29,21 +68,36 @@ func (r *SQLiteRepository) Create(ctx context.Context, word *models.Word) (*mode
 	}
 
 	now := time.Now()
-	result, err := r.db.ExecContext(ctx,
-		`INSERT INTO words (word, source, date_learned, part_of_speech, example_sentence, tags, created_at, updated_at)
-		 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
-		word.Word, word.Source, word.DateLearned, word.PartOfSpeech, word.ExampleSentence, string(tagsJSON), now, now,
-	)
-	if err != nil {
-		return nil, fmt.Errorf("failed to insert word: %w", err)
-	}
+	err = r.inTx(ctx, func(tx *SQLiteRepository) error {
+		result, err := tx.db.ExecContext(ctx,
+			`INSERT INTO words (word, source, date_learned, part_of_speech, example_sentence, tags, created_at, updated_at)
+			 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+			word.Word, word.Source, word.DateLearned, word.PartOfSpeech, word.ExampleSentence, string(tagsJSON), now, now,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to insert word: %w", err)
+		}
 
-	id, err := result.LastInsertId()
+		id, err := result.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("failed to get last insert id: %w", err)
+		}
+		word.ID = id
+
+		if err := tx.saveTrigrams(ctx, word); err != nil {
+			return err
+		}
+
+		if len(word.CustomFields) > 0 {
+			return tx.saveFieldValues(ctx, word)
+		}
+		return nil
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+		word.ID = 0
+		return nil, err
 	}
 
-	word.ID = id
 	word.CreatedAt = now
 	word.UpdatedAt = now
 	return word, nil
@@ -55,7 +109,7 @@ func (r *SQLiteRepository) GetByID(ctx context.Context, id int64) (*models.Word,
 		`SELECT id, word, source, date_learned, part_of_speech, example_sentence, tags, created_at, updated_at
 		 FROM words WHERE id = ?`, id,
 	)
-	return r.scanWord(row)
+	return r.scanWordWithFields(ctx, row)
 }
 
 // GetByWord retrieves a word by the word text itself
@@ -64,35 +118,137 @@ func (r *SQLiteRepository) GetByWord(ctx context.Context, word string) (*models.
 		`SELECT id, word, source, date_learned, part_of_speech, example_sentence, tags, created_at, updated_at
 		 FROM words WHERE word = ?`, word,
 	)
-	return r.scanWord(row)
+	return r.scanWordWithFields(ctx, row)
 }
 
 // List retrieves words with optional filtering
 func (r *SQLiteRepository) List(ctx context.Context, filter models.WordFilter) ([]*models.Word, error) {
-	query, args := r.buildListQuery(filter, false)
-	rows, err := r.db.QueryContext(ctx, query, args...)
+	page, err := r.ListPage(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	return page.Words, nil
+}
+
+// ListPage retrieves a page of words along with a cursor for the next page
+func (r *SQLiteRepository) ListPage(ctx context.Context, filter models.WordFilter) (*models.WordPage, error) {
+	lq, err := r.buildListQuery(filter, false)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(ctx, lq.sql, lq.args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query words: %w", err)
 	}
 	defer rows.Close()
 
-	var words []*models.Word
+	// Full-text searches select a highlighted snippet after the word columns, and
+	// keyset-ordered lists select the raw sort key last
+	var snippet sql.NullString
+	var sortKey interface{}
+	var extra []interface{}
+	if lq.snippet {
+		extra = append(extra, &snippet)
+	}
+	if !lq.order.relevance {
+		extra = append(extra, &sortKey)
+	}
+
+	page := &models.WordPage{}
 	for rows.Next() {
-		word, err := r.scanWordFromRows(rows)
+		word, err := r.scanWordFromRows(rows, extra...)
 		if err != nil {
 			return nil, err
 		}
-		words = append(words, word)
+		if lq.snippet {
+			word.Snippet = formatSnippet(snippet.String)
+		}
+		page.Words = append(page.Words, word)
 	}
 
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating rows: %w", err)
 	}
 
-	return words, nil
+	if err := r.loadFieldValues(ctx, page.Words); err != nil {
+		return nil, err
+	}
+
+	// A full page means there may be more
+	if filter.Limit > 0 && len(page.Words) == filter.Limit {
+		c := cursor{Sort: lq.order.sort, Filter: fingerprint(filter)}
+		if lq.order.relevance {
+			c.Offset = lq.offset + len(page.Words)
+		} else {
+			c.Key = sortKey
+			c.ID = page.Words[len(page.Words)-1].ID
+		}
+		page.NextCursor = c.encode()
+	}
+
+	return page, nil
 }
 
-// Update modifies an existing word
+// Restore writes a word with its own created_at and updated_at, as read from an
+// export: it inserts the word when ID is zero and replaces the word with that ID otherwise
+func (r *SQLiteRepository) Restore(ctx context.Context, word *models.Word) (*models.Word, error) {
+	tagsJSON, err := json.Marshal(word.Tags)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tags: %w", err)
+	}
+
+	inserted := word.ID == 0
+	err = r.inTx(ctx, func(tx *SQLiteRepository) error {
+		if inserted {
+			result, err := tx.db.ExecContext(ctx,
+				`INSERT INTO words (word, source, date_learned, part_of_speech, example_sentence, tags, created_at, updated_at)
+				 VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+				word.Word, word.Source, word.DateLearned, word.PartOfSpeech, word.ExampleSentence, string(tagsJSON),
+				word.CreatedAt, word.UpdatedAt,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to insert word: %w", err)
+			}
+			if word.ID, err = result.LastInsertId(); err != nil {
+				return fmt.Errorf("failed to get last insert id: %w", err)
+			}
+		} else {
+			result, err := tx.db.ExecContext(ctx,
+				`UPDATE words SET word = ?, source = ?, date_learned = ?, part_of_speech = ?,
+				 example_sentence = ?, tags = ?, created_at = ?, updated_at = ? WHERE id = ?`,
+				word.Word, word.Source, word.DateLearned, word.PartOfSpeech, word.ExampleSentence, string(tagsJSON),
+				word.CreatedAt, word.UpdatedAt, word.ID,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to update word: %w", err)
+			}
+			rowsAffected, err := result.RowsAffected()
+			if err != nil {
+				return fmt.Errorf("failed to get rows affected: %w", err)
+			}
+			if rowsAffected == 0 {
+				return sql.ErrNoRows
+			}
+		}
+
+		if err := tx.saveFieldValues(ctx, word); err != nil {
+			return err
+		}
+		return tx.saveTrigrams(ctx, word)
+	})
+	if err != nil {
+		if inserted {
+			word.ID = 0
+		}
+		return nil, err
+	}
+
+	return word, nil
+}
+
+// Update modifies an existing word, together with its custom field values and
+// trigrams, in one transaction
 func (r *SQLiteRepository) Update(ctx context.Context, word *models.Word) (*models.Word, error) {
 	tagsJSON, err := json.Marshal(word.Tags)
 	if err != nil {
@@ -100,14 +256,24 @@ func (r *SQLiteRepository) Update(ctx context.Context, word *models.Word) (*mode
 	}
 
 	now := time.Now()
-	_, err = r.db.ExecContext(ctx,
-		`UPDATE words SET word = ?, source = ?, date_learned = ?, part_of_speech = ?,
-		 example_sentence = ?, tags = ?, updated_at = ? WHERE id = ?`,
-		word.Word, word.Source, word.DateLearned, word.PartOfSpeech, word.ExampleSentence,
-		string(tagsJSON), now, word.ID,
-	)
+	err = r.inTx(ctx, func(tx *SQLiteRepository) error {
+		_, err := tx.db.ExecContext(ctx,
+			`UPDATE words SET word = ?, source = ?, date_learned = ?, part_of_speech = ?,
+			 example_sentence = ?, tags = ?, updated_at = ? WHERE id = ?`,
+			word.Word, word.Source, word.DateLearned, word.PartOfSpeech, word.ExampleSentence,
+			string(tagsJSON), now, word.ID,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to update word: %w", err)
+		}
+
+		if err := tx.saveFieldValues(ctx, word); err != nil {
+			return err
+		}
+		return tx.saveTrigrams(ctx, word)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to update word: %w", err)
+		return nil, err
 	}
 
 	word.UpdatedAt = now
@@ -116,20 +282,60 @@ func (r *SQLiteRepository) Update(ctx context.Context, word *models.Word) (*mode
 
 // Delete removes a word by ID
 func (r *SQLiteRepository) Delete(ctx context.Context, id int64) error {
-	result, err := r.db.ExecContext(ctx, `DELETE FROM words WHERE id = ?`, id)
+	// Dependent rows are removed explicitly and in the same transaction as the word,
+	// since the connection does not enforce foreign keys
+	return r.inTx(ctx, func(tx *SQLiteRepository) error {
+		if _, err := tx.db.ExecContext(ctx, `DELETE FROM attachments WHERE word_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete attachments: %w", err)
+		}
+
+		if _, err := tx.db.ExecContext(ctx, `DELETE FROM word_field_values WHERE word_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete custom field values: %w", err)
+		}
+
+		if _, err := tx.db.ExecContext(ctx, `DELETE FROM word_trigrams WHERE word_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete trigrams: %w", err)
+		}
+
+		result, err := tx.db.ExecContext(ctx, `DELETE FROM words WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete word: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return sql.ErrNoRows
+		}
+
+		return nil
+	})
+}
+
+// RecordReview counts a flash-card review of a word, and a lapse when it was not
+// remembered. Reviews are not edits, so updated_at is left alone.
+func (r *SQLiteRepository) RecordReview(ctx context.Context, id int64, remembered bool) error {
+	lapse := 0
+	if !remembered {
+		lapse = 1
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE words SET reviews = reviews + 1, lapses = lapses + ? WHERE id = ?`, lapse, id)
 	if err != nil {
-		return fmt.Errorf("failed to delete word: %w", err)
+		return fmt.Errorf("failed to record review: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
 		return fmt.Errorf("failed to get rows affected: %w", err)
 	}
-
 	if rowsAffected == 0 {
 		return sql.ErrNoRows
 	}
-
 	return nil
 }
 
@@ -139,74 +345,228 @@ func (r *SQLiteRepository) GetRandom(ctx context.Context) (*models.Word, error)
 		`SELECT id, word, source, date_learned, part_of_speech, example_sentence, tags, created_at, updated_at
 		 FROM words ORDER BY RANDOM() LIMIT 1`,
 	)
-	return r.scanWord(row)
+	return r.scanWordWithFields(ctx, row)
 }
 
 // Count returns the total number of words matching the filter
 func (r *SQLiteRepository) Count(ctx context.Context, filter models.WordFilter) (int64, error) {
-	query, args := r.buildListQuery(filter, true)
+	lq, err := r.buildListQuery(filter, true)
+	if err != nil {
+		return 0, err
+	}
 	var count int64
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
+	err = r.db.QueryRowContext(ctx, lq.sql, lq.args...).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count words: %w", err)
 	}
 	return count, nil
 }
 
-// buildListQuery constructs the SQL query for listing words
-func (r *SQLiteRepository) buildListQuery(filter models.WordFilter, countOnly bool) (string, []interface{}) {
+// presenceConditions test whether a word has a value for each has: attribute
+var presenceConditions = map[string]string{
+	"example": "(coalesce(words.example_sentence, '') <> '')",
+	"pos":     "(coalesce(words.part_of_speech, '') <> '')",
+	"tags":    "(coalesce(json_array_length(words.tags), 0) > 0)",
+}
+
+// listQuery is a built list or count query
+type listQuery struct {
+	sql     string
+	from    string // FROM, JOIN and WHERE clauses selecting the matching words
+	args    []interface{}
+	order   listOrder
+	offset  int  // rows skipped by the query
+	snippet bool // a snippet column follows the word columns
+}
+
+// buildListQuery constructs the SQL query for listing words. Count queries ignore
+// ordering and paging.
+func (r *SQLiteRepository) buildListQuery(filter models.WordFilter, countOnly bool) (*listQuery, error) {
 	var conditions []string
 	var args []interface{}
 
-	if filter.Search != "" {
-		conditions = append(conditions, "word LIKE ?")
-		args = append(args, "%"+filter.Search+"%")
+	terms := parseSearch(filter.Search)
+	useFTS := r.fts && len(terms) > 0
+	lq := &listQuery{
+		order:   resolveOrder(filter.Sort, useFTS),
+		offset:  filter.Offset,
+		snippet: useFTS && !countOnly,
+	}
+
+	if useFTS {
+		conditions = append(conditions, "words_fts MATCH ?")
+		args = append(args, ftsQuery(terms))
+	} else {
+		for _, term := range terms {
+			conditions = append(conditions, likeSearch)
+			pattern := "%" + term.text + "%"
+			for i := strings.Count(likeSearch, "?"); i > 0; i-- {
+				args = append(args, pattern)
+			}
+		}
 	}
 
+	sources := filter.Sources
 	if filter.Source != "" {
-		conditions = append(conditions, "source = ?")
-		args = append(args, filter.Source)
+		sources = append([]string{filter.Source}, sources...)
+	}
+	if len(sources) > 0 {
+		conditions = append(conditions, "words.source IN ("+placeholders(len(sources))+")")
+		args = appendStrings(args, sources)
+	}
+
+	if len(filter.ExcludeSources) > 0 {
+		conditions = append(conditions, "words.source NOT IN ("+placeholders(len(filter.ExcludeSources))+")")
+		args = appendStrings(args, filter.ExcludeSources)
 	}
 
+	// Tags are stored as a JSON array; every required tag gets its own EXISTS
+	tags := filter.Tags
 	if filter.Tag != "" {
-		conditions = append(conditions, "tags LIKE ?")
-		args = append(args, "%\""+filter.Tag+"\"%")
+		tags = append([]string{filter.Tag}, tags...)
+	}
+	for _, tag := range tags {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM json_each(words.tags) WHERE json_each.value = ?)")
+		args = append(args, tag)
+	}
+
+	if len(filter.AnyTags) > 0 {
+		conditions = append(conditions, "EXISTS (SELECT 1 FROM json_each(words.tags) WHERE json_each.value IN ("+
+			placeholders(len(filter.AnyTags))+"))")
+		args = appendStrings(args, filter.AnyTags)
+	}
+
+	if len(filter.ExcludeTags) > 0 {
+		conditions = append(conditions, "NOT EXISTS (SELECT 1 FROM json_each(words.tags) WHERE json_each.value IN ("+
+			placeholders(len(filter.ExcludeTags))+"))")
+		args = appendStrings(args, filter.ExcludeTags)
+	}
+
+	if len(filter.PartsOfSpeech) > 0 {
+		conditions = append(conditions, "words.part_of_speech IN ("+placeholders(len(filter.PartsOfSpeech))+")")
+		args = appendStrings(args, filter.PartsOfSpeech)
+	}
+
+	if len(filter.ExcludePartsOfSpeech) > 0 {
+		conditions = append(conditions, "(words.part_of_speech IS NULL OR words.part_of_speech NOT IN ("+
+			placeholders(len(filter.ExcludePartsOfSpeech))+"))")
+		args = appendStrings(args, filter.ExcludePartsOfSpeech)
+	}
+
+	for _, name := range filter.Has {
+		if cond, ok := presenceConditions[name]; ok {
+			conditions = append(conditions, cond)
+		}
+	}
+
+	for _, name := range filter.Missing {
+		if cond, ok := presenceConditions[name]; ok {
+			conditions = append(conditions, "NOT "+cond)
+		}
 	}
 
 	if filter.FromDate != "" {
-		conditions = append(conditions, "date_learned >= ?")
+		conditions = append(conditions, "words.date_learned >= ?")
 		args = append(args, filter.FromDate)
 	}
 
 	if filter.ToDate != "" {
-		conditions = append(conditions, "date_learned <= ?")
+		conditions = append(conditions, "words.date_learned <= ?")
 		args = append(args, filter.ToDate)
 	}
 
-	var query string
-	if countOnly {
-		query = "SELECT COUNT(*) FROM words"
-	} else {
-		query = `SELECT id, word, source, date_learned, part_of_speech, example_sentence, tags, created_at, updated_at FROM words`
+	// Sort field names so the generated SQL is stable
+	fieldNames := make([]string, 0, len(filter.Fields))
+	for name := range filter.Fields {
+		fieldNames = append(fieldNames, name)
+	}
+	sort.Strings(fieldNames)
+	for _, name := range fieldNames {
+		conditions = append(conditions, `EXISTS (SELECT 1 FROM word_field_values v
+			JOIN custom_fields f ON f.id = v.field_id
+			WHERE v.word_id = words.id AND f.name = ? AND v.value = ?)`)
+		args = append(args, name, filter.Fields[name])
+	}
+
+	// Continue after the last row of the previous page
+	if filter.Cursor != "" && !countOnly {
+		c, err := decodeCursor(filter.Cursor)
+		if err != nil || c.Sort != lq.order.sort || c.Filter != fingerprint(filter) {
+			return nil, ErrInvalidCursor
+		}
+
+		if lq.order.relevance {
+			lq.offset = c.Offset
+		} else {
+			key, err := lq.order.column.cursorValue(c.Key)
+			if err != nil {
+				return nil, ErrInvalidCursor
+			}
+			lq.offset = 0
+			op := ">"
+			if lq.order.desc {
+				op = "<"
+			}
+			expr := lq.order.column.expr
+			conditions = append(conditions, fmt.Sprintf("(%s %s ? OR (%s = ? AND words.id %s ?))", expr, op, expr, op))
+			args = append(args, key, key, c.ID)
+		}
+	}
+
+	lq.from = " FROM words"
+	if useFTS {
+		lq.from += " JOIN words_fts ON words_fts.rowid = words.id"
 	}
 
 	if len(conditions) > 0 {
-		query += " WHERE " + strings.Join(conditions, " AND ")
+		lq.from += " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	var query string
+	if countOnly {
+		query = "SELECT COUNT(*)" + lq.from
+	} else {
+		query = `SELECT words.id, words.word, words.source, words.date_learned, words.part_of_speech,
+			words.example_sentence, words.tags, words.created_at, words.updated_at`
+		if lq.snippet {
+			query += `, snippet(words_fts, -1, char(2), char(3), '…', 12)`
+		}
+		if !lq.order.relevance {
+			query += ", " + lq.order.column.key
+		}
+		query += lq.from
 	}
 
 	if !countOnly {
-		query += " ORDER BY date_learned DESC, id DESC"
+		query += " ORDER BY " + lq.order.clause()
 
 		if filter.Limit > 0 {
 			query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+		} else if lq.offset > 0 {
+			query += " LIMIT -1"
 		}
 
-		if filter.Offset > 0 {
-			query += fmt.Sprintf(" OFFSET %d", filter.Offset)
+		if lq.offset > 0 {
+			query += fmt.Sprintf(" OFFSET %d", lq.offset)
 		}
 	}
 
-	return query, args
+	lq.sql = query
+	lq.args = args
+	return lq, nil
+}
+
+// placeholders returns n comma-separated SQL parameter markers
+func placeholders(n int) string {
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
+
+// appendStrings appends values to a query argument list
+func appendStrings(args []interface{}, values []string) []interface{} {
+	for _, v := range values {
+		args = append(args, v)
+	}
+	return args
 }
 
 // scanWord scans a single row into a Word struct
@@ -241,17 +601,33 @@ func (r *SQLiteRepository) scanWord(row *sql.Row) (*models.Word, error) {
 	return &word, nil
 }
 
-// scanWordFromRows scans a row from sql.Rows into a Word struct
-func (r *SQLiteRepository) scanWordFromRows(rows *sql.Rows) (*models.Word, error) {
+// scanWordWithFields scans a single row and loads its custom field values
+func (r *SQLiteRepository) scanWordWithFields(ctx context.Context, row *sql.Row) (*models.Word, error) {
+	word, err := r.scanWord(row)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.loadFieldValues(ctx, []*models.Word{word}); err != nil {
+		return nil, err
+	}
+
+	return word, nil
+}
+
+// scanWordFromRows scans a row from sql.Rows into a Word struct; extra receives
+// any columns selected after the word columns
+func (r *SQLiteRepository) scanWordFromRows(rows *sql.Rows, extra ...interface{}) (*models.Word, error) {
 	var word models.Word
 	var tagsJSON string
 	var partOfSpeech, exampleSentence sql.NullString
 
-	err := rows.Scan(
+	dest := []interface{}{
 		&word.ID, &word.Word, &word.Source, &word.DateLearned,
 		&partOfSpeech, &exampleSentence, &tagsJSON,
 		&word.CreatedAt, &word.UpdatedAt,
-	)
+	}
+	err := rows.Scan(append(dest, extra...)...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to scan word: %w", err)
 	}
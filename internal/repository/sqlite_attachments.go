@@ -0,0 +1,134 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// CreateAttachment inserts attachment metadata and its thumbnail
+func (r *SQLiteRepository) CreateAttachment(ctx context.Context, attachment *models.Attachment, thumbnail []byte) (*models.Attachment, error) {
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO attachments (word_id, filename, content_type, size, width, height, checksum, thumbnail, created_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		attachment.WordID, attachment.Filename, attachment.ContentType, attachment.Size,
+		attachment.Width, attachment.Height, attachment.Checksum, thumbnail, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert attachment: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	attachment.ID = id
+	attachment.CreatedAt = now
+	return attachment, nil
+}
+
+// GetAttachment retrieves attachment metadata by ID
+func (r *SQLiteRepository) GetAttachment(ctx context.Context, id int64) (*models.Attachment, error) {
+	var a models.Attachment
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, word_id, filename, content_type, size, width, height, checksum, created_at
+		 FROM attachments WHERE id = ?`, id,
+	).Scan(&a.ID, &a.WordID, &a.Filename, &a.ContentType, &a.Size, &a.Width, &a.Height, &a.Checksum, &a.CreatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan attachment: %w", err)
+	}
+	return &a, nil
+}
+
+// ListAttachments retrieves the attachments of a word, oldest first
+func (r *SQLiteRepository) ListAttachments(ctx context.Context, wordID int64) ([]*models.Attachment, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, word_id, filename, content_type, size, width, height, checksum, created_at
+		 FROM attachments WHERE word_id = ? ORDER BY id`, wordID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer rows.Close()
+
+	var attachments []*models.Attachment
+	for rows.Next() {
+		var a models.Attachment
+		if err := rows.Scan(&a.ID, &a.WordID, &a.Filename, &a.ContentType, &a.Size,
+			&a.Width, &a.Height, &a.Checksum, &a.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+		attachments = append(attachments, &a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return attachments, nil
+}
+
+// GetAttachmentThumbnail retrieves the thumbnail image of an attachment
+func (r *SQLiteRepository) GetAttachmentThumbnail(ctx context.Context, id int64) ([]byte, error) {
+	var thumbnail []byte
+	err := r.db.QueryRowContext(ctx, `SELECT thumbnail FROM attachments WHERE id = ?`, id).Scan(&thumbnail)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read thumbnail: %w", err)
+	}
+	return thumbnail, nil
+}
+
+// SetAttachmentData stores the original file bytes in the database
+func (r *SQLiteRepository) SetAttachmentData(ctx context.Context, id int64, data []byte) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE attachments SET data = ? WHERE id = ?`, data, id)
+	if err != nil {
+		return fmt.Errorf("failed to store attachment data: %w", err)
+	}
+	return nil
+}
+
+// GetAttachmentData retrieves original file bytes stored in the database
+func (r *SQLiteRepository) GetAttachmentData(ctx context.Context, id int64) ([]byte, error) {
+	var data []byte
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM attachments WHERE id = ?`, id).Scan(&data)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to read attachment data: %w", err)
+	}
+	if data == nil {
+		return nil, sql.ErrNoRows
+	}
+	return data, nil
+}
+
+// DeleteAttachment removes an attachment by ID
+func (r *SQLiteRepository) DeleteAttachment(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM attachments WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
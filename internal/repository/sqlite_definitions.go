@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// GetDefinition retrieves the cached definition of a word
+func (r *SQLiteRepository) GetDefinition(ctx context.Context, word string) (*models.DictionaryResponse, error) {
+	var data string
+	err := r.db.QueryRowContext(ctx, `SELECT data FROM definitions WHERE word = ?`, word).Scan(&data)
+	if err != nil {
+		return nil, err
+	}
+
+	var definition models.DictionaryResponse
+	if err := json.Unmarshal([]byte(data), &definition); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal definition: %w", err)
+	}
+	return &definition, nil
+}
+
+// SaveDefinition caches the definition of a word
+func (r *SQLiteRepository) SaveDefinition(ctx context.Context, word string, definition *models.DictionaryResponse) error {
+	data, err := json.Marshal(definition)
+	if err != nil {
+		return fmt.Errorf("failed to marshal definition: %w", err)
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`INSERT INTO definitions (word, data, fetched_at) VALUES (?, ?, ?)
+		 ON CONFLICT (word) DO UPDATE SET data = excluded.data, fetched_at = excluded.fetched_at`,
+		word, string(data), time.Now(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save definition: %w", err)
+	}
+	return nil
+}
+
+// ListDefinitions retrieves every cached definition keyed by lowercased word
+func (r *SQLiteRepository) ListDefinitions(ctx context.Context) (map[string]*models.DictionaryResponse, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT word, data FROM definitions`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list definitions: %w", err)
+	}
+	defer rows.Close()
+
+	definitions := make(map[string]*models.DictionaryResponse)
+	for rows.Next() {
+		var word, data string
+		if err := rows.Scan(&word, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan definition: %w", err)
+		}
+
+		var definition models.DictionaryResponse
+		if err := json.Unmarshal([]byte(data), &definition); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal definition: %w", err)
+		}
+		definitions[strings.ToLower(word)] = &definition
+	}
+
+	return definitions, rows.Err()
+}
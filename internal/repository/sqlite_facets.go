@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// facetQueries select (facet, value, count) rows from the matched CTE; each is
+// limited separately so that one large facet does not crowd out the others
+var facetQueries = []string{
+	`SELECT * FROM (SELECT 'tag', json_each.value, COUNT(*) AS n FROM matched, json_each(matched.tags)
+		WHERE json_each.type = 'text'
+		GROUP BY json_each.value ORDER BY n DESC, json_each.value LIMIT ?)`,
+	`SELECT * FROM (SELECT 'source', source, COUNT(*) AS n FROM matched
+		GROUP BY source ORDER BY n DESC, source LIMIT ?)`,
+	`SELECT * FROM (SELECT 'pos', part_of_speech, COUNT(*) AS n FROM matched
+		WHERE coalesce(part_of_speech, '') <> ''
+		GROUP BY part_of_speech ORDER BY n DESC, part_of_speech LIMIT ?)`,
+	`SELECT * FROM (SELECT 'month', substr(date_learned, 1, 7) AS month, COUNT(*) AS n FROM matched
+		GROUP BY month ORDER BY month DESC LIMIT ?)`,
+}
+
+// Facets counts the words matching the filter per tag, source, part of speech and
+// month learned, returning at most limit values for each
+func (r *SQLiteRepository) Facets(ctx context.Context, filter models.WordFilter, limit int) (*models.Facets, error) {
+	filter.Cursor = ""
+	lq, err := r.buildListQuery(filter, true)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `WITH matched AS (SELECT words.source, words.part_of_speech, words.tags, words.date_learned` + lq.from + `)
+		SELECT 'total', '', COUNT(*) FROM matched`
+	args := append([]interface{}{}, lq.args...)
+	for _, fq := range facetQueries {
+		query += " UNION ALL " + fq
+		args = append(args, limit)
+	}
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query facets: %w", err)
+	}
+	defer rows.Close()
+
+	facets := &models.Facets{
+		Tags:          []models.FacetCount{},
+		Sources:       []models.FacetCount{},
+		PartsOfSpeech: []models.FacetCount{},
+		Months:        []models.FacetCount{},
+	}
+	for rows.Next() {
+		var facet string
+		var fc models.FacetCount
+		if err := rows.Scan(&facet, &fc.Value, &fc.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan facet: %w", err)
+		}
+
+		switch facet {
+		case "total":
+			facets.Total = fc.Count
+		case "tag":
+			facets.Tags = append(facets.Tags, fc)
+		case "source":
+			facets.Sources = append(facets.Sources, fc)
+		case "pos":
+			facets.PartsOfSpeech = append(facets.PartsOfSpeech, fc)
+		case "month":
+			facets.Months = append(facets.Months, fc)
+		}
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return facets, nil
+}
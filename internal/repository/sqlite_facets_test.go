@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+func TestSQLiteRepository_Facets(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	for _, w := range []*models.Word{
+		{Word: "habeas", Source: "Law Review", DateLearned: "2024-01-15", Tags: []string{"latin", "legal"}, PartOfSpeech: strPtr("noun")},
+		{Word: "mens rea", Source: "Law Review", DateLearned: "2024-02-03", Tags: []string{"latin", "legal"}, PartOfSpeech: strPtr("noun")},
+		{Word: "ad hoc", Source: "Article", DateLearned: "2024-02-10", Tags: []string{"latin"}, PartOfSpeech: strPtr("adjective")},
+		{Word: "thee", Source: "Poetry", DateLearned: "2024-02-11"},
+	} {
+		if _, err := repo.Create(ctx, w); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter models.WordFilter
+		limit  int
+		want   *models.Facets
+	}{
+		{
+			name:  "all words",
+			limit: 10,
+			want: &models.Facets{
+				Total:         4,
+				Tags:          []models.FacetCount{facet("latin", 3), facet("legal", 2)},
+				Sources:       []models.FacetCount{facet("Law Review", 2), facet("Article", 1), facet("Poetry", 1)},
+				PartsOfSpeech: []models.FacetCount{facet("noun", 2), facet("adjective", 1)},
+				Months:        []models.FacetCount{facet("2024-02", 3), facet("2024-01", 1)},
+			},
+		},
+		{
+			name:   "filtered and limited",
+			filter: models.WordFilter{Tags: []string{"latin"}, FromDate: "2024-02-01"},
+			limit:  1,
+			want: &models.Facets{
+				Total:         2,
+				Tags:          []models.FacetCount{facet("latin", 2)},
+				Sources:       []models.FacetCount{facet("Article", 1)},
+				PartsOfSpeech: []models.FacetCount{facet("adjective", 1)},
+				Months:        []models.FacetCount{facet("2024-02", 2)},
+			},
+		},
+		{
+			name:   "no matches",
+			filter: models.WordFilter{Sources: []string{"Missing"}},
+			limit:  10,
+			want: &models.Facets{
+				Tags:          []models.FacetCount{},
+				Sources:       []models.FacetCount{},
+				PartsOfSpeech: []models.FacetCount{},
+				Months:        []models.FacetCount{},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.Facets(ctx, tt.filter, tt.limit)
+			if err != nil {
+				t.Fatalf("Facets() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Facets() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func facet(value string, count int64) models.FacetCount {
+	return models.FacetCount{Value: value, Count: count}
+}
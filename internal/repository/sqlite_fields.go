@@ -0,0 +1,236 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// CreateField inserts a new custom field definition
+func (r *SQLiteRepository) CreateField(ctx context.Context, field *models.CustomField) (*models.CustomField, error) {
+	optionsJSON, err := marshalOptions(field.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO custom_fields (name, label, type, options, created_at, updated_at)
+		 VALUES (?, ?, ?, ?, ?, ?)`,
+		field.Name, field.Label, string(field.Type), optionsJSON, now, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert custom field: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	field.ID = id
+	field.CreatedAt = now
+	field.UpdatedAt = now
+	return field, nil
+}
+
+// GetField retrieves a custom field by its ID
+func (r *SQLiteRepository) GetField(ctx context.Context, id int64) (*models.CustomField, error) {
+	var field models.CustomField
+	var fieldType, optionsJSON string
+
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, name, label, type, options, created_at, updated_at FROM custom_fields WHERE id = ?`, id,
+	).Scan(&field.ID, &field.Name, &field.Label, &fieldType, &optionsJSON, &field.CreatedAt, &field.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan custom field: %w", err)
+	}
+
+	field.Type = models.FieldType(fieldType)
+	if err := json.Unmarshal([]byte(optionsJSON), &field.Options); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal options: %w", err)
+	}
+	return &field, nil
+}
+
+// ListFields retrieves all custom fields ordered by ID
+func (r *SQLiteRepository) ListFields(ctx context.Context) ([]*models.CustomField, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, name, label, type, options, created_at, updated_at FROM custom_fields ORDER BY id`,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query custom fields: %w", err)
+	}
+	defer rows.Close()
+
+	var fields []*models.CustomField
+	for rows.Next() {
+		var field models.CustomField
+		var fieldType, optionsJSON string
+		if err := rows.Scan(&field.ID, &field.Name, &field.Label, &fieldType, &optionsJSON,
+			&field.CreatedAt, &field.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan custom field: %w", err)
+		}
+		field.Type = models.FieldType(fieldType)
+		if err := json.Unmarshal([]byte(optionsJSON), &field.Options); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal options: %w", err)
+		}
+		fields = append(fields, &field)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return fields, nil
+}
+
+// UpdateField modifies the label and options of a custom field
+func (r *SQLiteRepository) UpdateField(ctx context.Context, field *models.CustomField) (*models.CustomField, error) {
+	optionsJSON, err := marshalOptions(field.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	_, err = r.db.ExecContext(ctx,
+		`UPDATE custom_fields SET label = ?, options = ?, updated_at = ? WHERE id = ?`,
+		field.Label, optionsJSON, now, field.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update custom field: %w", err)
+	}
+
+	field.UpdatedAt = now
+	return field, nil
+}
+
+// DeleteField removes a custom field and every value stored for it, in one transaction
+func (r *SQLiteRepository) DeleteField(ctx context.Context, id int64) error {
+	return r.inTx(ctx, func(tx *SQLiteRepository) error {
+		if _, err := tx.db.ExecContext(ctx, `DELETE FROM word_field_values WHERE field_id = ?`, id); err != nil {
+			return fmt.Errorf("failed to delete custom field values: %w", err)
+		}
+
+		result, err := tx.db.ExecContext(ctx, `DELETE FROM custom_fields WHERE id = ?`, id)
+		if err != nil {
+			return fmt.Errorf("failed to delete custom field: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+
+		if rowsAffected == 0 {
+			return sql.ErrNoRows
+		}
+
+		return nil
+	})
+}
+
+// CountFieldValues counts the words storing each value of a custom field
+func (r *SQLiteRepository) CountFieldValues(ctx context.Context, id int64) (map[string]int64, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT value, COUNT(*) FROM word_field_values WHERE field_id = ? GROUP BY value`, id,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count custom field values: %w", err)
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int64)
+	for rows.Next() {
+		var value string
+		var count int64
+		if err := rows.Scan(&value, &count); err != nil {
+			return nil, fmt.Errorf("failed to scan custom field value count: %w", err)
+		}
+		counts[value] = count
+	}
+
+	return counts, rows.Err()
+}
+
+// saveFieldValues replaces the custom field values stored for a word
+func (r *SQLiteRepository) saveFieldValues(ctx context.Context, word *models.Word) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM word_field_values WHERE word_id = ?`, word.ID); err != nil {
+		return fmt.Errorf("failed to clear custom field values: %w", err)
+	}
+
+	for name, value := range word.CustomFields {
+		_, err := r.db.ExecContext(ctx,
+			`INSERT INTO word_field_values (word_id, field_id, value)
+			 SELECT ?, id, ? FROM custom_fields WHERE name = ?`,
+			word.ID, value, name,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save custom field %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+// loadFieldValues fills in CustomFields for the given words with a single query
+func (r *SQLiteRepository) loadFieldValues(ctx context.Context, words []*models.Word) error {
+	if len(words) == 0 {
+		return nil
+	}
+
+	byID := make(map[int64]*models.Word, len(words))
+	placeholders := make([]string, len(words))
+	args := make([]interface{}, len(words))
+	for i, w := range words {
+		byID[w.ID] = w
+		placeholders[i] = "?"
+		args[i] = w.ID
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT v.word_id, f.name, v.value FROM word_field_values v
+		 JOIN custom_fields f ON f.id = v.field_id
+		 WHERE v.word_id IN (`+strings.Join(placeholders, ", ")+`)`,
+		args...,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to query custom field values: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var wordID int64
+		var name, value string
+		if err := rows.Scan(&wordID, &name, &value); err != nil {
+			return fmt.Errorf("failed to scan custom field value: %w", err)
+		}
+		w := byID[wordID]
+		if w.CustomFields == nil {
+			w.CustomFields = make(map[string]string)
+		}
+		w.CustomFields[name] = value
+	}
+
+	return rows.Err()
+}
+
+// marshalOptions encodes enum options, storing an empty list rather than null
+func marshalOptions(options []string) (string, error) {
+	if options == nil {
+		options = []string{}
+	}
+	optionsJSON, err := json.Marshal(options)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal options: %w", err)
+	}
+	return string(optionsJSON), nil
+}
@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+func TestSQLiteRepository_Fields(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	created, err := repo.CreateField(ctx, &models.CustomField{
+		Name:    "cefr",
+		Label:   "CEFR Level",
+		Type:    models.FieldTypeEnum,
+		Options: []string{"B2", "C1", "C2"},
+	})
+	if err != nil {
+		t.Fatalf("CreateField() error = %v", err)
+	}
+
+	got, err := repo.GetField(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetField() error = %v", err)
+	}
+	if got.Name != "cefr" || len(got.Options) != 3 {
+		t.Errorf("GetField() = %+v, want cefr with 3 options", got)
+	}
+
+	got.Label = "Level"
+	if _, err := repo.UpdateField(ctx, got); err != nil {
+		t.Fatalf("UpdateField() error = %v", err)
+	}
+
+	fields, err := repo.ListFields(ctx)
+	if err != nil {
+		t.Fatalf("ListFields() error = %v", err)
+	}
+	if len(fields) != 1 || fields[0].Label != "Level" {
+		t.Errorf("ListFields() = %+v, want one field labelled Level", fields)
+	}
+
+	if err := repo.DeleteField(ctx, created.ID); err != nil {
+		t.Errorf("DeleteField() error = %v", err)
+	}
+	if err := repo.DeleteField(ctx, created.ID); err == nil {
+		t.Error("DeleteField() of missing field should return error")
+	}
+}
+
+func TestSQLiteRepository_CustomFieldValues(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	repo.CreateField(ctx, &models.CustomField{Name: "translation", Label: "Translation", Type: models.FieldTypeText})
+	repo.CreateField(ctx, &models.CustomField{Name: "cefr", Label: "CEFR", Type: models.FieldTypeEnum, Options: []string{"C1", "C2"}})
+
+	words := []*models.Word{
+		{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15", Tags: []string{},
+			CustomFields: map[string]string{"translation": "flüchtig", "cefr": "C1"}},
+		{Word: "ubiquitous", Source: "Article", DateLearned: "2024-02-20", Tags: []string{},
+			CustomFields: map[string]string{"cefr": "C2"}},
+		{Word: "eloquent", Source: "Book", DateLearned: "2024-03-10", Tags: []string{}},
+	}
+	for _, w := range words {
+		if _, err := repo.Create(ctx, w); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	got, err := repo.GetByWord(ctx, "ephemeral")
+	if err != nil {
+		t.Fatalf("GetByWord() error = %v", err)
+	}
+	if got.CustomFields["translation"] != "flüchtig" || got.CustomFields["cefr"] != "C1" {
+		t.Errorf("GetByWord() custom fields = %v", got.CustomFields)
+	}
+
+	listed, err := repo.List(ctx, models.WordFilter{Fields: map[string]string{"cefr": "C2"}})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(listed) != 1 || listed[0].Word != "ubiquitous" {
+		t.Errorf("List() filtered by cefr = %v, want [ubiquitous]", listed)
+	}
+
+	count, err := repo.Count(ctx, models.WordFilter{Fields: map[string]string{"cefr": "C1", "translation": "flüchtig"}})
+	if err != nil {
+		t.Fatalf("Count() error = %v", err)
+	}
+	if count != 1 {
+		t.Errorf("Count() = %d, want 1", count)
+	}
+
+	// Update replaces the stored values
+	got.CustomFields = map[string]string{"cefr": "C2"}
+	if _, err := repo.Update(ctx, got); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, _ = repo.GetByID(ctx, got.ID)
+	if _, ok := got.CustomFields["translation"]; ok || got.CustomFields["cefr"] != "C2" {
+		t.Errorf("Update() custom fields = %v, want only cefr=C2", got.CustomFields)
+	}
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// importJobColumns lists the import_jobs columns read by scanImportJob
+const importJobColumns = `id, filename, status, total, processed, result, error, created_at, updated_at, finished_at`
+
+// CreateImportJob inserts a new import job
+func (r *SQLiteRepository) CreateImportJob(ctx context.Context, job *models.ImportJob) (*models.ImportJob, error) {
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO import_jobs (filename, status, total, processed, result, error, created_at, updated_at, finished_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		job.Filename, job.Status, job.Total, job.Processed, nullableJSON(job.Result), job.Error, now, now, job.FinishedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert import job: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	job.ID = id
+	job.CreatedAt = now
+	job.UpdatedAt = now
+	return job, nil
+}
+
+// GetImportJob retrieves an import job by its ID
+func (r *SQLiteRepository) GetImportJob(ctx context.Context, id int64) (*models.ImportJob, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+importJobColumns+` FROM import_jobs WHERE id = ?`, id)
+	return scanImportJob(row)
+}
+
+// UpdateImportJob saves the status, progress and outcome of an import job
+func (r *SQLiteRepository) UpdateImportJob(ctx context.Context, job *models.ImportJob) error {
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE import_jobs SET status = ?, total = ?, processed = ?, result = ?, error = ?, updated_at = ?,
+		finished_at = ? WHERE id = ?`,
+		job.Status, job.Total, job.Processed, nullableJSON(job.Result), job.Error, now, job.FinishedAt, job.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update import job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	job.UpdatedAt = now
+	return nil
+}
+
+// FailUnfinishedImportJobs marks the jobs that are queued or running as failed
+// with the given error, returning how many there were
+func (r *SQLiteRepository) FailUnfinishedImportJobs(ctx context.Context, reason string) (int64, error) {
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE import_jobs SET status = ?, error = ?, updated_at = ?, finished_at = ? WHERE status IN (?, ?)`,
+		models.ImportJobFailed, reason, now, now, models.ImportJobQueued, models.ImportJobRunning,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to update import jobs: %w", err)
+	}
+	return result.RowsAffected()
+}
+
+// DeleteImportJobsBefore removes the jobs that finished before a time
+func (r *SQLiteRepository) DeleteImportJobsBefore(ctx context.Context, before time.Time) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM import_jobs WHERE finished_at < ?`, before); err != nil {
+		return fmt.Errorf("failed to delete import jobs: %w", err)
+	}
+	return nil
+}
+
+// nullableJSON stores an empty JSON value as NULL
+func nullableJSON(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
+
+// scanImportJob scans an import job row selected with importJobColumns
+func scanImportJob(row rowScanner) (*models.ImportJob, error) {
+	var job models.ImportJob
+	var result sql.NullString
+	var finishedAt sql.NullTime
+
+	err := row.Scan(&job.ID, &job.Filename, &job.Status, &job.Total, &job.Processed, &result, &job.Error,
+		&job.CreatedAt, &job.UpdatedAt, &finishedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan import job: %w", err)
+	}
+
+	if result.Valid {
+		job.Result = []byte(result.String)
+	}
+	if finishedAt.Valid {
+		job.FinishedAt = &finishedAt.Time
+	}
+	return &job, nil
+}
@@ -0,0 +1,165 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// importProfileColumns lists the import_profiles columns read by scanImportProfile
+const importProfileColumns = `id, name, columns, delimiter, quote, encoding, date_format, defaults, created_at, updated_at`
+
+// CreateImportProfile inserts a new import profile
+func (r *SQLiteRepository) CreateImportProfile(ctx context.Context, profile *models.ImportProfile) (*models.ImportProfile, error) {
+	columnsJSON, defaultsJSON, err := marshalImportProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO import_profiles (name, columns, delimiter, quote, encoding, date_format, defaults, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		profile.Name, columnsJSON, profile.Delimiter, profile.Quote, profile.Encoding, profile.DateFormat, defaultsJSON, now, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert import profile: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	profile.ID = id
+	profile.CreatedAt = now
+	profile.UpdatedAt = now
+	return profile, nil
+}
+
+// GetImportProfile retrieves an import profile by its ID
+func (r *SQLiteRepository) GetImportProfile(ctx context.Context, id int64) (*models.ImportProfile, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+importProfileColumns+` FROM import_profiles WHERE id = ?`, id)
+	return scanImportProfile(row)
+}
+
+// GetImportProfileByName retrieves an import profile by name, ignoring case
+func (r *SQLiteRepository) GetImportProfileByName(ctx context.Context, name string) (*models.ImportProfile, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+importProfileColumns+` FROM import_profiles WHERE name = ? COLLATE NOCASE`, name)
+	return scanImportProfile(row)
+}
+
+// ListImportProfiles retrieves all import profiles ordered by name
+func (r *SQLiteRepository) ListImportProfiles(ctx context.Context) ([]*models.ImportProfile, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+importProfileColumns+` FROM import_profiles ORDER BY name COLLATE NOCASE`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query import profiles: %w", err)
+	}
+	defer rows.Close()
+
+	var profiles []*models.ImportProfile
+	for rows.Next() {
+		profile, err := scanImportProfile(rows)
+		if err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return profiles, nil
+}
+
+// UpdateImportProfile replaces the name and settings of an import profile
+func (r *SQLiteRepository) UpdateImportProfile(ctx context.Context, profile *models.ImportProfile) (*models.ImportProfile, error) {
+	columnsJSON, defaultsJSON, err := marshalImportProfile(profile)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE import_profiles SET name = ?, columns = ?, delimiter = ?, quote = ?, encoding = ?, date_format = ?,
+		defaults = ?, updated_at = ? WHERE id = ?`,
+		profile.Name, columnsJSON, profile.Delimiter, profile.Quote, profile.Encoding, profile.DateFormat,
+		defaultsJSON, now, profile.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update import profile: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	profile.UpdatedAt = now
+	return profile, nil
+}
+
+// DeleteImportProfile removes an import profile by ID
+func (r *SQLiteRepository) DeleteImportProfile(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM import_profiles WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete import profile: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// marshalImportProfile encodes the column mapping and defaults of a profile
+func marshalImportProfile(profile *models.ImportProfile) (string, string, error) {
+	columnsJSON, err := json.Marshal(profile.Columns)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal columns: %w", err)
+	}
+	defaultsJSON, err := json.Marshal(profile.Defaults)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to marshal defaults: %w", err)
+	}
+	return string(columnsJSON), string(defaultsJSON), nil
+}
+
+// scanImportProfile scans an import profile row selected with importProfileColumns
+func scanImportProfile(row rowScanner) (*models.ImportProfile, error) {
+	var profile models.ImportProfile
+	var columnsJSON, defaultsJSON string
+
+	err := row.Scan(&profile.ID, &profile.Name, &columnsJSON, &profile.Delimiter, &profile.Quote, &profile.Encoding,
+		&profile.DateFormat, &defaultsJSON, &profile.CreatedAt, &profile.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan import profile: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(columnsJSON), &profile.Columns); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal columns: %w", err)
+	}
+	if err := json.Unmarshal([]byte(defaultsJSON), &profile.Defaults); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal defaults: %w", err)
+	}
+	return &profile, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+func TestSQLiteRepository_ImportProfiles(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	profile := &models.ImportProfile{
+		Name:       "Team sheet",
+		Columns:    map[string]string{"Term": "word", "Notes": ""},
+		Delimiter:  ";",
+		Encoding:   "latin-1",
+		DateFormat: "MM/DD/YYYY",
+		Defaults:   map[string]string{"source": "Team sheet"},
+	}
+	created, err := repo.CreateImportProfile(ctx, profile)
+	if err != nil {
+		t.Fatalf("CreateImportProfile() error = %v", err)
+	}
+
+	got, err := repo.GetImportProfile(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetImportProfile() error = %v", err)
+	}
+	if got.Name != "Team sheet" || got.Delimiter != ";" || got.Quote != "" || got.Encoding != "latin-1" ||
+		got.DateFormat != "MM/DD/YYYY" || !reflect.DeepEqual(got.Columns, profile.Columns) ||
+		!reflect.DeepEqual(got.Defaults, profile.Defaults) {
+		t.Errorf("GetImportProfile() = %+v, want the saved settings", got)
+	}
+
+	byName, err := repo.GetImportProfileByName(ctx, "team SHEET")
+	if err != nil || byName.ID != created.ID {
+		t.Errorf("GetImportProfileByName() = %v, %v, want case-insensitive match", byName, err)
+	}
+
+	repo.CreateImportProfile(ctx, &models.ImportProfile{Name: "anki export"})
+
+	got.Name = "Vocabulary sheet"
+	got.Quote = "none"
+	if _, err := repo.UpdateImportProfile(ctx, got); err != nil {
+		t.Fatalf("UpdateImportProfile() error = %v", err)
+	}
+
+	profiles, err := repo.ListImportProfiles(ctx)
+	if err != nil {
+		t.Fatalf("ListImportProfiles() error = %v", err)
+	}
+	if len(profiles) != 2 || profiles[0].Name != "anki export" || profiles[1].Name != "Vocabulary sheet" ||
+		profiles[1].Quote != "none" {
+		t.Errorf("ListImportProfiles() = %+v, want anki export then Vocabulary sheet", profiles)
+	}
+
+	if err := repo.DeleteImportProfile(ctx, created.ID); err != nil {
+		t.Errorf("DeleteImportProfile() error = %v", err)
+	}
+	if err := repo.DeleteImportProfile(ctx, created.ID); err == nil {
+		t.Error("DeleteImportProfile() of missing profile should return error")
+	}
+	if _, err := repo.UpdateImportProfile(ctx, got); err == nil {
+		t.Error("UpdateImportProfile() of missing profile should return error")
+	}
+}
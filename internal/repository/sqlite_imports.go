@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// ImportedKeys returns the keys of the items already imported from an origin
+func (r *SQLiteRepository) ImportedKeys(ctx context.Context, origin string) (map[string]bool, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT item_key FROM imported_items WHERE origin = ?`, origin)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list imported items: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]bool)
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, fmt.Errorf("failed to scan imported item: %w", err)
+		}
+		keys[key] = true
+	}
+	return keys, rows.Err()
+}
+
+// MarkImported records items from an origin as imported; keys already recorded
+// keep their original import time
+func (r *SQLiteRepository) MarkImported(ctx context.Context, origin string, keys []string) error {
+	now := time.Now()
+	return r.inTx(ctx, func(tx *SQLiteRepository) error {
+		for _, key := range keys {
+			_, err := tx.db.ExecContext(ctx,
+				`INSERT OR IGNORE INTO imported_items (origin, item_key, imported_at) VALUES (?, ?, ?)`,
+				origin, key, now,
+			)
+			if err != nil {
+				return fmt.Errorf("failed to record imported item: %w", err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"strings"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// ErrInvalidCursor is returned when a cursor is malformed or was issued for a
+// different filter or sort order
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// sortColumn describes how a sort key orders and pages the word list
+type sortColumn struct {
+	expr    string // ORDER BY and comparison expression; each has a matching index
+	key     string // selected to build cursors: the raw stored value of expr
+	numeric bool   // key is an integer rather than text
+}
+
+// difficultyExpr rates how often a word is forgotten in reviews, in thousandths.
+// Counting one extra lapse and one extra remembered review keeps unreviewed words
+// at 500, between the words always remembered and those always forgotten.
+const difficultyExpr = "(words.lapses + 1) * 1000 / (words.reviews + 2)"
+
+// sortColumns maps sort keys to their columns. Timestamps are selected as text so
+// cursors compare against exactly what is stored.
+var sortColumns = map[string]sortColumn{
+	"word":         {expr: "words.word COLLATE NOCASE", key: "words.word"},
+	"date_learned": {expr: "words.date_learned", key: "words.date_learned"},
+	"created_at":   {expr: "words.created_at", key: "CAST(words.created_at AS TEXT)"},
+	"updated_at":   {expr: "words.updated_at", key: "CAST(words.updated_at AS TEXT)"},
+	"length":       {expr: "length(words.word)", key: "length(words.word)", numeric: true},
+	"difficulty":   {expr: difficultyExpr, key: difficultyExpr, numeric: true},
+}
+
+// defaultSort lists the most recently learned words first
+const defaultSort = "-date_learned"
+
+// relevanceSort orders full-text search results by bm25 rank
+const relevanceSort = "relevance"
+
+// listOrder is the resolved ordering of a list query
+type listOrder struct {
+	sort      string // normalized sort key, e.g. "-date_learned" or "relevance"
+	column    sortColumn
+	desc      bool
+	relevance bool
+}
+
+// resolveOrder picks the ordering for a sort key. Full-text searches without an
+// explicit sort are ordered by relevance; unknown keys fall back to the default.
+func resolveOrder(sort string, fts bool) listOrder {
+	if sort == "" && fts {
+		return listOrder{sort: relevanceSort, relevance: true}
+	}
+
+	name := strings.TrimPrefix(sort, "-")
+	column, ok := sortColumns[name]
+	if !ok {
+		sort = defaultSort
+		name = strings.TrimPrefix(sort, "-")
+		column = sortColumns[name]
+	}
+
+	return listOrder{sort: sort, column: column, desc: strings.HasPrefix(sort, "-")}
+}
+
+// clause returns the ORDER BY expressions, with id as a tie-breaker so the order is stable
+func (o listOrder) clause() string {
+	if o.relevance {
+		// Weight matches in the word itself above sentences, tags and other text, in
+		// words_fts column order: word, example_sentence, source, tags, custom, definitions
+		return "bm25(words_fts, 10.0, 2.0, 1.0, 3.0, 1.0, 1.0), words.date_learned DESC, words.id DESC"
+	}
+
+	direction := "ASC"
+	if o.desc {
+		direction = "DESC"
+	}
+	return fmt.Sprintf("%s %s, words.id %s", o.column.expr, direction, direction)
+}
+
+// cursorValue converts a decoded cursor key back to a query argument
+func (c sortColumn) cursorValue(key interface{}) (interface{}, error) {
+	switch v := key.(type) {
+	case string:
+		if !c.numeric {
+			return v, nil
+		}
+	case float64:
+		if c.numeric {
+			return int64(v), nil
+		}
+	}
+	return nil, fmt.Errorf("unexpected cursor key %v", key)
+}
+
+// cursor is the position after the last row of a page. Keyset-ordered lists store
+// the last sort key and id; relevance-ordered searches have no stable key and
+// store an offset instead.
+type cursor struct {
+	Sort   string      `json:"s"`
+	Filter string      `json:"f"`
+	Key    interface{} `json:"k,omitempty"`
+	ID     int64       `json:"i,omitempty"`
+	Offset int         `json:"o,omitempty"`
+}
+
+// encode returns the opaque string form of the cursor
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor parses a cursor returned by encode
+func decodeCursor(s string) (cursor, error) {
+	var c cursor
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return c, err
+	}
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, err
+	}
+	return c, nil
+}
+
+// fingerprint identifies the filter conditions of a list, ignoring paging, so a
+// cursor cannot be replayed against a different filter
+func fingerprint(filter models.WordFilter) string {
+	filter.Limit, filter.Offset, filter.Cursor = 0, 0, ""
+	h := fnv.New32a()
+	fmt.Fprintf(h, "%+v", filter)
+	return fmt.Sprintf("%08x", h.Sum32())
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+func TestSQLiteRepository_ListPage(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	// Several words share a date and a length so the id tie-breaker matters
+	for i := 0; i < 11; i++ {
+		repo.Create(ctx, &models.Word{
+			Word:        fmt.Sprintf("word%02d", i),
+			Source:      "Book",
+			DateLearned: fmt.Sprintf("2024-01-%02d", 10+i%3),
+		})
+	}
+
+	for _, sort := range []string{"", "word", "-word", "date_learned", "created_at", "-updated_at", "length", "-length", "difficulty", "-difficulty"} {
+		t.Run("sort="+sort, func(t *testing.T) {
+			want, err := repo.List(ctx, models.WordFilter{Sort: sort})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+
+			var got []*models.Word
+			filter := models.WordFilter{Sort: sort, Limit: 4}
+			for pages := 0; ; pages++ {
+				if pages > 5 {
+					t.Fatal("ListPage() did not terminate")
+				}
+				page, err := repo.ListPage(ctx, filter)
+				if err != nil {
+					t.Fatalf("ListPage() error = %v", err)
+				}
+				got = append(got, page.Words...)
+				if page.NextCursor == "" {
+					break
+				}
+				filter.Cursor = page.NextCursor
+			}
+
+			if len(got) != len(want) {
+				t.Fatalf("paged through %d words, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i].ID != want[i].ID {
+					t.Errorf("word %d = %s, want %s", i, got[i].Word, want[i].Word)
+				}
+			}
+		})
+	}
+}
+
+func TestSQLiteRepository_ListPage_StableUnderInserts(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	for i := 0; i < 6; i++ {
+		repo.Create(ctx, &models.Word{Word: fmt.Sprintf("old%d", i), Source: "Book", DateLearned: "2024-01-15"})
+	}
+
+	filter := models.WordFilter{Sort: "-created_at", Limit: 3}
+	first, err := repo.ListPage(ctx, filter)
+	if err != nil {
+		t.Fatalf("ListPage() error = %v", err)
+	}
+
+	// A word added while paging would shift an offset-based second page
+	time.Sleep(time.Millisecond)
+	repo.Create(ctx, &models.Word{Word: "new", Source: "Book", DateLearned: "2024-01-15"})
+
+	filter.Cursor = first.NextCursor
+	second, err := repo.ListPage(ctx, filter)
+	if err != nil {
+		t.Fatalf("ListPage() error = %v", err)
+	}
+
+	seen := make(map[int64]bool)
+	for _, w := range append(first.Words, second.Words...) {
+		if seen[w.ID] {
+			t.Errorf("word %s returned twice", w.Word)
+		}
+		seen[w.ID] = true
+	}
+	if len(seen) != 6 {
+		t.Errorf("paged through %d distinct words, want 6", len(seen))
+	}
+}
+
+func TestSQLiteRepository_ListPage_InvalidCursor(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		repo.Create(ctx, &models.Word{Word: fmt.Sprintf("word%d", i), Source: "Book", DateLearned: "2024-01-15"})
+	}
+
+	page, _ := repo.ListPage(ctx, models.WordFilter{Sort: "word", Limit: 1})
+	if page.NextCursor == "" {
+		t.Fatal("ListPage() returned no cursor for a full page")
+	}
+
+	tests := []struct {
+		name   string
+		filter models.WordFilter
+	}{
+		{name: "garbage", filter: models.WordFilter{Sort: "word", Limit: 1, Cursor: "not-a-cursor"}},
+		{name: "different sort", filter: models.WordFilter{Sort: "-word", Limit: 1, Cursor: page.NextCursor}},
+		{name: "different filter", filter: models.WordFilter{Sort: "word", Source: "Article", Limit: 1, Cursor: page.NextCursor}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := repo.ListPage(ctx, tt.filter); !errors.Is(err, ErrInvalidCursor) {
+				t.Errorf("ListPage() error = %v, want ErrInvalidCursor", err)
+			}
+		})
+	}
+
+	// The limit may change between pages
+	if _, err := repo.ListPage(ctx, models.WordFilter{Sort: "word", Limit: 5, Cursor: page.NextCursor}); err != nil {
+		t.Errorf("ListPage() with a new limit error = %v", err)
+	}
+}
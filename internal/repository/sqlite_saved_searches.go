@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// savedSearchColumns lists the saved_searches columns read by scanSavedSearch
+const savedSearchColumns = `id, name, query, filter, created_at, updated_at`
+
+// CreateSavedSearch inserts a new saved search
+func (r *SQLiteRepository) CreateSavedSearch(ctx context.Context, search *models.SavedSearch) (*models.SavedSearch, error) {
+	filterJSON, err := json.Marshal(search.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal filter: %w", err)
+	}
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO saved_searches (name, query, filter, created_at, updated_at) VALUES (?, ?, ?, ?, ?)`,
+		search.Name, search.Query, string(filterJSON), now, now,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to insert saved search: %w", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get last insert id: %w", err)
+	}
+
+	search.ID = id
+	search.CreatedAt = now
+	search.UpdatedAt = now
+	return search, nil
+}
+
+// GetSavedSearch retrieves a saved search by its ID
+func (r *SQLiteRepository) GetSavedSearch(ctx context.Context, id int64) (*models.SavedSearch, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+savedSearchColumns+` FROM saved_searches WHERE id = ?`, id)
+	return scanSavedSearch(row)
+}
+
+// GetSavedSearchByName retrieves a saved search by name, ignoring case
+func (r *SQLiteRepository) GetSavedSearchByName(ctx context.Context, name string) (*models.SavedSearch, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+savedSearchColumns+` FROM saved_searches WHERE name = ? COLLATE NOCASE`, name)
+	return scanSavedSearch(row)
+}
+
+// ListSavedSearches retrieves all saved searches ordered by name
+func (r *SQLiteRepository) ListSavedSearches(ctx context.Context) ([]*models.SavedSearch, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+savedSearchColumns+` FROM saved_searches ORDER BY name COLLATE NOCASE`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	var searches []*models.SavedSearch
+	for rows.Next() {
+		search, err := scanSavedSearch(rows)
+		if err != nil {
+			return nil, err
+		}
+		searches = append(searches, search)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return searches, nil
+}
+
+// UpdateSavedSearch replaces the name, query and filter of a saved search
+func (r *SQLiteRepository) UpdateSavedSearch(ctx context.Context, search *models.SavedSearch) (*models.SavedSearch, error) {
+	filterJSON, err := json.Marshal(search.Filter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal filter: %w", err)
+	}
+
+	now := time.Now()
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE saved_searches SET name = ?, query = ?, filter = ?, updated_at = ? WHERE id = ?`,
+		search.Name, search.Query, string(filterJSON), now, search.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update saved search: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	search.UpdatedAt = now
+	return search, nil
+}
+
+// DeleteSavedSearch removes a saved search by ID
+func (r *SQLiteRepository) DeleteSavedSearch(ctx context.Context, id int64) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM saved_searches WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete saved search: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// scanSavedSearch scans a saved search row selected with savedSearchColumns
+func scanSavedSearch(row rowScanner) (*models.SavedSearch, error) {
+	var search models.SavedSearch
+	var filterJSON string
+
+	err := row.Scan(&search.ID, &search.Name, &search.Query, &filterJSON, &search.CreatedAt, &search.UpdatedAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to scan saved search: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(filterJSON), &search.Filter); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal filter: %w", err)
+	}
+	return &search, nil
+}
@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+func TestSQLiteRepository_SavedSearches(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	filter := models.WordFilter{
+		Tags:    []string{"interview"},
+		Missing: []string{"example"},
+		Sort:    "-created_at",
+		Limit:   10, // not stored
+	}
+	created, err := repo.CreateSavedSearch(ctx, &models.SavedSearch{
+		Name:   "Interview prep",
+		Query:  "after:-3m",
+		Filter: filter,
+	})
+	if err != nil {
+		t.Fatalf("CreateSavedSearch() error = %v", err)
+	}
+
+	got, err := repo.GetSavedSearch(ctx, created.ID)
+	if err != nil {
+		t.Fatalf("GetSavedSearch() error = %v", err)
+	}
+	filter.Limit = 0
+	if got.Name != "Interview prep" || got.Query != "after:-3m" || !reflect.DeepEqual(got.Filter, filter) {
+		t.Errorf("GetSavedSearch() = %+v, want the saved name, query and filter", got)
+	}
+
+	byName, err := repo.GetSavedSearchByName(ctx, "interview PREP")
+	if err != nil || byName.ID != created.ID {
+		t.Errorf("GetSavedSearchByName() = %v, %v, want case-insensitive match", byName, err)
+	}
+
+	repo.CreateSavedSearch(ctx, &models.SavedSearch{Name: "archaic"})
+
+	got.Name = "Job interviews"
+	if _, err := repo.UpdateSavedSearch(ctx, got); err != nil {
+		t.Fatalf("UpdateSavedSearch() error = %v", err)
+	}
+
+	searches, err := repo.ListSavedSearches(ctx)
+	if err != nil {
+		t.Fatalf("ListSavedSearches() error = %v", err)
+	}
+	if len(searches) != 2 || searches[0].Name != "archaic" || searches[1].Name != "Job interviews" {
+		t.Errorf("ListSavedSearches() = %+v, want archaic then Job interviews", searches)
+	}
+
+	if err := repo.DeleteSavedSearch(ctx, created.ID); err != nil {
+		t.Errorf("DeleteSavedSearch() error = %v", err)
+	}
+	if err := repo.DeleteSavedSearch(ctx, created.ID); err == nil {
+		t.Error("DeleteSavedSearch() of missing search should return error")
+	}
+	if _, err := repo.UpdateSavedSearch(ctx, got); err == nil {
+		t.Error("UpdateSavedSearch() of missing search should return error")
+	}
+}
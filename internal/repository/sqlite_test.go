@@ -3,7 +3,10 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
+	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/mattn/go-sqlite3"
 
@@ -17,7 +20,7 @@ func setupTestDB(t *testing.T) *sql.DB {
 		t.Fatalf("failed to open test db: %v", err)
 	}
 
-	// Create the words table
+	// Create the words, custom field, attachment and trigram tables
 	_, err = db.Exec(`
 		CREATE TABLE words (
 			id INTEGER PRIMARY KEY AUTOINCREMENT,
@@ -28,8 +31,91 @@ func setupTestDB(t *testing.T) *sql.DB {
 			example_sentence TEXT,
 			tags TEXT DEFAULT '[]',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			reviews INTEGER NOT NULL DEFAULT 0,
+			lapses INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE TABLE custom_fields (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			label TEXT NOT NULL,
+			type TEXT NOT NULL,
+			options TEXT DEFAULT '[]',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)
+		);
+		CREATE TABLE word_field_values (
+			word_id INTEGER NOT NULL,
+			field_id INTEGER NOT NULL,
+			value TEXT NOT NULL,
+			PRIMARY KEY (word_id, field_id)
+		);
+		CREATE TABLE attachments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			word_id INTEGER NOT NULL,
+			filename TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			checksum TEXT NOT NULL,
+			data BLOB,
+			thumbnail BLOB NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE word_trigrams (
+			trigram TEXT NOT NULL,
+			word_id INTEGER NOT NULL,
+			PRIMARY KEY (trigram, word_id)
+		);
+
+		CREATE TABLE saved_searches (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			query TEXT NOT NULL DEFAULT '',
+			filter TEXT NOT NULL DEFAULT '{}',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE import_profiles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			columns TEXT NOT NULL DEFAULT '{}',
+			delimiter TEXT NOT NULL DEFAULT '',
+			quote TEXT NOT NULL DEFAULT '',
+			encoding TEXT NOT NULL DEFAULT '',
+			date_format TEXT NOT NULL DEFAULT '',
+			defaults TEXT NOT NULL DEFAULT '{}',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE definitions (
+			word TEXT PRIMARY KEY COLLATE NOCASE,
+			data TEXT NOT NULL,
+			fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE imported_items (
+			origin TEXT NOT NULL,
+			item_key TEXT NOT NULL,
+			imported_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (origin, item_key)
+		);
+
+		CREATE TABLE import_jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			filename TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'queued',
+			total INTEGER NOT NULL DEFAULT 0,
+			processed INTEGER NOT NULL DEFAULT 0,
+			result TEXT,
+			error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			finished_at DATETIME
+		);
 	`)
 	if err != nil {
 		t.Fatalf("failed to create table: %v", err)
@@ -263,6 +349,156 @@ func TestSQLiteRepository_List(t *testing.T) {
 	}
 }
 
+func TestSQLiteRepository_ListBooleanFilters(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	for _, w := range []*models.Word{
+		{Word: "habeas", Source: "Law Review", DateLearned: "2024-01-15", Tags: []string{"latin", "legal"},
+			ExampleSentence: strPtr("The writ of habeas corpus")},
+		{Word: "thee", Source: "Poetry", DateLearned: "2024-01-16", Tags: []string{"archaic"}, PartOfSpeech: strPtr("pronoun")},
+		{Word: "mens rea", Source: "Law Review", DateLearned: "2024-01-17", Tags: []string{"latin", "legal", "archaic"}},
+		{Word: "ad hoc", Source: "Article", DateLearned: "2024-01-18", Tags: []string{"latin"}},
+		{Word: "latinate", Source: "Book", DateLearned: "2024-01-19", Tags: []string{"latinish"}},
+	} {
+		repo.Create(ctx, w)
+	}
+
+	tests := []struct {
+		name   string
+		filter models.WordFilter
+		want   []string
+	}{
+		{
+			name:   "all tags",
+			filter: models.WordFilter{Tags: []string{"latin", "legal"}},
+			want:   []string{"mens rea", "habeas"},
+		},
+		{
+			name:   "all tags but not archaic",
+			filter: models.WordFilter{Tags: []string{"latin", "legal"}, ExcludeTags: []string{"archaic"}},
+			want:   []string{"habeas"},
+		},
+		{
+			name:   "any tag",
+			filter: models.WordFilter{AnyTags: []string{"legal", "archaic"}},
+			want:   []string{"mens rea", "thee", "habeas"},
+		},
+		{
+			name:   "single tag is exact",
+			filter: models.WordFilter{Tag: "latin"},
+			want:   []string{"ad hoc", "mens rea", "habeas"},
+		},
+		{
+			name:   "single tag combines with tags",
+			filter: models.WordFilter{Tag: "latin", Tags: []string{"archaic"}},
+			want:   []string{"mens rea"},
+		},
+		{
+			name:   "any source",
+			filter: models.WordFilter{Sources: []string{"Poetry", "Article", "Missing"}},
+			want:   []string{"ad hoc", "thee"},
+		},
+		{
+			name:   "excluded sources",
+			filter: models.WordFilter{ExcludeSources: []string{"Law Review", "Book"}},
+			want:   []string{"ad hoc", "thee"},
+		},
+		{
+			name:   "source and tags",
+			filter: models.WordFilter{Source: "Law Review", ExcludeTags: []string{"archaic"}},
+			want:   []string{"habeas"},
+		},
+		{
+			name:   "has example",
+			filter: models.WordFilter{Has: []string{"example"}},
+			want:   []string{"habeas"},
+		},
+		{
+			name:   "tagged but missing an example",
+			filter: models.WordFilter{Tags: []string{"latin"}, Missing: []string{"example"}},
+			want:   []string{"ad hoc", "mens rea"},
+		},
+		{
+			name:   "has part of speech and tags",
+			filter: models.WordFilter{Has: []string{"pos", "tags"}},
+			want:   []string{"thee"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := repo.List(ctx, tt.filter)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var words []string
+			for _, w := range got {
+				words = append(words, w.Word)
+			}
+			if strings.Join(words, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("List() = %v, want %v", words, tt.want)
+			}
+
+			count, err := repo.Count(ctx, tt.filter)
+			if err != nil || int(count) != len(got) {
+				t.Errorf("Count() = %d, %v, want %d to match List()", count, err, len(got))
+			}
+		})
+	}
+}
+
+func TestSQLiteRepository_ListSort(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	// Created in this order, so created_at and id ascend together
+	for _, w := range []*models.Word{
+		{Word: "ubiquitous", Source: "Article", DateLearned: "2024-02-20"},
+		{Word: "Ephemeral", Source: "Book", DateLearned: "2024-01-15"},
+		{Word: "eloquent", Source: "Book", DateLearned: "2024-03-10"},
+		{Word: "apt", Source: "Book", DateLearned: "2024-03-10"},
+	} {
+		repo.Create(ctx, w)
+	}
+
+	tests := []struct {
+		sort string
+		want []string
+	}{
+		{sort: "", want: []string{"apt", "eloquent", "ubiquitous", "Ephemeral"}},
+		{sort: "word", want: []string{"apt", "eloquent", "Ephemeral", "ubiquitous"}},
+		{sort: "-word", want: []string{"ubiquitous", "Ephemeral", "eloquent", "apt"}},
+		{sort: "date_learned", want: []string{"Ephemeral", "ubiquitous", "eloquent", "apt"}},
+		{sort: "created_at", want: []string{"ubiquitous", "Ephemeral", "eloquent", "apt"}},
+		{sort: "-created_at", want: []string{"apt", "eloquent", "Ephemeral", "ubiquitous"}},
+		{sort: "length", want: []string{"apt", "eloquent", "Ephemeral", "ubiquitous"}},
+		{sort: "-length", want: []string{"ubiquitous", "Ephemeral", "eloquent", "apt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			got, err := repo.List(ctx, models.WordFilter{Sort: tt.sort})
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			var words []string
+			for _, w := range got {
+				words = append(words, w.Word)
+			}
+			if strings.Join(words, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("List(sort=%q) = %v, want %v", tt.sort, words, tt.want)
+			}
+		})
+	}
+}
+
 func TestSQLiteRepository_Update(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -300,6 +536,95 @@ func TestSQLiteRepository_Update(t *testing.T) {
 	}
 }
 
+func TestSQLiteRepository_Restore(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	createdAt := time.Date(2023, 3, 1, 9, 30, 0, 0, time.UTC)
+	updatedAt := time.Date(2023, 6, 1, 18, 0, 0, 0, time.UTC)
+
+	// Restoring without an ID inserts the word with its own timestamps
+	restored, err := repo.Restore(ctx, &models.Word{
+		Word:        "ephemeral",
+		Source:      "Book",
+		DateLearned: "2023-03-01",
+		Tags:        []string{"literature"},
+		CreatedAt:   createdAt,
+		UpdatedAt:   updatedAt,
+	})
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	got, _ := repo.GetByID(ctx, restored.ID)
+	if !got.CreatedAt.Equal(createdAt) || !got.UpdatedAt.Equal(updatedAt) {
+		t.Errorf("Restore() timestamps = %v, %v, want %v, %v", got.CreatedAt, got.UpdatedAt, createdAt, updatedAt)
+	}
+
+	// Restoring with an ID replaces the word, timestamps included
+	got.Source = "Magazine"
+	got.UpdatedAt = updatedAt.AddDate(0, 1, 0)
+	if _, err := repo.Restore(ctx, got); err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+
+	again, _ := repo.GetByID(ctx, restored.ID)
+	if again.Source != "Magazine" || !again.UpdatedAt.Equal(got.UpdatedAt) {
+		t.Errorf("Restore() = %+v, want source Magazine updated at %v", again, got.UpdatedAt)
+	}
+
+	got.ID = 999
+	if _, err := repo.Restore(ctx, got); err != sql.ErrNoRows {
+		t.Errorf("Restore() missing ID error = %v, want sql.ErrNoRows", err)
+	}
+}
+
+func TestSQLiteRepository_RecordReview(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	for _, w := range []string{"apt", "eloquent", "ubiquitous"} {
+		repo.Create(ctx, &models.Word{Word: w, Source: "Book", DateLearned: "2024-01-15"})
+	}
+
+	// apt is always remembered, ubiquitous mostly forgotten and eloquent never reviewed
+	reviews := []struct {
+		id         int64
+		remembered bool
+	}{{1, true}, {1, true}, {3, false}, {3, false}, {3, true}}
+	for _, rv := range reviews {
+		if err := repo.RecordReview(ctx, rv.id, rv.remembered); err != nil {
+			t.Fatalf("RecordReview() error = %v", err)
+		}
+	}
+	if err := repo.RecordReview(ctx, 9999, true); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("RecordReview() of a missing word error = %v, want sql.ErrNoRows", err)
+	}
+
+	for sort, want := range map[string]string{
+		"difficulty":  "apt,eloquent,ubiquitous",
+		"-difficulty": "ubiquitous,eloquent,apt",
+	} {
+		got, err := repo.List(ctx, models.WordFilter{Sort: sort})
+		if err != nil {
+			t.Fatalf("List() error = %v", err)
+		}
+		var words []string
+		for _, w := range got {
+			words = append(words, w.Word)
+		}
+		if strings.Join(words, ",") != want {
+			t.Errorf("List(sort=%q) = %v, want %v", sort, words, want)
+		}
+	}
+}
+
 func TestSQLiteRepository_Delete(t *testing.T) {
 	db := setupTestDB(t)
 	defer db.Close()
@@ -427,3 +752,190 @@ func TestSQLiteRepository_Count(t *testing.T) {
 func strPtr(s string) *string {
 	return &s
 }
+
+func TestSQLiteRepository_ImportedKeys(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	if err := repo.MarkImported(ctx, "kindle", []string{"en:ephemeral", "en:leviathan"}); err != nil {
+		t.Fatalf("MarkImported() error = %v", err)
+	}
+	// Marking a key twice is not an error
+	if err := repo.MarkImported(ctx, "kindle", []string{"en:ephemeral"}); err != nil {
+		t.Fatalf("MarkImported() error = %v", err)
+	}
+	repo.MarkImported(ctx, "kobo", []string{"en:ephemeral"})
+
+	keys, err := repo.ImportedKeys(ctx, "kindle")
+	if err != nil {
+		t.Fatalf("ImportedKeys() error = %v", err)
+	}
+	if len(keys) != 2 || !keys["en:ephemeral"] || !keys["en:leviathan"] {
+		t.Errorf("ImportedKeys() = %v, want the two kindle keys", keys)
+	}
+}
+
+func TestSQLiteRepository_WithTx(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	word := func(text string) *models.Word {
+		return &models.Word{Word: text, Source: "Book", DateLearned: "2024-01-15", Tags: []string{}}
+	}
+
+	// A failing function leaves nothing behind, including field definitions
+	errAbort := errors.New("abort")
+	err := repo.WithTx(ctx, func(tx Tx) error {
+		if _, err := tx.CreateField(ctx, &models.CustomField{Name: "cefr", Label: "CEFR", Type: models.FieldTypeText}); err != nil {
+			return err
+		}
+		if _, err := tx.Create(ctx, word("ephemeral")); err != nil {
+			return err
+		}
+		if _, err := tx.GetByWord(ctx, "ephemeral"); err != nil {
+			t.Errorf("GetByWord() in transaction error = %v", err)
+		}
+		return errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Fatalf("WithTx() error = %v, want %v", err, errAbort)
+	}
+	if _, err := repo.GetByWord(ctx, "ephemeral"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByWord() after rollback error = %v, want sql.ErrNoRows", err)
+	}
+	if fields, _ := repo.ListFields(ctx); len(fields) != 0 {
+		t.Errorf("ListFields() after rollback = %d fields, want 0", len(fields))
+	}
+
+	// Nested calls join the outer transaction and commit with it
+	err = repo.WithTx(ctx, func(tx Tx) error {
+		if _, err := tx.Create(ctx, word("ubiquitous")); err != nil {
+			return err
+		}
+		return tx.WithTx(ctx, func(inner Tx) error {
+			return inner.MarkImported(ctx, "kindle", []string{"en:ubiquitous"})
+		})
+	})
+	if err != nil {
+		t.Fatalf("WithTx() error = %v", err)
+	}
+	if _, err := repo.GetByWord(ctx, "ubiquitous"); err != nil {
+		t.Errorf("GetByWord() after commit error = %v", err)
+	}
+	if keys, _ := repo.ImportedKeys(ctx, "kindle"); !keys["en:ubiquitous"] {
+		t.Errorf("ImportedKeys() after commit = %v", keys)
+	}
+}
+
+func TestSQLiteRepository_WritesAreAtomic(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	field, _ := repo.CreateField(ctx, &models.CustomField{Name: "cefr", Label: "CEFR", Type: models.FieldTypeText})
+	word, err := repo.Create(ctx, &models.Word{
+		Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15", Tags: []string{},
+		CustomFields: map[string]string{"cefr": "C1"},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	// Failing trigram and field writes must undo the word row written before them
+	if _, err := db.Exec(`CREATE TRIGGER fail_trigrams BEFORE INSERT ON word_trigrams
+		BEGIN SELECT RAISE(ABORT, 'trigrams unavailable'); END`); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := repo.Create(ctx, &models.Word{Word: "ubiquitous", Source: "Book", DateLearned: "2024-01-15", Tags: []string{}}); err == nil {
+		t.Fatal("Create() with failing trigrams should return error")
+	}
+	if _, err := repo.GetByWord(ctx, "ubiquitous"); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetByWord() after failed Create() error = %v, want sql.ErrNoRows", err)
+	}
+
+	changed := *word
+	changed.Source = "Article"
+	changed.CustomFields = map[string]string{"cefr": "C2"}
+	if _, err := repo.Update(ctx, &changed); err == nil {
+		t.Fatal("Update() with failing trigrams should return error")
+	}
+	got, _ := repo.GetByID(ctx, word.ID)
+	if got.Source != "Book" || got.CustomFields["cefr"] != "C1" {
+		t.Errorf("GetByID() after failed Update() = %q %v, want Book with cefr=C1", got.Source, got.CustomFields)
+	}
+
+	// A field that cannot be deleted keeps its values
+	if _, err := db.Exec(`CREATE TRIGGER fail_fields BEFORE DELETE ON custom_fields
+		BEGIN SELECT RAISE(ABORT, 'fields locked'); END`); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.DeleteField(ctx, field.ID); err == nil {
+		t.Fatal("DeleteField() with failing delete should return error")
+	}
+	got, _ = repo.GetByID(ctx, word.ID)
+	if got.CustomFields["cefr"] != "C1" {
+		t.Errorf("GetByID() after failed DeleteField() custom fields = %v, want cefr=C1", got.CustomFields)
+	}
+}
+
+func TestSQLiteRepository_ImportJobs(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	queued, err := repo.CreateImportJob(ctx, &models.ImportJob{Filename: "words.csv", Status: models.ImportJobQueued})
+	if err != nil {
+		t.Fatalf("CreateImportJob() error = %v", err)
+	}
+
+	// An old finished job, and one left running
+	finished := time.Now().Add(-48 * time.Hour)
+	old, _ := repo.CreateImportJob(ctx, &models.ImportJob{Status: models.ImportJobDone, FinishedAt: &finished})
+	running, _ := repo.CreateImportJob(ctx, &models.ImportJob{Status: models.ImportJobRunning})
+
+	queued.Status, queued.Total, queued.Processed = models.ImportJobDone, 2, 2
+	queued.Result = []byte(`{"imported":2}`)
+	now := time.Now()
+	queued.FinishedAt = &now
+	if err := repo.UpdateImportJob(ctx, queued); err != nil {
+		t.Fatalf("UpdateImportJob() error = %v", err)
+	}
+
+	got, err := repo.GetImportJob(ctx, queued.ID)
+	if err != nil {
+		t.Fatalf("GetImportJob() error = %v", err)
+	}
+	if got.Filename != "words.csv" || got.Status != models.ImportJobDone || got.Processed != 2 ||
+		string(got.Result) != `{"imported":2}` || got.FinishedAt == nil {
+		t.Errorf("GetImportJob() = %+v", got)
+	}
+
+	n, err := repo.FailUnfinishedImportJobs(ctx, "interrupted")
+	if err != nil || n != 1 {
+		t.Fatalf("FailUnfinishedImportJobs() = %d, %v, want 1", n, err)
+	}
+	if got, _ := repo.GetImportJob(ctx, running.ID); got.Status != models.ImportJobFailed || got.Error != "interrupted" {
+		t.Errorf("GetImportJob() after FailUnfinishedImportJobs() = %+v", got)
+	}
+
+	if err := repo.DeleteImportJobsBefore(ctx, time.Now().Add(-24*time.Hour)); err != nil {
+		t.Fatalf("DeleteImportJobsBefore() error = %v", err)
+	}
+	if _, err := repo.GetImportJob(ctx, old.ID); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("GetImportJob() of an old job error = %v, want sql.ErrNoRows", err)
+	}
+	if _, err := repo.GetImportJob(ctx, queued.ID); err != nil {
+		t.Errorf("GetImportJob() of a recent job error = %v", err)
+	}
+}
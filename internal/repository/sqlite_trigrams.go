@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/lehmann314159/vocabulator/internal/fuzzy"
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// SuggestCandidates returns up to limit words sharing the most trigrams with the given set
+func (r *SQLiteRepository) SuggestCandidates(ctx context.Context, trigrams []string, limit int) ([]*models.Word, error) {
+	if len(trigrams) == 0 {
+		return nil, nil
+	}
+
+	args := appendStrings(make([]interface{}, 0, len(trigrams)+1), trigrams)
+	args = append(args, limit)
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT w.id, w.word, w.source, w.date_learned, w.part_of_speech, w.example_sentence, w.tags,
+			w.created_at, w.updated_at
+		 FROM words w
+		 JOIN (SELECT word_id, COUNT(*) AS shared FROM word_trigrams
+			WHERE trigram IN (`+placeholders(len(trigrams))+`) GROUP BY word_id) t ON t.word_id = w.id
+		 ORDER BY t.shared DESC, w.id
+		 LIMIT ?`,
+		args...,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query suggestions: %w", err)
+	}
+	defer rows.Close()
+
+	var words []*models.Word
+	for rows.Next() {
+		word, err := r.scanWordFromRows(rows)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, word)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	return words, nil
+}
+
+// IndexTrigrams adds trigram rows for words that have none, such as words created
+// before the index existed, and returns how many words were indexed
+func (r *SQLiteRepository) IndexTrigrams(ctx context.Context) (int, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, word FROM words WHERE id NOT IN (SELECT DISTINCT word_id FROM word_trigrams)`,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to find unindexed words: %w", err)
+	}
+
+	var words []*models.Word
+	for rows.Next() {
+		var word models.Word
+		if err := rows.Scan(&word.ID, &word.Word); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan word: %w", err)
+		}
+		words = append(words, &word)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating rows: %w", err)
+	}
+
+	for _, word := range words {
+		if err := r.saveTrigrams(ctx, word); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(words), nil
+}
+
+// saveTrigrams replaces the trigram rows for a word
+func (r *SQLiteRepository) saveTrigrams(ctx context.Context, word *models.Word) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM word_trigrams WHERE word_id = ?`, word.ID); err != nil {
+		return fmt.Errorf("failed to clear trigrams: %w", err)
+	}
+
+	trigrams := fuzzy.Trigrams(word.Word)
+	if len(trigrams) == 0 {
+		return nil
+	}
+
+	values := strings.TrimSuffix(strings.Repeat("(?, ?),", len(trigrams)), ",")
+	args := make([]interface{}, 0, len(trigrams)*2)
+	for _, t := range trigrams {
+		args = append(args, t, word.ID)
+	}
+
+	if _, err := r.db.ExecContext(ctx,
+		`INSERT INTO word_trigrams (trigram, word_id) VALUES `+values, args...,
+	); err != nil {
+		return fmt.Errorf("failed to save trigrams: %w", err)
+	}
+
+	return nil
+}
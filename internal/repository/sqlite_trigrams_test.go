@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/fuzzy"
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+func TestSQLiteRepository_Trigrams(t *testing.T) {
+	db := setupTestDB(t)
+	defer db.Close()
+
+	repo := NewSQLiteRepository(db)
+	ctx := context.Background()
+
+	countTrigrams := func(id int64) int {
+		t.Helper()
+		var n int
+		if err := db.QueryRow(`SELECT COUNT(*) FROM word_trigrams WHERE word_id = ?`, id).Scan(&n); err != nil {
+			t.Fatalf("failed to count trigrams: %v", err)
+		}
+		return n
+	}
+
+	word, _ := repo.Create(ctx, &models.Word{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15"})
+	if got, want := countTrigrams(word.ID), len(fuzzy.Trigrams("ephemeral")); got != want {
+		t.Errorf("Create() stored %d trigrams, want %d", got, want)
+	}
+
+	candidates, err := repo.SuggestCandidates(ctx, fuzzy.Trigrams("ephmeral"), 10)
+	if err != nil || len(candidates) != 1 || candidates[0].Word != "ephemeral" {
+		t.Errorf("SuggestCandidates() = %v, %v", candidates, err)
+	}
+
+	// Words written before the index existed are picked up by IndexTrigrams
+	db.Exec(`DELETE FROM word_trigrams`)
+	indexed, err := repo.IndexTrigrams(ctx)
+	if err != nil || indexed != 1 {
+		t.Errorf("IndexTrigrams() = %d, %v, want 1", indexed, err)
+	}
+	if indexed, _ := repo.IndexTrigrams(ctx); indexed != 0 {
+		t.Errorf("IndexTrigrams() second run = %d, want 0", indexed)
+	}
+
+	repo.Delete(ctx, word.ID)
+	if got := countTrigrams(word.ID); got != 0 {
+		t.Errorf("Delete() left %d trigrams", got)
+	}
+}
@@ -0,0 +1,247 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	_ "image/gif" // decoders for image.Decode
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// DefaultMaxAttachmentSize is the upload limit used when none is configured
+const DefaultMaxAttachmentSize = 5 << 20 // 5 MB
+
+// maxAttachmentPixels caps the decoded size of an image, so that a small file
+// declaring huge dimensions cannot exhaust memory when it is decoded
+const maxAttachmentPixels = 40_000_000
+
+// allowedAttachmentTypes are the sniffed content types accepted for upload
+var allowedAttachmentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+// AttachmentService provides business logic for word attachments
+type AttachmentService struct {
+	repo    repository.AttachmentRepository
+	words   repository.WordRepository
+	storage AttachmentStorage
+	maxSize int64
+}
+
+// NewAttachmentService creates a new attachment service
+func NewAttachmentService(repo repository.AttachmentRepository, words repository.WordRepository, storage AttachmentStorage, maxSize int64) *AttachmentService {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxAttachmentSize
+	}
+	return &AttachmentService{
+		repo:    repo,
+		words:   words,
+		storage: storage,
+		maxSize: maxSize,
+	}
+}
+
+// MaxSize returns the upload limit in bytes
+func (s *AttachmentService) MaxSize() int64 {
+	return s.maxSize
+}
+
+// Upload validates an image, generates its thumbnail and stores it for a word
+func (s *AttachmentService) Upload(ctx context.Context, wordID int64, filename string, r io.Reader) (*models.Attachment, error) {
+	if _, err := s.words.GetByID(ctx, wordID); err != nil {
+		return nil, err
+	}
+
+	// Read one byte past the limit so oversized files can be detected
+	data, err := io.ReadAll(io.LimitReader(r, s.maxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if int64(len(data)) > s.maxSize {
+		return nil, validation.Errors{{
+			Field:   "file",
+			Code:    validation.CodeTooLarge,
+			Message: fmt.Sprintf("file must be at most %d bytes", s.maxSize),
+		}}
+	}
+
+	// Trust the file contents rather than the client-supplied type
+	contentType := http.DetectContentType(data)
+	if !allowedAttachmentTypes[contentType] {
+		return nil, unsupportedAttachment()
+	}
+
+	// Check the declared dimensions before decoding allocates the full image
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, unsupportedAttachment()
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > maxAttachmentPixels {
+		return nil, validation.Errors{{
+			Field:   "file",
+			Code:    validation.CodeTooLarge,
+			Message: fmt.Sprintf("image must be at most %d pixels", maxAttachmentPixels),
+		}}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, unsupportedAttachment()
+	}
+
+	thumbnail, err := makeThumbnail(img, thumbnailSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate thumbnail: %w", err)
+	}
+
+	sum := sha256.Sum256(data)
+	attachment := &models.Attachment{
+		WordID:      wordID,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Checksum:    hex.EncodeToString(sum[:]),
+	}
+
+	attachment, err = s.repo.CreateAttachment(ctx, attachment, thumbnail)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.storage.Save(ctx, attachment.ID, data); err != nil {
+		// Don't leave metadata pointing at a file that was never written
+		s.repo.DeleteAttachment(ctx, attachment.ID)
+		return nil, err
+	}
+
+	setAttachmentURLs(attachment)
+	return attachment, nil
+}
+
+// Get retrieves attachment metadata by ID
+func (s *AttachmentService) Get(ctx context.Context, id int64) (*models.Attachment, error) {
+	attachment, err := s.repo.GetAttachment(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	setAttachmentURLs(attachment)
+	return attachment, nil
+}
+
+// List retrieves the attachments of a word
+func (s *AttachmentService) List(ctx context.Context, wordID int64) ([]*models.Attachment, error) {
+	attachments, err := s.repo.ListAttachments(ctx, wordID)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range attachments {
+		setAttachmentURLs(a)
+	}
+	return attachments, nil
+}
+
+// Open returns attachment metadata together with the original file
+func (s *AttachmentService) Open(ctx context.Context, id int64) (*models.Attachment, []byte, error) {
+	attachment, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := s.storage.Load(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, data, nil
+}
+
+// Thumbnail returns attachment metadata together with its JPEG thumbnail
+func (s *AttachmentService) Thumbnail(ctx context.Context, id int64) (*models.Attachment, []byte, error) {
+	attachment, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := s.repo.GetAttachmentThumbnail(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return attachment, data, nil
+}
+
+// Delete removes an attachment and its stored file
+func (s *AttachmentService) Delete(ctx context.Context, id int64) error {
+	if err := s.repo.DeleteAttachment(ctx, id); err != nil {
+		return err
+	}
+	return s.storage.Delete(ctx, id)
+}
+
+// DeleteWord removes a word together with its attachments. The attachment rows go
+// in the same transaction as the word; stored files are removed once it commits, and
+// a file that cannot be removed is only logged, since nothing refers to it any more.
+func (s *AttachmentService) DeleteWord(ctx context.Context, wordID int64) error {
+	attachments, err := s.repo.ListAttachments(ctx, wordID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.words.Delete(ctx, wordID); err != nil {
+		return err
+	}
+
+	for _, a := range attachments {
+		if err := s.storage.Delete(ctx, a.ID); err != nil {
+			log.Printf("Failed to remove file of attachment %d: %v", a.ID, err)
+		}
+	}
+	return nil
+}
+
+// unsupportedAttachment builds the error returned for files that are not supported images
+func unsupportedAttachment() validation.Errors {
+	return validation.Errors{{
+		Field:   "file",
+		Code:    validation.CodeUnsupported,
+		Message: "file must be a JPEG, PNG or GIF image",
+	}}
+}
+
+// cleanFilename strips any client-supplied directory and control characters
+func cleanFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		return "attachment"
+	}
+	return name
+}
+
+// setAttachmentURLs fills in the API URLs for serving an attachment
+func setAttachmentURLs(a *models.Attachment) {
+	a.URL = fmt.Sprintf("/api/v1/attachments/%d", a.ID)
+	a.ThumbnailURL = fmt.Sprintf("/api/v1/attachments/%d/thumbnail", a.ID)
+}
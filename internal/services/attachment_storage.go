@@ -0,0 +1,96 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/lehmann314159/vocabulator/internal/repository"
+)
+
+// AttachmentStorage stores the original bytes of uploaded attachments
+type AttachmentStorage interface {
+	// Save stores the file for an attachment ID
+	Save(ctx context.Context, id int64, data []byte) error
+
+	// Load returns the file for an attachment ID, or sql.ErrNoRows if it is missing
+	Load(ctx context.Context, id int64) ([]byte, error)
+
+	// Delete removes the file for an attachment ID; missing files are not an error
+	Delete(ctx context.Context, id int64) error
+}
+
+// DiskStorage keeps attachment files in a local directory, one file per attachment ID
+type DiskStorage struct {
+	dir string
+}
+
+// NewDiskStorage creates the directory if needed and returns a disk-backed storage
+func NewDiskStorage(dir string) (*DiskStorage, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create attachments directory: %w", err)
+	}
+	return &DiskStorage{dir: dir}, nil
+}
+
+// Save stores the file for an attachment ID
+func (s *DiskStorage) Save(ctx context.Context, id int64, data []byte) error {
+	if err := os.WriteFile(s.path(id), data, 0o644); err != nil {
+		return fmt.Errorf("failed to write attachment: %w", err)
+	}
+	return nil
+}
+
+// Load returns the file for an attachment ID
+func (s *DiskStorage) Load(ctx context.Context, id int64) ([]byte, error) {
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, sql.ErrNoRows
+		}
+		return nil, fmt.Errorf("failed to read attachment: %w", err)
+	}
+	return data, nil
+}
+
+// Delete removes the file for an attachment ID
+func (s *DiskStorage) Delete(ctx context.Context, id int64) error {
+	if err := os.Remove(s.path(id)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete attachment: %w", err)
+	}
+	return nil
+}
+
+// path returns the file path for an attachment ID
+func (s *DiskStorage) path(id int64) string {
+	return filepath.Join(s.dir, strconv.FormatInt(id, 10))
+}
+
+// DatabaseStorage keeps attachment files as BLOBs in the attachments table
+type DatabaseStorage struct {
+	repo repository.AttachmentRepository
+}
+
+// NewDatabaseStorage returns a storage that writes files into SQLite
+func NewDatabaseStorage(repo repository.AttachmentRepository) *DatabaseStorage {
+	return &DatabaseStorage{repo: repo}
+}
+
+// Save stores the file for an attachment ID
+func (s *DatabaseStorage) Save(ctx context.Context, id int64, data []byte) error {
+	return s.repo.SetAttachmentData(ctx, id, data)
+}
+
+// Load returns the file for an attachment ID
+func (s *DatabaseStorage) Load(ctx context.Context, id int64) ([]byte, error) {
+	return s.repo.GetAttachmentData(ctx, id)
+}
+
+// Delete is a no-op because the BLOB is removed together with the attachment row
+func (s *DatabaseStorage) Delete(ctx context.Context, id int64) error {
+	return nil
+}
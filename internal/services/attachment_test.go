@@ -0,0 +1,168 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// testPNG encodes a solid-colour PNG of the given size
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 40, B: 40, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatalf("failed to encode png: %v", err)
+	}
+	return buf.Bytes()
+}
+
+// testPNGBomb returns a tiny PNG whose header declares w x h pixels
+func testPNGBomb(t *testing.T, w, h uint32) []byte {
+	t.Helper()
+	data := testPNG(t, 1, 1)
+	// The IHDR chunk follows the 8-byte signature: length, type, then width and height
+	binary.BigEndian.PutUint32(data[16:], w)
+	binary.BigEndian.PutUint32(data[20:], h)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestAttachmentService_Upload(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo := svc.repo.(*repository.SQLiteRepository)
+
+	storage, err := NewDiskStorage(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDiskStorage() error = %v", err)
+	}
+	attachmentSvc := NewAttachmentService(repo, repo, storage, 64<<10)
+
+	word, _ := svc.Create(ctx, &models.CreateWordRequest{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15"})
+
+	tests := []struct {
+		name     string
+		wordID   int64
+		data     []byte
+		wantCode string
+		wantErr  bool
+	}{
+		{name: "valid png", wordID: word.ID, data: testPNG(t, 600, 300)},
+		{name: "not an image", wordID: word.ID, data: []byte("hello, world"), wantCode: validation.CodeUnsupported, wantErr: true},
+		{name: "too large", wordID: word.ID, data: bytes.Repeat([]byte{0}, 65<<10), wantCode: validation.CodeTooLarge, wantErr: true},
+		{name: "decompression bomb", wordID: word.ID, data: testPNGBomb(t, 40000, 40000), wantCode: validation.CodeTooLarge, wantErr: true},
+		{name: "missing word", wordID: 9999, data: testPNG(t, 10, 10), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := attachmentSvc.Upload(ctx, tt.wordID, "../../mnemonic.png", bytes.NewReader(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Upload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantCode != "" {
+				var verrs validation.Errors
+				if !errors.As(err, &verrs) || verrs[0].Code != tt.wantCode {
+					t.Errorf("Upload() error = %v, want code %s", err, tt.wantCode)
+				}
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if got.Filename != "mnemonic.png" || got.ContentType != "image/png" {
+				t.Errorf("Upload() = %s (%s), want mnemonic.png (image/png)", got.Filename, got.ContentType)
+			}
+			if got.Width != 600 || got.Height != 300 {
+				t.Errorf("Upload() dimensions = %dx%d, want 600x300", got.Width, got.Height)
+			}
+
+			_, data, err := attachmentSvc.Open(ctx, got.ID)
+			if err != nil || !bytes.Equal(data, tt.data) {
+				t.Errorf("Open() error = %v, data matches = %v", err, bytes.Equal(data, tt.data))
+			}
+
+			_, thumb, err := attachmentSvc.Thumbnail(ctx, got.ID)
+			if err != nil {
+				t.Fatalf("Thumbnail() error = %v", err)
+			}
+			cfg, err := jpeg.DecodeConfig(bytes.NewReader(thumb))
+			if err != nil {
+				t.Fatalf("thumbnail is not a JPEG: %v", err)
+			}
+			if cfg.Width != thumbnailSize || cfg.Height != thumbnailSize/2 {
+				t.Errorf("thumbnail size = %dx%d, want %dx%d", cfg.Width, cfg.Height, thumbnailSize, thumbnailSize/2)
+			}
+		})
+	}
+
+	attachments, _ := attachmentSvc.List(ctx, word.ID)
+	if err := attachmentSvc.DeleteWord(ctx, word.ID); err != nil {
+		t.Fatalf("DeleteWord() error = %v", err)
+	}
+	if _, err := svc.GetByID(ctx, word.ID); err == nil {
+		t.Error("DeleteWord() left the word behind")
+	}
+	remaining, _ := attachmentSvc.List(ctx, word.ID)
+	if len(remaining) != 0 {
+		t.Errorf("DeleteWord() left %d attachments", len(remaining))
+	}
+	for _, a := range attachments {
+		if _, err := storage.Load(ctx, a.ID); !errors.Is(err, sql.ErrNoRows) {
+			t.Errorf("DeleteWord() left the file of attachment %d: %v", a.ID, err)
+		}
+	}
+}
+
+func TestAttachmentService_DatabaseStorage(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo := svc.repo.(*repository.SQLiteRepository)
+	attachmentSvc := NewAttachmentService(repo, repo, NewDatabaseStorage(repo), 0)
+
+	word, _ := svc.Create(ctx, &models.CreateWordRequest{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15"})
+	data := testPNG(t, 20, 20)
+
+	created, err := attachmentSvc.Upload(ctx, word.ID, "small.png", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Upload() error = %v", err)
+	}
+	if !strings.HasSuffix(created.URL, "/attachments/1") {
+		t.Errorf("Upload() URL = %s", created.URL)
+	}
+
+	_, got, err := attachmentSvc.Open(ctx, created.ID)
+	if err != nil || !bytes.Equal(got, data) {
+		t.Errorf("Open() error = %v, data matches = %v", err, bytes.Equal(got, data))
+	}
+
+	if err := attachmentSvc.Delete(ctx, created.ID); err != nil {
+		t.Errorf("Delete() error = %v", err)
+	}
+	if _, _, err := attachmentSvc.Open(ctx, created.ID); err == nil {
+		t.Error("Open() after Delete() should return error")
+	}
+}
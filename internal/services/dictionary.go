@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
 )
 
 const (
@@ -19,6 +20,7 @@ const (
 type DictionaryService struct {
 	client  *http.Client
 	baseURL string
+	cache   repository.DefinitionRepository
 }
 
 // NewDictionaryService creates a new dictionary service
@@ -39,11 +41,47 @@ func NewDictionaryServiceWithClient(client *http.Client, baseURL string) *Dictio
 	}
 }
 
+// WithCache makes lookups read from and fill the given definition cache, so each
+// word is fetched from the dictionary API once
+func (s *DictionaryService) WithCache(cache repository.DefinitionRepository) *DictionaryService {
+	s.cache = cache
+	return s
+}
+
 // ErrWordNotFound is returned when the word is not found in the dictionary
 var ErrWordNotFound = fmt.Errorf("word not found in dictionary")
 
-// Lookup fetches the definition of a word from the dictionary API
+// Lookup fetches the definition of a word from the cache or the dictionary API
 func (s *DictionaryService) Lookup(ctx context.Context, word string) (*models.DictionaryResponse, error) {
+	if s.cache != nil {
+		if definition, err := s.cache.GetDefinition(ctx, word); err == nil {
+			return definition, nil
+		}
+	}
+
+	definition, err := s.fetch(ctx, word)
+	if err != nil {
+		return nil, err
+	}
+
+	// A failed cache write only costs a repeat lookup later
+	if s.cache != nil {
+		_ = s.cache.SaveDefinition(ctx, word, definition)
+	}
+	return definition, nil
+}
+
+// Cached returns every cached definition keyed by lowercased word, without calling
+// the dictionary API; it is empty when the service has no cache
+func (s *DictionaryService) Cached(ctx context.Context) (map[string]*models.DictionaryResponse, error) {
+	if s.cache == nil {
+		return map[string]*models.DictionaryResponse{}, nil
+	}
+	return s.cache.ListDefinitions(ctx)
+}
+
+// fetch looks a word up in the dictionary API
+func (s *DictionaryService) fetch(ctx context.Context, word string) (*models.DictionaryResponse, error) {
 	url := fmt.Sprintf("%s/%s", s.baseURL, word)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
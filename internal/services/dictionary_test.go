@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/repository"
 )
 
 func TestDictionaryService_Lookup(t *testing.T) {
@@ -107,6 +109,43 @@ func TestDictionaryService_GetFirstPartOfSpeech(t *testing.T) {
 	}
 }
 
+func TestDictionaryService_Cache(t *testing.T) {
+	words, cleanup := setupTestService(t)
+	defer cleanup()
+
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`[{"word": "ephemeral", "meanings": [{"partOfSpeech": "adjective", "definitions": [{"definition": "lasting a short time"}]}]}]`))
+	}))
+	defer server.Close()
+
+	repo := words.repo.(*repository.SQLiteRepository)
+	svc := NewDictionaryServiceWithClient(server.Client(), server.URL).WithCache(repo)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := svc.Lookup(ctx, "ephemeral"); err != nil {
+			t.Fatalf("Lookup() error = %v", err)
+		}
+	}
+	if _, err := svc.Lookup(ctx, "Ephemeral"); err != nil {
+		t.Fatalf("Lookup() error = %v", err)
+	}
+	if requests != 1 {
+		t.Errorf("dictionary API requests = %d, want 1", requests)
+	}
+
+	cached, err := svc.Cached(ctx)
+	if err != nil {
+		t.Fatalf("Cached() error = %v", err)
+	}
+	if def := cached["ephemeral"]; def == nil || def.Meanings[0].PartOfSpeech != "adjective" {
+		t.Errorf("Cached() = %v, want ephemeral", cached)
+	}
+}
+
 func TestDictionaryService_NewService(t *testing.T) {
 	svc := NewDictionaryService()
 
@@ -0,0 +1,305 @@
+package services
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// exportBatchSize is how many words an export reads from the database at a time
+const exportBatchSize = 500
+
+// ExportFormats lists the formats Export writes
+var ExportFormats = []string{"csv", "tsv", "json", "ndjson"}
+
+// delimitedColumns are the columns of a CSV or TSV export when no fields are
+// chosen, followed by one per custom field
+var delimitedColumns = []string{"word", "source", "date_learned", "part_of_speech", "example_sentence", "tags"}
+
+// ExportColumns are the word attributes an export can be limited to, besides the
+// names of custom fields
+var ExportColumns = []string{"id", "word", "source", "date_learned", "part_of_speech", "example_sentence", "tags",
+	"created_at", "updated_at"}
+
+// NotesField is the custom field that vault and dictionary exports show as a
+// word's notes, when one is defined
+const NotesField = "notes"
+
+// ExportOptions selects the words and fields of an export
+type ExportOptions struct {
+	Filter models.WordFilter // words to export, as accepted by the word list; paging is ignored
+	Fields []string          // ExportColumns and custom fields to write, in order; empty writes the format's usual set
+}
+
+// Export writes the words matching a filter as csv, tsv, json or ndjson. Without
+// fields, CSV and TSV exports have the columns ImportCSV reads and the JSON formats
+// are full backups. Words are read a batch at a time, so an export of any size is
+// written without holding every word in memory. An invalid format, filter or field
+// list is reported before anything is written.
+func (s *WordService) Export(ctx context.Context, w io.Writer, format string, opts ExportOptions) error {
+	if !slices.Contains(ExportFormats, format) {
+		return validation.Errors{{
+			Field:   "format",
+			Code:    validation.CodeInvalidValue,
+			Message: fmt.Sprintf("format must be one of: %s", strings.Join(ExportFormats, ", ")),
+		}}
+	}
+	if err := validation.ValidateFilter(opts.Filter); err != nil {
+		return err
+	}
+
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch custom fields: %w", err)
+	}
+	columns, err := exportColumns(opts.Fields, fields)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "csv", "tsv":
+		if columns == nil {
+			columns = slices.Clone(delimitedColumns)
+			for _, field := range fields {
+				columns = append(columns, field.Name)
+			}
+		}
+		comma := ','
+		if format == "tsv" {
+			comma = '\t'
+		}
+		return s.exportDelimited(ctx, w, comma, opts.Filter, columns)
+	default:
+		filter := opts.Filter
+		if filter.Sort == "" {
+			// Backups list words oldest first
+			filter.Sort = "created_at"
+		}
+		header := models.ExportHeader{
+			SchemaVersion: models.ExportSchemaVersion,
+			ExportedAt:    time.Now().UTC(),
+			Fields:        exportedFields(fields, columns),
+		}
+		if format == "ndjson" {
+			return s.exportNDJSON(ctx, w, header, filter, columns)
+		}
+		return s.exportJSON(ctx, w, header, filter, columns)
+	}
+}
+
+// exportColumns checks the fields chosen for an export, dropping repeats; it
+// returns nil when none are chosen
+func exportColumns(requested []string, fields []*models.CustomField) ([]string, error) {
+	var columns []string
+	var errs validation.Errors
+	for _, name := range requested {
+		name = strings.TrimSpace(name)
+		if name == "" || slices.Contains(columns, name) {
+			continue
+		}
+		if !slices.Contains(ExportColumns, name) && !fieldListed(fields, name) {
+			errs.Add("fields", validation.CodeUnknownField, fmt.Sprintf("'%s' is not a word field or custom field", name))
+			continue
+		}
+		columns = append(columns, name)
+	}
+	return columns, errs.Err()
+}
+
+// exportedFields returns the custom field definitions among the exported columns,
+// or all of them when every field is exported
+func exportedFields(fields []*models.CustomField, columns []string) []*models.CustomField {
+	exported := []*models.CustomField{}
+	for _, field := range fields {
+		if columns == nil || slices.Contains(columns, field.Name) {
+			exported = append(exported, field)
+		}
+	}
+	return exported
+}
+
+// eachWord calls fn with every word matching a filter, reading them a page at a
+// time in the filter's order
+func (s *WordService) eachWord(ctx context.Context, filter models.WordFilter, fn func(*models.Word) error) error {
+	filter.Limit, filter.Offset, filter.Cursor = exportBatchSize, 0, ""
+	for {
+		page, err := s.repo.ListPage(ctx, filter)
+		if err != nil {
+			return fmt.Errorf("failed to fetch words: %w", err)
+		}
+		for _, word := range page.Words {
+			word.Snippet = ""
+			if err := fn(word); err != nil {
+				return err
+			}
+		}
+		if page.NextCursor == "" {
+			return nil
+		}
+		filter.Cursor = page.NextCursor
+	}
+}
+
+// exportDelimited writes a header row of column names and one row per word
+func (s *WordService) exportDelimited(ctx context.Context, w io.Writer, comma rune, filter models.WordFilter, columns []string) error {
+	// Rows still buffered when the export fails are dropped rather than flushed
+	writer := csv.NewWriter(w)
+	writer.Comma = comma
+
+	if err := writer.Write(columns); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	record := make([]string, len(columns))
+	err := s.eachWord(ctx, filter, func(word *models.Word) error {
+		for i, column := range columns {
+			record[i] = exportValue(word, column)
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write record: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+// exportValue formats a column of a word for a CSV or TSV export
+func exportValue(word *models.Word, column string) string {
+	switch column {
+	case "id":
+		return strconv.FormatInt(word.ID, 10)
+	case "word":
+		return word.Word
+	case "source":
+		return word.Source
+	case "date_learned":
+		return word.DateLearned
+	case "part_of_speech":
+		if word.PartOfSpeech != nil {
+			return *word.PartOfSpeech
+		}
+		return ""
+	case "example_sentence":
+		if word.ExampleSentence != nil {
+			return *word.ExampleSentence
+		}
+		return ""
+	case "tags":
+		return strings.Join(word.Tags, ",")
+	case "created_at":
+		return word.CreatedAt.UTC().Format(time.RFC3339)
+	case "updated_at":
+		return word.UpdatedAt.UTC().Format(time.RFC3339)
+	}
+	return word.CustomFields[column]
+}
+
+// exportObject returns the value a word is written as in a JSON export: the word
+// itself, or only its chosen columns with custom fields under custom_fields
+func exportObject(word *models.Word, columns []string) interface{} {
+	if columns == nil {
+		return word
+	}
+
+	object := make(map[string]interface{}, len(columns))
+	custom := make(map[string]string)
+	for _, column := range columns {
+		switch column {
+		case "id":
+			object[column] = word.ID
+		case "tags":
+			object[column] = word.Tags
+		case "created_at":
+			object[column] = word.CreatedAt
+		case "updated_at":
+			object[column] = word.UpdatedAt
+		case "part_of_speech", "example_sentence":
+			if value := exportValue(word, column); value != "" {
+				object[column] = value
+			}
+		case "word", "source", "date_learned":
+			object[column] = exportValue(word, column)
+		default:
+			if value, ok := word.CustomFields[column]; ok {
+				custom[column] = value
+			}
+		}
+	}
+	if len(custom) > 0 {
+		object["custom_fields"] = custom
+	}
+	return object
+}
+
+// exportJSON writes a single JSON document in the layout of models.ExportDocument,
+// with the words written into it one at a time
+func (s *WordService) exportJSON(ctx context.Context, w io.Writer, header models.ExportHeader, filter models.WordFilter,
+	columns []string) error {
+	head, err := json.MarshalIndent(header, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	// Reopen the header object, which ends in "\n}", to append the words
+	if _, err := fmt.Fprintf(w, "%s,\n  \"words\": [", head[:len(head)-2]); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+
+	separator := "\n    "
+	err = s.eachWord(ctx, filter, func(word *models.Word) error {
+		data, err := json.MarshalIndent(exportObject(word, columns), "    ", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to write word: %w", err)
+		}
+		if _, err := io.WriteString(w, separator); err != nil {
+			return fmt.Errorf("failed to write word: %w", err)
+		}
+		if _, err := w.Write(data); err != nil {
+			return fmt.Errorf("failed to write word: %w", err)
+		}
+		separator = ",\n    "
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	end := "\n  ]\n}\n"
+	if separator == "\n    " {
+		end = "]\n}\n"
+	}
+	if _, err := io.WriteString(w, end); err != nil {
+		return fmt.Errorf("failed to write export: %w", err)
+	}
+	return nil
+}
+
+// exportNDJSON writes the export header on the first line and then one word per line
+func (s *WordService) exportNDJSON(ctx context.Context, w io.Writer, header models.ExportHeader, filter models.WordFilter,
+	columns []string) error {
+	encoder := json.NewEncoder(w)
+	if err := encoder.Encode(header); err != nil {
+		return fmt.Errorf("failed to write export header: %w", err)
+	}
+	return s.eachWord(ctx, filter, func(word *models.Word) error {
+		if err := encoder.Encode(exportObject(word, columns)); err != nil {
+			return fmt.Errorf("failed to write word: %w", err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,107 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/lehmann314159/vocabulator/internal/anki"
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// Anki IDs of the exported note type and deck. They are fixed so that importing a
+// newer export reuses the note type and deck created by the previous one.
+const (
+	ankiModelID = 1719504000001
+	ankiDeckID  = 1719504000002
+)
+
+// ankiFields are the fields of the exported note type, in order
+var ankiFields = []string{"Word", "PartOfSpeech", "Example", "Source", "Tags", "Definition"}
+
+// ankiModel is the note type of exported words: the word and part of speech on
+// the front, the definition, example and source on the back
+var ankiModel = anki.Model{
+	ID:     ankiModelID,
+	Name:   "Vocabulator Word",
+	Fields: ankiFields,
+	Front: `<div class="word">{{Word}}</div>
+{{#PartOfSpeech}}<div class="pos">{{PartOfSpeech}}</div>{{/PartOfSpeech}}`,
+	Back: `{{FrontSide}}
+<hr id="answer">
+{{#Definition}}<div class="definition">{{Definition}}</div>{{/Definition}}
+{{#Example}}<div class="example">{{Example}}</div>{{/Example}}
+<div class="source">{{Source}}</div>`,
+	CSS: `.card { font-family: sans-serif; font-size: 20px; text-align: center; }
+.word { font-size: 32px; font-weight: bold; }
+.pos, .source { color: #888; font-style: italic; }
+.definition { text-align: left; }
+.example { margin-top: 1em; font-style: italic; }`,
+}
+
+// ExportAnki writes the words matching filter as an Anki .apkg deck, one note per
+// word, with Vocabulator tags as Anki tags. The Definition field is filled from
+// cached dictionary lookups only; words never looked up have no definition.
+func (s *WordService) ExportAnki(ctx context.Context, w io.Writer, filter models.WordFilter) error {
+	definitions, err := s.dictionary.Cached(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch definitions: %w", err)
+	}
+
+	deck := &anki.Deck{
+		ID:    ankiDeckID,
+		Name:  "Vocabulator",
+		Model: ankiModel,
+	}
+
+	// Notes go straight into the collection as each batch of words is read
+	deck.Stream = func(add func(anki.Note) error) error {
+		return s.eachWord(ctx, filter, func(word *models.Word) error {
+			partOfSpeech, example := "", ""
+			if word.PartOfSpeech != nil {
+				partOfSpeech = *word.PartOfSpeech
+			}
+			if word.ExampleSentence != nil {
+				example = *word.ExampleSentence
+			}
+
+			return add(anki.Note{
+				GUID: anki.GUID("vocabulator:" + strings.ToLower(word.Word)),
+				Fields: []string{
+					html.EscapeString(word.Word),
+					html.EscapeString(partOfSpeech),
+					html.EscapeString(example),
+					html.EscapeString(word.Source),
+					html.EscapeString(strings.Join(word.Tags, ", ")),
+					definitionHTML(definitions[strings.ToLower(word.Word)]),
+				},
+				Tags: word.Tags,
+			})
+		})
+	}
+
+	return anki.WritePackage(w, deck)
+}
+
+// definitionHTML formats a dictionary definition as HTML: each part of speech
+// followed by a numbered list of its senses
+func definitionHTML(definition *models.DictionaryResponse) string {
+	if definition == nil {
+		return ""
+	}
+
+	var b strings.Builder
+	for _, meaning := range definition.Meanings {
+		if len(meaning.Definitions) == 0 {
+			continue
+		}
+		fmt.Fprintf(&b, "<i>%s</i><ol>", html.EscapeString(meaning.PartOfSpeech))
+		for _, sense := range meaning.Definitions {
+			fmt.Fprintf(&b, "<li>%s</li>", html.EscapeString(sense.Definition))
+		}
+		b.WriteString("</ol>")
+	}
+	return b.String()
+}
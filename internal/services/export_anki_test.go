@@ -0,0 +1,81 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+)
+
+func TestWordService_ExportAnki(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo := svc.repo.(*repository.SQLiteRepository)
+	svc.dictionary.WithCache(repo)
+
+	svc.Create(ctx, &models.CreateWordRequest{
+		Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15",
+		PartOfSpeech: strPtr("adjective"), ExampleSentence: strPtr("An <ephemeral> joy"),
+		Tags: []string{"literature", "word of the day"},
+	})
+	svc.Create(ctx, &models.CreateWordRequest{Word: "ubiquitous", Source: "Article", DateLearned: "2024-02-20"})
+	repo.SaveDefinition(ctx, "ephemeral", &models.DictionaryResponse{
+		Word:     "ephemeral",
+		Meanings: []models.Meaning{{PartOfSpeech: "adjective", Definitions: []models.Definition{{Definition: "lasting a very short time"}}}},
+	})
+
+	var buf bytes.Buffer
+	if err := svc.ExportAnki(ctx, &buf, models.WordFilter{Tags: []string{"literature"}}); err != nil {
+		t.Fatalf("ExportAnki() error = %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("ExportAnki() did not write a zip: %v", err)
+	}
+	rc, err := archive.Open("collection.anki2")
+	if err != nil {
+		t.Fatalf("ExportAnki() package has no collection: %v", err)
+	}
+	collection, _ := io.ReadAll(rc)
+	rc.Close()
+
+	path := filepath.Join(t.TempDir(), "collection.anki2")
+	os.WriteFile(path, collection, 0o600)
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var count int
+	var tags, flds string
+	if err := db.QueryRow(`SELECT count(*), tags, flds FROM notes`).Scan(&count, &tags, &flds); err != nil {
+		t.Fatalf("failed to read notes: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("ExportAnki() notes = %d, want 1 matching the filter", count)
+	}
+	if tags != " literature word_of_the_day " {
+		t.Errorf("ExportAnki() tags = %q", tags)
+	}
+
+	fields := strings.Split(flds, "\x1f")
+	want := []string{
+		"ephemeral", "adjective", "An &lt;ephemeral&gt; joy", "Book", "literature, word of the day",
+		"<i>adjective</i><ol><li>lasting a very short time</li></ol>",
+	}
+	if strings.Join(fields, "|") != strings.Join(want, "|") {
+		t.Errorf("ExportAnki() fields = %q, want %q", fields, want)
+	}
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// ConflictStrategy decides what an import does with a word that already exists
+type ConflictStrategy string
+
+// Supported conflict strategies
+const (
+	ConflictSkip      ConflictStrategy = "skip"      // keep the existing word
+	ConflictOverwrite ConflictStrategy = "overwrite" // replace it with the imported word
+	ConflictNewer     ConflictStrategy = "newer"     // replace it if the imported word was updated more recently
+	ConflictMerge     ConflictStrategy = "merge"     // add its missing tags and fill its empty fields
+)
+
+// ParseConflictStrategy reads a conflict strategy name; empty means skip
+func ParseConflictStrategy(name string) (ConflictStrategy, error) {
+	switch strategy := ConflictStrategy(name); strategy {
+	case "":
+		return ConflictSkip, nil
+	case ConflictSkip, ConflictOverwrite, ConflictNewer, ConflictMerge:
+		return strategy, nil
+	}
+	return "", fmt.Errorf("unknown conflict strategy %q: must be skip, overwrite, newer or merge", name)
+}
+
+// ImportOptions configures how an import writes its rows
+type ImportOptions struct {
+	Conflict ConflictStrategy // what to do with words that already exist; empty means skip
+
+	// AllOrNothing writes every row in a single transaction, rolling all of them
+	// back if any row is invalid or fails to write
+	AllOrNothing bool
+
+	// Profile describes how to read a CSV or TSV file; nil reads the export format
+	Profile *models.ImportProfile
+
+	// Progress, if set, is called as rows are written with the number of rows
+	// processed, the total and the result so far
+	Progress ImportProgress
+}
+
+// ImportProgress reports how far an import has got
+type ImportProgress func(processed, total int, result *ImportResult)
+
+// Import reads words in the given format: csv, tsv, json, ndjson, kindle (a vocab.db
+// file) or kobo (a KoboReader.sqlite file). Words that already exist are resolved
+// with the conflict strategy, which defaults to skipping them.
+func (s *WordService) Import(ctx context.Context, r io.Reader, format string, opts ImportOptions) (*ImportResult, error) {
+	plan, err := s.planImport(ctx, r, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.runImport(ctx, plan)
+}
+
+// planImport reads the rows of a file in the given format; see Import
+func (s *WordService) planImport(ctx context.Context, r io.Reader, format string, opts ImportOptions) (*importPlan, error) {
+	var plan *importPlan
+	var err error
+
+	switch format {
+	case "csv":
+		plan, err = s.planCSV(ctx, r, opts.Profile)
+	case "tsv":
+		profile := models.ImportProfile{}
+		if opts.Profile != nil {
+			profile = *opts.Profile
+		}
+		if profile.Delimiter == "" {
+			profile.Delimiter = "\t"
+		}
+		plan, err = s.planCSV(ctx, r, &profile)
+	case "json":
+		plan, err = s.planJSON(ctx, r, opts.Conflict)
+	case "ndjson":
+		plan, err = s.planNDJSON(ctx, r, opts.Conflict)
+	case "kindle":
+		plan, err = s.planKindle(ctx, r)
+	case "kobo":
+		plan, err = s.planKobo(ctx, r)
+	case "markdown":
+		plan, err = s.planMarkdown(ctx, r)
+	default:
+		return nil, fmt.Errorf("unknown import format %q: must be csv, tsv, json, ndjson, kindle, kobo or markdown", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	plan.configure(opts)
+	return plan, nil
+}
+
+// maxNDJSONLine is the longest NDJSON line accepted on import
+const maxNDJSONLine = 1 << 20
+
+// ExportJSON writes every word with its ID, timestamps and custom field values as a
+// single JSON document
+func (s *WordService) ExportJSON(ctx context.Context, w io.Writer) error {
+	return s.Export(ctx, w, "json", ExportOptions{})
+}
+
+// ExportNDJSON writes the export header on the first line and then one word per line
+func (s *WordService) ExportNDJSON(ctx context.Context, w io.Writer) error {
+	return s.Export(ctx, w, "ndjson", ExportOptions{})
+}
+
+// ImportJSON imports a document written by ExportJSON, keeping each word's
+// timestamps. Words are matched to existing ones by their text and resolved
+// with the conflict strategy; missing custom field definitions are created.
+func (s *WordService) ImportJSON(ctx context.Context, r io.Reader, strategy ConflictStrategy) (*ImportResult, error) {
+	plan, err := s.planJSON(ctx, r, strategy)
+	if err != nil {
+		return nil, err
+	}
+	return s.runImport(ctx, plan)
+}
+
+// ImportNDJSON imports a file written by ExportNDJSON; see ImportJSON
+func (s *WordService) ImportNDJSON(ctx context.Context, r io.Reader, strategy ConflictStrategy) (*ImportResult, error) {
+	plan, err := s.planNDJSON(ctx, r, strategy)
+	if err != nil {
+		return nil, err
+	}
+	return s.runImport(ctx, plan)
+}
+
+// planJSON reads the words of a JSON export
+func (s *WordService) planJSON(ctx context.Context, r io.Reader, strategy ConflictStrategy) (*importPlan, error) {
+	var doc models.ExportDocument
+	if err := json.NewDecoder(r).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to read JSON export: %w", err)
+	}
+
+	plan, err := s.exportPlan(ctx, doc.ExportHeader, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	for i, word := range doc.Words {
+		plan.add(fmt.Sprintf("word %d", i+1), word)
+	}
+	return plan, nil
+}
+
+// planNDJSON reads the words of an NDJSON export
+func (s *WordService) planNDJSON(ctx context.Context, r io.Reader, strategy ConflictStrategy) (*importPlan, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), maxNDJSONLine)
+
+	var plan *importPlan
+	lineNum := 0
+
+	for scanner.Scan() {
+		lineNum++
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		// The first line is the export header
+		if plan == nil {
+			var header models.ExportHeader
+			if err := json.Unmarshal(line, &header); err != nil {
+				return nil, fmt.Errorf("failed to read NDJSON header: %w", err)
+			}
+			var err error
+			if plan, err = s.exportPlan(ctx, header, strategy); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		label := fmt.Sprintf("line %d", lineNum)
+		var word models.Word
+		if err := json.Unmarshal(line, &word); err != nil {
+			plan.invalid(label, err.Error())
+			continue
+		}
+		plan.add(label, &word)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read NDJSON export: %w", err)
+	}
+	if plan == nil {
+		return nil, fmt.Errorf("NDJSON export is empty")
+	}
+	return plan, nil
+}
+
+// exportPlan checks the schema version of an export and starts a plan that
+// restores its words, creating the custom field definitions it needs that are
+// missing
+func (s *WordService) exportPlan(ctx context.Context, header models.ExportHeader, strategy ConflictStrategy) (*importPlan, error) {
+	if header.SchemaVersion < 1 {
+		return nil, fmt.Errorf("missing schema_version: not a vocabulator export")
+	}
+	if header.SchemaVersion > models.ExportSchemaVersion {
+		return nil, fmt.Errorf("unsupported schema_version %d: this server reads up to version %d",
+			header.SchemaVersion, models.ExportSchemaVersion)
+	}
+
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &importPlan{fields: fields, restore: true, strategy: strategy}
+	for _, def := range header.Fields {
+		field := &models.CustomField{Name: def.Name, Label: def.Label, Type: def.Type, Options: def.Options}
+		validation.NormalizeField(field)
+		if fieldListed(plan.fields, field.Name) {
+			continue
+		}
+		if err := validation.ValidateField(field); err != nil {
+			return nil, fmt.Errorf("custom field %s: %w", field.Name, err)
+		}
+		plan.fields = append(plan.fields, field)
+		plan.newFields = append(plan.newFields, field)
+	}
+
+	return plan, nil
+}
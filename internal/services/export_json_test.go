@@ -0,0 +1,193 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+func TestWordService_ExportImportJSON_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+
+	formats := []struct {
+		name   string
+		export func(*WordService, *bytes.Buffer) error
+		imp    func(*WordService, *bytes.Buffer) (*ImportResult, error)
+	}{
+		{
+			name:   "json",
+			export: func(s *WordService, buf *bytes.Buffer) error { return s.ExportJSON(ctx, buf) },
+			imp: func(s *WordService, buf *bytes.Buffer) (*ImportResult, error) {
+				return s.ImportJSON(ctx, buf, ConflictSkip)
+			},
+		},
+		{
+			name:   "ndjson",
+			export: func(s *WordService, buf *bytes.Buffer) error { return s.ExportNDJSON(ctx, buf) },
+			imp: func(s *WordService, buf *bytes.Buffer) (*ImportResult, error) {
+				return s.ImportNDJSON(ctx, buf, ConflictSkip)
+			},
+		},
+	}
+
+	for _, tt := range formats {
+		t.Run(tt.name, func(t *testing.T) {
+			src, cleanup := setupTestService(t)
+			defer cleanup()
+
+			src.fields.CreateField(ctx, &models.CustomField{Name: "difficulty", Label: "Difficulty", Type: models.FieldTypeNumber})
+			created, err := src.Create(ctx, &models.CreateWordRequest{
+				Word:            "ephemeral",
+				Source:          "Book",
+				DateLearned:     "2024-01-15",
+				ExampleSentence: strPtr("The ephemeral beauty of spring"),
+				Tags:            []string{"literature"},
+				CustomFields:    map[string]string{"difficulty": "3"},
+			})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			var buf bytes.Buffer
+			if err := tt.export(src, &buf); err != nil {
+				t.Fatalf("export error = %v", err)
+			}
+
+			dst, cleanup := setupTestService(t)
+			defer cleanup()
+
+			result, err := tt.imp(dst, &buf)
+			if err != nil {
+				t.Fatalf("import error = %v", err)
+			}
+			if result.Imported != 1 || result.Skipped != 0 {
+				t.Fatalf("import result = %+v, want 1 imported", result)
+			}
+
+			got, err := dst.repo.GetByWord(ctx, "ephemeral")
+			if err != nil {
+				t.Fatalf("GetByWord() error = %v", err)
+			}
+			if !got.CreatedAt.Equal(created.CreatedAt) || !got.UpdatedAt.Equal(created.UpdatedAt) {
+				t.Errorf("timestamps = %v, %v, want %v, %v", got.CreatedAt, got.UpdatedAt, created.CreatedAt, created.UpdatedAt)
+			}
+			if got.CustomFields["difficulty"] != "3" {
+				t.Errorf("custom fields = %v, want difficulty 3", got.CustomFields)
+			}
+			if got.ExampleSentence == nil || *got.ExampleSentence != "The ephemeral beauty of spring" {
+				t.Errorf("example sentence = %v", got.ExampleSentence)
+			}
+		})
+	}
+}
+
+func TestWordService_ImportJSON_Conflicts(t *testing.T) {
+	ctx := context.Background()
+
+	existingUpdated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		strategy    ConflictStrategy
+		updatedAt   time.Time
+		wantSource  string
+		wantUpdated int
+		wantSkipped int
+	}{
+		{
+			name:        "skip keeps existing",
+			strategy:    ConflictSkip,
+			updatedAt:   existingUpdated.AddDate(0, 1, 0),
+			wantSource:  "Book",
+			wantSkipped: 1,
+		},
+		{
+			name:        "overwrite replaces older",
+			strategy:    ConflictOverwrite,
+			updatedAt:   existingUpdated.AddDate(0, -1, 0),
+			wantSource:  "Podcast",
+			wantUpdated: 1,
+		},
+		{
+			name:        "newer replaces when newer",
+			strategy:    ConflictNewer,
+			updatedAt:   existingUpdated.AddDate(0, 1, 0),
+			wantSource:  "Podcast",
+			wantUpdated: 1,
+		},
+		{
+			name:        "newer keeps when older",
+			strategy:    ConflictNewer,
+			updatedAt:   existingUpdated.AddDate(0, -1, 0),
+			wantSource:  "Book",
+			wantSkipped: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, cleanup := setupTestService(t)
+			defer cleanup()
+
+			svc.repo.Restore(ctx, &models.Word{
+				Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15", Tags: []string{},
+				CreatedAt: existingUpdated, UpdatedAt: existingUpdated,
+			})
+
+			doc := models.ExportDocument{
+				ExportHeader: models.ExportHeader{SchemaVersion: models.ExportSchemaVersion},
+				Words: []*models.Word{
+					{Word: "ephemeral", Source: "Podcast", DateLearned: "2024-01-15", CreatedAt: existingUpdated, UpdatedAt: tt.updatedAt},
+					{Word: "ubiquitous", Source: "Article", DateLearned: "2024-02-20"},
+				},
+			}
+			data, _ := json.Marshal(doc)
+
+			result, err := svc.ImportJSON(ctx, bytes.NewReader(data), tt.strategy)
+			if err != nil {
+				t.Fatalf("ImportJSON() error = %v", err)
+			}
+			if result.Imported != 1 || result.Updated != tt.wantUpdated || result.Skipped != tt.wantSkipped {
+				t.Errorf("ImportJSON() = %+v, want 1 imported, %d updated, %d skipped", result, tt.wantUpdated, tt.wantSkipped)
+			}
+
+			got, _ := svc.repo.GetByWord(ctx, "ephemeral")
+			if got.Source != tt.wantSource {
+				t.Errorf("source = %v, want %v", got.Source, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestWordService_ImportJSON_SchemaVersion(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "missing version", input: `{"words":[]}`},
+		{name: "future version", input: `{"schema_version":99,"words":[]}`},
+		{name: "not JSON", input: `word,source,date_learned`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.ImportJSON(ctx, strings.NewReader(tt.input), ConflictSkip); err == nil {
+				t.Error("ImportJSON() should return error")
+			}
+		})
+	}
+
+	if _, err := ParseConflictStrategy("replace"); err == nil {
+		t.Error("ParseConflictStrategy() should reject unknown strategy")
+	}
+}
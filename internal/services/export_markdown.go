@@ -0,0 +1,335 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/obsidian"
+)
+
+// Folders of an exported vault: one note per word, and an index note per source
+// and per tag linking to its words
+const (
+	vaultWords   = "Words"
+	vaultSources = "Sources"
+	vaultTags    = "Tags"
+	vaultIndex   = "Vocabulary.md"
+)
+
+// Section headings of a word note that are read back on import
+const (
+	sectionExample    = "## Example"
+	sectionNotes      = "## Notes"
+	sectionDefinition = "## Definition"
+)
+
+// maxVaultNote is the largest note read from a vault on import
+const maxVaultNote = 1 << 20
+
+// ExportMarkdown writes the words matching filter as a zip of an Obsidian vault.
+// Each word is a note in Words/ with its source, date learned, part of speech,
+// tags and custom fields as properties, and a body with its example sentence,
+// notes and cached definition. Notes in Sources/ and Tags/ list the words of each
+// source and tag as wiki-links, and Vocabulary.md links to all of them.
+func (s *WordService) ExportMarkdown(ctx context.Context, w io.Writer, filter models.WordFilter) error {
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch custom fields: %w", err)
+	}
+	definitions, err := s.dictionary.Cached(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch definitions: %w", err)
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = "word"
+	}
+
+	zw := zip.NewWriter(w)
+	names := make(map[string]bool)
+	sources := make(map[string][]string)
+	tags := make(map[string][]string)
+
+	err = s.eachWord(ctx, filter, func(word *models.Word) error {
+		name := uniqueNoteName(names, obsidian.FileName(word.Word))
+		link := obsidian.Link(path.Join(vaultWords, name), word.Word)
+		sources[word.Source] = append(sources[word.Source], link)
+		for _, tag := range word.Tags {
+			tags[tag] = append(tags[tag], link)
+		}
+
+		note := wordNote(word, fields, definitions[strings.ToLower(word.Word)])
+		header := &zip.FileHeader{
+			Name:     path.Join(vaultWords, name+".md"),
+			Method:   zip.Deflate,
+			Modified: word.UpdatedAt,
+		}
+		return writeVaultFile(zw, header, obsidian.Format(note))
+	})
+	if err != nil {
+		return err
+	}
+
+	var index strings.Builder
+	index.WriteString("# Vocabulary\n")
+	for _, group := range []struct {
+		folder string
+		links  map[string][]string
+	}{{vaultSources, sources}, {vaultTags, tags}} {
+		if len(group.links) == 0 {
+			continue
+		}
+		fmt.Fprintf(&index, "\n## %s\n\n", group.folder)
+
+		used := make(map[string]bool)
+		for _, title := range sortedKeys(group.links) {
+			name := uniqueNoteName(used, obsidian.FileName(title))
+			fmt.Fprintf(&index, "- %s\n", obsidian.Link(path.Join(group.folder, name), title))
+
+			var b strings.Builder
+			fmt.Fprintf(&b, "# %s\n\n", title)
+			for _, link := range group.links[title] {
+				fmt.Fprintf(&b, "- %s\n", link)
+			}
+			header := &zip.FileHeader{Name: path.Join(group.folder, name+".md"), Method: zip.Deflate}
+			if err := writeVaultFile(zw, header, []byte(b.String())); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := writeVaultFile(zw, &zip.FileHeader{Name: vaultIndex, Method: zip.Deflate}, []byte(index.String())); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// wordNote returns the note of a word
+func wordNote(word *models.Word, fields []*models.CustomField, definition *models.DictionaryResponse) *obsidian.Note {
+	note := &obsidian.Note{}
+	note.Set("word", word.Word)
+	note.Set("source", word.Source)
+	note.Set("date_learned", word.DateLearned)
+	if word.PartOfSpeech != nil {
+		note.Set("part_of_speech", *word.PartOfSpeech)
+	}
+	note.SetList("tags", word.Tags)
+	for _, field := range fields {
+		if field.Name != NotesField {
+			note.Set(field.Name, word.CustomFields[field.Name])
+		}
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n", word.Word)
+	if word.ExampleSentence != nil && *word.ExampleSentence != "" {
+		fmt.Fprintf(&b, "\n%s\n\n", sectionExample)
+		for _, line := range strings.Split(*word.ExampleSentence, "\n") {
+			fmt.Fprintf(&b, "> %s\n", line)
+		}
+	}
+	if notes := word.CustomFields[NotesField]; notes != "" {
+		fmt.Fprintf(&b, "\n%s\n\n%s\n", sectionNotes, notes)
+	}
+	if text := definitionMarkdown(definition); text != "" {
+		fmt.Fprintf(&b, "\n%s\n\n%s", sectionDefinition, text)
+	}
+	note.Body = b.String()
+	return note
+}
+
+// definitionMarkdown formats a dictionary definition as Markdown: each part of
+// speech followed by a numbered list of its senses
+func definitionMarkdown(definition *models.DictionaryResponse) string {
+	if definition == nil {
+		return ""
+	}
+
+	var parts []string
+	for _, meaning := range definition.Meanings {
+		if len(meaning.Definitions) == 0 {
+			continue
+		}
+		var b strings.Builder
+		fmt.Fprintf(&b, "*%s*\n\n", meaning.PartOfSpeech)
+		for i, sense := range meaning.Definitions {
+			fmt.Fprintf(&b, "%d. %s\n", i+1, sense.Definition)
+		}
+		parts = append(parts, b.String())
+	}
+	return strings.Join(parts, "\n")
+}
+
+// uniqueNoteName returns name, numbered if a note in the same folder already has
+// it; file names are compared ignoring case as on macOS and Windows
+func uniqueNoteName(used map[string]bool, name string) string {
+	unique := name
+	for i := 2; used[strings.ToLower(unique)]; i++ {
+		unique = fmt.Sprintf("%s %d", name, i)
+	}
+	used[strings.ToLower(unique)] = true
+	return unique
+}
+
+// writeVaultFile adds a file to a vault zip
+func writeVaultFile(zw *zip.Writer, header *zip.FileHeader, data []byte) error {
+	f, err := zw.CreateHeader(header)
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", header.Name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s: %w", header.Name, err)
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of m sorted ignoring case
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool { return strings.ToLower(keys[i]) < strings.ToLower(keys[j]) })
+	return keys
+}
+
+// ImportMarkdown reads back the word notes of a vault exported by ExportMarkdown,
+// as a zip. Notes are matched to words by their word property, or their file name
+// without one, and strategy decides what happens to words that already exist, so
+// importing with ConflictOverwrite applies edits made in the vault. Definitions
+// come from the dictionary and are not imported.
+func (s *WordService) ImportMarkdown(ctx context.Context, r io.Reader, strategy ConflictStrategy) (*ImportResult, error) {
+	plan, err := s.planMarkdown(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	plan.strategy = strategy
+	return s.runImport(ctx, plan)
+}
+
+// planMarkdown reads the notes in the Words folder of a vault zip
+func (s *WordService) planMarkdown(ctx context.Context, r io.Reader) (*importPlan, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vault: %w", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, fmt.Errorf("not a zip of a Markdown vault: %w", err)
+	}
+
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &importPlan{fields: fields}
+	for _, f := range zr.File {
+		dir, file := path.Split(f.Name)
+		if path.Base(dir) != vaultWords || path.Ext(file) != ".md" || f.FileInfo().IsDir() {
+			continue
+		}
+
+		note, err := readVaultNote(f)
+		if err != nil {
+			plan.invalid(f.Name, err.Error())
+			continue
+		}
+		plan.add(f.Name, markdownWord(note, strings.TrimSuffix(file, ".md"), fields))
+	}
+
+	if len(plan.rows) == 0 {
+		return nil, fmt.Errorf("no word notes found in a %s folder", vaultWords)
+	}
+	return plan, nil
+}
+
+// readVaultNote reads and parses a note from a vault zip
+func readVaultNote(f *zip.File) (*obsidian.Note, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(io.LimitReader(rc, maxVaultNote+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxVaultNote {
+		return nil, fmt.Errorf("note is larger than %d bytes", maxVaultNote)
+	}
+	return obsidian.Parse(data)
+}
+
+// markdownWord converts a word note to a word, taking the word from its file name
+// when the note has no word property
+func markdownWord(note *obsidian.Note, name string, fields []*models.CustomField) *models.Word {
+	word := &models.Word{
+		Word:         note.Value("word"),
+		Source:       note.Value("source"),
+		DateLearned:  note.Value("date_learned"),
+		Tags:         []string{},
+		CustomFields: make(map[string]string),
+	}
+	if word.Word == "" {
+		word.Word = name
+	}
+	if pos := note.Value("part_of_speech"); pos != "" {
+		word.PartOfSpeech = &pos
+	}
+	for _, tag := range note.Values("tags") {
+		word.Tags = append(word.Tags, strings.TrimPrefix(tag, "#"))
+	}
+
+	sections := noteSections(note.Body)
+	if example := sections[sectionExample]; example != "" {
+		word.ExampleSentence = &example
+	}
+	for _, field := range fields {
+		if field.Name == NotesField {
+			if notes := sections[sectionNotes]; notes != "" {
+				word.CustomFields[field.Name] = notes
+			}
+		} else if value := note.Value(field.Name); value != "" {
+			word.CustomFields[field.Name] = value
+		}
+	}
+	return word
+}
+
+// noteSections returns the text under the example and notes headings of a word
+// note, with the example's quote markers removed
+func noteSections(body string) map[string]string {
+	sections := make(map[string]string)
+	var current string
+	var lines []string
+	flush := func() {
+		if current != "" {
+			sections[current] = strings.TrimSpace(strings.Join(lines, "\n"))
+		}
+		lines = nil
+	}
+
+	for _, line := range strings.Split(strings.ReplaceAll(body, "\r\n", "\n"), "\n") {
+		switch heading := strings.TrimSpace(line); heading {
+		case sectionExample, sectionNotes, sectionDefinition:
+			flush()
+			current = heading
+			continue
+		}
+		if current == sectionExample {
+			line = strings.TrimPrefix(strings.TrimPrefix(line, ">"), " ")
+		}
+		lines = append(lines, line)
+	}
+	flush()
+	return sections
+}
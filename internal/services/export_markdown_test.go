@@ -0,0 +1,152 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+)
+
+// readVault returns the files of a vault zip by name
+func readVault(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("not a zip: %v", err)
+	}
+	files := make(map[string]string)
+	for _, f := range archive.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(content)
+	}
+	return files
+}
+
+// writeVault zips files
+func writeVault(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestWordService_ExportMarkdown(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo := svc.repo.(*repository.SQLiteRepository)
+	svc.dictionary.WithCache(repo)
+
+	svc.fields.CreateField(ctx, &models.CustomField{Name: "notes", Label: "Notes", Type: models.FieldTypeText})
+	svc.fields.CreateField(ctx, &models.CustomField{Name: "cefr", Label: "CEFR", Type: models.FieldTypeText})
+
+	svc.Create(ctx, &models.CreateWordRequest{
+		Word: "ephemeral", Source: "The Book: Part 1", DateLearned: "2024-01-15",
+		PartOfSpeech: strPtr("adjective"), ExampleSentence: strPtr("An ephemeral joy"),
+		Tags: []string{"literature", "gre"}, CustomFields: map[string]string{"notes": "Seen twice.", "cefr": "C1"},
+	})
+	svc.Create(ctx, &models.CreateWordRequest{Word: "either/or", Source: "Article", DateLearned: "2024-02-20",
+		Tags: []string{"gre"}})
+	repo.SaveDefinition(ctx, "ephemeral", &models.DictionaryResponse{
+		Word:     "ephemeral",
+		Meanings: []models.Meaning{{PartOfSpeech: "adjective", Definitions: []models.Definition{{Definition: "lasting a very short time"}}}},
+	})
+
+	var buf bytes.Buffer
+	if err := svc.ExportMarkdown(ctx, &buf, models.WordFilter{}); err != nil {
+		t.Fatalf("ExportMarkdown() error = %v", err)
+	}
+	files := readVault(t, buf.Bytes())
+
+	want := "---\n" +
+		"word: ephemeral\n" +
+		"source: \"The Book: Part 1\"\n" +
+		"date_learned: 2024-01-15\n" +
+		"part_of_speech: adjective\n" +
+		"tags:\n  - literature\n  - gre\n" +
+		"cefr: C1\n" +
+		"---\n" +
+		"# ephemeral\n\n" +
+		"## Example\n\n> An ephemeral joy\n\n" +
+		"## Notes\n\nSeen twice.\n\n" +
+		"## Definition\n\n*adjective*\n\n1. lasting a very short time\n"
+	if got := files["Words/ephemeral.md"]; got != want {
+		t.Errorf("word note = %q, want %q", got, want)
+	}
+	if _, ok := files["Words/either-or.md"]; !ok {
+		t.Errorf("vault files = %v, want Words/either-or.md", vaultNames(files))
+	}
+	if got := files["Tags/gre.md"]; got != "# gre\n\n- [[Words/either-or|either/or]]\n- [[Words/ephemeral|ephemeral]]\n" {
+		t.Errorf("tag note = %q", got)
+	}
+	if got := files["Sources/The Book- Part 1.md"]; !strings.Contains(got, "[[Words/ephemeral|ephemeral]]") {
+		t.Errorf("source note = %q, want a link to ephemeral", got)
+	}
+	if index := files["Vocabulary.md"]; !strings.Contains(index, "- [[Sources/The Book- Part 1|The Book: Part 1]]") ||
+		!strings.Contains(index, "- [[Tags/literature|literature]]") {
+		t.Errorf("index note = %q, want links to sources and tags", index)
+	}
+
+	// Edits made in the vault are imported back over the existing words
+	files["Words/ephemeral.md"] = strings.NewReplacer("cefr: C1", "cefr: C2", "Seen twice.", "Seen three times.",
+		"  - gre\n", "  - gre\n  - \"#review\"\n").Replace(files["Words/ephemeral.md"])
+	files["Words/laconic.md"] = "---\nsource: Notebook\ndate_learned: 2024-03-01\n---\n# laconic\n"
+	files["Words/bad.md"] = "---\nword: bad\n"
+
+	result, err := svc.ImportMarkdown(ctx, bytes.NewReader(writeVault(t, files)), ConflictOverwrite)
+	if err != nil {
+		t.Fatalf("ImportMarkdown() error = %v", err)
+	}
+	if result.Imported != 1 || result.Updated != 2 || result.Skipped != 1 {
+		t.Errorf("ImportMarkdown() = %+v, want 1 imported, 2 updated and 1 skipped", result)
+	}
+
+	word, err := svc.repo.GetByWord(ctx, "ephemeral")
+	if err != nil {
+		t.Fatalf("GetByWord() error = %v", err)
+	}
+	if word.CustomFields["cefr"] != "C2" || word.CustomFields["notes"] != "Seen three times." ||
+		strings.Join(word.Tags, ",") != "literature,gre,review" || *word.ExampleSentence != "An ephemeral joy" ||
+		word.Source != "The Book: Part 1" {
+		t.Errorf("re-imported word = %+v, want the vault's edits", word)
+	}
+	if word, err := svc.repo.GetByWord(ctx, "laconic"); err != nil || word.Source != "Notebook" {
+		t.Errorf("new word from file name = %+v, %v", word, err)
+	}
+
+	if _, err := svc.ImportMarkdown(ctx, strings.NewReader("not a zip"), ConflictSkip); err == nil {
+		t.Error("ImportMarkdown() should reject files that are not zips")
+	}
+}
+
+// vaultNames returns the names of a vault's files
+func vaultNames(files map[string]string) []string {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	return names
+}
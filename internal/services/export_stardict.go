@@ -0,0 +1,75 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/stardict"
+)
+
+// stardictName is the title and file name of exported dictionaries
+const stardictName = "Vocabulator"
+
+// ExportStarDict writes the words matching filter as a zipped StarDict dictionary
+// for e-readers and dictionary programs. Each entry shows a word's cached
+// definition, or its part of speech, with its example sentence, notes and source.
+// Words with no definition, example or notes are left out; words never looked up
+// have no definition.
+func (s *WordService) ExportStarDict(ctx context.Context, w io.Writer, filter models.WordFilter) error {
+	definitions, err := s.dictionary.Cached(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch definitions: %w", err)
+	}
+
+	dict := &stardict.Dictionary{
+		Name:        stardictName,
+		Description: "Personal vocabulary exported from Vocabulator",
+	}
+	err = s.eachWord(ctx, filter, func(word *models.Word) error {
+		if entry := stardictEntry(word, definitions[strings.ToLower(word.Word)]); entry != "" {
+			dict.Entries = append(dict.Entries, stardict.Entry{Word: word.Word, Definition: entry})
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return stardict.WritePackage(w, dict)
+}
+
+// stardictEntry formats the dictionary entry of a word as HTML, or returns "" when
+// the word has nothing to show
+func stardictEntry(word *models.Word, definition *models.DictionaryResponse) string {
+	var b strings.Builder
+	hasContent := false
+	if text := definitionHTML(definition); text != "" {
+		b.WriteString(text)
+		hasContent = true
+	} else if word.PartOfSpeech != nil && *word.PartOfSpeech != "" {
+		// Definitions name their own parts of speech
+		fmt.Fprintf(&b, "<i>%s</i><br>", html.EscapeString(*word.PartOfSpeech))
+	}
+	if word.ExampleSentence != nil && *word.ExampleSentence != "" {
+		fmt.Fprintf(&b, "<p><i>%s</i></p>", multilineHTML(*word.ExampleSentence))
+		hasContent = true
+	}
+	if notes := word.CustomFields[NotesField]; notes != "" {
+		fmt.Fprintf(&b, "<p>%s</p>", multilineHTML(notes))
+		hasContent = true
+	}
+	if !hasContent {
+		return ""
+	}
+	fmt.Fprintf(&b, "<p><small>%s</small></p>", html.EscapeString(word.Source))
+	return b.String()
+}
+
+// multilineHTML escapes text for HTML, keeping its line breaks
+func multilineHTML(text string) string {
+	return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>")
+}
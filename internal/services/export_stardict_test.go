@@ -0,0 +1,65 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+)
+
+func TestWordService_ExportStarDict(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	repo := svc.repo.(*repository.SQLiteRepository)
+	svc.dictionary.WithCache(repo)
+	svc.fields.CreateField(ctx, &models.CustomField{Name: "notes", Label: "Notes", Type: models.FieldTypeText})
+
+	svc.Create(ctx, &models.CreateWordRequest{
+		Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15", PartOfSpeech: strPtr("adjective"),
+		ExampleSentence: strPtr("An <ephemeral> joy"),
+	})
+	svc.Create(ctx, &models.CreateWordRequest{Word: "laconic", Source: "Book", DateLearned: "2024-01-16",
+		PartOfSpeech: strPtr("adjective"), CustomFields: map[string]string{"notes": "Said of replies"}})
+	svc.Create(ctx, &models.CreateWordRequest{Word: "ubiquitous", Source: "Article", DateLearned: "2024-02-20"})
+	repo.SaveDefinition(ctx, "ephemeral", &models.DictionaryResponse{
+		Word:     "ephemeral",
+		Meanings: []models.Meaning{{PartOfSpeech: "adjective", Definitions: []models.Definition{{Definition: "lasting a very short time"}}}},
+	})
+
+	var buf bytes.Buffer
+	if err := svc.ExportStarDict(ctx, &buf, models.WordFilter{}); err != nil {
+		t.Fatalf("ExportStarDict() error = %v", err)
+	}
+	files := readVault(t, buf.Bytes())
+
+	if ifo := files["Vocabulator/Vocabulator.ifo"]; !strings.Contains(ifo, "wordcount=2\n") {
+		t.Errorf(".ifo = %q, want 2 words", ifo)
+	}
+
+	idx, dict := []byte(files["Vocabulator/Vocabulator.idx"]), files["Vocabulator/Vocabulator.dict"]
+	entries := make(map[string]string)
+	for len(idx) > 0 {
+		end := bytes.IndexByte(idx, 0)
+		offset, size := binary.BigEndian.Uint32(idx[end+1:]), binary.BigEndian.Uint32(idx[end+5:])
+		entries[string(idx[:end])] = dict[offset : offset+size]
+		idx = idx[end+9:]
+	}
+
+	want := "<i>adjective</i><ol><li>lasting a very short time</li></ol>" +
+		"<p><i>An &lt;ephemeral&gt; joy</i></p><p><small>Book</small></p>"
+	if entries["ephemeral"] != want {
+		t.Errorf("ephemeral entry = %q, want %q", entries["ephemeral"], want)
+	}
+	if want := "<i>adjective</i><br><p>Said of replies</p><p><small>Book</small></p>"; entries["laconic"] != want {
+		t.Errorf("laconic entry = %q, want %q", entries["laconic"], want)
+	}
+	if _, ok := entries["ubiquitous"]; ok {
+		t.Error("ExportStarDict() should leave out words with nothing to show")
+	}
+}
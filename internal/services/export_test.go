@@ -0,0 +1,114 @@
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+func TestWordService_Export(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	svc.fields.CreateField(ctx, &models.CustomField{Name: "cefr", Label: "CEFR", Type: models.FieldTypeText})
+	svc.fields.CreateField(ctx, &models.CustomField{Name: "notes", Label: "Notes", Type: models.FieldTypeText})
+
+	svc.Create(ctx, &models.CreateWordRequest{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15",
+		Tags: []string{"literature"}, CustomFields: map[string]string{"cefr": "C1", "notes": "short-lived"}})
+	svc.Create(ctx, &models.CreateWordRequest{Word: "laconic", Source: "Book", DateLearned: "2024-03-01",
+		ExampleSentence: strPtr("A laconic\treply")})
+	svc.Create(ctx, &models.CreateWordRequest{Word: "ubiquitous", Source: "Article", DateLearned: "2024-02-20"})
+
+	filter := models.WordFilter{Sources: []string{"Book"}, Sort: "word"}
+
+	var buf bytes.Buffer
+	err := svc.Export(ctx, &buf, "tsv", ExportOptions{Filter: filter, Fields: []string{"word", "cefr", "example_sentence", "word"}})
+	if err != nil {
+		t.Fatalf("Export() tsv error = %v", err)
+	}
+	want := "word\tcefr\texample_sentence\nephemeral\tC1\t\nlaconic\t\t\"A laconic\treply\"\n"
+	if buf.String() != want {
+		t.Errorf("Export() tsv = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := svc.Export(ctx, &buf, "json", ExportOptions{Filter: filter, Fields: []string{"word", "cefr"}}); err != nil {
+		t.Fatalf("Export() json error = %v", err)
+	}
+	var doc struct {
+		Fields []*models.CustomField `json:"fields"`
+		Words  []map[string]any      `json:"words"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("Export() json is not valid JSON: %v\n%s", err, buf.String())
+	}
+	if len(doc.Fields) != 1 || doc.Fields[0].Name != "cefr" {
+		t.Errorf("Export() json fields = %+v, want only cefr", doc.Fields)
+	}
+	if len(doc.Words) != 2 || len(doc.Words[0]) != 2 || doc.Words[0]["word"] != "ephemeral" ||
+		doc.Words[0]["custom_fields"].(map[string]any)["cefr"] != "C1" || len(doc.Words[1]) != 1 {
+		t.Errorf("Export() json words = %v, want ephemeral and laconic with only word and cefr", doc.Words)
+	}
+
+	// A filter matching nothing still writes a complete document
+	buf.Reset()
+	if err := svc.Export(ctx, &buf, "json", ExportOptions{Filter: models.WordFilter{Search: "nothing"}}); err != nil {
+		t.Fatalf("Export() empty json error = %v", err)
+	}
+	var empty models.ExportDocument
+	if err := json.Unmarshal(buf.Bytes(), &empty); err != nil || empty.Words == nil || len(empty.Words) != 0 {
+		t.Errorf("Export() empty json = %q, %v, want an empty words list", buf.String(), err)
+	}
+
+	buf.Reset()
+	err = svc.Export(ctx, &buf, "csv", ExportOptions{Fields: []string{"word", "rating", "snippet"}})
+	var errs validation.Errors
+	if !errors.As(err, &errs) || len(errs) != 2 || !errs.Has("fields") || buf.Len() != 0 {
+		t.Errorf("Export() unknown fields error = %v, output %q, want two errors for fields and no output", err, buf.String())
+	}
+	if err := svc.Export(ctx, &buf, "xml", ExportOptions{}); !errors.As(err, &errs) || !errs.Has("format") {
+		t.Errorf("Export() unknown format error = %v, want error for format", err)
+	}
+}
+
+func TestWordService_Export_Batches(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	total := exportBatchSize + 2
+	for i := 0; i < total; i++ {
+		_, err := svc.Create(ctx, &models.CreateWordRequest{Word: fmt.Sprintf("word%04d", i), Source: "Book", DateLearned: "2024-01-15"})
+		if err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := svc.Export(ctx, &buf, "ndjson", ExportOptions{Fields: []string{"word"}}); err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+
+	scanner := bufio.NewScanner(&buf)
+	scanner.Scan() // header
+	var words []string
+	for scanner.Scan() {
+		var word struct{ Word string }
+		if err := json.Unmarshal(scanner.Bytes(), &word); err != nil {
+			t.Fatalf("line %q: %v", scanner.Text(), err)
+		}
+		words = append(words, word.Word)
+	}
+	if len(words) != total || words[0] != "word0000" || words[total-1] != fmt.Sprintf("word%04d", total-1) {
+		t.Errorf("Export() wrote %d words from %q to %q, want %d oldest first", len(words), words[0],
+			words[len(words)-1], total)
+	}
+}
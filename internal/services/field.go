@@ -0,0 +1,128 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// FieldService provides business logic for custom field definitions
+type FieldService struct {
+	repo repository.FieldRepository
+}
+
+// NewFieldService creates a new field service
+func NewFieldService(repo repository.FieldRepository) *FieldService {
+	return &FieldService{repo: repo}
+}
+
+// Create defines a new custom field
+func (s *FieldService) Create(ctx context.Context, req *models.CreateFieldRequest) (*models.CustomField, error) {
+	field := &models.CustomField{
+		Name:    req.Name,
+		Label:   req.Label,
+		Type:    req.Type,
+		Options: req.Options,
+	}
+
+	validation.NormalizeField(field)
+	if err := validation.ValidateField(field); err != nil {
+		return nil, err
+	}
+
+	fields, err := s.repo.ListFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, existing := range fields {
+		if existing.Name == field.Name {
+			return nil, validation.Errors{{
+				Field:   "name",
+				Code:    validation.CodeDuplicate,
+				Message: fmt.Sprintf("field '%s' already exists", field.Name),
+			}}
+		}
+	}
+
+	return s.repo.CreateField(ctx, field)
+}
+
+// GetByID retrieves a custom field by ID
+func (s *FieldService) GetByID(ctx context.Context, id int64) (*models.CustomField, error) {
+	return s.repo.GetField(ctx, id)
+}
+
+// List retrieves all custom fields
+func (s *FieldService) List(ctx context.Context) ([]*models.CustomField, error) {
+	return s.repo.ListFields(ctx)
+}
+
+// Update changes the label or enum options of a custom field
+func (s *FieldService) Update(ctx context.Context, id int64, req *models.UpdateFieldRequest) (*models.CustomField, error) {
+	field, err := s.repo.GetField(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Label != nil {
+		field.Label = strings.TrimSpace(*req.Label)
+	}
+	if req.Options != nil {
+		field.Options = req.Options
+	}
+
+	validation.NormalizeField(field)
+	if err := validation.ValidateField(field); err != nil {
+		return nil, err
+	}
+
+	if field.Type == models.FieldTypeEnum && req.Options != nil {
+		if err := s.checkRemovedOptions(ctx, field); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.repo.UpdateField(ctx, field)
+}
+
+// checkRemovedOptions rejects new enum options that leave out a value words still
+// store, since those words could no longer be saved unchanged
+func (s *FieldService) checkRemovedOptions(ctx context.Context, field *models.CustomField) error {
+	counts, err := s.repo.CountFieldValues(ctx, field.ID)
+	if err != nil {
+		return err
+	}
+
+	kept := make(map[string]bool, len(field.Options))
+	for _, opt := range field.Options {
+		kept[opt] = true
+	}
+
+	var removed []string
+	for value := range counts {
+		if !kept[value] {
+			removed = append(removed, value)
+		}
+	}
+	if len(removed) == 0 {
+		return nil
+	}
+
+	sort.Strings(removed)
+	var errs validation.Errors
+	for _, value := range removed {
+		errs.Add("options", validation.CodeInUse,
+			fmt.Sprintf("option '%s' is still used by %d word(s)", value, counts[value]))
+	}
+	return errs
+}
+
+// Delete removes a custom field and all of its values
+func (s *FieldService) Delete(ctx context.Context, id int64) error {
+	return s.repo.DeleteField(ctx, id)
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+func TestFieldService_Create(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	fieldSvc := NewFieldService(svc.fields)
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		req     *models.CreateFieldRequest
+		wantErr bool
+	}{
+		{
+			name:    "text field",
+			req:     &models.CreateFieldRequest{Name: "translation", Type: models.FieldTypeText},
+			wantErr: false,
+		},
+		{
+			name:    "enum field",
+			req:     &models.CreateFieldRequest{Name: "cefr", Label: "CEFR Level", Type: models.FieldTypeEnum, Options: []string{"B2", "C1"}},
+			wantErr: false,
+		},
+		{
+			name:    "duplicate name",
+			req:     &models.CreateFieldRequest{Name: "Translation", Type: models.FieldTypeText},
+			wantErr: true,
+		},
+		{
+			name:    "enum without options",
+			req:     &models.CreateFieldRequest{Name: "domain", Type: models.FieldTypeEnum},
+			wantErr: true,
+		},
+		{
+			name:    "unknown type",
+			req:     &models.CreateFieldRequest{Name: "domain", Type: "color"},
+			wantErr: true,
+		},
+		{
+			name:    "reserved name",
+			req:     &models.CreateFieldRequest{Name: "source", Type: models.FieldTypeText},
+			wantErr: true,
+		},
+		{
+			name:    "invalid name",
+			req:     &models.CreateFieldRequest{Name: "my field", Type: models.FieldTypeText},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := fieldSvc.Create(ctx, tt.req)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFieldService_UpdateOptions(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	fieldSvc := NewFieldService(svc.fields)
+	ctx := context.Background()
+
+	field, _ := fieldSvc.Create(ctx, &models.CreateFieldRequest{Name: "cefr", Type: models.FieldTypeEnum, Options: []string{"B2", "C1", "C2"}})
+	svc.Create(ctx, &models.CreateWordRequest{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15", CustomFields: map[string]string{"cefr": "C1"}})
+
+	// Dropping an option no word uses is fine
+	if _, err := fieldSvc.Update(ctx, field.ID, &models.UpdateFieldRequest{Options: []string{"C1", "C2"}}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// Dropping one a word stores would strand that word's value
+	_, err := fieldSvc.Update(ctx, field.ID, &models.UpdateFieldRequest{Options: []string{"C2"}})
+	var verrs validation.Errors
+	if !errors.As(err, &verrs) || len(verrs) != 1 || verrs[0].Field != "options" || verrs[0].Code != validation.CodeInUse {
+		t.Fatalf("Update() error = %v, want options in_use", err)
+	}
+	got, _ := fieldSvc.GetByID(ctx, field.ID)
+	if strings.Join(got.Options, ",") != "C1,C2" {
+		t.Errorf("options after rejected Update() = %v, want [C1 C2]", got.Options)
+	}
+}
+
+func TestWordService_CustomFields(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	fieldSvc := NewFieldService(svc.fields)
+	ctx := context.Background()
+
+	fieldSvc.Create(ctx, &models.CreateFieldRequest{Name: "cefr", Type: models.FieldTypeEnum, Options: []string{"C1", "C2"}})
+	fieldSvc.Create(ctx, &models.CreateFieldRequest{Name: "frequency", Type: models.FieldTypeNumber})
+
+	tests := []struct {
+		name    string
+		fields  map[string]string
+		wantErr bool
+	}{
+		{name: "valid values", fields: map[string]string{"cefr": "C1", "frequency": "3.5"}, wantErr: false},
+		{name: "enum value not allowed", fields: map[string]string{"cefr": "A1"}, wantErr: true},
+		{name: "number not numeric", fields: map[string]string{"frequency": "often"}, wantErr: true},
+		{name: "unknown field", fields: map[string]string{"mood": "happy"}, wantErr: true},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.Create(ctx, &models.CreateWordRequest{
+				Word:         "word" + string(rune('a'+i)),
+				Source:       "Book",
+				DateLearned:  "2024-01-15",
+				CustomFields: tt.fields,
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Create() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	// An empty value in an update clears the field
+	created, _ := svc.repo.GetByWord(ctx, "worda")
+	updated, err := svc.Update(ctx, created.ID, &models.UpdateWordRequest{CustomFields: map[string]string{"frequency": ""}})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, ok := updated.CustomFields["frequency"]; ok || updated.CustomFields["cefr"] != "C1" {
+		t.Errorf("Update() custom fields = %v, want only cefr=C1", updated.CustomFields)
+	}
+}
+
+func TestWordService_CustomFieldsCSV(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	fieldSvc := NewFieldService(svc.fields)
+	ctx := context.Background()
+
+	fieldSvc.Create(ctx, &models.CreateFieldRequest{Name: "translation", Type: models.FieldTypeText})
+
+	csvData := `word,source,date_learned,translation
+ephemeral,Book,2024-01-15,flüchtig
+ubiquitous,Article,2024-02-20,`
+
+	result, err := svc.ImportCSV(ctx, strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("ImportCSV() error = %v", err)
+	}
+	if result.Imported != 2 {
+		t.Errorf("ImportCSV() imported = %d, want 2", result.Imported)
+	}
+
+	word, _ := svc.repo.GetByWord(ctx, "ephemeral")
+	if word.CustomFields["translation"] != "flüchtig" {
+		t.Errorf("ImportCSV() translation = %q, want flüchtig", word.CustomFields["translation"])
+	}
+
+	var buf bytes.Buffer
+	if err := svc.ExportCSV(ctx, &buf); err != nil {
+		t.Fatalf("ExportCSV() error = %v", err)
+	}
+	if !strings.HasPrefix(buf.String(), "word,source,date_learned,part_of_speech,example_sentence,tags,translation") {
+		t.Errorf("ExportCSV() header = %q", strings.SplitN(buf.String(), "\n", 2)[0])
+	}
+	if !strings.Contains(buf.String(), "flüchtig") {
+		t.Error("ExportCSV() missing custom field value")
+	}
+}
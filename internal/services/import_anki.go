@@ -0,0 +1,284 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/lehmann314159/vocabulator/internal/anki"
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// AnkiTargets are the word fields an Anki field can be mapped to, besides the
+// names of custom fields
+var AnkiTargets = []string{"word", "part_of_speech", "example_sentence", "source", "tags"}
+
+// DefaultAnkiSource is the source of imported words that have no mapped source field
+const DefaultAnkiSource = "Anki"
+
+// ankiFieldGuesses maps common Anki field names, lowercased with spaces and
+// punctuation removed, to word fields
+var ankiFieldGuesses = map[string]string{
+	"word": "word", "front": "word", "expression": "word", "vocab": "word", "vocabulary": "word", "term": "word",
+	"partofspeech": "part_of_speech", "pos": "part_of_speech",
+	"example": "example_sentence", "examplesentence": "example_sentence", "sentence": "example_sentence",
+	"source": "source",
+	"tags":   "tags",
+}
+
+// AnkiImportOptions configures an Anki import
+type AnkiImportOptions struct {
+	ImportOptions
+
+	// Mapping maps Anki field names to AnkiTargets or custom field names. Fields
+	// left out are not imported; an empty mapping is guessed from the field names.
+	Mapping map[string]string
+	Source  string // source of words with no mapped source field; defaults to DefaultAnkiSource
+}
+
+// AnkiNoteType describes a note type found in an Anki package
+type AnkiNoteType struct {
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+	Notes  int      `json:"notes"`
+}
+
+// AnkiPackageInfo describes the note types of an Anki package with a suggested mapping
+type AnkiPackageInfo struct {
+	NoteTypes []AnkiNoteType    `json:"note_types"`
+	Mapping   map[string]string `json:"mapping"`
+}
+
+// FieldNames returns the distinct field names of all note types, in order of appearance
+func (p *AnkiPackageInfo) FieldNames() []string {
+	var names []string
+	for _, noteType := range p.NoteTypes {
+		for _, name := range noteType.Fields {
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// InspectAnki lists the note types in an .apkg file and suggests a field mapping
+func (s *WordService) InspectAnki(ctx context.Context, r io.ReaderAt, size int64) (*AnkiPackageInfo, error) {
+	collection, err := anki.ReadPackage(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return &AnkiPackageInfo{
+		NoteTypes: ankiNoteTypes(collection),
+		Mapping:   guessAnkiMapping(collection, fields),
+	}, nil
+}
+
+// ImportAnki creates words from the notes of an .apkg file. Field values are
+// converted from HTML to text, Anki tags are added to the word's tags, and the
+// note's creation date becomes the date learned. Existing words are resolved
+// with the conflict strategy, comparing against the note's modification time.
+func (s *WordService) ImportAnki(ctx context.Context, r io.ReaderAt, size int64, opts AnkiImportOptions) (*ImportResult, error) {
+	plan, err := s.planAnki(ctx, r, size, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.runImport(ctx, plan)
+}
+
+// planAnki reads the notes of an Anki package through the field mapping
+func (s *WordService) planAnki(ctx context.Context, r io.ReaderAt, size int64, opts AnkiImportOptions) (*importPlan, error) {
+	collection, err := anki.ReadPackage(r, size)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mapping := opts.Mapping
+	if len(mapping) == 0 {
+		mapping = guessAnkiMapping(collection, fields)
+	}
+	if err := validateAnkiMapping(mapping, fields); err != nil {
+		return nil, err
+	}
+
+	source := strings.TrimSpace(opts.Source)
+	if source == "" {
+		source = DefaultAnkiSource
+	}
+
+	plan := &importPlan{fields: fields, restore: true}
+	plan.configure(opts.ImportOptions)
+	for i, note := range collection.Notes {
+		label := fmt.Sprintf("note %d", i+1)
+
+		model, ok := collection.Model(note.ModelID)
+		if !ok {
+			plan.invalid(label, "unknown note type")
+			continue
+		}
+
+		word := ankiWord(note, model, mapping, source)
+		if word.Word == "" {
+			plan.invalid(label, "no value for word")
+			continue
+		}
+		plan.add(fmt.Sprintf("%s (%s)", label, word.Word), word)
+	}
+
+	return plan, nil
+}
+
+// ankiWord builds a word from a note using the field mapping
+func ankiWord(note anki.Note, model anki.Model, mapping map[string]string, source string) *models.Word {
+	word := &models.Word{
+		Source:      source,
+		DateLearned: note.Created.Format(validation.DateFormat),
+		Tags:        note.Tags,
+		CreatedAt:   note.Created,
+		UpdatedAt:   note.Modified,
+	}
+
+	for i, value := range note.Fields {
+		if i >= len(model.Fields) {
+			break
+		}
+		text := anki.Text(value)
+		if text == "" {
+			continue
+		}
+
+		switch target := mapping[model.Fields[i]]; target {
+		case "":
+		case "word":
+			if word.Word == "" {
+				word.Word = text
+			}
+		case "part_of_speech":
+			word.PartOfSpeech = &text
+		case "example_sentence":
+			word.ExampleSentence = &text
+		case "source":
+			word.Source = text
+		case "tags":
+			word.Tags = append(slices.Clone(word.Tags), strings.Split(text, ",")...)
+		default:
+			if word.CustomFields == nil {
+				word.CustomFields = make(map[string]string)
+			}
+			word.CustomFields[target] = text
+		}
+	}
+
+	return word
+}
+
+// ankiNoteTypes lists the note types of a collection with their note counts,
+// leaving out note types without notes
+func ankiNoteTypes(collection *anki.Collection) []AnkiNoteType {
+	counts := make(map[int64]int)
+	for _, note := range collection.Notes {
+		counts[note.ModelID]++
+	}
+
+	noteTypes := []AnkiNoteType{}
+	for _, model := range collection.Models {
+		if counts[model.ID] > 0 {
+			noteTypes = append(noteTypes, AnkiNoteType{Name: model.Name, Fields: model.Fields, Notes: counts[model.ID]})
+		}
+	}
+	return noteTypes
+}
+
+// guessAnkiMapping maps Anki fields to word fields or custom fields with the same
+// name. Note types with no field recognized as the word get their first field.
+func guessAnkiMapping(collection *anki.Collection, fields []*models.CustomField) map[string]string {
+	mapping := make(map[string]string)
+	for _, noteType := range ankiNoteTypes(collection) {
+		hasWord := false
+		for _, name := range noteType.Fields {
+			key := ankiFieldKey(name)
+			target, ok := ankiFieldGuesses[key]
+			if !ok {
+				for _, field := range fields {
+					if ankiFieldKey(field.Name) == key {
+						target = field.Name
+					}
+				}
+			}
+			if target != "" {
+				mapping[name] = target
+			}
+			hasWord = hasWord || target == "word"
+		}
+
+		if !hasWord && len(noteType.Fields) > 0 {
+			mapping[noteType.Fields[0]] = "word"
+		}
+	}
+	return mapping
+}
+
+// ankiFieldKey lowercases a field name and drops everything but letters and digits
+func ankiFieldKey(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return -1
+	}, strings.ToLower(name))
+}
+
+// validateAnkiMapping checks that every mapped field targets a word field or an
+// existing custom field, and that some field provides the word itself
+func validateAnkiMapping(mapping map[string]string, fields []*models.CustomField) error {
+	names := make([]string, 0, len(mapping))
+	for name := range mapping {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var errs validation.Errors
+	hasWord := false
+	for _, name := range names {
+		target := mapping[name]
+		hasWord = hasWord || target == "word"
+		if target == "" || slices.Contains(AnkiTargets, target) || slices.ContainsFunc(fields, func(f *models.CustomField) bool {
+			return f.Name == target
+		}) {
+			continue
+		}
+		errs = append(errs, validation.FieldError{
+			Field:   "map." + name,
+			Code:    validation.CodeUnknownField,
+			Message: fmt.Sprintf("'%s' is not a word field or custom field", target),
+		})
+	}
+
+	if !hasWord {
+		errs = append(errs, validation.FieldError{
+			Field:   "mapping",
+			Code:    validation.CodeRequired,
+			Message: "one Anki field must be mapped to word",
+		})
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/anki"
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// testAnkiPackage writes a deck of basic Front/Back/Notes notes
+func testAnkiPackage(t *testing.T) *bytes.Reader {
+	t.Helper()
+
+	deck := &anki.Deck{
+		ID:   1,
+		Name: "GRE",
+		Model: anki.Model{
+			ID:     10,
+			Name:   "Basic",
+			Fields: []string{"Front", "Back", "Notes"},
+		},
+		Notes: []anki.Note{
+			{GUID: "a", Fields: []string{"<b>laconic</b>", "using few words", "from Laconia"}, Tags: []string{"gre", "Adjectives"}},
+			{GUID: "b", Fields: []string{"garrulous", "talkative", ""}, Tags: []string{"gre"}},
+			{GUID: "c", Fields: []string{"", "no front", ""}},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := anki.WritePackage(&buf, deck); err != nil {
+		t.Fatalf("WritePackage() error = %v", err)
+	}
+	return bytes.NewReader(buf.Bytes())
+}
+
+func TestWordService_InspectAnki(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	svc.fields.CreateField(ctx, &models.CustomField{Name: "notes", Label: "Notes", Type: models.FieldTypeText})
+
+	pkg := testAnkiPackage(t)
+	info, err := svc.InspectAnki(ctx, pkg, pkg.Size())
+	if err != nil {
+		t.Fatalf("InspectAnki() error = %v", err)
+	}
+
+	if len(info.NoteTypes) != 1 || info.NoteTypes[0].Name != "Basic" || info.NoteTypes[0].Notes != 3 {
+		t.Errorf("InspectAnki() note types = %+v, want Basic with 3 notes", info.NoteTypes)
+	}
+	want := map[string]string{"Front": "word", "Notes": "notes"}
+	if len(info.Mapping) != len(want) || info.Mapping["Front"] != "word" || info.Mapping["Notes"] != "notes" {
+		t.Errorf("InspectAnki() mapping = %v, want %v", info.Mapping, want)
+	}
+}
+
+func TestWordService_ImportAnki(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name         string
+		mapping      map[string]string
+		wantImported int
+		wantSkipped  int
+		wantField    string // field of the expected validation error
+	}{
+		{
+			name:         "explicit mapping",
+			mapping:      map[string]string{"Front": "word", "Back": "example_sentence", "Notes": "notes"},
+			wantImported: 2,
+			wantSkipped:  1,
+		},
+		{
+			name:         "guessed mapping",
+			wantImported: 2,
+			wantSkipped:  1,
+		},
+		{
+			name:      "unknown target",
+			mapping:   map[string]string{"Front": "word", "Back": "meaning"},
+			wantField: "map.Back",
+		},
+		{
+			name:      "no word field",
+			mapping:   map[string]string{"Back": "example_sentence"},
+			wantField: "mapping",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc, cleanup := setupTestService(t)
+			defer cleanup()
+
+			svc.fields.CreateField(ctx, &models.CustomField{Name: "notes", Label: "Notes", Type: models.FieldTypeText})
+
+			pkg := testAnkiPackage(t)
+			result, err := svc.ImportAnki(ctx, pkg, pkg.Size(), AnkiImportOptions{Mapping: tt.mapping})
+			if tt.wantField != "" {
+				var verrs validation.Errors
+				if !errors.As(err, &verrs) || !verrs.Has(tt.wantField) {
+					t.Errorf("ImportAnki() error = %v, want validation error on %s", err, tt.wantField)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ImportAnki() error = %v", err)
+			}
+			if result.Imported != tt.wantImported || result.Skipped != tt.wantSkipped {
+				t.Errorf("ImportAnki() = %+v, want %d imported, %d skipped", result, tt.wantImported, tt.wantSkipped)
+			}
+
+			word, err := svc.repo.GetByWord(ctx, "laconic")
+			if err != nil {
+				t.Fatalf("GetByWord() error = %v", err)
+			}
+			if word.Source != DefaultAnkiSource || word.CustomFields["notes"] != "from Laconia" {
+				t.Errorf("imported word = %+v", word)
+			}
+			if len(word.Tags) != 2 || word.Tags[1] != "adjectives" {
+				t.Errorf("imported tags = %v, want gre, adjectives", word.Tags)
+			}
+			if word.DateLearned != word.CreatedAt.Format(validation.DateFormat) {
+				t.Errorf("date learned = %s, want the note's creation date", word.DateLearned)
+			}
+		})
+	}
+}
+
+func TestWordService_ImportAnki_RoundTrip(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	svc.Create(ctx, &models.CreateWordRequest{
+		Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15",
+		PartOfSpeech: strPtr("adjective"), ExampleSentence: strPtr("Fame is ephemeral"),
+		Tags: []string{"literature"},
+	})
+
+	var buf bytes.Buffer
+	if err := svc.ExportAnki(ctx, &buf, models.WordFilter{}); err != nil {
+		t.Fatalf("ExportAnki() error = %v", err)
+	}
+
+	// Importing our own export into a fresh instance restores the words
+	dst, cleanup := setupTestService(t)
+	defer cleanup()
+
+	result, err := dst.ImportAnki(ctx, bytes.NewReader(buf.Bytes()), int64(buf.Len()), AnkiImportOptions{})
+	if err != nil {
+		t.Fatalf("ImportAnki() error = %v", err)
+	}
+	if result.Imported != 1 {
+		t.Fatalf("ImportAnki() = %+v, want 1 imported", result)
+	}
+
+	word, _ := dst.repo.GetByWord(ctx, "ephemeral")
+	if word.Source != "Book" || *word.PartOfSpeech != "adjective" || *word.ExampleSentence != "Fame is ephemeral" {
+		t.Errorf("round-tripped word = %+v", word)
+	}
+	if len(word.Tags) != 1 || word.Tags[0] != "literature" {
+		t.Errorf("round-tripped tags = %v, want literature", word.Tags)
+	}
+}
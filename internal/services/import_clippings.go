@@ -0,0 +1,202 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/lehmann314159/vocabulator/internal/kindle"
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// clippingsOrigin records Kindle highlights in the import log
+const clippingsOrigin = "clippings"
+
+// DefaultClippingWords is the longest highlight, in words, offered as a candidate
+// when no limit is given
+const DefaultClippingWords = 3
+
+// MaxClippingWords is the highest limit accepted for candidate highlights
+const MaxClippingWords = 10
+
+// ClippingLocationField is the text custom field that holds the location of an
+// imported highlight. It is created on the first import that needs it.
+const ClippingLocationField = "location"
+
+// ClippingCandidate is a short Kindle highlight offered for import as a word
+type ClippingCandidate struct {
+	Key         string `json:"key"` // identifies the highlight in the import log
+	Word        string `json:"word"`
+	Source      string `json:"source"`
+	Author      string `json:"author,omitempty"`
+	Page        string `json:"page,omitempty"`
+	Location    string `json:"location,omitempty"`
+	DateLearned string `json:"date_learned"`
+	Exists      bool   `json:"exists"`   // a word with this text already exists
+	Imported    bool   `json:"imported"` // the highlight was imported before
+}
+
+// ClippingCandidates reads a Kindle "My Clippings.txt" file and returns its
+// highlights of at most maxWords words as candidate words, for the user to review
+// before importing them with ImportClippings. Notes and bookmarks are left out,
+// and a highlight repeated in the file is offered once.
+func (s *WordService) ClippingCandidates(ctx context.Context, r io.Reader, maxWords int) ([]*ClippingCandidate, error) {
+	if maxWords < 1 || maxWords > MaxClippingWords {
+		return nil, validation.Errors{{
+			Field:   "max_words",
+			Code:    validation.CodeInvalidValue,
+			Message: fmt.Sprintf("must be between 1 and %d", MaxClippingWords),
+		}}
+	}
+
+	clippings, err := kindle.ParseClippings(r)
+	if err != nil {
+		return nil, err
+	}
+
+	imported, err := s.repo.ImportedKeys(ctx, clippingsOrigin)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []*ClippingCandidate{}
+	seen := make(map[string]bool)
+	for _, clipping := range clippings {
+		text := clippingText(clipping.Text)
+		if clipping.Kind != kindle.KindHighlight || text == "" || len(strings.Fields(text)) > maxWords {
+			continue
+		}
+		if seen[strings.ToLower(text)] {
+			continue
+		}
+		seen[strings.ToLower(text)] = true
+
+		candidate := clippingCandidate(clipping, text)
+		candidate.Imported = imported[candidate.Key]
+		if existing, _ := s.repo.GetByWord(ctx, text); existing != nil {
+			candidate.Exists = true
+		}
+		candidates = append(candidates, candidate)
+	}
+	return candidates, nil
+}
+
+// ImportClippings creates words from reviewed clipping candidates. The book title
+// becomes the source, the clipping date the date learned and the location the
+// value of the location custom field. Words that already exist are skipped.
+// Candidates are imported even if they were before, since the user picked them.
+func (s *WordService) ImportClippings(ctx context.Context, candidates []*ClippingCandidate) (*ImportResult, error) {
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &importPlan{fields: fields, restore: true, strategy: ConflictSkip}
+	location := clippingLocationField(candidates, fields)
+	if location != nil && !fieldListed(fields, location.Name) {
+		plan.fields = append(plan.fields, location)
+		plan.newFields = append(plan.newFields, location)
+	}
+
+	for i, candidate := range candidates {
+		word := &models.Word{
+			Word:         candidate.Word,
+			Source:       candidate.Source,
+			DateLearned:  candidate.DateLearned,
+			Tags:         []string{},
+			CustomFields: map[string]string{},
+		}
+		if word.Source == "" {
+			word.Source = DefaultKindleSource
+		}
+		if location != nil && candidate.Location != "" {
+			word.CustomFields[location.Name] = candidate.Location
+		}
+		plan.add(fmt.Sprintf("candidate %d (%s)", i+1, candidate.Word), word)
+	}
+
+	result, err := s.runImport(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []string
+	for i, row := range plan.rows {
+		if row.Status != RowInvalid && candidates[i].Key != "" {
+			done = append(done, candidates[i].Key)
+		}
+	}
+	if err := s.repo.MarkImported(ctx, clippingsOrigin, done); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// clippingLocationField returns the field that holds highlight locations, which
+// is new when a candidate has a location and the field does not exist yet. It
+// returns nil when no candidate has a location or a field of that name holds
+// something other than text.
+func clippingLocationField(candidates []*ClippingCandidate, fields []*models.CustomField) *models.CustomField {
+	for _, field := range fields {
+		if field.Name == ClippingLocationField {
+			if field.Type != models.FieldTypeText {
+				return nil
+			}
+			return field
+		}
+	}
+
+	for _, candidate := range candidates {
+		if candidate.Location != "" {
+			return &models.CustomField{Name: ClippingLocationField, Label: "Location", Type: models.FieldTypeText}
+		}
+	}
+	return nil
+}
+
+// fieldListed reports whether fields has a field of the given name
+func fieldListed(fields []*models.CustomField, name string) bool {
+	for _, field := range fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// clippingCandidate converts a highlight to a candidate word
+func clippingCandidate(clipping kindle.Clipping, text string) *ClippingCandidate {
+	candidate := &ClippingCandidate{
+		Word:     text,
+		Source:   clipping.Book,
+		Author:   clipping.Author,
+		Page:     clipping.Page,
+		Location: clipping.Location,
+	}
+
+	// A highlight is identified by where it is and what it says
+	position := clipping.Location
+	if position == "" {
+		position = "p" + clipping.Page
+	}
+	candidate.Key = fmt.Sprintf("%s|%s|%s", clipping.Book, position, strings.ToLower(text))
+
+	if clipping.Added.IsZero() {
+		candidate.DateLearned = validation.Today().Format(validation.DateFormat)
+	} else {
+		candidate.DateLearned = clipping.Added.Format(validation.DateFormat)
+	}
+	return candidate
+}
+
+// clippingText reduces a highlight to a single line without the punctuation
+// around it, so that "ephemeral," becomes "ephemeral"
+func clippingText(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.TrimFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
@@ -0,0 +1,126 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+// testClippings is a "My Clippings.txt" with three highlights, a note and a
+// highlight too long to be a word
+const testClippings = `Moby-Dick (Herman Melville)
+- Your Highlight on page 12 | Location 170-171 | Added on Sunday, March 3, 2024 10:15:32 PM
+
+leviathan,
+==========
+Moby-Dick (Herman Melville)
+- Your Note on page 12 | Location 171 | Added on Sunday, March 3, 2024 10:16:00 PM
+
+look up
+==========
+Moby-Dick (Herman Melville)
+- Your Highlight on page 40 | Location 610-612 | Added on Monday, March 4, 2024 09:00:00 AM
+
+Call me Ishmael. Some years ago, never mind how long precisely.
+==========
+Dune (Frank Herbert)
+- Your Highlight on page 5 | Location 77 | Added on Tuesday, March 5, 2024 08:00:00 PM
+
+“Kwisatz Haderach”
+==========
+Dune (Frank Herbert)
+- Your Highlight on page 6 | Location 80 | Added on Tuesday, March 5, 2024 08:05:00 PM
+
+Leviathan
+==========
+`
+
+func TestWordService_ClippingCandidates(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		maxWords int
+		want     []string
+		wantErr  bool
+	}{
+		{name: "single words", maxWords: 1, want: []string{"leviathan"}},
+		{name: "short phrases", maxWords: 3, want: []string{"leviathan", "Kwisatz Haderach"}},
+		{name: "too low", maxWords: 0, wantErr: true},
+		{name: "too high", maxWords: MaxClippingWords + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates, err := svc.ClippingCandidates(ctx, strings.NewReader(testClippings), tt.maxWords)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ClippingCandidates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var got []string
+			for _, candidate := range candidates {
+				got = append(got, candidate.Word)
+			}
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Errorf("ClippingCandidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordService_ImportClippings(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	candidates, err := svc.ClippingCandidates(ctx, strings.NewReader(testClippings), 3)
+	if err != nil {
+		t.Fatalf("ClippingCandidates() error = %v", err)
+	}
+	first := candidates[0]
+	if first.Source != "Moby-Dick" || first.Location != "170-171" || first.DateLearned != "2024-03-03" ||
+		first.Exists || first.Imported {
+		t.Errorf("ClippingCandidates()[0] = %+v", first)
+	}
+
+	// The user edits the second candidate before importing
+	candidates[1].Word = "kwisatz haderach"
+	result, err := svc.ImportClippings(ctx, candidates)
+	if err != nil {
+		t.Fatalf("ImportClippings() error = %v", err)
+	}
+	if result.Imported != 2 || result.Skipped != 0 {
+		t.Errorf("ImportClippings() = %+v, want 2 imported", result)
+	}
+
+	word, err := svc.repo.GetByWord(ctx, "leviathan")
+	if err != nil {
+		t.Fatalf("GetByWord() error = %v", err)
+	}
+	if word.Source != "Moby-Dick" || word.DateLearned != "2024-03-03" || word.CustomFields[ClippingLocationField] != "170-171" {
+		t.Errorf("imported word = %+v", word)
+	}
+	if _, err := svc.repo.GetByWord(ctx, "kwisatz haderach"); err != nil {
+		t.Errorf("GetByWord() edited candidate error = %v", err)
+	}
+
+	// Reading the file again flags what was imported
+	candidates, err = svc.ClippingCandidates(ctx, strings.NewReader(testClippings), 3)
+	if err != nil {
+		t.Fatalf("ClippingCandidates() error = %v", err)
+	}
+	for _, candidate := range candidates {
+		if !candidate.Imported {
+			t.Errorf("ClippingCandidates() re-read %q not flagged as imported", candidate.Word)
+		}
+	}
+	if !candidates[0].Exists {
+		t.Errorf("ClippingCandidates() re-read %q not flagged as existing", candidates[0].Word)
+	}
+}
@@ -0,0 +1,301 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+)
+
+// ErrImportQueueFull is returned when too many imports are waiting to run
+var ErrImportQueueFull = errors.New("too many imports are waiting to run; try again later")
+
+// importQueueSize bounds the imports waiting to run
+const importQueueSize = 16
+
+// ImportJobRetention is how long finished import jobs are kept
+const ImportJobRetention = 7 * 24 * time.Hour
+
+// importProgressInterval is how often the progress of a running job is saved
+const importProgressInterval = 2 * time.Second
+
+// interruptedJobError is recorded for jobs left unfinished by a restart, whose
+// uploads are gone
+const interruptedJobError = "the server restarted before the import finished; upload the file again"
+
+// ImportJobService runs imports in the background, one at a time, recording
+// their progress and outcome as import jobs
+type ImportJobService struct {
+	repo      repository.ImportJobRepository
+	words     *WordService
+	queue     chan *importTask
+	saveEvery time.Duration // how often the progress of a running job is saved
+
+	// running is the job being run and partial its result so far. They are kept in
+	// memory for polling and saved every saveEvery rather than on every row, since
+	// an all-or-nothing import holds the database while it writes.
+	mu      sync.Mutex
+	running *models.ImportJob
+	partial *ImportResult
+}
+
+// importTask is a queued import
+type importTask struct {
+	job     *models.ImportJob
+	run     func(ctx context.Context, progress ImportProgress) (*ImportResult, error)
+	cleanup func() // releases what the task holds, such as its upload
+}
+
+// NewImportJobService creates a new import job service; Start runs its jobs
+func NewImportJobService(repo repository.ImportJobRepository, words *WordService) *ImportJobService {
+	return &ImportJobService{
+		repo:      repo,
+		words:     words,
+		queue:     make(chan *importTask, importQueueSize),
+		saveEvery: importProgressInterval,
+	}
+}
+
+// Start marks the jobs a restart interrupted as failed, removes old finished
+// jobs and then runs queued imports in the background until ctx is done
+func (s *ImportJobService) Start(ctx context.Context) error {
+	if _, err := s.repo.FailUnfinishedImportJobs(ctx, interruptedJobError); err != nil {
+		return err
+	}
+	if err := s.repo.DeleteImportJobsBefore(ctx, time.Now().Add(-ImportJobRetention)); err != nil {
+		return err
+	}
+
+	go s.work(ctx)
+	return nil
+}
+
+// EnqueueImport copies an uploaded file aside and queues it to be imported like
+// WordService.Import, or like WordService.ImportAnki for the apkg format
+func (s *ImportJobService) EnqueueImport(ctx context.Context, r io.Reader, filename, format string, opts AnkiImportOptions) (*models.ImportJob, error) {
+	upload, err := os.CreateTemp("", "vocabulator-import-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+	cleanup := func() {
+		upload.Close()
+		os.Remove(upload.Name())
+	}
+
+	size, err := io.Copy(upload, r)
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+
+	run := func(ctx context.Context, progress ImportProgress) (*ImportResult, error) {
+		opts.Progress = progress
+		if format == "apkg" {
+			return s.words.ImportAnki(ctx, upload, size, opts)
+		}
+		if _, err := upload.Seek(0, io.SeekStart); err != nil {
+			return nil, fmt.Errorf("failed to read upload: %w", err)
+		}
+		return s.words.Import(ctx, upload, format, opts.ImportOptions)
+	}
+	return s.enqueue(ctx, &models.ImportJob{Filename: filename}, run, cleanup)
+}
+
+// EnqueueCommit queues the rows of a previewed import to be written like
+// WordService.CommitImport. The preview is taken at once, so it cannot be
+// confirmed twice.
+func (s *ImportJobService) EnqueueCommit(ctx context.Context, token string) (*models.ImportJob, error) {
+	plan, err := s.words.staging.take(token)
+	if err != nil {
+		return nil, err
+	}
+
+	run := func(ctx context.Context, progress ImportProgress) (*ImportResult, error) {
+		plan.progress = progress
+		return s.words.apply(ctx, plan)
+	}
+	return s.enqueue(ctx, &models.ImportJob{Total: len(plan.rows)}, run, func() {})
+}
+
+// Get retrieves an import job with its latest progress
+func (s *ImportJobService) Get(ctx context.Context, id int64) (*models.ImportJob, error) {
+	if job := s.snapshot(); job != nil && job.ID == id {
+		return job, nil
+	}
+	return s.repo.GetImportJob(ctx, id)
+}
+
+// snapshot copies the running job with its partial result, or returns nil if no
+// job is running
+func (s *ImportJobService) snapshot() *models.ImportJob {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.running == nil {
+		return nil
+	}
+	job := *s.running
+	if s.partial != nil {
+		job.Result, _ = json.Marshal(s.partial)
+	}
+	return &job
+}
+
+// ImportJobResult decodes the import result recorded for a job, or returns nil if
+// it has none yet
+func ImportJobResult(job *models.ImportJob) (*ImportResult, error) {
+	if len(job.Result) == 0 {
+		return nil, nil
+	}
+	var result ImportResult
+	if err := json.Unmarshal(job.Result, &result); err != nil {
+		return nil, fmt.Errorf("failed to read import result: %w", err)
+	}
+	return &result, nil
+}
+
+// enqueue records a new job and queues its task
+func (s *ImportJobService) enqueue(ctx context.Context, job *models.ImportJob,
+	run func(context.Context, ImportProgress) (*ImportResult, error), cleanup func()) (*models.ImportJob, error) {
+	job.Status = models.ImportJobQueued
+	if _, err := s.repo.CreateImportJob(ctx, job); err != nil {
+		cleanup()
+		return nil, err
+	}
+
+	queued := *job
+	select {
+	case s.queue <- &importTask{job: job, run: run, cleanup: cleanup}:
+		return &queued, nil
+	default:
+		cleanup()
+		s.finish(ctx, job, ErrImportQueueFull)
+		return nil, ErrImportQueueFull
+	}
+}
+
+// work runs queued tasks until ctx is done, then releases the tasks left waiting
+func (s *ImportJobService) work(ctx context.Context) {
+	for {
+		select {
+		case task := <-s.queue:
+			s.runTask(ctx, task)
+		case <-ctx.Done():
+			for {
+				select {
+				case task := <-s.queue:
+					task.cleanup()
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// runTask runs an import, keeping its progress in memory while it runs, saving it
+// periodically and recording its outcome
+func (s *ImportJobService) runTask(ctx context.Context, task *importTask) {
+	defer task.cleanup()
+
+	job := task.job
+	job.Status = models.ImportJobRunning
+	if err := s.repo.UpdateImportJob(ctx, job); err != nil {
+		log.Printf("Failed to start import job %d: %v", job.ID, err)
+	}
+
+	s.mu.Lock()
+	s.running = job
+	s.mu.Unlock()
+
+	stopSaving := make(chan struct{})
+	saved := make(chan struct{})
+	go func() {
+		defer close(saved)
+		s.saveProgress(ctx, stopSaving)
+	}()
+
+	result, err := task.run(ctx, func(processed, total int, result *ImportResult) {
+		// Rows already reported are not changed again, so the result can be
+		// shared without its later errors
+		partial := *result
+		partial.Errors = slices.Clip(result.Errors)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		job.Processed, job.Total = processed, total
+		s.partial = &partial
+	})
+
+	// No progress save may land after the outcome
+	close(stopSaving)
+	<-saved
+
+	// The job stays in memory until its outcome is saved, so polling never sees
+	// the row it replaces
+	s.mu.Lock()
+	conclude(job, result, err)
+	s.partial = nil
+	s.mu.Unlock()
+
+	// The outcome is recorded even when the server is shutting down
+	if err := s.repo.UpdateImportJob(context.WithoutCancel(ctx), job); err != nil {
+		log.Printf("Failed to record the outcome of import job %d: %v", job.ID, err)
+	}
+
+	s.mu.Lock()
+	s.running = nil
+	s.mu.Unlock()
+}
+
+// saveProgress writes the running job's progress every saveEvery until stop is
+// closed. Saving is best effort: while an all-or-nothing import holds the database
+// a save waits for it or fails, and a later save or the outcome catches up. Failures
+// are logged, since the stored progress goes stale until then.
+func (s *ImportJobService) saveProgress(ctx context.Context, stop <-chan struct{}) {
+	ticker := time.NewTicker(s.saveEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if job := s.snapshot(); job != nil {
+				if err := s.repo.UpdateImportJob(ctx, job); err != nil {
+					log.Printf("Failed to save the progress of import job %d: %v", job.ID, err)
+				}
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// finish records the outcome of a job that never ran
+func (s *ImportJobService) finish(ctx context.Context, job *models.ImportJob, err error) {
+	conclude(job, nil, err)
+	if err := s.repo.UpdateImportJob(ctx, job); err != nil {
+		log.Printf("Failed to record the outcome of import job %d: %v", job.ID, err)
+	}
+}
+
+// conclude sets the status and outcome of a finished job
+func conclude(job *models.ImportJob, result *ImportResult, err error) {
+	now := time.Now()
+	job.FinishedAt = &now
+	if err != nil {
+		job.Status, job.Error = models.ImportJobFailed, err.Error()
+		return
+	}
+	job.Status = models.ImportJobDone
+	job.Processed = job.Total
+	job.Result, _ = json.Marshal(result)
+}
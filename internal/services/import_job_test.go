@@ -0,0 +1,205 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+)
+
+// waitForImportJob polls a job until it finishes
+func waitForImportJob(t *testing.T, jobs *ImportJobService, id int64) *models.ImportJob {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, err := jobs.Get(context.Background(), id)
+		if err != nil {
+			t.Fatalf("Get() error = %v", err)
+		}
+		if job.Finished() {
+			return job
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not finish: %+v", job)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestImportJobService(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := svc.repo.(*repository.SQLiteRepository)
+	leftover, _ := repo.CreateImportJob(ctx, &models.ImportJob{Status: models.ImportJobRunning})
+
+	jobs := NewImportJobService(repo, svc)
+	if err := jobs.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// Jobs a restart interrupted are failed, as their uploads are gone
+	if job, _ := jobs.Get(ctx, leftover.ID); job.Status != models.ImportJobFailed || job.Error == "" {
+		t.Errorf("Get() of an interrupted job = %+v, want failed", job)
+	}
+
+	csvData := `word,source,date_learned
+ephemeral,Book,2024-01-15
+laconic,Book,2024-01-16
+`
+	opts := AnkiImportOptions{ImportOptions: ImportOptions{Conflict: ConflictSkip, AllOrNothing: true}}
+	job, err := jobs.EnqueueImport(ctx, strings.NewReader(csvData), "words.csv", "csv", opts)
+	if err != nil {
+		t.Fatalf("EnqueueImport() error = %v", err)
+	}
+	if job.Status != models.ImportJobQueued || job.Filename != "words.csv" {
+		t.Errorf("EnqueueImport() = %+v", job)
+	}
+
+	job = waitForImportJob(t, jobs, job.ID)
+	if job.Status != models.ImportJobDone || job.Total != 2 || job.Processed != 2 || job.FinishedAt == nil {
+		t.Errorf("finished job = %+v", job)
+	}
+	result, err := ImportJobResult(job)
+	if err != nil || result == nil || result.Imported != 2 {
+		t.Errorf("ImportJobResult() = %+v, %v, want 2 imported", result, err)
+	}
+	if _, err := svc.repo.GetByWord(ctx, "laconic"); err != nil {
+		t.Errorf("GetByWord() after import error = %v", err)
+	}
+
+	// A file that cannot be read fails its job
+	job, _ = jobs.EnqueueImport(ctx, strings.NewReader("not,a\nvalid,header\n"), "bad.csv", "csv", opts)
+	if job = waitForImportJob(t, jobs, job.ID); job.Status != models.ImportJobFailed || job.Error == "" {
+		t.Errorf("job for an unreadable file = %+v, want failed", job)
+	}
+}
+
+func TestImportJobService_EnqueueCommit(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	jobs := NewImportJobService(svc.repo.(*repository.SQLiteRepository), svc)
+	if err := jobs.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	csvData := `word,source,date_learned
+ephemeral,Book,2024-01-15
+laconic,Book,not a date
+`
+	preview, err := svc.PreviewImport(ctx, strings.NewReader(csvData), "csv", ImportOptions{Conflict: ConflictSkip})
+	if err != nil {
+		t.Fatalf("PreviewImport() error = %v", err)
+	}
+
+	job, err := jobs.EnqueueCommit(ctx, preview.Token)
+	if err != nil {
+		t.Fatalf("EnqueueCommit() error = %v", err)
+	}
+	if job.Total != 2 {
+		t.Errorf("EnqueueCommit() total = %d, want 2", job.Total)
+	}
+
+	// The preview is taken when the job is queued, so it cannot be confirmed twice
+	if _, err := jobs.EnqueueCommit(ctx, preview.Token); !errors.Is(err, ErrImportNotStaged) {
+		t.Errorf("EnqueueCommit() again error = %v, want ErrImportNotStaged", err)
+	}
+
+	job = waitForImportJob(t, jobs, job.ID)
+	result, _ := ImportJobResult(job)
+	if job.Status != models.ImportJobDone || result == nil || result.Imported != 1 || result.Skipped != 1 {
+		t.Errorf("finished job = %+v, result %+v", job, result)
+	}
+}
+
+func TestWordService_ImportProgress(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	csvData := `word,source,date_learned
+ephemeral,Book,2024-01-15
+laconic,Book,not a date
+ubiquitous,Article,2024-02-20
+`
+	var reports []int
+	opts := ImportOptions{
+		Conflict: ConflictSkip,
+		Progress: func(processed, total int, result *ImportResult) {
+			if total != 3 {
+				t.Errorf("Progress() total = %d, want 3", total)
+			}
+			reports = append(reports, processed)
+		},
+	}
+	if _, err := svc.Import(context.Background(), strings.NewReader(csvData), "csv", opts); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if want := []int{0, 1, 2, 3}; !slices.Equal(reports, want) {
+		t.Errorf("Progress() reports = %v, want %v", reports, want)
+	}
+}
+
+func TestImportJobService_SavesProgress(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	repo := svc.repo.(*repository.SQLiteRepository)
+	jobs := NewImportJobService(repo, svc)
+	jobs.saveEvery = 10 * time.Millisecond
+	if err := jobs.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// The task reports some progress, then waits until it has been saved
+	release := make(chan struct{})
+	run := func(ctx context.Context, progress ImportProgress) (*ImportResult, error) {
+		progress(3, 10, &ImportResult{Imported: 2, Skipped: 1, Errors: []string{"row 2: bad date"}})
+		<-release
+		return &ImportResult{Imported: 10}, nil
+	}
+	job, err := jobs.enqueue(ctx, &models.ImportJob{Filename: "words.csv"}, run, func() {})
+	if err != nil {
+		t.Fatalf("enqueue() error = %v", err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		saved, err := repo.GetImportJob(ctx, job.ID)
+		if err != nil {
+			t.Fatalf("GetImportJob() error = %v", err)
+		}
+		if saved.Processed == 3 {
+			result, _ := ImportJobResult(saved)
+			if saved.Status != models.ImportJobRunning || saved.Total != 10 || result == nil || len(result.Errors) != 1 {
+				t.Errorf("saved progress = %+v, result %+v", saved, result)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("progress was not saved: %+v", saved)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(release)
+	if job = waitForImportJob(t, jobs, job.ID); job.Status != models.ImportJobDone || job.Processed != 10 {
+		t.Errorf("finished job = %+v", job)
+	}
+}
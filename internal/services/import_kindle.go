@@ -0,0 +1,71 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/lehmann314159/vocabulator/internal/kindle"
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// kindleOrigin records Kindle lookups in the import log
+const kindleOrigin = "kindle"
+
+// DefaultKindleSource is the source of Kindle lookups with no book title
+const DefaultKindleSource = "Kindle"
+
+// ImportKindle creates words from a Kindle Vocabulary Builder database. Each word
+// is imported once, from its earliest lookup: the stem becomes the word, the book
+// title its source, the usage its example sentence and the lookup date its date
+// learned. Lookups imported before are skipped, even if their word was deleted
+// since, so the same vocab.db can be imported again to pick up new lookups.
+func (s *WordService) ImportKindle(ctx context.Context, r io.Reader) (*ImportResult, error) {
+	plan, err := s.planKindle(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return s.runImport(ctx, plan)
+}
+
+// planKindle reads the lookups of a vocab.db file, keyed in the import log
+func (s *WordService) planKindle(ctx context.Context, r io.Reader) (*importPlan, error) {
+	lookups, err := kindle.ReadVocab(r)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &importPlan{fields: fields, restore: true, strategy: ConflictSkip, origin: kindleOrigin}
+	for _, lookup := range lookups {
+		row := plan.add(fmt.Sprintf("%s (%s)", lookup.Key, lookup.Book), kindleWord(lookup))
+		row.key = lookup.Key
+	}
+	return plan, nil
+}
+
+// kindleWord converts a Kindle lookup to a word
+func kindleWord(lookup kindle.Lookup) *models.Word {
+	word := &models.Word{
+		Word:        lookup.Stem,
+		Source:      lookup.Book,
+		DateLearned: lookup.Time.Format(validation.DateFormat),
+		Tags:        []string{},
+	}
+	if word.Word == "" {
+		word.Word = lookup.Word
+	}
+	if word.Source == "" {
+		word.Source = DefaultKindleSource
+	}
+	if lookup.Usage != "" {
+		usage := lookup.Usage
+		word.ExampleSentence = &usage
+	}
+	return word
+}
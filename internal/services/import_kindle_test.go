@@ -0,0 +1,101 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// testVocabDB creates a Kindle vocab.db with the given lookups, one per word
+func testVocabDB(t *testing.T, lookups [][4]string) []byte {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "vocab.db")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE WORDS (id TEXT PRIMARY KEY NOT NULL, word TEXT, stem TEXT, lang TEXT,
+			category INTEGER DEFAULT 0, timestamp INTEGER DEFAULT 0, profileid TEXT);
+		CREATE TABLE LOOKUPS (id TEXT PRIMARY KEY NOT NULL, word_key TEXT, book_key TEXT, dict_key TEXT,
+			pos TEXT, usage TEXT, timestamp INTEGER DEFAULT 0);
+		CREATE TABLE BOOK_INFO (id TEXT PRIMARY KEY NOT NULL, asin TEXT, guid TEXT, lang TEXT,
+			title TEXT, authors TEXT);
+		INSERT INTO BOOK_INFO (id, title, authors) VALUES ('b1', 'Moby-Dick', 'Herman Melville');
+	`)
+	if err != nil {
+		t.Fatalf("failed to create vocab.db: %v", err)
+	}
+
+	// Each lookup is word, stem, usage and date
+	for i, lookup := range lookups {
+		date, _ := time.ParseInLocation("2006-01-02", lookup[3], time.Local)
+		key := "en:" + lookup[0]
+		db.Exec(`INSERT INTO WORDS (id, word, stem, lang) VALUES (?, ?, ?, 'en')`, key, lookup[0], lookup[1])
+		db.Exec(`INSERT INTO LOOKUPS (id, word_key, book_key, usage, timestamp) VALUES (?, ?, 'b1', ?, ?)`,
+			i, key, lookup[2], date.Add(12*time.Hour).UnixMilli())
+	}
+	db.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestWordService_ImportKindle(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	first := testVocabDB(t, [][4]string{
+		{"leviathans", "leviathan", "The leviathans swam past.", "2024-03-01"},
+		{"harpooneer", "harpooneer", "", "2024-03-02"},
+	})
+
+	result, err := svc.ImportKindle(ctx, bytes.NewReader(first))
+	if err != nil {
+		t.Fatalf("ImportKindle() error = %v", err)
+	}
+	if result.Imported != 2 || result.Skipped != 0 {
+		t.Errorf("ImportKindle() = %+v, want 2 imported", result)
+	}
+
+	word, err := svc.repo.GetByWord(ctx, "leviathan")
+	if err != nil {
+		t.Fatalf("GetByWord() error = %v", err)
+	}
+	if word.Source != "Moby-Dick" || word.DateLearned != "2024-03-01" ||
+		word.ExampleSentence == nil || *word.ExampleSentence != "The leviathans swam past." {
+		t.Errorf("imported word = %+v", word)
+	}
+
+	// A deleted word stays deleted when the same lookups are imported again, and
+	// only new lookups are added
+	svc.Delete(ctx, word.ID)
+	second := testVocabDB(t, [][4]string{
+		{"leviathans", "leviathan", "The leviathans swam past.", "2024-03-01"},
+		{"harpooneer", "harpooneer", "", "2024-03-02"},
+		{"cetology", "cetology", "A chapter on cetology.", "2024-04-10"},
+	})
+
+	result, err = svc.ImportKindle(ctx, bytes.NewReader(second))
+	if err != nil {
+		t.Fatalf("ImportKindle() error = %v", err)
+	}
+	if result.Imported != 1 || result.Skipped != 2 {
+		t.Errorf("ImportKindle() re-import = %+v, want 1 imported, 2 skipped", result)
+	}
+	if _, err := svc.repo.GetByWord(ctx, "leviathan"); err == nil {
+		t.Error("ImportKindle() re-import restored a deleted word")
+	}
+}
@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"io"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/kobo"
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// DefaultKoboSource is the source of Kobo words not looked up in a known book
+const DefaultKoboSource = "Kobo"
+
+// ImportKobo creates words from the word list of a Kobo KoboReader.sqlite
+// database. The book a word was looked up in becomes its source and the day it
+// was saved its date learned. Like ImportCSV, words that already exist are
+// skipped and reported.
+func (s *WordService) ImportKobo(ctx context.Context, r io.Reader) (*ImportResult, error) {
+	plan, err := s.planKobo(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return s.runImport(ctx, plan)
+}
+
+// planKobo reads the word list of a KoboReader.sqlite file
+func (s *WordService) planKobo(ctx context.Context, r io.Reader) (*importPlan, error) {
+	entries, err := kobo.ReadWordList(r)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &importPlan{fields: fields}
+	for _, entry := range entries {
+		plan.add(entry.Word, koboWord(entry))
+	}
+	return plan, nil
+}
+
+// koboWord converts a Kobo word list entry to a word
+func koboWord(entry kobo.Entry) *models.Word {
+	created := entry.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+
+	word := &models.Word{
+		Word:         entry.Word,
+		Source:       entry.Book,
+		DateLearned:  created.Local().Format(validation.DateFormat),
+		Tags:         []string{},
+		CustomFields: make(map[string]string),
+	}
+	if word.Source == "" {
+		word.Source = DefaultKoboSource
+	}
+	return word
+}
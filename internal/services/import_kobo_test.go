@@ -0,0 +1,81 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// testKoboDB creates a KoboReader.sqlite with a book and three saved words
+func testKoboDB(t *testing.T) []byte {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "KoboReader.sqlite")
+	db, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	_, err = db.Exec(`
+		CREATE TABLE content (ContentID TEXT NOT NULL, ContentType TEXT NOT NULL, MimeType TEXT NOT NULL,
+			Title TEXT, Attribution TEXT, PRIMARY KEY (ContentID));
+		CREATE TABLE WordList (Text TEXT NOT NULL, VolumeId TEXT NULL, DictSuffix TEXT NULL,
+			DateCreated TEXT NULL, PRIMARY KEY (Text));
+		INSERT INTO content VALUES ('dune-uuid', '6', 'application/x-kobo-epub+zip', 'Dune', 'Frank Herbert');
+		INSERT INTO WordList VALUES
+			('sietch', 'dune-uuid', '-en', '2024-03-05T12:00:00Z'),
+			('kanly', 'dune-uuid', '-en', '2024-03-06T12:00:00Z'),
+			('ephemeral', 'unknown-uuid', '-en', '2024-03-07T12:00:00Z');
+	`)
+	if err != nil {
+		t.Fatalf("failed to create KoboReader.sqlite: %v", err)
+	}
+	db.Close()
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return data
+}
+
+func TestWordService_ImportKobo(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	svc.Create(ctx, &models.CreateWordRequest{Word: "kanly", Source: "Earlier import", DateLearned: "2024-01-01"})
+
+	result, err := svc.ImportKobo(ctx, bytes.NewReader(testKoboDB(t)))
+	if err != nil {
+		t.Fatalf("ImportKobo() error = %v", err)
+	}
+	if result.Imported != 2 || result.Skipped != 1 || len(result.Errors) != 1 ||
+		!strings.Contains(result.Errors[0], "already exists") {
+		t.Errorf("ImportKobo() = %+v, want 2 imported and kanly skipped as existing", result)
+	}
+
+	word, err := svc.repo.GetByWord(ctx, "sietch")
+	if err != nil {
+		t.Fatalf("GetByWord() error = %v", err)
+	}
+	if word.Source != "Dune" || word.DateLearned != "2024-03-05" {
+		t.Errorf("imported word = %+v", word)
+	}
+
+	word, err = svc.repo.GetByWord(ctx, "ephemeral")
+	if err != nil {
+		t.Fatalf("GetByWord() error = %v", err)
+	}
+	if word.Source != DefaultKoboSource {
+		t.Errorf("imported word source = %q, want %q", word.Source, DefaultKoboSource)
+	}
+}
@@ -0,0 +1,525 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// ImportRowStatus is what importing a row of a file does
+type ImportRowStatus string
+
+// Import row statuses
+const (
+	RowNew       ImportRowStatus = "new"       // creates a word
+	RowUpdate    ImportRowStatus = "update"    // replaces an existing word under the conflict strategy
+	RowDuplicate ImportRowStatus = "duplicate" // skipped: the word exists or the row was imported before
+	RowInvalid   ImportRowStatus = "invalid"   // skipped: the row could not be read or failed validation
+)
+
+// ImportRow is a row of an import file with what importing it does and why
+type ImportRow struct {
+	Label  string          `json:"label"` // where the row is in the file, such as "line 3"
+	Word   *models.Word    `json:"word,omitempty"`
+	Status ImportRowStatus `json:"status"`
+	Reason string          `json:"reason,omitempty"`
+
+	key string // identifies the row in the import log
+}
+
+// importPlan holds the rows read from an import file until they are written
+type importPlan struct {
+	rows []*ImportRow
+
+	// fields are the custom fields rows are validated against, including the
+	// newFields that are created before any row is written
+	fields    []*models.CustomField
+	newFields []*models.CustomField
+
+	// restore keeps each word's timestamps; otherwise words are created anew.
+	// Words that already exist are resolved with strategy, skipping them by default.
+	restore  bool
+	strategy ConflictStrategy
+
+	// origin names the import log row keys are checked against and recorded in
+	origin string
+
+	// atomic writes the rows in one transaction, or none if any row fails
+	atomic bool
+
+	// progress is told about each row as it is written
+	progress ImportProgress
+}
+
+// configure applies import options to a plan; an empty conflict strategy keeps
+// the plan's own
+func (p *importPlan) configure(opts ImportOptions) {
+	if opts.Conflict != "" {
+		p.strategy = opts.Conflict
+	}
+	p.atomic = opts.AllOrNothing
+	p.progress = opts.Progress
+}
+
+// report tells the plan's progress callback, if any, how many rows are done
+func (p *importPlan) report(processed int, result *ImportResult) {
+	if p.progress != nil {
+		p.progress(processed, len(p.rows), result)
+	}
+}
+
+// add appends a row to be classified
+func (p *importPlan) add(label string, word *models.Word) *ImportRow {
+	row := &ImportRow{Label: label, Word: word}
+	p.rows = append(p.rows, row)
+	return row
+}
+
+// invalid appends a row that could not be read
+func (p *importPlan) invalid(label, reason string) {
+	p.rows = append(p.rows, &ImportRow{Label: label, Status: RowInvalid, Reason: reason})
+}
+
+// runImport classifies and writes a plan in one go
+func (s *WordService) runImport(ctx context.Context, plan *importPlan) (*ImportResult, error) {
+	if err := s.classify(ctx, plan); err != nil {
+		return nil, err
+	}
+	return s.apply(ctx, plan)
+}
+
+// classify decides what importing each row does without writing anything. Rows
+// conflict with existing words and with the words of earlier rows.
+func (s *WordService) classify(ctx context.Context, plan *importPlan) error {
+	var imported map[string]bool
+	if plan.origin != "" {
+		var err error
+		if imported, err = s.repo.ImportedKeys(ctx, plan.origin); err != nil {
+			return err
+		}
+	}
+
+	if plan.strategy == "" {
+		plan.strategy = ConflictSkip
+	}
+
+	planned := make(map[string]*models.Word)
+	now := time.Now()
+
+	for _, row := range plan.rows {
+		if row.Status == RowInvalid {
+			continue
+		}
+		if row.key != "" && imported[row.key] {
+			row.Status, row.Reason = RowDuplicate, "imported before"
+			continue
+		}
+
+		word := row.Word
+		if plan.restore {
+			// IDs belong to the exporting instance
+			word.ID = 0
+			word.Snippet = ""
+		}
+
+		validation.NormalizeWord(word)
+		if err := validation.ValidateWord(word, plan.fields); err != nil {
+			row.Status, row.Reason = RowInvalid, err.Error()
+			continue
+		}
+
+		if plan.restore {
+			if word.CreatedAt.IsZero() {
+				word.CreatedAt = now
+			}
+			if word.UpdatedAt.IsZero() {
+				word.UpdatedAt = word.CreatedAt
+			}
+		}
+
+		existing := planned[word.Word]
+		if existing == nil {
+			existing, _ = s.repo.GetByWord(ctx, word.Word)
+		}
+
+		result := word
+		switch {
+		case existing == nil:
+			row.Status = RowNew
+		case plan.strategy == ConflictSkip:
+			row.Status, row.Reason = RowDuplicate, fmt.Sprintf("word '%s' already exists", word.Word)
+		case plan.strategy == ConflictNewer && !word.UpdatedAt.After(existing.UpdatedAt):
+			row.Status, row.Reason = RowDuplicate, fmt.Sprintf("existing word '%s' is as new or newer", word.Word)
+		case plan.strategy == ConflictNewer:
+			row.Status, row.Reason = RowUpdate, fmt.Sprintf("newer than the existing word '%s'", word.Word)
+		case plan.strategy == ConflictMerge:
+			merged, changed := mergeWord(existing, word)
+			if !changed {
+				row.Status, row.Reason = RowDuplicate, fmt.Sprintf("existing word '%s' has nothing to add", word.Word)
+				break
+			}
+			if err := validation.ValidateWord(merged, plan.fields); err != nil {
+				row.Status, row.Reason = RowInvalid, fmt.Sprintf("merged with the existing word: %v", err)
+				break
+			}
+			row.Status, row.Reason = RowUpdate, fmt.Sprintf("merges into the existing word '%s'", word.Word)
+			result = merged
+		default:
+			row.Status, row.Reason = RowUpdate, fmt.Sprintf("overwrites the existing word '%s'", word.Word)
+		}
+
+		if row.Status == RowNew || row.Status == RowUpdate {
+			planned[word.Word] = result
+		}
+	}
+	return nil
+}
+
+// mergeWord returns a copy of an existing word with the tags it lacks and the
+// empty fields it has filled from an imported word, and whether anything changed.
+// The existing word's source and date learned are kept.
+func mergeWord(existing, imported *models.Word) (*models.Word, bool) {
+	merged := *existing
+	merged.Tags = slices.Clone(existing.Tags)
+	merged.CustomFields = maps.Clone(existing.CustomFields)
+	if merged.CustomFields == nil {
+		merged.CustomFields = make(map[string]string)
+	}
+	changed := false
+
+	for _, tag := range imported.Tags {
+		if !slices.Contains(merged.Tags, tag) {
+			merged.Tags = append(merged.Tags, tag)
+			changed = true
+		}
+	}
+	if (merged.PartOfSpeech == nil || *merged.PartOfSpeech == "") && imported.PartOfSpeech != nil && *imported.PartOfSpeech != "" {
+		merged.PartOfSpeech = imported.PartOfSpeech
+		changed = true
+	}
+	if (merged.ExampleSentence == nil || *merged.ExampleSentence == "") && imported.ExampleSentence != nil && *imported.ExampleSentence != "" {
+		merged.ExampleSentence = imported.ExampleSentence
+		changed = true
+	}
+	for name, value := range imported.CustomFields {
+		if merged.CustomFields[name] == "" && value != "" {
+			merged.CustomFields[name] = value
+			changed = true
+		}
+	}
+
+	if changed {
+		merged.UpdatedAt = time.Now()
+	}
+	return &merged, changed
+}
+
+// errImportFailed rolls back an all-or-nothing import with a failed row
+var errImportFailed = errors.New("import failed")
+
+// apply writes the new and updated rows of a classified plan, after creating its
+// new custom fields, and reports the outcome of every row. An atomic plan is
+// written in one transaction, which is rolled back if any row fails.
+func (s *WordService) apply(ctx context.Context, plan *importPlan) (*ImportResult, error) {
+	if !plan.atomic {
+		return s.applyTo(ctx, s.repo, s.fields, plan)
+	}
+
+	var result *ImportResult
+	err := s.repo.WithTx(ctx, func(tx repository.Tx) error {
+		var err error
+		if result, err = s.applyTo(ctx, tx, tx, plan); err != nil {
+			return err
+		}
+		if result.failed > 0 {
+			return errImportFailed
+		}
+		return nil
+	})
+	if errors.Is(err, errImportFailed) {
+		return &ImportResult{Skipped: len(plan.rows), Errors: result.Errors, RolledBack: true}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// applyTo writes a classified plan with the given repositories
+func (s *WordService) applyTo(ctx context.Context, words repository.WordRepository, fields repository.FieldRepository,
+	plan *importPlan) (*ImportResult, error) {
+	if err := createFields(ctx, fields, plan.newFields); err != nil {
+		return nil, err
+	}
+
+	result := &ImportResult{}
+	var done []string
+	for i, row := range plan.rows {
+		plan.report(i, result)
+		switch row.Status {
+		case RowInvalid:
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", row.Label, row.Reason))
+			result.Skipped++
+			result.failed++
+			continue
+		case RowDuplicate:
+			// Restoring an export over itself skips existing words silently
+			if !plan.restore {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %s", row.Label, row.Reason))
+			}
+			result.Skipped++
+		default:
+			updated, err := writeRow(ctx, words, plan, row)
+			if err != nil {
+				result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", row.Label, err))
+				result.Skipped++
+				result.failed++
+				continue
+			}
+			if updated {
+				result.Updated++
+			} else {
+				result.Imported++
+			}
+		}
+
+		if row.key != "" {
+			done = append(done, row.key)
+		}
+	}
+
+	if plan.origin != "" {
+		if err := words.MarkImported(ctx, plan.origin, done); err != nil {
+			return nil, err
+		}
+	}
+	plan.report(len(plan.rows), result)
+	return result, nil
+}
+
+// writeRow writes a new or updated row, reporting whether it replaced an existing
+// word. Words may have changed since the plan was classified: a new row whose word
+// now exists is rejected rather than overwriting it, and merges are made with the
+// word as it is now.
+func writeRow(ctx context.Context, words repository.WordRepository, plan *importPlan, row *ImportRow) (bool, error) {
+	existing, _ := words.GetByWord(ctx, row.Word.Word)
+	if existing != nil && row.Status == RowNew {
+		return false, fmt.Errorf("word '%s' already exists", row.Word.Word)
+	}
+
+	if existing == nil {
+		if !plan.restore {
+			_, err := words.Create(ctx, row.Word)
+			return false, err
+		}
+		row.Word.ID = 0
+		_, err := words.Restore(ctx, row.Word)
+		return false, err
+	}
+
+	word := row.Word
+	switch {
+	case plan.strategy == ConflictMerge:
+		word, _ = mergeWord(existing, row.Word)
+	case !plan.restore:
+		// Rows without timestamps keep the existing word's creation time
+		word.CreatedAt, word.UpdatedAt = existing.CreatedAt, time.Now()
+	}
+	word.ID = existing.ID
+
+	if _, err := words.Restore(ctx, word); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// createFields creates the given custom field definitions, leaving out any that
+// exist by now
+func createFields(ctx context.Context, repo repository.FieldRepository, fields []*models.CustomField) error {
+	if len(fields) == 0 {
+		return nil
+	}
+
+	existing, err := repo.ListFields(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range fields {
+		if fieldListed(existing, field.Name) {
+			continue
+		}
+		if _, err := repo.CreateField(ctx, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ErrImportNotStaged is returned when confirming a preview that does not exist,
+// has expired or was already confirmed
+var ErrImportNotStaged = errors.New("import preview not found or expired")
+
+// ImportStagingTTL is how long a previewed import can be confirmed
+const ImportStagingTTL = 30 * time.Minute
+
+// maxStagedImports bounds the previews kept in memory; the oldest go first
+const maxStagedImports = 16
+
+// ImportPreview lists what importing a file would do, row by row. Confirming it
+// with its token writes exactly these rows.
+type ImportPreview struct {
+	Token     string       `json:"token"`
+	ExpiresAt time.Time    `json:"expires_at"`
+	New       int          `json:"new"`
+	Update    int          `json:"update"`
+	Duplicate int          `json:"duplicate"`
+	Invalid   int          `json:"invalid"`
+	NewFields []string     `json:"new_fields,omitempty"` // custom fields the import creates
+	Rows      []*ImportRow `json:"rows"`
+}
+
+// stagedImport is a classified plan waiting to be confirmed
+type stagedImport struct {
+	plan    *importPlan
+	expires time.Time
+}
+
+// importStaging keeps previewed imports in memory until they are confirmed
+type importStaging struct {
+	mu      sync.Mutex
+	imports map[string]*stagedImport
+}
+
+// PreviewImport reads a file like Import but only classifies its rows, staging
+// them to be written by CommitImport with the same options
+func (s *WordService) PreviewImport(ctx context.Context, r io.Reader, format string, opts ImportOptions) (*ImportPreview, error) {
+	plan, err := s.planImport(ctx, r, format, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.preview(ctx, plan)
+}
+
+// PreviewAnki reads an Anki package like ImportAnki but only classifies its
+// notes, staging them to be written by CommitImport
+func (s *WordService) PreviewAnki(ctx context.Context, r io.ReaderAt, size int64, opts AnkiImportOptions) (*ImportPreview, error) {
+	plan, err := s.planAnki(ctx, r, size, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.preview(ctx, plan)
+}
+
+// CommitImport writes the rows of a previewed import. A preview can be confirmed
+// once.
+func (s *WordService) CommitImport(ctx context.Context, token string) (*ImportResult, error) {
+	plan, err := s.staging.take(token)
+	if err != nil {
+		return nil, err
+	}
+	return s.apply(ctx, plan)
+}
+
+// preview classifies a plan and stages it under a new token
+func (s *WordService) preview(ctx context.Context, plan *importPlan) (*ImportPreview, error) {
+	if err := s.classify(ctx, plan); err != nil {
+		return nil, err
+	}
+
+	token, err := stagingToken()
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &ImportPreview{
+		Token:     token,
+		ExpiresAt: time.Now().Add(ImportStagingTTL),
+		Rows:      plan.rows,
+	}
+	for _, row := range plan.rows {
+		switch row.Status {
+		case RowNew:
+			preview.New++
+		case RowUpdate:
+			preview.Update++
+		case RowDuplicate:
+			preview.Duplicate++
+		case RowInvalid:
+			preview.Invalid++
+		}
+	}
+	for _, field := range plan.newFields {
+		preview.NewFields = append(preview.NewFields, field.Name)
+	}
+
+	s.staging.add(token, &stagedImport{plan: plan, expires: preview.ExpiresAt})
+	return preview, nil
+}
+
+// add stores a staged import, dropping expired ones and, past the limit, the
+// oldest
+func (st *importStaging) add(token string, staged *stagedImport) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if st.imports == nil {
+		st.imports = make(map[string]*stagedImport)
+	}
+
+	now := time.Now()
+	for t, other := range st.imports {
+		if now.After(other.expires) {
+			delete(st.imports, t)
+		}
+	}
+
+	if len(st.imports) >= maxStagedImports {
+		tokens := make([]string, 0, len(st.imports))
+		for t := range st.imports {
+			tokens = append(tokens, t)
+		}
+		sort.Slice(tokens, func(i, j int) bool {
+			return st.imports[tokens[i]].expires.Before(st.imports[tokens[j]].expires)
+		})
+		for _, t := range tokens[:len(tokens)-maxStagedImports+1] {
+			delete(st.imports, t)
+		}
+	}
+
+	st.imports[token] = staged
+}
+
+// take removes a staged import and returns its plan, which can then be written once
+func (st *importStaging) take(token string) (*importPlan, error) {
+	st.mu.Lock()
+	staged := st.imports[token]
+	delete(st.imports, token)
+	st.mu.Unlock()
+
+	if staged == nil || time.Now().After(staged.expires) {
+		return nil, ErrImportNotStaged
+	}
+	return staged.plan, nil
+}
+
+// stagingToken returns a random token for a staged import
+func stagingToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+func TestWordService_PreviewImport(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	svc.Create(ctx, &models.CreateWordRequest{Word: "ubiquitous", Source: "Article", DateLearned: "2024-02-20"})
+
+	csvData := `word,source,date_learned
+ephemeral,Book,2024-01-15
+ubiquitous,Article,2024-02-20
+laconic,Book,not a date
+ephemeral,Podcast,2024-03-01
+`
+	preview, err := svc.PreviewImport(ctx, strings.NewReader(csvData), "csv", ImportOptions{Conflict: ConflictSkip})
+	if err != nil {
+		t.Fatalf("PreviewImport() error = %v", err)
+	}
+
+	want := []ImportRowStatus{RowNew, RowDuplicate, RowInvalid, RowDuplicate}
+	if len(preview.Rows) != len(want) {
+		t.Fatalf("PreviewImport() = %d rows, want %d", len(preview.Rows), len(want))
+	}
+	for i, row := range preview.Rows {
+		if row.Status != want[i] {
+			t.Errorf("row %d (%s) status = %s, want %s", i, row.Label, row.Status, want[i])
+		}
+		if row.Status != RowNew && row.Reason == "" {
+			t.Errorf("row %d (%s) has no reason", i, row.Label)
+		}
+	}
+	if preview.New != 1 || preview.Duplicate != 2 || preview.Invalid != 1 || preview.Token == "" {
+		t.Errorf("PreviewImport() counts = %+v", preview)
+	}
+
+	// Nothing is written until the preview is confirmed
+	if _, err := svc.repo.GetByWord(ctx, "ephemeral"); err == nil {
+		t.Fatal("PreviewImport() wrote a word")
+	}
+
+	result, err := svc.CommitImport(ctx, preview.Token)
+	if err != nil {
+		t.Fatalf("CommitImport() error = %v", err)
+	}
+	if result.Imported != 1 || result.Skipped != 3 {
+		t.Errorf("CommitImport() = %+v, want 1 imported, 3 skipped", result)
+	}
+	word, err := svc.repo.GetByWord(ctx, "ephemeral")
+	if err != nil || word.Source != "Book" {
+		t.Errorf("committed word = %+v, %v", word, err)
+	}
+
+	if _, err := svc.CommitImport(ctx, preview.Token); !errors.Is(err, ErrImportNotStaged) {
+		t.Errorf("CommitImport() again error = %v, want ErrImportNotStaged", err)
+	}
+	if _, err := svc.CommitImport(ctx, "unknown"); !errors.Is(err, ErrImportNotStaged) {
+		t.Errorf("CommitImport() unknown token error = %v, want ErrImportNotStaged", err)
+	}
+}
+
+func TestWordService_PreviewImport_Update(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	svc.Create(ctx, &models.CreateWordRequest{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15"})
+
+	doc := `{"schema_version": 1, "fields": [{"name": "cefr", "label": "CEFR", "type": "text"}],
+		"words": [
+			{"word": "ephemeral", "source": "Podcast", "date_learned": "2024-01-15"},
+			{"word": "laconic", "source": "Book", "date_learned": "2024-02-01", "custom_fields": {"cefr": "C1"}}
+		]}`
+	preview, err := svc.PreviewImport(ctx, strings.NewReader(doc), "json", ImportOptions{Conflict: ConflictOverwrite})
+	if err != nil {
+		t.Fatalf("PreviewImport() error = %v", err)
+	}
+	if preview.Update != 1 || preview.New != 1 || len(preview.NewFields) != 1 || preview.NewFields[0] != "cefr" {
+		t.Errorf("PreviewImport() = %+v, want 1 update, 1 new and the cefr field", preview)
+	}
+
+	// The preview creates neither words nor fields
+	fields, _ := svc.fields.ListFields(ctx)
+	if len(fields) != 0 {
+		t.Errorf("PreviewImport() created %d fields", len(fields))
+	}
+
+	// A word added after the preview is not overwritten by a row previewed as new
+	svc.Create(ctx, &models.CreateWordRequest{Word: "laconic", Source: "Manual", DateLearned: "2024-03-01"})
+
+	result, err := svc.CommitImport(ctx, preview.Token)
+	if err != nil {
+		t.Fatalf("CommitImport() error = %v", err)
+	}
+	if result.Updated != 1 || result.Imported != 0 || result.Skipped != 1 {
+		t.Errorf("CommitImport() = %+v, want 1 updated, 1 skipped", result)
+	}
+	if word, _ := svc.repo.GetByWord(ctx, "ephemeral"); word == nil || word.Source != "Podcast" {
+		t.Errorf("updated word = %+v", word)
+	}
+	if word, _ := svc.repo.GetByWord(ctx, "laconic"); word == nil || word.Source != "Manual" {
+		t.Errorf("word added after the preview = %+v", word)
+	}
+}
+
+func TestWordService_Import_Merge(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	pos := "adjective"
+	svc.Create(ctx, &models.CreateWordRequest{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15",
+		PartOfSpeech: &pos, Tags: []string{"gre"}})
+	svc.Create(ctx, &models.CreateWordRequest{Word: "laconic", Source: "Book", DateLearned: "2024-02-01",
+		Tags: []string{"gre"}})
+
+	csvData := `word,source,date_learned,part_of_speech,example_sentence,tags
+ephemeral,Podcast,2024-03-01,noun,Fame is ephemeral.,"gre,literary"
+laconic,Podcast,2024-03-01,,,gre
+`
+	result, err := svc.Import(ctx, strings.NewReader(csvData), "csv", ImportOptions{Conflict: ConflictMerge})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Updated != 1 || result.Skipped != 1 {
+		t.Errorf("Import() = %+v, want 1 updated, 1 skipped", result)
+	}
+
+	word, err := svc.repo.GetByWord(ctx, "ephemeral")
+	if err != nil {
+		t.Fatalf("GetByWord() error = %v", err)
+	}
+	if word.Source != "Book" || word.DateLearned != "2024-01-15" {
+		t.Errorf("merged word source = %q, date = %q, want the existing ones", word.Source, word.DateLearned)
+	}
+	if *word.PartOfSpeech != "adjective" {
+		t.Errorf("merged part of speech = %q, want the existing one", *word.PartOfSpeech)
+	}
+	if word.ExampleSentence == nil || *word.ExampleSentence != "Fame is ephemeral." {
+		t.Errorf("merged example sentence = %v, want the imported one", word.ExampleSentence)
+	}
+	if strings.Join(word.Tags, ",") != "gre,literary" {
+		t.Errorf("merged tags = %v, want [gre literary]", word.Tags)
+	}
+}
+
+func TestWordService_Import_AllOrNothing(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	svc.Create(ctx, &models.CreateWordRequest{Word: "ubiquitous", Source: "Article", DateLearned: "2024-02-20"})
+
+	csvData := `word,source,date_learned
+ephemeral,Book,2024-01-15
+ubiquitous,Podcast,2024-03-01
+laconic,Book,not a date
+`
+	opts := ImportOptions{Conflict: ConflictOverwrite, AllOrNothing: true}
+	result, err := svc.Import(ctx, strings.NewReader(csvData), "csv", opts)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if !result.RolledBack || result.Imported != 0 || result.Updated != 0 || result.Skipped != 3 || len(result.Errors) != 1 {
+		t.Errorf("Import() = %+v, want a rollback with 1 error", result)
+	}
+	if _, err := svc.repo.GetByWord(ctx, "ephemeral"); err == nil {
+		t.Error("rolled back import created a word")
+	}
+	if word, _ := svc.repo.GetByWord(ctx, "ubiquitous"); word == nil || word.Source != "Article" {
+		t.Errorf("rolled back import changed a word: %+v", word)
+	}
+
+	// Without the failing row the import is written in full
+	csvData = strings.Replace(csvData, "laconic,Book,not a date\n", "", 1)
+	result, err = svc.Import(ctx, strings.NewReader(csvData), "csv", opts)
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.RolledBack || result.Imported != 1 || result.Updated != 1 {
+		t.Errorf("Import() = %+v, want 1 imported, 1 updated", result)
+	}
+	word, _ := svc.repo.GetByWord(ctx, "ubiquitous")
+	if word == nil || word.Source != "Podcast" || word.CreatedAt.IsZero() {
+		t.Errorf("overwritten word = %+v", word)
+	}
+}
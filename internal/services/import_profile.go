@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// ImportProfileService provides business logic for CSV import profiles
+type ImportProfileService struct {
+	repo   repository.ImportProfileRepository
+	fields repository.FieldRepository
+}
+
+// NewImportProfileService creates a new import profile service
+func NewImportProfileService(repo repository.ImportProfileRepository, fields repository.FieldRepository) *ImportProfileService {
+	return &ImportProfileService{repo: repo, fields: fields}
+}
+
+// Create saves a named import profile after checking its settings
+func (s *ImportProfileService) Create(ctx context.Context, req *models.ImportProfileRequest) (*models.ImportProfile, error) {
+	profile := &models.ImportProfile{}
+	applyProfileRequest(profile, req)
+
+	if err := s.validate(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	return s.repo.CreateImportProfile(ctx, profile)
+}
+
+// GetByID retrieves an import profile
+func (s *ImportProfileService) GetByID(ctx context.Context, id int64) (*models.ImportProfile, error) {
+	return s.repo.GetImportProfile(ctx, id)
+}
+
+// List retrieves all import profiles
+func (s *ImportProfileService) List(ctx context.Context) ([]*models.ImportProfile, error) {
+	return s.repo.ListImportProfiles(ctx)
+}
+
+// Update replaces the name and settings of an import profile
+func (s *ImportProfileService) Update(ctx context.Context, id int64, req *models.ImportProfileRequest) (*models.ImportProfile, error) {
+	profile, err := s.repo.GetImportProfile(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	applyProfileRequest(profile, req)
+
+	if err := s.validate(ctx, profile); err != nil {
+		return nil, err
+	}
+
+	return s.repo.UpdateImportProfile(ctx, profile)
+}
+
+// Delete removes an import profile
+func (s *ImportProfileService) Delete(ctx context.Context, id int64) error {
+	return s.repo.DeleteImportProfile(ctx, id)
+}
+
+// applyProfileRequest copies the settings of a request to a profile
+func applyProfileRequest(profile *models.ImportProfile, req *models.ImportProfileRequest) {
+	profile.Name = req.Name
+	profile.Columns = req.Columns
+	profile.Delimiter = req.Delimiter
+	profile.Quote = req.Quote
+	profile.Encoding = req.Encoding
+	profile.DateFormat = req.DateFormat
+	profile.Defaults = req.Defaults
+}
+
+// validate normalizes an import profile and checks its settings against the
+// current custom fields, and that its name is not taken
+func (s *ImportProfileService) validate(ctx context.Context, profile *models.ImportProfile) error {
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return err
+	}
+
+	validation.NormalizeImportProfile(profile)
+	if err := validation.ValidateImportProfile(profile, fields); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.GetImportProfileByName(ctx, profile.Name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if existing != nil && existing.ID != profile.ID {
+		return validation.Errors{{
+			Field:   "name",
+			Code:    validation.CodeDuplicate,
+			Message: fmt.Sprintf("an import profile named '%s' already exists", profile.Name),
+		}}
+	}
+
+	return nil
+}
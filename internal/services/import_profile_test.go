@@ -0,0 +1,107 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+func TestImportProfileService(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	repo := svc.repo.(*repository.SQLiteRepository)
+	profileSvc := NewImportProfileService(repo, repo)
+	ctx := context.Background()
+
+	created, err := profileSvc.Create(ctx, &models.ImportProfileRequest{
+		Name:      " Team sheet ",
+		Columns:   map[string]string{" Term ": "word"},
+		Delimiter: "tab",
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if created.Name != "Team sheet" || created.Delimiter != "\t" || created.Columns["Term"] != "word" {
+		t.Errorf("Create() = %+v, want a normalized profile", created)
+	}
+
+	_, err = profileSvc.Create(ctx, &models.ImportProfileRequest{Name: "team SHEET"})
+	var errs validation.Errors
+	if !errors.As(err, &errs) || !errs.Has("name") {
+		t.Errorf("Create() duplicate name error = %v, want error for name", err)
+	}
+
+	_, err = profileSvc.Update(ctx, created.ID, &models.ImportProfileRequest{Name: "Team sheet", DateFormat: "someday"})
+	if !errors.As(err, &errs) || !errs.Has("date_format") {
+		t.Errorf("Update() bad date format error = %v, want error for date_format", err)
+	}
+
+	updated, err := profileSvc.Update(ctx, created.ID, &models.ImportProfileRequest{Name: "Team sheet", Delimiter: ";"})
+	if err != nil || updated.Delimiter != ";" || len(updated.Columns) != 0 {
+		t.Errorf("Update() = %+v, %v, want the settings replaced", updated, err)
+	}
+}
+
+func TestWordService_Import_Profile(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	svc.fields.CreateField(ctx, &models.CustomField{Name: "cefr", Label: "CEFR", Type: models.FieldTypeText})
+
+	profile := &models.ImportProfile{
+		Name:       "Team sheet",
+		Columns:    map[string]string{"Term": "word", "Learned": "date_learned", "Level": "cefr", "Comment": ""},
+		Delimiter:  ";",
+		Encoding:   "latin-1",
+		DateFormat: "MM/DD/YYYY",
+		Defaults:   map[string]string{"source": "Team sheet"},
+	}
+
+	// Latin-1 text, as saved by older spreadsheets
+	csvData := []byte("Term;Learned;Level;Comment;Source\n" +
+		"caf\xe9;03/15/2024;A1;\"Ordered one; then another\";\n" +
+		"na\xefve;2024-03-15;B1;;Novel\n" +
+		"d\xe9j\xe0 vu;12/01/2023;;;Podcast\n")
+
+	result, err := svc.Import(ctx, bytes.NewReader(csvData), "csv", ImportOptions{Profile: profile})
+	if err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+	if result.Imported != 2 || result.Skipped != 1 || len(result.Errors) != 1 || !strings.Contains(result.Errors[0], "MM/DD/YYYY") {
+		t.Errorf("Import() = %+v, want 2 imported and a date format error", result)
+	}
+
+	word, err := svc.repo.GetByWord(ctx, "café")
+	if err != nil {
+		t.Fatalf("GetByWord() error = %v", err)
+	}
+	if word.Source != "Team sheet" || word.DateLearned != "2024-03-15" || word.CustomFields["cefr"] != "A1" {
+		t.Errorf("imported word = %+v, want the default source, a converted date and the cefr value", word)
+	}
+	if word, _ := svc.repo.GetByWord(ctx, "déjà vu"); word == nil || word.Source != "Podcast" {
+		t.Errorf("imported word = %+v, want its own source", word)
+	}
+
+	// A TSV file is read with tabs unless the profile says otherwise
+	tsvData := "word\tsource\tdate_learned\nlaconic\tBook\t2024-02-01\n"
+	result, err = svc.Import(ctx, strings.NewReader(tsvData), "tsv", ImportOptions{})
+	if err != nil || result.Imported != 1 {
+		t.Errorf("Import() tsv = %+v, %v, want 1 imported", result, err)
+	}
+
+	// Required columns without defaults are still required
+	_, err = svc.Import(ctx, strings.NewReader("Term;Learned\nx;01/01/2024\n"), "csv", ImportOptions{
+		Profile: &models.ImportProfile{Columns: profile.Columns, Delimiter: ";"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "source") {
+		t.Errorf("Import() without source error = %v, want missing source column", err)
+	}
+}
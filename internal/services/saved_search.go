@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/querylang"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// SavedSearchService provides business logic for saved searches (smart lists)
+type SavedSearchService struct {
+	repo  repository.SavedSearchRepository
+	words repository.WordRepository
+}
+
+// NewSavedSearchService creates a new saved search service
+func NewSavedSearchService(repo repository.SavedSearchRepository, words repository.WordRepository) *SavedSearchService {
+	return &SavedSearchService{repo: repo, words: words}
+}
+
+// Create saves a named search after checking that its query and filter are valid
+func (s *SavedSearchService) Create(ctx context.Context, req *models.SavedSearchRequest) (*models.SavedSearch, error) {
+	search := &models.SavedSearch{
+		Name:   req.Name,
+		Query:  req.Query,
+		Filter: req.Filter,
+	}
+
+	if err := s.validate(ctx, search); err != nil {
+		return nil, err
+	}
+
+	created, err := s.repo.CreateSavedSearch(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	return created, s.count(ctx, created)
+}
+
+// GetByID retrieves a saved search with its current word count
+func (s *SavedSearchService) GetByID(ctx context.Context, id int64) (*models.SavedSearch, error) {
+	search, err := s.repo.GetSavedSearch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return search, s.count(ctx, search)
+}
+
+// List retrieves all saved searches with their current word counts
+func (s *SavedSearchService) List(ctx context.Context) ([]*models.SavedSearch, error) {
+	searches, err := s.repo.ListSavedSearches(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, search := range searches {
+		if err := s.count(ctx, search); err != nil {
+			return nil, err
+		}
+	}
+	return searches, nil
+}
+
+// Update replaces the name, query and filter of a saved search
+func (s *SavedSearchService) Update(ctx context.Context, id int64, req *models.SavedSearchRequest) (*models.SavedSearch, error) {
+	search, err := s.repo.GetSavedSearch(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	search.Name = req.Name
+	search.Query = req.Query
+	search.Filter = req.Filter
+
+	if err := s.validate(ctx, search); err != nil {
+		return nil, err
+	}
+
+	updated, err := s.repo.UpdateSavedSearch(ctx, search)
+	if err != nil {
+		return nil, err
+	}
+	return updated, s.count(ctx, updated)
+}
+
+// Delete removes a saved search
+func (s *SavedSearchService) Delete(ctx context.Context, id int64) error {
+	return s.repo.DeleteSavedSearch(ctx, id)
+}
+
+// Filter returns the word filter a saved search stands for, with its query compiled.
+// Relative dates in the query are resolved against today's date.
+func (s *SavedSearchService) Filter(ctx context.Context, id int64) (models.WordFilter, error) {
+	search, err := s.repo.GetSavedSearch(ctx, id)
+	if err != nil {
+		return models.WordFilter{}, err
+	}
+	return s.Compile(search)
+}
+
+// validate normalizes a saved search and checks its name, query and filter
+func (s *SavedSearchService) validate(ctx context.Context, search *models.SavedSearch) error {
+	validation.NormalizeSavedSearch(search)
+	if err := validation.ValidateSavedSearch(search); err != nil {
+		return err
+	}
+
+	if _, err := s.Compile(search); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.GetSavedSearchByName(ctx, search.Name)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return err
+	}
+	if existing != nil && existing.ID != search.ID {
+		return validation.Errors{{
+			Field:   "name",
+			Code:    validation.CodeDuplicate,
+			Message: fmt.Sprintf("a saved search named '%s' already exists", search.Name),
+		}}
+	}
+
+	return nil
+}
+
+// count fills in the number of words currently matching a saved search
+func (s *SavedSearchService) count(ctx context.Context, search *models.SavedSearch) error {
+	filter, err := s.Compile(search)
+	if err != nil {
+		return err
+	}
+
+	search.Count, err = s.words.Count(ctx, filter)
+	return err
+}
+
+// Compile merges the query of a saved search into its structured filter, reporting
+// query syntax errors as a field error on query. The filter's own search is applied
+// first, so the query narrows it rather than clashing with its qualifiers.
+func (s *SavedSearchService) Compile(search *models.SavedSearch) (models.WordFilter, error) {
+	filter := search.Filter
+	if err := querylang.Apply(&filter); err != nil {
+		return filter, queryError("filter.search", err)
+	}
+	if err := querylang.Narrow(&filter, search.Query); err != nil {
+		return filter, queryError("query", err)
+	}
+	return filter, nil
+}
+
+// queryError reports a query syntax error as a field error on field
+func queryError(field string, err error) error {
+	var qerr *querylang.Error
+	if errors.As(err, &qerr) {
+		return validation.Errors{{
+			Field:    field,
+			Code:     validation.CodeInvalidFormat,
+			Message:  qerr.Msg,
+			Position: qerr.Pos,
+		}}
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/repository"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+func TestSavedSearchService_Create(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	repo := svc.repo.(*repository.SQLiteRepository)
+	searchSvc := NewSavedSearchService(repo, repo)
+	ctx := context.Background()
+
+	tests := []struct {
+		name      string
+		req       *models.SavedSearchRequest
+		wantField string // field of the expected validation error, empty for success
+	}{
+		{
+			name: "query only",
+			req:  &models.SavedSearchRequest{Name: " Interview prep ", Query: "tag:interview -has:example after:-3m"},
+		},
+		{
+			name: "filter only",
+			req:  &models.SavedSearchRequest{Name: "Legal", Filter: models.WordFilter{Tags: []string{"legal"}, Sort: "word"}},
+		},
+		{
+			name:      "missing name",
+			req:       &models.SavedSearchRequest{Query: "tag:legal"},
+			wantField: "name",
+		},
+		{
+			name:      "duplicate name",
+			req:       &models.SavedSearchRequest{Name: "interview PREP"},
+			wantField: "name",
+		},
+		{
+			name:      "bad query",
+			req:       &models.SavedSearchRequest{Name: "Broken", Query: "pos:gerundive"},
+			wantField: "query",
+		},
+		{
+			name:      "bad filter",
+			req:       &models.SavedSearchRequest{Name: "Broken", Filter: models.WordFilter{Sort: "popularity"}},
+			wantField: "filter.sort",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := searchSvc.Create(ctx, tt.req)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("Create() error = %v", err)
+				}
+				if got.ID == 0 {
+					t.Error("Create() returned search without ID")
+				}
+				return
+			}
+
+			var verrs validation.Errors
+			if !errors.As(err, &verrs) || !verrs.Has(tt.wantField) {
+				t.Errorf("Create() error = %v, want validation error on %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestSavedSearchService_Counts(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	repo := svc.repo.(*repository.SQLiteRepository)
+	searchSvc := NewSavedSearchService(repo, repo)
+	ctx := context.Background()
+
+	recent := time.Now().AddDate(0, 0, -10).Format(validation.DateFormat)
+	for _, req := range []*models.CreateWordRequest{
+		{Word: "rapport", Source: "Interview", DateLearned: recent, Tags: []string{"interview"}},
+		{Word: "synergy", Source: "Interview", DateLearned: recent, Tags: []string{"interview"},
+			ExampleSentence: strPtr("We found synergy between the teams")},
+		{Word: "candor", Source: "Interview", DateLearned: "2020-01-15", Tags: []string{"interview"}},
+	} {
+		if _, err := svc.Create(ctx, req); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	list, err := searchSvc.Create(ctx, &models.SavedSearchRequest{
+		Name:  "This quarter, no example",
+		Query: "tag:interview -has:example after:-3m",
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if list.Count != 1 {
+		t.Errorf("Create() count = %d, want 1", list.Count)
+	}
+
+	// New matching words show up without editing the list
+	svc.Create(ctx, &models.CreateWordRequest{Word: "onboarding", Source: "Interview", DateLearned: recent, Tags: []string{"interview"}})
+
+	lists, err := searchSvc.List(ctx)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(lists) != 1 || lists[0].Count != 2 {
+		t.Errorf("List() = %+v, want one list with 2 words", lists)
+	}
+
+	filter, err := searchSvc.Filter(ctx, list.ID)
+	if err != nil {
+		t.Fatalf("Filter() error = %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		word, err := svc.GetRandomMatching(ctx, filter)
+		if err != nil {
+			t.Fatalf("GetRandomMatching() error = %v", err)
+		}
+		if word.Word != "rapport" && word.Word != "onboarding" {
+			t.Errorf("GetRandomMatching() = %s, want a word from the list", word.Word)
+		}
+	}
+
+	// A query repeating a qualifier of the filter's own search narrows it
+	filter, err = searchSvc.Compile(&models.SavedSearch{
+		Query:  "after:2024-03-01",
+		Filter: models.WordFilter{Search: "tag:interview after:2024-01-01"},
+	})
+	if err != nil || filter.FromDate != "2024-03-01" || len(filter.Tags) != 1 {
+		t.Errorf("Compile() = %+v, %v, want tag interview from 2024-03-01", filter, err)
+	}
+
+	if _, err := svc.GetRandomMatching(ctx, models.WordFilter{Tags: []string{"nothing"}}); err == nil {
+		t.Error("GetRandomMatching() with no matches should return error")
+	}
+}
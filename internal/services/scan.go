@@ -0,0 +1,231 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/lehmann314159/vocabulator/internal/dsv"
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/textscan"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+// DefaultScanLimit is the number of candidates ScanText returns when no limit is given
+const DefaultScanLimit = 100
+
+// MaxScanLimit is the highest limit accepted for scan candidates
+const MaxScanLimit = 500
+
+// MaxScanSize is the largest text, in bytes, that can be scanned
+const MaxScanSize = 8 << 20
+
+// ScanOptions controls which words of a text are offered as candidates
+type ScanOptions struct {
+	// Common leaves out words among this many of the most common English words;
+	// 0 leaves out every word of the bundled frequency list
+	Common int
+	Limit  int // the most candidates to return; 0 for DefaultScanLimit
+}
+
+// ScanCandidate is an uncommon word of a scanned text that is not in the collection
+type ScanCandidate struct {
+	Word    string   `json:"word"`
+	Forms   []string `json:"forms"` // the forms the word takes in the text
+	Count   int      `json:"count"`
+	Rank    int      `json:"rank,omitempty"` // position in the frequency list; 0 when it is not in it
+	Context string   `json:"context"`        // the sentence the word first appears in
+}
+
+// ScanResult is the outcome of scanning a text
+type ScanResult struct {
+	Words      int              `json:"words"`   // words in the text
+	Unknown    int              `json:"unknown"` // candidates found, before the limit
+	Candidates []*ScanCandidate `json:"candidates"`
+}
+
+// ScannedWords are the candidates picked from a scanned text to add as words
+type ScannedWords struct {
+	Source      string        `json:"source"`
+	DateLearned string        `json:"date_learned,omitempty"` // today when empty
+	Tags        []string      `json:"tags,omitempty"`
+	Words       []ScannedWord `json:"words"`
+}
+
+// ScannedWord is a word picked from a scanned text
+type ScannedWord struct {
+	Word    string `json:"word"`
+	Context string `json:"context,omitempty"` // becomes the example sentence
+}
+
+// ScanText finds the words of an English text that are uncommon and not already
+// in the collection, rarest first: words missing from the bundled frequency list
+// come before listed ones, then more frequent words in the text come first.
+func (s *WordService) ScanText(ctx context.Context, text string, opts ScanOptions) (*ScanResult, error) {
+	var errs validation.Errors
+	if strings.TrimSpace(text) == "" {
+		errs.Add("text", validation.CodeRequired, "is required")
+	} else if len(text) > MaxScanSize {
+		errs.Add("text", validation.CodeTooLarge, fmt.Sprintf("must be at most %d MB", MaxScanSize>>20))
+	}
+	if opts.Common < 0 || opts.Common > textscan.ListSize() {
+		errs.Add("common", validation.CodeInvalidValue, fmt.Sprintf("must be between 0 and %d", textscan.ListSize()))
+	}
+	if opts.Limit < 0 || opts.Limit > MaxScanLimit {
+		errs.Add("limit", validation.CodeInvalidValue, fmt.Sprintf("must be between 0 and %d", MaxScanLimit))
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+	if opts.Common == 0 {
+		opts.Common = textscan.ListSize()
+	}
+	if opts.Limit == 0 {
+		opts.Limit = DefaultScanLimit
+	}
+
+	known := make(map[string]bool)
+	err := s.eachWord(ctx, models.WordFilter{}, func(word *models.Word) error {
+		known[strings.ToLower(word.Word)] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	analysis := textscan.Analyze(text)
+	result := &ScanResult{Words: analysis.Words, Candidates: []*ScanCandidate{}}
+	for _, term := range analysis.Terms {
+		if term.Rank > 0 && term.Rank <= opts.Common || known[term.Lemma] || anyKnown(known, term.Forms) {
+			continue
+		}
+		result.Candidates = append(result.Candidates, &ScanCandidate{
+			Word:    term.Lemma,
+			Forms:   term.Forms,
+			Count:   term.Count,
+			Rank:    term.Rank,
+			Context: term.Context,
+		})
+	}
+
+	sort.SliceStable(result.Candidates, func(i, j int) bool {
+		a, b := result.Candidates[i], result.Candidates[j]
+		if (a.Rank == 0) != (b.Rank == 0) {
+			return a.Rank == 0
+		}
+		if a.Rank != b.Rank {
+			return a.Rank > b.Rank
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Word < b.Word
+	})
+
+	result.Unknown = len(result.Candidates)
+	if len(result.Candidates) > opts.Limit {
+		result.Candidates = result.Candidates[:opts.Limit]
+	}
+	return result, nil
+}
+
+// ScanFile scans a .txt or .epub file; see ScanText. Text files may be in any
+// encoding dsv.Decode detects.
+func (s *WordService) ScanFile(ctx context.Context, r io.ReaderAt, size int64, name string, opts ScanOptions) (*ScanResult, error) {
+	var text string
+	switch strings.ToLower(path.Ext(name)) {
+	case ".epub":
+		var err error
+		if text, err = textscan.EPUBText(r, size); err != nil {
+			return nil, err
+		}
+	case ".txt", "":
+		if size > MaxScanSize {
+			return nil, validation.Errors{{
+				Field:   "file",
+				Code:    validation.CodeTooLarge,
+				Message: fmt.Sprintf("must be at most %d MB", MaxScanSize>>20),
+			}}
+		}
+		data, err := io.ReadAll(io.NewSectionReader(r, 0, size))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read text file: %w", err)
+		}
+		if data, err = dsv.Decode(data, dsv.EncodingAuto); err != nil {
+			return nil, err
+		}
+		text = string(data)
+	default:
+		return nil, validation.Errors{{
+			Field:   "file",
+			Code:    validation.CodeUnsupported,
+			Message: "must be a .txt or .epub file",
+		}}
+	}
+	return s.ScanText(ctx, text, opts)
+}
+
+// ImportScanned adds the words picked from a scanned text, with the text's source
+// and each word's context as its example sentence. Words that already exist are
+// skipped.
+func (s *WordService) ImportScanned(ctx context.Context, scanned *ScannedWords) (*ImportResult, error) {
+	var errs validation.Errors
+	if strings.TrimSpace(scanned.Source) == "" {
+		errs.Add("source", validation.CodeRequired, "is required")
+	}
+	if len(scanned.Words) == 0 {
+		errs.Add("words", validation.CodeRequired, "must list at least one word")
+	}
+	if err := errs.Err(); err != nil {
+		return nil, err
+	}
+
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	dateLearned := scanned.DateLearned
+	if dateLearned == "" {
+		dateLearned = validation.Today().Format(validation.DateFormat)
+	}
+
+	plan := &importPlan{fields: fields, strategy: ConflictSkip}
+	for i, picked := range scanned.Words {
+		word := &models.Word{
+			Word:         picked.Word,
+			Source:       scanned.Source,
+			DateLearned:  dateLearned,
+			Tags:         append([]string{}, scanned.Tags...),
+			CustomFields: map[string]string{},
+		}
+		if example := exampleSentence(picked.Context); example != "" {
+			word.ExampleSentence = &example
+		}
+		plan.add(fmt.Sprintf("word %d (%s)", i+1, picked.Word), word)
+	}
+	return s.runImport(ctx, plan)
+}
+
+// exampleSentence trims a context to fit an example sentence
+func exampleSentence(context string) string {
+	context = strings.TrimSpace(context)
+	if utf8.RuneCountInString(context) <= validation.MaxExampleSentenceLength {
+		return context
+	}
+	return string([]rune(context)[:validation.MaxExampleSentenceLength-1]) + "…"
+}
+
+// anyKnown reports whether any of the forms is a known word
+func anyKnown(known map[string]bool, forms []string) bool {
+	for _, form := range forms {
+		if known[form] {
+			return true
+		}
+	}
+	return false
+}
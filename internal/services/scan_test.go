@@ -0,0 +1,133 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+	"github.com/lehmann314159/vocabulator/internal/validation"
+)
+
+const testChapter = `The house was quiet. Its ephemeral silence obfuscated the quarrel, and the
+laconic butler obfuscates everything. Nobody mentioned the lavender wallpaper.
+
+Later the wallpaper peeled. The butler's laconic replies were noted.`
+
+func TestWordService_ScanText(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	svc.Create(ctx, &models.CreateWordRequest{Word: "Laconic", Source: "Book", DateLearned: "2024-01-15"})
+
+	result, err := svc.ScanText(ctx, testChapter, ScanOptions{})
+	if err != nil {
+		t.Fatalf("ScanText() error = %v", err)
+	}
+	if result.Words != 31 {
+		t.Errorf("ScanText() words = %d, want 31", result.Words)
+	}
+
+	words := scanWords(result)
+	// Unlisted words come first, the most frequent in the text first
+	if got := strings.Join(words, ","); got != "butler,obfuscate,wallpaper,ephemeral,lavender" {
+		t.Fatalf("ScanText() candidates = %s", got)
+	}
+
+	obfuscate := result.Candidates[1]
+	if obfuscate.Count != 2 || strings.Join(obfuscate.Forms, ",") != "obfuscated,obfuscates" ||
+		obfuscate.Context != "Its ephemeral silence obfuscated the quarrel, and the laconic butler obfuscates everything." {
+		t.Errorf("ScanText() candidate = %+v", obfuscate)
+	}
+
+	result, err = svc.ScanText(ctx, testChapter, ScanOptions{Common: 100, Limit: 2})
+	if err != nil {
+		t.Fatalf("ScanText() error = %v", err)
+	}
+	if len(result.Candidates) != 2 || result.Unknown <= 5 {
+		t.Errorf("ScanText() with fewer common words = %d of %d candidates", len(result.Candidates), result.Unknown)
+	}
+
+	var verrs validation.Errors
+	_, err = svc.ScanText(ctx, " ", ScanOptions{Limit: MaxScanLimit + 1})
+	if !errors.As(err, &verrs) || !verrs.Has("text") || !verrs.Has("limit") {
+		t.Errorf("ScanText() error = %v, want text and limit errors", err)
+	}
+}
+
+func TestWordService_ScanFile(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// Latin-1 text is decoded
+	data := []byte("The na\xefve courtier simpered.")
+	result, err := svc.ScanFile(ctx, bytes.NewReader(data), int64(len(data)), "chapter.txt", ScanOptions{})
+	if err != nil {
+		t.Fatalf("ScanFile() error = %v", err)
+	}
+	if len(result.Candidates) != 3 || result.Candidates[1].Word != "naïve" {
+		t.Errorf("ScanFile() candidates = %v", scanWords(result))
+	}
+
+	var verrs validation.Errors
+	if _, err := svc.ScanFile(ctx, bytes.NewReader(data), int64(len(data)), "chapter.pdf", ScanOptions{}); !errors.As(err, &verrs) {
+		t.Errorf("ScanFile() error = %v, want a validation error", err)
+	}
+}
+
+func TestWordService_ImportScanned(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	svc.Create(ctx, &models.CreateWordRequest{Word: "laconic", Source: "Book", DateLearned: "2024-01-15"})
+
+	result, err := svc.ImportScanned(ctx, &ScannedWords{
+		Source:      "Bleak House",
+		DateLearned: "2024-05-01",
+		Tags:        []string{"dickens"},
+		Words: []ScannedWord{
+			{Word: "obfuscate", Context: "Its silence obfuscated the quarrel."},
+			{Word: "laconic", Context: "A laconic butler."},
+			{Word: "lavender"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ImportScanned() error = %v", err)
+	}
+	if result.Imported != 2 || result.Skipped != 1 {
+		t.Errorf("ImportScanned() = %+v, want 2 imported and 1 skipped", result)
+	}
+
+	word, err := svc.repo.GetByWord(ctx, "obfuscate")
+	if err != nil {
+		t.Fatalf("GetByWord() error = %v", err)
+	}
+	if word.Source != "Bleak House" || word.DateLearned != "2024-05-01" || len(word.Tags) != 1 ||
+		word.ExampleSentence == nil || *word.ExampleSentence != "Its silence obfuscated the quarrel." {
+		t.Errorf("imported word = %+v", word)
+	}
+	if word, _ := svc.repo.GetByWord(ctx, "lavender"); word == nil || word.ExampleSentence != nil {
+		t.Errorf("imported word without context = %+v", word)
+	}
+
+	var verrs validation.Errors
+	_, err = svc.ImportScanned(ctx, &ScannedWords{Words: []ScannedWord{{Word: "quarrel"}}})
+	if !errors.As(err, &verrs) || !verrs.Has("source") {
+		t.Errorf("ImportScanned() error = %v, want a source error", err)
+	}
+}
+
+// scanWords lists the words of scan candidates
+func scanWords(result *ScanResult) []string {
+	var words []string
+	for _, c := range result.Candidates {
+		words = append(words, c.Word)
+	}
+	return words
+}
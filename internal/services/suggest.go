@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+
+	"github.com/lehmann314159/vocabulator/internal/fuzzy"
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// Suggestion limits
+const (
+	DefaultSuggestLimit = 10
+	MaxSuggestLimit     = 50
+
+	// minSuggestCandidates is how many trigram matches are re-ranked by edit distance
+	minSuggestCandidates = 50
+
+	// minSimilarity admits candidates that are too far apart by edit distance but
+	// still share most of their trigrams, such as long words with several typos
+	minSimilarity = 0.4
+)
+
+// Suggest returns the words closest to a possibly misspelled or partial query,
+// best match first. Candidates sharing trigrams with the query are ranked by
+// Damerau-Levenshtein distance to the whole word or, for search-as-you-type, to
+// its beginning, then by trigram similarity.
+func (s *WordService) Suggest(ctx context.Context, query string, limit int) ([]models.Suggestion, error) {
+	query = fuzzy.Normalize(query)
+	if query == "" {
+		return []models.Suggestion{}, nil
+	}
+	if limit <= 0 {
+		limit = DefaultSuggestLimit
+	}
+	if limit > MaxSuggestLimit {
+		limit = MaxSuggestLimit
+	}
+
+	candidates, err := s.repo.SuggestCandidates(ctx, fuzzy.Trigrams(query), max(limit*5, minSuggestCandidates))
+	if err != nil {
+		return nil, err
+	}
+
+	queryLen := len([]rune(query))
+	maxDistance := fuzzy.MaxDistance(queryLen)
+
+	type ranked struct {
+		suggestion models.Suggestion
+		rank       int
+	}
+	var matches []ranked
+
+	for _, word := range candidates {
+		distance := fuzzy.Distance(query, word.Word)
+		rank := min(distance, fuzzy.Distance(query, prefix(fuzzy.Normalize(word.Word), queryLen)))
+		similarity := fuzzy.Similarity(query, word.Word)
+
+		if rank > maxDistance && similarity < minSimilarity {
+			continue
+		}
+
+		matches = append(matches, ranked{
+			suggestion: models.Suggestion{
+				ID:         word.ID,
+				Word:       word.Word,
+				Distance:   distance,
+				Similarity: math.Round(similarity*1000) / 1000,
+			},
+			rank: rank,
+		})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if a.rank != b.rank {
+			return a.rank < b.rank
+		}
+		if a.suggestion.Similarity != b.suggestion.Similarity {
+			return a.suggestion.Similarity > b.suggestion.Similarity
+		}
+		return a.suggestion.Word < b.suggestion.Word
+	})
+
+	suggestions := make([]models.Suggestion, 0, min(limit, len(matches)))
+	for i := 0; i < len(matches) && i < limit; i++ {
+		suggestions = append(suggestions, matches[i].suggestion)
+	}
+
+	return suggestions, nil
+}
+
+// prefix returns the first n runes of s
+func prefix(s string, n int) string {
+	runes := []rune(s)
+	if len(runes) <= n {
+		return s
+	}
+	return string(runes[:n])
+}
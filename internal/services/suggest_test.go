@@ -0,0 +1,69 @@
+package services
+
+import (
+	"context"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+func TestWordService_Suggest(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+	for _, word := range []string{"obsequious", "obvious", "ephemeral", "ubiquitous", "eloquent"} {
+		if _, err := svc.Create(ctx, &models.CreateWordRequest{Word: word, Source: "Book", DateLearned: "2024-01-15"}); err != nil {
+			t.Fatalf("Create(%s) error = %v", word, err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		query     string
+		wantFirst string
+		wantNone  bool
+	}{
+		{name: "substitution", query: "obsequeous", wantFirst: "obsequious"},
+		{name: "transposition", query: "ephmeeral", wantFirst: "ephemeral"},
+		{name: "prefix while typing", query: "ubiq", wantFirst: "ubiquitous"},
+		{name: "prefix with typo", query: "eloqe", wantFirst: "eloquent"},
+		{name: "case and spacing", query: "  OBVIOUS ", wantFirst: "obvious"},
+		{name: "nothing close", query: "zzzzzz", wantNone: true},
+		{name: "empty query", query: "", wantNone: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.Suggest(ctx, tt.query, 0)
+			if err != nil {
+				t.Fatalf("Suggest() error = %v", err)
+			}
+			if tt.wantNone {
+				if len(got) != 0 {
+					t.Errorf("Suggest(%q) = %v, want none", tt.query, got)
+				}
+				return
+			}
+			if len(got) == 0 || got[0].Word != tt.wantFirst {
+				t.Errorf("Suggest(%q) = %v, want %s first", tt.query, got, tt.wantFirst)
+			}
+		})
+	}
+
+	// Renamed words are re-indexed
+	word, _ := svc.repo.GetByWord(ctx, "obvious")
+	newWord := "laconic"
+	if _, err := svc.Update(ctx, word.ID, &models.UpdateWordRequest{Word: &newWord}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	got, _ := svc.Suggest(ctx, "lacnic", 0)
+	if len(got) == 0 || got[0].Word != "laconic" {
+		t.Errorf("Suggest() after rename = %v, want laconic", got)
+	}
+
+	got, _ = svc.Suggest(ctx, "o", 1)
+	if len(got) != 1 {
+		t.Errorf("Suggest() with limit 1 returned %d suggestions", len(got))
+	}
+}
@@ -1,48 +1,42 @@
 package services
 
 import (
+	"bytes"
 	"context"
-	"encoding/csv"
+	"database/sql"
+	"errors"
 	"fmt"
 	"io"
+	"math/rand/v2"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/lehmann314159/vocabulator/internal/dsv"
 	"github.com/lehmann314159/vocabulator/internal/models"
 	"github.com/lehmann314159/vocabulator/internal/repository"
+	"github.com/lehmann314159/vocabulator/internal/validation"
 )
 
 // WordService provides business logic for word operations
 type WordService struct {
 	repo       repository.WordRepository
+	fields     repository.FieldRepository
 	dictionary *DictionaryService
+	staging    importStaging // previewed imports awaiting confirmation
 }
 
 // NewWordService creates a new word service
-func NewWordService(repo repository.WordRepository, dictionary *DictionaryService) *WordService {
+func NewWordService(repo repository.WordRepository, fields repository.FieldRepository, dictionary *DictionaryService) *WordService {
 	return &WordService{
 		repo:       repo,
+		fields:     fields,
 		dictionary: dictionary,
 	}
 }
 
 // Create creates a new word
 func (s *WordService) Create(ctx context.Context, req *models.CreateWordRequest) (*models.Word, error) {
-	if req.Word == "" {
-		return nil, fmt.Errorf("word is required")
-	}
-	if req.Source == "" {
-		return nil, fmt.Errorf("source is required")
-	}
-	if req.DateLearned == "" {
-		return nil, fmt.Errorf("date_learned is required")
-	}
-
-	// Check for duplicate
-	existing, err := s.repo.GetByWord(ctx, req.Word)
-	if err == nil && existing != nil {
-		return nil, fmt.Errorf("word '%s' already exists", req.Word)
-	}
-
 	word := &models.Word{
 		Word:            req.Word,
 		Source:          req.Source,
@@ -50,10 +44,26 @@ func (s *WordService) Create(ctx context.Context, req *models.CreateWordRequest)
 		PartOfSpeech:    req.PartOfSpeech,
 		ExampleSentence: req.ExampleSentence,
 		Tags:            req.Tags,
+		CustomFields:    make(map[string]string),
+	}
+	for name, value := range req.CustomFields {
+		word.CustomFields[name] = value
 	}
 
-	if word.Tags == nil {
-		word.Tags = []string{}
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	validation.NormalizeWord(word)
+	if err := validation.ValidateWord(word, fields); err != nil {
+		return nil, err
+	}
+
+	// Check for duplicate
+	existing, err := s.repo.GetByWord(ctx, word.Word)
+	if err == nil && existing != nil {
+		return nil, validation.Duplicate(word.Word)
 	}
 
 	return s.repo.Create(ctx, word)
@@ -69,6 +79,19 @@ func (s *WordService) List(ctx context.Context, filter models.WordFilter) ([]*mo
 	return s.repo.List(ctx, filter)
 }
 
+// ListPage retrieves a page of words and the cursor for the next one
+func (s *WordService) ListPage(ctx context.Context, filter models.WordFilter) (*models.WordPage, error) {
+	page, err := s.repo.ListPage(ctx, filter)
+	if errors.Is(err, repository.ErrInvalidCursor) {
+		return nil, validation.Errors{{
+			Field:   "cursor",
+			Code:    validation.CodeInvalidValue,
+			Message: "cursor is invalid or belongs to a different filter or sort",
+		}}
+	}
+	return page, err
+}
+
 // Update updates an existing word
 func (s *WordService) Update(ctx context.Context, id int64, req *models.UpdateWordRequest) (*models.Word, error) {
 	word, err := s.repo.GetByID(ctx, id)
@@ -76,30 +99,58 @@ func (s *WordService) Update(ctx context.Context, id int64, req *models.UpdateWo
 		return nil, err
 	}
 
+	// Only the fields the request sets are validated, so that an invalid stored value
+	// does not block unrelated changes
+	original := word.Word
+	var set []string
 	if req.Word != nil {
-		// Check for duplicate if word is being changed
-		if *req.Word != word.Word {
-			existing, err := s.repo.GetByWord(ctx, *req.Word)
-			if err == nil && existing != nil {
-				return nil, fmt.Errorf("word '%s' already exists", *req.Word)
-			}
-		}
 		word.Word = *req.Word
+		set = append(set, "word")
 	}
 	if req.Source != nil {
 		word.Source = *req.Source
+		set = append(set, "source")
 	}
 	if req.DateLearned != nil {
 		word.DateLearned = *req.DateLearned
+		set = append(set, "date_learned")
 	}
 	if req.PartOfSpeech != nil {
 		word.PartOfSpeech = req.PartOfSpeech
+		set = append(set, "part_of_speech")
 	}
 	if req.ExampleSentence != nil {
 		word.ExampleSentence = req.ExampleSentence
+		set = append(set, "example_sentence")
 	}
 	if req.Tags != nil {
 		word.Tags = req.Tags
+		set = append(set, "tags")
+	}
+	if len(req.CustomFields) > 0 && word.CustomFields == nil {
+		word.CustomFields = make(map[string]string)
+	}
+	for name, value := range req.CustomFields {
+		word.CustomFields[name] = value
+		set = append(set, "custom_fields."+name)
+	}
+
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	validation.NormalizeWord(word)
+	if err := validation.ValidateWordUpdate(word, fields, set); err != nil {
+		return nil, err
+	}
+
+	// Check for duplicate if word is being changed
+	if word.Word != original {
+		existing, err := s.repo.GetByWord(ctx, word.Word)
+		if err == nil && existing != nil {
+			return nil, validation.Duplicate(word.Word)
+		}
 	}
 
 	return s.repo.Update(ctx, word)
@@ -110,11 +161,59 @@ func (s *WordService) Delete(ctx context.Context, id int64) error {
 	return s.repo.Delete(ctx, id)
 }
 
+// Facet limits: how many values are returned per facet
+const (
+	DefaultFacetLimit = 20
+	MaxFacetLimit     = 200
+)
+
+// Facets counts the words matching the filter by tag, source, part of speech and
+// month learned; limit caps the values per facet
+func (s *WordService) Facets(ctx context.Context, filter models.WordFilter, limit int) (*models.Facets, error) {
+	if limit <= 0 {
+		limit = DefaultFacetLimit
+	}
+	if limit > MaxFacetLimit {
+		limit = MaxFacetLimit
+	}
+	return s.repo.Facets(ctx, filter, limit)
+}
+
+// Review records a flash-card review of a word; words often not remembered sort
+// first with sort=-difficulty
+func (s *WordService) Review(ctx context.Context, id int64, remembered bool) error {
+	return s.repo.RecordReview(ctx, id, remembered)
+}
+
 // GetRandom retrieves a random word
 func (s *WordService) GetRandom(ctx context.Context) (*models.Word, error) {
 	return s.repo.GetRandom(ctx)
 }
 
+// GetRandomMatching retrieves a random word from those matching the filter, such
+// as a saved search; it returns sql.ErrNoRows when nothing matches
+func (s *WordService) GetRandomMatching(ctx context.Context, filter models.WordFilter) (*models.Word, error) {
+	count, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if count == 0 {
+		return nil, sql.ErrNoRows
+	}
+
+	filter.Limit = 1
+	filter.Offset = rand.IntN(int(count))
+	filter.Cursor = ""
+	words, err := s.repo.List(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, sql.ErrNoRows
+	}
+	return words[0], nil
+}
+
 // GetDefinition fetches the definition of a word from the dictionary
 func (s *WordService) GetDefinition(ctx context.Context, id int64) (*models.DictionaryResponse, error) {
 	word, err := s.repo.GetByID(ctx, id)
@@ -125,38 +224,85 @@ func (s *WordService) GetDefinition(ctx context.Context, id int64) (*models.Dict
 	return s.dictionary.Lookup(ctx, word.Word)
 }
 
-// ImportResult contains the results of a CSV import operation
+// ImportResult contains the results of an import operation
 type ImportResult struct {
 	Imported int      `json:"imported"`
+	Updated  int      `json:"updated"` // existing words replaced under a conflict strategy
 	Skipped  int      `json:"skipped"`
 	Errors   []string `json:"errors,omitempty"`
+
+	// RolledBack is set when an all-or-nothing import failed and wrote nothing
+	RolledBack bool `json:"rolled_back,omitempty"`
+
+	failed int // rows that were invalid or failed to write
 }
 
 // ImportCSV imports words from a CSV reader
 func (s *WordService) ImportCSV(ctx context.Context, r io.Reader) (*ImportResult, error) {
-	reader := csv.NewReader(r)
+	plan, err := s.planCSV(ctx, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.runImport(ctx, plan)
+}
+
+// planCSV reads the rows of a CSV file laid out as the profile describes; a nil
+// profile reads the export format. Its words are created anew, and words that
+// already exist are reported as duplicates unless a conflict strategy is configured.
+func (s *WordService) planCSV(ctx context.Context, r io.Reader, profile *models.ImportProfile) (*importPlan, error) {
+	if profile == nil {
+		profile = &models.ImportProfile{}
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CSV file: %w", err)
+	}
+	if data, err = dsv.Decode(data, profile.Encoding); err != nil {
+		return nil, err
+	}
+
+	reader := dsv.NewReader(bytes.NewReader(data))
+	if profile.Delimiter != "" {
+		reader.Comma, _ = utf8.DecodeRuneInString(profile.Delimiter)
+	}
+	switch profile.Quote {
+	case "":
+	case "none":
+		reader.Quote = 0
+	default:
+		reader.Quote, _ = utf8.DecodeRuneInString(profile.Quote)
+	}
+
+	layout := validation.DateFormat
+	if profile.DateFormat != "" {
+		if layout, err = validation.DateLayout(profile.DateFormat); err != nil {
+			return nil, err
+		}
+	}
 
 	// Read header
 	header, err := reader.Read()
 	if err != nil {
 		return nil, fmt.Errorf("failed to read CSV header: %w", err)
 	}
-
-	// Map column names to indices
-	colIndex := make(map[string]int)
-	for i, col := range header {
-		colIndex[strings.ToLower(strings.TrimSpace(col))] = i
-	}
+	colIndex := csvColumns(header, profile.Columns)
 
 	// Validate required columns
 	requiredCols := []string{"word", "source", "date_learned"}
 	for _, col := range requiredCols {
-		if _, ok := colIndex[col]; !ok {
+		if _, ok := colIndex[col]; !ok && profile.Defaults[col] == "" {
 			return nil, fmt.Errorf("missing required column: %s", col)
 		}
 	}
 
-	result := &ImportResult{}
+	// Extra columns named after custom fields are imported as their values
+	fields, err := s.fields.ListFields(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &importPlan{fields: fields}
 	lineNum := 1 // Header is line 1
 
 	for {
@@ -165,115 +311,92 @@ func (s *WordService) ImportCSV(ctx context.Context, r io.Reader) (*ImportResult
 		if err == io.EOF {
 			break
 		}
+		label := fmt.Sprintf("line %d", lineNum)
 		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", lineNum, err))
-			result.Skipped++
+			plan.invalid(label, err.Error())
 			continue
 		}
 
-		word := &models.Word{
-			Word:        strings.TrimSpace(record[colIndex["word"]]),
-			Source:      strings.TrimSpace(record[colIndex["source"]]),
-			DateLearned: strings.TrimSpace(record[colIndex["date_learned"]]),
-			Tags:        []string{},
+		// value reads a column, falling back to the profile's default when the
+		// column is missing or empty
+		value := func(target string) string {
+			if idx, ok := colIndex[target]; ok && idx < len(record) {
+				if val := strings.TrimSpace(record[idx]); val != "" {
+					return val
+				}
+			}
+			return profile.Defaults[target]
 		}
 
-		if word.Word == "" || word.Source == "" || word.DateLearned == "" {
-			result.Errors = append(result.Errors, fmt.Sprintf("line %d: missing required field", lineNum))
-			result.Skipped++
-			continue
+		word := &models.Word{
+			Word:         value("word"),
+			Source:       value("source"),
+			DateLearned:  value("date_learned"),
+			Tags:         []string{},
+			CustomFields: make(map[string]string),
 		}
 
-		// Optional fields
-		if idx, ok := colIndex["part_of_speech"]; ok && idx < len(record) {
-			if val := strings.TrimSpace(record[idx]); val != "" {
-				word.PartOfSpeech = &val
+		// Dates in the file are in the profile's format; defaults are in ours
+		if layout != validation.DateFormat && word.DateLearned != profile.Defaults["date_learned"] {
+			parsed, err := time.Parse(layout, word.DateLearned)
+			if err != nil {
+				plan.invalid(label, fmt.Sprintf("date_learned '%s' does not match %s", word.DateLearned, profile.DateFormat))
+				continue
 			}
+			word.DateLearned = parsed.Format(validation.DateFormat)
 		}
 
-		if idx, ok := colIndex["example_sentence"]; ok && idx < len(record) {
-			if val := strings.TrimSpace(record[idx]); val != "" {
-				word.ExampleSentence = &val
-			}
+		// Optional fields
+		if val := value("part_of_speech"); val != "" {
+			word.PartOfSpeech = &val
 		}
 
-		if idx, ok := colIndex["tags"]; ok && idx < len(record) {
-			if val := strings.TrimSpace(record[idx]); val != "" {
-				// Split comma-separated tags
-				tags := strings.Split(val, ",")
-				for i, tag := range tags {
-					tags[i] = strings.TrimSpace(tag)
-				}
-				word.Tags = tags
-			}
+		if val := value("example_sentence"); val != "" {
+			word.ExampleSentence = &val
 		}
 
-		// Check for duplicate
-		existing, _ := s.repo.GetByWord(ctx, word.Word)
-		if existing != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("line %d: word '%s' already exists", lineNum, word.Word))
-			result.Skipped++
-			continue
+		if val := value("tags"); val != "" {
+			// Split comma-separated tags; NormalizeWord trims them
+			word.Tags = strings.Split(val, ",")
 		}
 
-		_, err = s.repo.Create(ctx, word)
-		if err != nil {
-			result.Errors = append(result.Errors, fmt.Sprintf("line %d: %v", lineNum, err))
-			result.Skipped++
-			continue
+		for _, field := range fields {
+			if val := value(field.Name); val != "" {
+				word.CustomFields[field.Name] = val
+			}
 		}
 
-		result.Imported++
+		plan.add(label, word)
 	}
 
-	return result, nil
+	return plan, nil
 }
 
-// ExportCSV exports all words to CSV format
-func (s *WordService) ExportCSV(ctx context.Context, w io.Writer) error {
-	words, err := s.repo.List(ctx, models.WordFilter{})
-	if err != nil {
-		return fmt.Errorf("failed to fetch words: %w", err)
-	}
-
-	writer := csv.NewWriter(w)
-	defer writer.Flush()
-
-	// Write header
-	header := []string{"word", "source", "date_learned", "part_of_speech", "example_sentence", "tags"}
-	if err := writer.Write(header); err != nil {
-		return fmt.Errorf("failed to write header: %w", err)
-	}
-
-	// Write records
-	for _, word := range words {
-		partOfSpeech := ""
-		if word.PartOfSpeech != nil {
-			partOfSpeech = *word.PartOfSpeech
-		}
-
-		exampleSentence := ""
-		if word.ExampleSentence != nil {
-			exampleSentence = *word.ExampleSentence
-		}
-
-		tags := strings.Join(word.Tags, ",")
-
-		record := []string{
-			word.Word,
-			word.Source,
-			word.DateLearned,
-			partOfSpeech,
-			exampleSentence,
-			tags,
+// csvColumns maps word fields and custom field names to the index of the column
+// holding them. A header is matched to a target by the profile's columns, ignoring
+// case, or else by its own name; the first column for a target wins.
+func csvColumns(header []string, columns map[string]string) map[string]int {
+	colIndex := make(map[string]int)
+	for i, col := range header {
+		col = strings.TrimSpace(col)
+		target := strings.ToLower(col)
+		for name, mapped := range columns {
+			if strings.EqualFold(name, col) {
+				target = mapped
+				break
+			}
 		}
 
-		if err := writer.Write(record); err != nil {
-			return fmt.Errorf("failed to write record: %w", err)
+		if _, ok := colIndex[target]; target != "" && !ok {
+			colIndex[target] = i
 		}
 	}
+	return colIndex
+}
 
-	return nil
+// ExportCSV exports all words to CSV format
+func (s *WordService) ExportCSV(ctx context.Context, w io.Writer) error {
+	return s.Export(ctx, w, "csv", ExportOptions{})
 }
 
 // Count returns the total number of words
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"database/sql"
+	"errors"
 	"strings"
 	"testing"
 
@@ -11,6 +12,7 @@ import (
 
 	"github.com/lehmann314159/vocabulator/internal/models"
 	"github.com/lehmann314159/vocabulator/internal/repository"
+	"github.com/lehmann314159/vocabulator/internal/validation"
 )
 
 func setupTestService(t *testing.T) (*WordService, func()) {
@@ -20,6 +22,9 @@ func setupTestService(t *testing.T) (*WordService, func()) {
 	if err != nil {
 		t.Fatalf("failed to open test db: %v", err)
 	}
+	// Each connection would open its own empty database, and imports run in the
+	// background on another goroutine
+	db.SetMaxOpenConns(1)
 
 	_, err = db.Exec(`
 		CREATE TABLE words (
@@ -31,8 +36,91 @@ func setupTestService(t *testing.T) (*WordService, func()) {
 			example_sentence TEXT,
 			tags TEXT DEFAULT '[]',
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			reviews INTEGER NOT NULL DEFAULT 0,
+			lapses INTEGER NOT NULL DEFAULT 0
+		);
+		CREATE TABLE custom_fields (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			label TEXT NOT NULL,
+			type TEXT NOT NULL,
+			options TEXT DEFAULT '[]',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE word_field_values (
+			word_id INTEGER NOT NULL,
+			field_id INTEGER NOT NULL,
+			value TEXT NOT NULL,
+			PRIMARY KEY (word_id, field_id)
+		);
+		CREATE TABLE attachments (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			word_id INTEGER NOT NULL,
+			filename TEXT NOT NULL,
+			content_type TEXT NOT NULL,
+			size INTEGER NOT NULL,
+			width INTEGER NOT NULL,
+			height INTEGER NOT NULL,
+			checksum TEXT NOT NULL,
+			data BLOB,
+			thumbnail BLOB NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE TABLE word_trigrams (
+			trigram TEXT NOT NULL,
+			word_id INTEGER NOT NULL,
+			PRIMARY KEY (trigram, word_id)
+		);
+
+		CREATE TABLE saved_searches (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			query TEXT NOT NULL DEFAULT '',
+			filter TEXT NOT NULL DEFAULT '{}',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE import_profiles (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			name TEXT NOT NULL UNIQUE,
+			columns TEXT NOT NULL DEFAULT '{}',
+			delimiter TEXT NOT NULL DEFAULT '',
+			quote TEXT NOT NULL DEFAULT '',
+			encoding TEXT NOT NULL DEFAULT '',
+			date_format TEXT NOT NULL DEFAULT '',
+			defaults TEXT NOT NULL DEFAULT '{}',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
-		)
+		);
+
+		CREATE TABLE definitions (
+			word TEXT PRIMARY KEY COLLATE NOCASE,
+			data TEXT NOT NULL,
+			fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+
+		CREATE TABLE imported_items (
+			origin TEXT NOT NULL,
+			item_key TEXT NOT NULL,
+			imported_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			PRIMARY KEY (origin, item_key)
+		);
+
+		CREATE TABLE import_jobs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			filename TEXT NOT NULL DEFAULT '',
+			status TEXT NOT NULL DEFAULT 'queued',
+			total INTEGER NOT NULL DEFAULT 0,
+			processed INTEGER NOT NULL DEFAULT 0,
+			result TEXT,
+			error TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			finished_at DATETIME
+		);
 	`)
 	if err != nil {
 		t.Fatalf("failed to create table: %v", err)
@@ -40,7 +128,7 @@ func setupTestService(t *testing.T) (*WordService, func()) {
 
 	repo := repository.NewSQLiteRepository(db)
 	dictSvc := NewDictionaryService()
-	svc := NewWordService(repo, dictSvc)
+	svc := NewWordService(repo, repo, dictSvc)
 
 	cleanup := func() {
 		db.Close()
@@ -94,6 +182,25 @@ func TestWordService_Create(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "malformed date",
+			req: &models.CreateWordRequest{
+				Word:        "test",
+				Source:      "Book",
+				DateLearned: "15/01/2024",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown part of speech",
+			req: &models.CreateWordRequest{
+				Word:         "test",
+				Source:       "Book",
+				DateLearned:  "2024-01-15",
+				PartOfSpeech: strPtr("gerundive"),
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -135,6 +242,25 @@ func TestWordService_Create_Duplicate(t *testing.T) {
 	}
 }
 
+func TestWordService_Create_NormalizesTags(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	got, err := svc.Create(context.Background(), &models.CreateWordRequest{
+		Word:        "ephemeral",
+		Source:      "Book",
+		DateLearned: "2024-01-15",
+		Tags:        []string{" Literature", "literature", "", "NATURE"},
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if strings.Join(got.Tags, ",") != "literature,nature" {
+		t.Errorf("Create() tags = %v, want [literature nature]", got.Tags)
+	}
+}
+
 func TestWordService_Update(t *testing.T) {
 	svc, cleanup := setupTestService(t)
 	defer cleanup()
@@ -168,6 +294,28 @@ func TestWordService_Update(t *testing.T) {
 	}
 }
 
+func TestWordService_Update_LegacyValues(t *testing.T) {
+	svc, cleanup := setupTestService(t)
+	defer cleanup()
+
+	ctx := context.Background()
+
+	// A word stored before validation, with a date in another format
+	legacy, _ := svc.repo.Create(ctx, &models.Word{Word: "ephemeral", Source: "Book", DateLearned: "15/01/2024", Tags: []string{}})
+
+	newSource := "Article"
+	if _, err := svc.Update(ctx, legacy.ID, &models.UpdateWordRequest{Source: &newSource}); err != nil {
+		t.Errorf("Update() of another field error = %v, want nil", err)
+	}
+
+	badDate, emptySource := "2024/01/15", ""
+	_, err := svc.Update(ctx, legacy.ID, &models.UpdateWordRequest{DateLearned: &badDate, Source: &emptySource})
+	var verrs validation.Errors
+	if !errors.As(err, &verrs) || len(verrs) != 2 || !verrs.Has("date_learned") || !verrs.Has("source") {
+		t.Errorf("Update() error = %v, want date_learned and source errors", err)
+	}
+}
+
 func TestWordService_ImportCSV(t *testing.T) {
 	ctx := context.Background()
 
@@ -195,6 +343,14 @@ ephemeral,,2024-01-15`,
 			wantSkipped:  1,
 			wantErr:      false,
 		},
+		{
+			name: "invalid date",
+			csv: `word,source,date_learned
+ephemeral,Book,January 15`,
+			wantImported: 0,
+			wantSkipped:  1,
+			wantErr:      false,
+		},
 		{
 			name:    "missing required column",
 			csv:     `word,source`,
@@ -303,3 +459,7 @@ func TestWordService_GetRandom(t *testing.T) {
 		t.Error("GetRandom() returned empty word")
 	}
 }
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,145 @@
+// Package stardict writes StarDict dictionaries: an .ifo description, an .idx
+// index of headwords and a .dict file of their definitions, as read by GoldenDict,
+// KOReader and other dictionary programs.
+package stardict
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maxWordLength is the longest headword, in bytes, StarDict readers accept
+const maxWordLength = 255
+
+// Entry is a headword with its definition as HTML
+type Entry struct {
+	Word       string
+	Definition string
+}
+
+// Dictionary is the content of a package
+type Dictionary struct {
+	Name        string // file names and the title shown by readers
+	Description string
+	Entries     []Entry
+}
+
+// ErrTooLarge is returned for dictionaries whose definitions do not fit the 32-bit
+// offsets of the index
+var ErrTooLarge = errors.New("dictionary is larger than 4 GB")
+
+// WritePackage writes a dictionary as a zip holding a folder named after it with
+// the .ifo, .idx and .dict files, to unpack into a reader's dictionary folder.
+// Entries are sorted as StarDict requires; entries with an empty or overlong
+// headword are left out.
+func WritePackage(w io.Writer, dict *Dictionary) error {
+	entries := make([]Entry, 0, len(dict.Entries))
+	for _, entry := range dict.Entries {
+		entry.Word = strings.TrimSpace(entry.Word)
+		if entry.Word != "" && len(entry.Word) <= maxWordLength && !strings.ContainsRune(entry.Word, 0) {
+			entries = append(entries, entry)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return Less(entries[i].Word, entries[j].Word) })
+
+	var idx, data bytes.Buffer
+	for _, entry := range entries {
+		if uint64(data.Len())+uint64(len(entry.Definition)) > math.MaxUint32 {
+			return ErrTooLarge
+		}
+		idx.WriteString(entry.Word)
+		idx.WriteByte(0)
+		binary.Write(&idx, binary.BigEndian, uint32(data.Len()))
+		binary.Write(&idx, binary.BigEndian, uint32(len(entry.Definition)))
+		data.WriteString(entry.Definition)
+	}
+
+	var ifo strings.Builder
+	ifo.WriteString("StarDict's dict ifo file\n")
+	ifo.WriteString("version=2.4.2\n")
+	fmt.Fprintf(&ifo, "wordcount=%d\n", len(entries))
+	fmt.Fprintf(&ifo, "idxfilesize=%d\n", idx.Len())
+	fmt.Fprintf(&ifo, "bookname=%s\n", ifoValue(dict.Name))
+	if dict.Description != "" {
+		fmt.Fprintf(&ifo, "description=%s\n", ifoValue(dict.Description))
+	}
+	fmt.Fprintf(&ifo, "date=%s\n", time.Now().Format("2006.01.02"))
+	// Every definition is a single block of HTML
+	ifo.WriteString("sametypesequence=h\n")
+
+	name := fileName(dict.Name)
+	zw := zip.NewWriter(w)
+	for _, file := range []struct {
+		ext  string
+		data []byte
+	}{
+		{".ifo", []byte(ifo.String())},
+		{".idx", idx.Bytes()},
+		{".dict", data.Bytes()},
+	} {
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: path.Join(name, name+file.ext), Method: zip.Deflate})
+		if err != nil {
+			return fmt.Errorf("failed to write %s%s: %w", name, file.ext, err)
+		}
+		if _, err := f.Write(file.data); err != nil {
+			return fmt.Errorf("failed to write %s%s: %w", name, file.ext, err)
+		}
+	}
+	return zw.Close()
+}
+
+// Less orders headwords as StarDict indexes them: comparing ASCII letters
+// without case, then bytes
+func Less(a, b string) bool {
+	if c := asciiFoldCompare(a, b); c != 0 {
+		return c < 0
+	}
+	return a < b
+}
+
+// asciiFoldCompare compares strings byte by byte with ASCII letters lowercased,
+// like GLib's g_ascii_strcasecmp
+func asciiFoldCompare(a, b string) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		ca, cb := asciiLower(a[i]), asciiLower(b[i])
+		if ca != cb {
+			return int(ca) - int(cb)
+		}
+	}
+	return len(a) - len(b)
+}
+
+func asciiLower(c byte) byte {
+	if 'A' <= c && c <= 'Z' {
+		return c + 'a' - 'A'
+	}
+	return c
+}
+
+// ifoValue keeps a value on its line of the .ifo file
+func ifoValue(s string) string {
+	return strings.NewReplacer("\r\n", "<br>", "\n", "<br>", "\r", "").Replace(s)
+}
+
+// fileName returns a file name for the dictionary
+func fileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`/\:*?"<>|`, r) || r < ' ' {
+			return '-'
+		}
+		return r
+	}, strings.TrimSpace(name))
+	if name == "" {
+		return "dictionary"
+	}
+	return name
+}
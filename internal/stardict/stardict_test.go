@@ -0,0 +1,94 @@
+package stardict
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWritePackage(t *testing.T) {
+	dict := &Dictionary{
+		Name:        "My Words",
+		Description: "Line one\nline two",
+		Entries: []Entry{
+			{Word: "laconic", Definition: "<b>brief</b>"},
+			{Word: "Ephemeral", Definition: "short-lived"},
+			{Word: "apple", Definition: "fruit"},
+			{Word: "  ", Definition: "skipped"},
+			{Word: "éclair", Definition: "pastry"},
+		},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePackage(&buf, dict); err != nil {
+		t.Fatalf("WritePackage() error = %v", err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("WritePackage() did not write a zip: %v", err)
+	}
+	files := make(map[string][]byte)
+	for _, f := range archive.File {
+		rc, _ := f.Open()
+		files[f.Name], _ = io.ReadAll(rc)
+		rc.Close()
+	}
+
+	idx, data := files["My Words/My Words.idx"], files["My Words/My Words.dict"]
+	var words, definitions []string
+	for len(idx) > 0 {
+		end := bytes.IndexByte(idx, 0)
+		if end < 0 || len(idx) < end+9 {
+			t.Fatalf("truncated index entry %q", idx)
+		}
+		offset := binary.BigEndian.Uint32(idx[end+1:])
+		size := binary.BigEndian.Uint32(idx[end+5:])
+		words = append(words, string(idx[:end]))
+		definitions = append(definitions, string(data[offset:offset+size]))
+		idx = idx[end+9:]
+	}
+
+	if got := strings.Join(words, ","); got != "apple,Ephemeral,laconic,éclair" {
+		t.Errorf("index words = %s, want apple,Ephemeral,laconic,éclair", got)
+	}
+	if got := strings.Join(definitions, ","); got != "fruit,short-lived,<b>brief</b>,pastry" {
+		t.Errorf("definitions = %s", got)
+	}
+
+	ifo := string(files["My Words/My Words.ifo"])
+	for _, line := range []string{
+		"StarDict's dict ifo file\nversion=2.4.2\n",
+		"wordcount=4\n",
+		"idxfilesize=" + strconv.Itoa(len(files["My Words/My Words.idx"])) + "\n",
+		"bookname=My Words\n",
+		"description=Line one<br>line two\n",
+		"sametypesequence=h\n",
+	} {
+		if !strings.Contains(ifo, line) {
+			t.Errorf(".ifo = %q, want it to contain %q", ifo, line)
+		}
+	}
+}
+
+func TestLess(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"apple", "Banana", true},
+		{"Apple", "apple", true},
+		{"apple", "Apple", false},
+		{"app", "apple", true},
+		{"zebra", "éclair", true},
+	}
+	for _, tt := range tests {
+		if got := Less(tt.a, tt.b); got != tt.want {
+			t.Errorf("Less(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package textscan
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// ErrNotEPUB is returned for files that are not EPUB books
+var ErrNotEPUB = errors.New("not an EPUB file")
+
+// ErrTooLong is returned for books with more text than EPUBText reads
+var ErrTooLong = errors.New("book text is too long")
+
+// maxEPUBText is the most text, in bytes, EPUBText reads from a book
+const maxEPUBText = 16 << 20
+
+// skippedElements hold no text of the book
+var skippedElements = map[string]bool{"head": true, "script": true, "style": true, "title": true}
+
+// blockElements end a paragraph
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "td": true, "th": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "section": true, "article": true, "aside": true, "pre": true,
+	"dt": true, "dd": true, "figcaption": true, "hr": true,
+}
+
+// container is META-INF/container.xml, which names the package document
+type container struct {
+	Rootfiles []struct {
+		FullPath string `xml:"full-path,attr"`
+	} `xml:"rootfiles>rootfile"`
+}
+
+// packageDocument is the part of the .opf package document that lists the
+// book's files in reading order
+type packageDocument struct {
+	Items []struct {
+		ID        string `xml:"id,attr"`
+		Href      string `xml:"href,attr"`
+		MediaType string `xml:"media-type,attr"`
+	} `xml:"manifest>item"`
+	Spine []struct {
+		IDRef string `xml:"idref,attr"`
+	} `xml:"spine>itemref"`
+}
+
+// EPUBText returns the text of an EPUB book's chapters in reading order, with a
+// blank line after each paragraph, heading and list item
+func EPUBText(r io.ReaderAt, size int64) (string, error) {
+	archive, err := zip.NewReader(r, size)
+	if err != nil {
+		return "", ErrNotEPUB
+	}
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, f := range archive.File {
+		files[f.Name] = f
+	}
+
+	var c container
+	if err := readXML(files, "META-INF/container.xml", &c); err != nil {
+		return "", err
+	}
+	if len(c.Rootfiles) == 0 {
+		return "", fmt.Errorf("%w: container.xml names no package document", ErrNotEPUB)
+	}
+	opfPath := c.Rootfiles[0].FullPath
+
+	var opf packageDocument
+	if err := readXML(files, opfPath, &opf); err != nil {
+		return "", err
+	}
+
+	hrefs := make(map[string]string)
+	for _, item := range opf.Items {
+		if strings.Contains(item.MediaType, "html") {
+			hrefs[item.ID] = item.Href
+		}
+	}
+
+	var b strings.Builder
+	for _, ref := range opf.Spine {
+		href, ok := hrefs[ref.IDRef]
+		if !ok {
+			continue
+		}
+		name, err := url.PathUnescape(strings.SplitN(href, "#", 2)[0])
+		if err != nil {
+			continue
+		}
+		f := files[path.Join(path.Dir(opfPath), name)]
+		if f == nil {
+			continue
+		}
+		if err := chapterText(&b, f); err != nil {
+			return "", err
+		}
+		if b.Len() > maxEPUBText {
+			return "", ErrTooLong
+		}
+	}
+	return b.String(), nil
+}
+
+// readXML decodes a file of the book
+func readXML(files map[string]*zip.File, name string, v any) error {
+	f := files[name]
+	if f == nil {
+		return fmt.Errorf("%w: %s is missing", ErrNotEPUB, name)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	defer rc.Close()
+
+	if err := xml.NewDecoder(rc).Decode(v); err != nil {
+		return fmt.Errorf("failed to read %s: %w", name, err)
+	}
+	return nil
+}
+
+// chapterText appends the text of an XHTML chapter to b. Chapters are read
+// leniently, as HTML, since books are not always well-formed.
+func chapterText(b *strings.Builder, f *zip.File) error {
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	defer rc.Close()
+
+	d := xml.NewDecoder(io.LimitReader(rc, maxEPUBText))
+	d.Strict = false
+	d.AutoClose = xml.HTMLAutoClose
+	d.Entity = xml.HTMLEntity
+
+	skipping := 0
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			name := strings.ToLower(t.Name.Local)
+			if skippedElements[name] {
+				skipping++
+			} else if blockElements[name] && skipping == 0 {
+				b.WriteString("\n\n")
+			}
+		case xml.EndElement:
+			name := strings.ToLower(t.Name.Local)
+			if skippedElements[name] && skipping > 0 {
+				skipping--
+			} else if blockElements[name] && skipping == 0 {
+				b.WriteString("\n\n")
+			}
+		case xml.CharData:
+			if skipping == 0 {
+				b.Write(t)
+			}
+		}
+	}
+	b.WriteString("\n\n")
+	return nil
+}
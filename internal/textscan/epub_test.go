@@ -0,0 +1,74 @@
+package textscan
+
+import (
+	"archive/zip"
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// writeEPUB zips files into an EPUB book
+func writeEPUB(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f.Write([]byte(content))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestEPUBText(t *testing.T) {
+	book := writeEPUB(t, map[string]string{
+		"mimetype": "application/epub+zip",
+		"META-INF/container.xml": `<?xml version="1.0"?>
+<container version="1.0" xmlns="urn:oasis:names:tc:opendocument:xmlns:container">
+  <rootfiles><rootfile full-path="OEBPS/content.opf" media-type="application/oebps-package+xml"/></rootfiles>
+</container>`,
+		"OEBPS/content.opf": `<?xml version="1.0"?>
+<package xmlns="http://www.idpf.org/2007/opf" version="3.0">
+  <manifest>
+    <item id="c2" href="Text/chapter%202.xhtml" media-type="application/xhtml+xml"/>
+    <item id="c1" href="Text/chapter1.xhtml" media-type="application/xhtml+xml"/>
+    <item id="css" href="style.css" media-type="text/css"/>
+  </manifest>
+  <spine><itemref idref="c1"/><itemref idref="css"/><itemref idref="c2"/></spine>
+</package>`,
+		"OEBPS/Text/chapter1.xhtml": `<html><head><title>One</title><style>p {}</style></head>
+<body><h1>Chapter One</h1><p>It was a dark&nbsp;night<br>and cold &amp; wet.</p></body></html>`,
+		"OEBPS/Text/chapter 2.xhtml": `<html><body><p>The end<p>Really</body></html>`,
+		"OEBPS/style.css":            "p { margin: 0 }",
+	})
+
+	text, err := EPUBText(bytes.NewReader(book), int64(len(book)))
+	if err != nil {
+		t.Fatalf("EPUBText() error = %v", err)
+	}
+
+	got := Sentences(text)
+	want := []string{"Chapter One", "It was a dark night", "and cold & wet.", "The end", "Really"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("EPUBText() sentences = %q, want %q", got, want)
+	}
+}
+
+func TestEPUBText_NotEPUB(t *testing.T) {
+	data := []byte("just text")
+	if _, err := EPUBText(bytes.NewReader(data), int64(len(data))); !errors.Is(err, ErrNotEPUB) {
+		t.Errorf("EPUBText() error = %v, want ErrNotEPUB", err)
+	}
+
+	book := writeEPUB(t, map[string]string{"mimetype": "application/epub+zip"})
+	if _, err := EPUBText(bytes.NewReader(book), int64(len(book))); !errors.Is(err, ErrNotEPUB) {
+		t.Errorf("EPUBText() error = %v, want ErrNotEPUB", err)
+	}
+}
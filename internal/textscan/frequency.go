@@ -0,0 +1,44 @@
+package textscan
+
+import (
+	_ "embed"
+	"strings"
+	"sync"
+)
+
+// frequencyList holds the bundled English words, most common first
+//
+//go:embed frequency.txt
+var frequencyList string
+
+var (
+	ranksOnce sync.Once
+	ranks     map[string]int
+)
+
+// Rank returns the position of a lemma in the bundled list of common English
+// words, from 1 for the most common, or 0 when the lemma is not in the list
+func Rank(lemma string) int {
+	ranksOnce.Do(loadRanks)
+	return ranks[strings.ToLower(lemma)]
+}
+
+// ListSize returns the number of words in the bundled frequency list
+func ListSize() int {
+	ranksOnce.Do(loadRanks)
+	return len(ranks)
+}
+
+// loadRanks reads the frequency list, skipping comments and repeated words
+func loadRanks() {
+	ranks = make(map[string]int)
+	for _, line := range strings.Split(frequencyList, "\n") {
+		word := strings.TrimSpace(line)
+		if word == "" || strings.HasPrefix(word, "#") {
+			continue
+		}
+		if _, ok := ranks[word]; !ok {
+			ranks[word] = len(ranks) + 1
+		}
+	}
+}
@@ -0,0 +1,178 @@
+package textscan
+
+import "strings"
+
+// irregular maps irregular inflections to their lemmas
+var irregular = map[string]string{
+	"am": "be", "is": "be", "are": "be", "was": "be", "were": "be", "been": "be", "being": "be",
+	"has": "have", "had": "have", "having": "have",
+	"does": "do", "did": "do", "done": "do", "doing": "do",
+	"went": "go", "gone": "go", "goes": "go",
+	"said": "say", "says": "say", "made": "make", "took": "take", "taken": "take",
+	"came": "come", "saw": "see", "seen": "see", "knew": "know", "known": "know",
+	"got": "get", "gotten": "get", "gave": "give", "given": "give", "found": "find",
+	"thought": "think", "told": "tell", "became": "become", "left": "leave", "felt": "feel",
+	"brought": "bring", "began": "begin", "begun": "begin", "kept": "keep", "held": "hold",
+	"wrote": "write", "written": "write", "stood": "stand", "heard": "hear", "meant": "mean",
+	"met": "meet", "ran": "run", "paid": "pay", "sat": "sit", "spoke": "speak", "spoken": "speak",
+	"lain": "lie", "led": "lead", "grew": "grow", "grown": "grow", "lost": "lose",
+	"fell": "fall", "fallen": "fall", "sent": "send", "built": "build", "understood": "understand",
+	"drew": "draw", "drawn": "draw", "broke": "break", "broken": "break", "spent": "spend",
+	"rose": "rise", "risen": "rise", "drove": "drive", "driven": "drive", "bought": "buy",
+	"wore": "wear", "worn": "wear", "chose": "choose", "chosen": "choose", "sought": "seek",
+	"threw": "throw", "thrown": "throw", "caught": "catch", "dealt": "deal", "won": "win",
+	"fought": "fight", "taught": "teach", "ate": "eat", "eaten": "eat", "sang": "sing", "sung": "sing",
+	"flew": "fly", "flown": "fly", "forgot": "forget", "forgotten": "forget", "hid": "hide",
+	"hidden": "hide", "slept": "sleep", "swam": "swim", "swum": "swim", "woke": "wake",
+	"woken": "wake", "sold": "sell", "shook": "shake", "shaken": "shake", "struck": "strike",
+	"stole": "steal", "stolen": "steal", "bore": "bear", "borne": "bear", "tore": "tear",
+	"torn": "tear", "froze": "freeze", "frozen": "freeze", "bit": "bite", "bitten": "bite",
+	"fed": "feed", "fled": "flee", "hung": "hang", "laid": "lay", "lit": "light", "rode": "ride",
+	"ridden": "ride", "shot": "shoot", "shone": "shine", "sank": "sink", "sunk": "sink",
+	"slid": "slide", "spun": "spin", "stuck": "stick", "swore": "swear", "sworn": "swear",
+	"swept": "sweep", "swung": "swing", "wept": "weep", "wound": "wind", "dug": "dig",
+	"drank": "drink", "drunk": "drink", "lent": "lend", "rang": "ring", "rung": "ring",
+	"children": "child", "men": "man", "women": "woman", "people": "person", "feet": "foot",
+	"teeth": "tooth", "mice": "mouse", "geese": "goose", "lives": "life", "wives": "wife",
+	"knives": "knife", "leaves": "leaf", "halves": "half", "selves": "self", "wolves": "wolf",
+	"loaves": "loaf", "thieves": "thief", "shelves": "shelf", "calves": "calf", "hooves": "hoof",
+	"better": "good", "best": "good", "worse": "bad", "worst": "bad",
+	"further": "far", "furthest": "far", "farther": "far", "farthest": "far",
+}
+
+// Lemma returns the dictionary form of a lowercase word, such as "run" for
+// "running". Words are reduced by their common English inflections, preferring a
+// form in the bundled frequency list; words it cannot reduce are returned as is.
+func Lemma(word string) string {
+	return lemmatize(word, func(string) bool { return false })
+}
+
+// lemmatize reduces a word like Lemma, also accepting the forms for which seen
+// returns true, such as the other words of a text
+func lemmatize(word string, seen func(string) bool) string {
+	if lemma, ok := irregular[word]; ok {
+		return lemma
+	}
+	if Rank(word) > 0 {
+		return word
+	}
+
+	candidates := lemmaCandidates(word)
+	for _, candidate := range candidates {
+		if Rank(candidate) > 0 {
+			return candidate
+		}
+	}
+	for _, candidate := range candidates {
+		if seen(candidate) {
+			return candidate
+		}
+	}
+	return guessLemma(word)
+}
+
+// lemmaCandidates lists the forms a word may be an inflection of, most likely first
+func lemmaCandidates(word string) []string {
+	var candidates []string
+	add := func(stem, suffix string) {
+		if len(stem)+len(suffix) >= 3 {
+			candidates = append(candidates, stem+suffix)
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(word, "ies"):
+		add(strings.TrimSuffix(word, "ies"), "y")
+		add(strings.TrimSuffix(word, "s"), "")
+	case strings.HasSuffix(word, "es"):
+		add(strings.TrimSuffix(word, "s"), "")
+		add(strings.TrimSuffix(word, "es"), "")
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		add(strings.TrimSuffix(word, "s"), "")
+	}
+
+	for _, suffix := range []string{"ed", "ing", "er", "est"} {
+		if !strings.HasSuffix(word, suffix) {
+			continue
+		}
+		stem := strings.TrimSuffix(word, suffix)
+		if suffix != "ing" && strings.HasSuffix(stem, "i") {
+			add(strings.TrimSuffix(stem, "i"), "y")
+		}
+		if suffix == "ing" && strings.HasSuffix(stem, "y") {
+			add(strings.TrimSuffix(stem, "y"), "ie")
+		}
+		if doubled(stem) {
+			add(stem[:len(stem)-1], "")
+		}
+		add(stem, "")
+		add(stem, "e")
+	}
+
+	// Adverbs count as known when their adjective is
+	if stem, ok := strings.CutSuffix(word, "ly"); ok {
+		if strings.HasSuffix(stem, "i") {
+			add(strings.TrimSuffix(stem, "i"), "y")
+		}
+		add(stem, "")
+		add(stem, "le")
+	}
+	return candidates
+}
+
+// guessLemma reduces a word that is not in the frequency list by the usual
+// spelling of English inflections
+func guessLemma(word string) string {
+	n := len(word)
+	switch {
+	case n > 4 && strings.HasSuffix(word, "ies"):
+		return word[:n-3] + "y"
+	case n > 5 && hasAnySuffix(word, "sses", "ches", "shes", "xes", "zzes"):
+		return word[:n-2]
+	case n > 4 && strings.HasSuffix(word, "s") && !hasAnySuffix(word, "ss", "us", "is", "ics"):
+		return word[:n-1]
+	case n > 5 && strings.HasSuffix(word, "ied"):
+		return word[:n-3] + "y"
+	case n > 5 && strings.HasSuffix(word, "ed"):
+		return restoreStem(word[:n-2])
+	case n > 6 && strings.HasSuffix(word, "ing"):
+		return restoreStem(word[:n-3])
+	}
+	return word
+}
+
+// restoreStem undoes the spelling changes of -ed and -ing: "stopp" becomes
+// "stop" and "obfuscat" becomes "obfuscate"
+func restoreStem(stem string) string {
+	if doubled(stem) {
+		return stem[:len(stem)-1]
+	}
+	if hasAnySuffix(stem, "at", "iz", "yz", "is", "ir", "ur", "v", "c", "rg", "dg", "ut") ||
+		(strings.HasSuffix(stem, "l") && len(stem) > 2 && strings.IndexByte("bcdfgkpstz", stem[len(stem)-2]) >= 0) {
+		return stem + "e"
+	}
+	return stem
+}
+
+// doubled reports whether a stem ends in a consonant doubled before -ed or -ing,
+// as in "stopp" or "travell", but not one that is always double, as in "miss"
+func doubled(stem string) bool {
+	n := len(stem)
+	if n < 3 || stem[n-1] != stem[n-2] || isVowel(stem[n-1]) {
+		return false
+	}
+	return !strings.ContainsRune("sfz", rune(stem[n-1]))
+}
+
+func isVowel(c byte) bool {
+	return strings.IndexByte("aeiou", c) >= 0
+}
+
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,239 @@
+// Package textscan finds the words of an English text with their dictionary forms
+// and how common they are, to pick out the ones a reader may not know.
+package textscan
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// MinWordLength is the shortest word, in letters, that Analyze reports
+const MinWordLength = 3
+
+// maxContext is the longest context, in runes, kept for a term
+const maxContext = 300
+
+// abbreviations are words that end with a period without ending a sentence
+var abbreviations = map[string]bool{
+	"mr": true, "mrs": true, "ms": true, "dr": true, "st": true, "jr": true, "sr": true,
+	"prof": true, "rev": true, "gen": true, "col": true, "capt": true, "lt": true, "sgt": true,
+	"mt": true, "vs": true, "etc": true, "no": true, "vol": true, "ch": true, "fig": true,
+	"e.g": true, "i.e": true, "cf": true, "approx": true, "inc": true, "ltd": true, "co": true,
+}
+
+// Term is a word of a text in its dictionary form
+type Term struct {
+	Lemma   string
+	Forms   []string // the lowercase forms the word takes in the text, in order of appearance
+	Count   int      // how often the word appears in any form
+	Rank    int      // the word's position in the frequency list; 0 when it is not in it
+	Context string   // the sentence the word first appears in, shortened when long
+}
+
+// Analysis is the result of Analyze
+type Analysis struct {
+	Words int     // the number of words in the text, including short ones
+	Terms []*Term // in order of first appearance
+}
+
+// token is a word of a sentence
+type token struct {
+	text     string // as written, without a possessive 's
+	sentence int
+	offset   int  // in runes from the start of the sentence
+	first    bool // the token starts its sentence
+}
+
+// Analyze splits a text into sentences and words and groups the words by lemma.
+// Words shorter than MinWordLength, contractions, abbreviations and names (words that are
+// capitalized in the middle of sentences and never written in lowercase) are
+// counted but not reported.
+func Analyze(text string) *Analysis {
+	sentences := Sentences(text)
+	analysis := &Analysis{}
+
+	var tokens []token
+	forms := make(map[string]bool)
+	for i, sentence := range sentences {
+		for j, tok := range words(sentence) {
+			analysis.Words++
+			tok.sentence, tok.first = i, j == 0
+			if strings.ContainsAny(tok.text, "'’") || len([]rune(tok.text)) < MinWordLength ||
+				abbreviations[strings.ToLower(tok.text)] {
+				continue
+			}
+			tokens = append(tokens, tok)
+			forms[strings.ToLower(tok.text)] = true
+		}
+	}
+
+	seen := func(form string) bool { return forms[form] }
+	lemmas := make(map[string]string)
+	terms := make(map[string]*Term)
+	lowercase := make(map[string]bool) // the lemma is written in lowercase
+	name := make(map[string]bool)      // the lemma is capitalized in the middle of a sentence
+	for _, tok := range tokens {
+		form := strings.ToLower(tok.text)
+		lemma, ok := lemmas[form]
+		if !ok {
+			lemma = lemmatize(form, seen)
+			lemmas[form] = lemma
+		}
+
+		term := terms[lemma]
+		if term == nil {
+			term = &Term{Lemma: lemma, Rank: Rank(lemma), Context: context(sentences[tok.sentence], tok.offset)}
+			terms[lemma] = term
+			analysis.Terms = append(analysis.Terms, term)
+		}
+		term.Count++
+		if !slices.Contains(term.Forms, form) {
+			term.Forms = append(term.Forms, form)
+		}
+
+		if tok.text == form {
+			lowercase[lemma] = true
+		} else if !tok.first {
+			name[lemma] = true
+		}
+	}
+
+	kept := analysis.Terms[:0]
+	for _, term := range analysis.Terms {
+		if lowercase[term.Lemma] || !name[term.Lemma] {
+			kept = append(kept, term)
+		}
+	}
+	analysis.Terms = kept
+	return analysis
+}
+
+// Sentences splits text into sentences, each on a single line. Paragraphs, set
+// apart by blank lines, always end a sentence.
+func Sentences(text string) []string {
+	var sentences []string
+	for _, paragraph := range paragraphs(text) {
+		runes := []rune(strings.Join(strings.Fields(paragraph), " "))
+		start := 0
+		for i := 0; i < len(runes); i++ {
+			if !strings.ContainsRune(".!?…", runes[i]) {
+				continue
+			}
+			end := i + 1
+			for end < len(runes) && strings.ContainsRune(".!?…\"')]”’", runes[end]) {
+				end++
+			}
+			if end < len(runes) && runes[end] != ' ' {
+				continue
+			}
+			// A quotation can end with a question that does not end the sentence
+			if end+1 < len(runes) && unicode.IsLower(runes[end+1]) {
+				continue
+			}
+			if runes[i] == '.' && abbreviated(runes[start:i]) {
+				continue
+			}
+			if sentence := strings.TrimSpace(string(runes[start:end])); sentence != "" {
+				sentences = append(sentences, sentence)
+			}
+			start, i = end, end
+		}
+		if sentence := strings.TrimSpace(string(runes[start:])); sentence != "" {
+			sentences = append(sentences, sentence)
+		}
+	}
+	return sentences
+}
+
+// paragraphs splits text at blank lines
+func paragraphs(text string) []string {
+	var result []string
+	var current strings.Builder
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			if current.Len() > 0 {
+				result = append(result, current.String())
+				current.Reset()
+			}
+			continue
+		}
+		current.WriteString(line)
+		current.WriteByte('\n')
+	}
+	if current.Len() > 0 {
+		result = append(result, current.String())
+	}
+	return result
+}
+
+// abbreviated reports whether the text before a period ends with an abbreviation
+// or an initial, as in "Mr." or "J."
+func abbreviated(before []rune) bool {
+	start := len(before)
+	for start > 0 && before[start-1] != ' ' {
+		start--
+	}
+	word := strings.ToLower(strings.TrimLeft(string(before[start:]), "\"'(“‘"))
+	if len([]rune(word)) == 1 {
+		return unicode.IsLetter([]rune(word)[0])
+	}
+	return abbreviations[word]
+}
+
+// words returns the words of a sentence: runs of letters, joined by apostrophes
+// within them. Possessive endings are dropped.
+func words(sentence string) []token {
+	var tokens []token
+	runes := []rune(sentence)
+	for i := 0; i < len(runes); {
+		if !unicode.IsLetter(runes[i]) {
+			i++
+			continue
+		}
+		start := i
+		for i < len(runes) && (unicode.IsLetter(runes[i]) || apostrophe(runes, i)) {
+			i++
+		}
+		text := string(runes[start:i])
+		for _, possessive := range []string{"'s", "’s"} {
+			text = strings.TrimSuffix(text, possessive)
+		}
+		tokens = append(tokens, token{text: text, offset: start})
+	}
+	return tokens
+}
+
+// apostrophe reports whether the rune at i is an apostrophe between two letters
+func apostrophe(runes []rune, i int) bool {
+	return (runes[i] == '\'' || runes[i] == '’') && i > 0 && i+1 < len(runes) &&
+		unicode.IsLetter(runes[i-1]) && unicode.IsLetter(runes[i+1])
+}
+
+// context returns a sentence, or the part of a long sentence around the word at
+// offset, cut at spaces and marked with ellipses
+func context(sentence string, offset int) string {
+	runes := []rune(sentence)
+	if len(runes) <= maxContext {
+		return sentence
+	}
+
+	start := max(0, offset-maxContext/2)
+	end := min(len(runes), start+maxContext)
+	start = max(0, end-maxContext)
+	for start > 0 && start < offset && runes[start-1] != ' ' {
+		start++
+	}
+	for end < len(runes) && end > offset && runes[end] != ' ' {
+		end--
+	}
+
+	result := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		result = "…" + result
+	}
+	if end < len(runes) {
+		result += "…"
+	}
+	return result
+}
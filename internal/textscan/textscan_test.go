@@ -0,0 +1,116 @@
+package textscan
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSentences(t *testing.T) {
+	text := "Mr. Darcy walked in. \"Is it late?\" she asked!\nHe said no.\n\nChapter Two\nThe end"
+	want := []string{
+		"Mr. Darcy walked in.",
+		"\"Is it late?\" she asked!",
+		"He said no.",
+		"Chapter Two The end",
+	}
+	if got := Sentences(text); !reflect.DeepEqual(got, want) {
+		t.Errorf("Sentences() = %q, want %q", got, want)
+	}
+}
+
+func TestLemma(t *testing.T) {
+	tests := map[string]string{
+		"running":     "run",
+		"houses":      "house",
+		"studies":     "study",
+		"children":    "child",
+		"went":        "go",
+		"happier":     "happy",
+		"bigger":      "big",
+		"obfuscated":  "obfuscate",
+		"stopped":     "stop",
+		"patrolled":   "patrol",
+		"ephemeral":   "ephemeral",
+		"lavender":    "lavender",
+		"analysis":    "analysis",
+		"mellifluous": "mellifluous",
+		"quibbles":    "quibble",
+		"lying":       "lie",
+		"rarely":      "rare",
+	}
+	for word, want := range tests {
+		if got := Lemma(word); got != want {
+			t.Errorf("Lemma(%q) = %q, want %q", word, got, want)
+		}
+	}
+}
+
+func TestRank(t *testing.T) {
+	if Rank("the") != 1 {
+		t.Errorf("Rank(the) = %d, want 1", Rank("the"))
+	}
+	if Rank("house") == 0 || Rank("house") > ListSize() {
+		t.Errorf("Rank(house) = %d, want a rank in the list", Rank("house"))
+	}
+	if Rank("ephemeral") != 0 {
+		t.Errorf("Rank(ephemeral) = %d, want 0", Rank("ephemeral"))
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	text := "The obfuscated answer puzzled Elizabeth. Obfuscating it further, Darcy's " +
+		"reply was laconic and didn't help. Elizabeth obfuscates nothing."
+
+	analysis := Analyze(text)
+	if analysis.Words != 18 {
+		t.Errorf("Words = %d, want 18", analysis.Words)
+	}
+
+	terms := make(map[string]*Term)
+	for _, term := range analysis.Terms {
+		terms[term.Lemma] = term
+	}
+
+	obfuscate := terms["obfuscate"]
+	if obfuscate == nil {
+		t.Fatalf("Analyze() terms = %v, want obfuscate", terms)
+	}
+	if obfuscate.Count != 3 || !reflect.DeepEqual(obfuscate.Forms, []string{"obfuscated", "obfuscating", "obfuscates"}) {
+		t.Errorf("obfuscate = %+v", obfuscate)
+	}
+	if obfuscate.Rank != 0 || obfuscate.Context != "The obfuscated answer puzzled Elizabeth." {
+		t.Errorf("obfuscate = %+v", obfuscate)
+	}
+	if terms["answer"] == nil || terms["answer"].Rank == 0 {
+		t.Errorf("answer = %+v, want a ranked term", terms["answer"])
+	}
+	if terms["laconic"] == nil || !strings.Contains(terms["laconic"].Context, "reply was laconic") {
+		t.Errorf("laconic = %+v", terms["laconic"])
+	}
+
+	for _, skipped := range []string{"elizabeth", "darcy", "didn", "didn't", "it"} {
+		if terms[skipped] != nil {
+			t.Errorf("Analyze() should skip %q", skipped)
+		}
+	}
+}
+
+func TestAnalyze_LongContext(t *testing.T) {
+	text := strings.Repeat("word ", 100) + "sesquipedalian " + strings.Repeat("word ", 100) + "end."
+
+	for _, term := range Analyze(text).Terms {
+		if term.Lemma != "sesquipedalian" {
+			continue
+		}
+		if n := len([]rune(term.Context)); n > maxContext+2 {
+			t.Errorf("context has %d runes, want at most %d", n, maxContext+2)
+		}
+		if !strings.HasPrefix(term.Context, "…word") || !strings.HasSuffix(term.Context, "word…") ||
+			!strings.Contains(term.Context, " sesquipedalian ") {
+			t.Errorf("context = %q", term.Context)
+		}
+		return
+	}
+	t.Error("Analyze() did not find sesquipedalian")
+}
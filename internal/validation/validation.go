@@ -0,0 +1,606 @@
+package validation
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/lehmann314159/vocabulator/internal/dsv"
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+// DateFormat is the layout used for date_learned values
+const DateFormat = "2006-01-02"
+
+// Field length limits
+const (
+	MaxWordLength            = 100
+	MaxSourceLength          = 200
+	MaxExampleSentenceLength = 1000
+	MaxTagLength             = 50
+	MaxTags                  = 20
+	MaxCustomValueLength     = 500
+	MaxFieldNameLength       = 50
+	MaxSavedSearchNameLength = 100
+	MaxImportProfileName     = 100
+)
+
+// Error codes returned in FieldError.Code
+const (
+	CodeRequired      = "required"
+	CodeInvalidFormat = "invalid_format"
+	CodeFutureDate    = "future_date"
+	CodeTooLong       = "too_long"
+	CodeTooMany       = "too_many"
+	CodeInvalidValue  = "invalid_value"
+	CodeDuplicate     = "duplicate"
+	CodeUnknownField  = "unknown_field"
+	CodeTooLarge      = "too_large"
+	CodeUnsupported   = "unsupported_type"
+	CodeInUse         = "in_use"
+)
+
+// PartsOfSpeech lists the accepted part_of_speech values
+var PartsOfSpeech = []string{
+	"noun",
+	"verb",
+	"adjective",
+	"adverb",
+	"pronoun",
+	"preposition",
+	"conjunction",
+	"interjection",
+}
+
+// SortFields lists the accepted sort keys; any of them may be prefixed with "-" to sort descending
+var SortFields = []string{
+	"word",
+	"date_learned",
+	"created_at",
+	"updated_at",
+	"length",
+	"difficulty",
+}
+
+// PresenceFields lists the optional word attributes a filter can require or exclude
+var PresenceFields = []string{
+	"example",
+	"pos",
+	"tags",
+}
+
+// ImportTargets lists the word fields a column of an imported file can be mapped
+// to, besides the names of custom fields
+var ImportTargets = []string{
+	"word",
+	"source",
+	"date_learned",
+	"part_of_speech",
+	"example_sentence",
+	"tags",
+}
+
+// dateTokens maps the tokens of a date format such as MM/DD/YYYY to the parts of a
+// Go time layout, longest first so that MMMM is not read as MM twice
+var dateTokens = []struct{ token, layout string }{
+	{"YYYY", "2006"},
+	{"YY", "06"},
+	{"MMMM", "January"},
+	{"MMM", "Jan"},
+	{"MM", "01"},
+	{"M", "1"},
+	{"DD", "02"},
+	{"D", "2"},
+}
+
+// FieldError describes a validation failure for a single field
+type FieldError struct {
+	Field    string `json:"field"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+	Position int    `json:"position,omitempty"` // 1-based position in the value, for search syntax errors
+}
+
+// Errors is a list of field errors; it implements the error interface
+type Errors []FieldError
+
+// Error joins the individual messages
+func (e Errors) Error() string {
+	messages := make([]string, len(e))
+	for i, fe := range e {
+		messages[i] = fe.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+// Add appends a field error
+func (e *Errors) Add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+// Has reports whether there is an error for the given field
+func (e Errors) Has(field string) bool {
+	return e.For(field) != ""
+}
+
+// For returns the message of the first error for the given field
+func (e Errors) For(field string) string {
+	for _, fe := range e {
+		if fe.Field == field {
+			return fe.Message
+		}
+	}
+	return ""
+}
+
+// Err returns nil when there are no errors, so callers can return it directly
+func (e Errors) Err() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
+
+// Duplicate builds the error returned when a word already exists
+func Duplicate(word string) Errors {
+	return Errors{{Field: "word", Code: CodeDuplicate, Message: fmt.Sprintf("word '%s' already exists", word)}}
+}
+
+// NormalizeWord trims text fields, lowercases the part of speech and normalizes tags
+func NormalizeWord(w *models.Word) {
+	w.Word = strings.TrimSpace(w.Word)
+	w.Source = strings.TrimSpace(w.Source)
+	w.DateLearned = strings.TrimSpace(w.DateLearned)
+
+	if w.PartOfSpeech != nil {
+		pos := strings.ToLower(strings.TrimSpace(*w.PartOfSpeech))
+		w.PartOfSpeech = &pos
+	}
+	if w.ExampleSentence != nil {
+		ex := strings.TrimSpace(*w.ExampleSentence)
+		w.ExampleSentence = &ex
+	}
+
+	w.Tags = NormalizeTags(w.Tags)
+
+	for name, value := range w.CustomFields {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			delete(w.CustomFields, name)
+			continue
+		}
+		w.CustomFields[name] = value
+	}
+}
+
+// NormalizeTags trims and lowercases tags, dropping empty and duplicate entries
+func NormalizeTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// ValidateWord checks a normalized word, including its custom field values against
+// the given definitions, and returns Errors, or nil if it is valid
+func ValidateWord(w *models.Word, fields []*models.CustomField) error {
+	var errs Errors
+
+	if w.Word == "" {
+		errs.Add("word", CodeRequired, "word is required")
+	} else if utf8.RuneCountInString(w.Word) > MaxWordLength {
+		errs.Add("word", CodeTooLong, fmt.Sprintf("word must be at most %d characters", MaxWordLength))
+	}
+
+	if w.Source == "" {
+		errs.Add("source", CodeRequired, "source is required")
+	} else if utf8.RuneCountInString(w.Source) > MaxSourceLength {
+		errs.Add("source", CodeTooLong, fmt.Sprintf("source must be at most %d characters", MaxSourceLength))
+	}
+
+	validateDate(&errs, w.DateLearned)
+
+	if w.PartOfSpeech != nil && *w.PartOfSpeech != "" && !IsPartOfSpeech(*w.PartOfSpeech) {
+		errs.Add("part_of_speech", CodeInvalidValue,
+			fmt.Sprintf("part_of_speech must be one of: %s", strings.Join(PartsOfSpeech, ", ")))
+	}
+
+	if w.ExampleSentence != nil && utf8.RuneCountInString(*w.ExampleSentence) > MaxExampleSentenceLength {
+		errs.Add("example_sentence", CodeTooLong,
+			fmt.Sprintf("example_sentence must be at most %d characters", MaxExampleSentenceLength))
+	}
+
+	if len(w.Tags) > MaxTags {
+		errs.Add("tags", CodeTooMany, fmt.Sprintf("at most %d tags are allowed", MaxTags))
+	}
+	for _, tag := range w.Tags {
+		if utf8.RuneCountInString(tag) > MaxTagLength {
+			errs.Add("tags", CodeTooLong, fmt.Sprintf("tag '%s' must be at most %d characters", tag, MaxTagLength))
+		}
+	}
+
+	errs = append(errs, validateCustomFields(w.CustomFields, fields)...)
+
+	return errs.Err()
+}
+
+// ValidateWordUpdate checks a normalized word after a partial update, reporting only
+// errors for the fields the update set, named as in FieldError.Field. Values already
+// stored, such as a date_learned saved before validation existed, do not block an
+// update that leaves them alone.
+func ValidateWordUpdate(w *models.Word, fields []*models.CustomField, set []string) error {
+	var errs Errors
+	if all, ok := ValidateWord(w, fields).(Errors); ok {
+		for _, fe := range all {
+			if contains(set, fe.Field) {
+				errs = append(errs, fe)
+			}
+		}
+	}
+	return errs.Err()
+}
+
+// validateDate checks that date_learned is a YYYY-MM-DD date that is not in the future
+func validateDate(errs *Errors, date string) {
+	if date == "" {
+		errs.Add("date_learned", CodeRequired, "date_learned is required")
+		return
+	}
+
+	parsed, err := time.Parse(DateFormat, date)
+	if err != nil {
+		errs.Add("date_learned", CodeInvalidFormat, "date_learned must be in YYYY-MM-DD format")
+		return
+	}
+
+	if parsed.After(Today()) {
+		errs.Add("date_learned", CodeFutureDate, "date_learned cannot be in the future")
+	}
+}
+
+// IsDate reports whether s is a valid YYYY-MM-DD date
+func IsDate(s string) bool {
+	_, err := time.Parse(DateFormat, s)
+	return err == nil
+}
+
+// IsPartOfSpeech reports whether pos is in the allowed vocabulary
+func IsPartOfSpeech(pos string) bool {
+	return contains(PartsOfSpeech, pos)
+}
+
+// IsPresenceField reports whether name is an attribute accepted by has:
+func IsPresenceField(name string) bool {
+	return contains(PresenceFields, name)
+}
+
+// Today returns the current local date at midnight UTC, for comparison with parsed dates
+func Today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// ValidateFilter checks the date bounds, presence attributes and sort key of a list filter
+func ValidateFilter(filter models.WordFilter) error {
+	var errs Errors
+	if filter.FromDate != "" && !IsDate(filter.FromDate) {
+		errs.Add("from_date", CodeInvalidFormat, "from_date must be in YYYY-MM-DD format")
+	}
+	if filter.ToDate != "" && !IsDate(filter.ToDate) {
+		errs.Add("to_date", CodeInvalidFormat, "to_date must be in YYYY-MM-DD format")
+	}
+	for _, name := range append(append([]string{}, filter.Has...), filter.Missing...) {
+		if !IsPresenceField(name) {
+			errs.Add("has", CodeInvalidValue,
+				fmt.Sprintf("'%s' is not one of: %s", name, strings.Join(PresenceFields, ", ")))
+		}
+	}
+	if filter.Sort != "" && !IsSortField(filter.Sort) {
+		errs.Add("sort", CodeInvalidValue,
+			fmt.Sprintf("sort must be one of: %s, optionally prefixed with -", strings.Join(SortFields, ", ")))
+	}
+	return errs.Err()
+}
+
+// IsSortField reports whether sort is an accepted sort key, with or without a "-" prefix
+func IsSortField(sort string) bool {
+	return contains(SortFields, strings.TrimPrefix(sort, "-"))
+}
+
+// fieldNamePattern restricts custom field names to identifiers usable as CSV columns and query parameters
+var fieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// reservedFieldNames are the built-in word columns a custom field may not shadow
+var reservedFieldNames = map[string]bool{
+	"id": true, "word": true, "source": true, "date_learned": true, "part_of_speech": true,
+	"example_sentence": true, "tags": true, "created_at": true, "updated_at": true,
+}
+
+// NormalizeField trims a custom field definition and defaults its label to its name
+func NormalizeField(f *models.CustomField) {
+	f.Name = strings.ToLower(strings.TrimSpace(f.Name))
+	f.Label = strings.TrimSpace(f.Label)
+	if f.Label == "" {
+		f.Label = f.Name
+	}
+	f.Type = models.FieldType(strings.ToLower(strings.TrimSpace(string(f.Type))))
+
+	options := make([]string, 0, len(f.Options))
+	seen := make(map[string]bool)
+	for _, opt := range f.Options {
+		opt = strings.TrimSpace(opt)
+		if opt == "" || seen[opt] {
+			continue
+		}
+		seen[opt] = true
+		options = append(options, opt)
+	}
+	f.Options = options
+}
+
+// ValidateField checks a normalized custom field definition
+func ValidateField(f *models.CustomField) error {
+	var errs Errors
+
+	switch {
+	case f.Name == "":
+		errs.Add("name", CodeRequired, "name is required")
+	case len(f.Name) > MaxFieldNameLength:
+		errs.Add("name", CodeTooLong, fmt.Sprintf("name must be at most %d characters", MaxFieldNameLength))
+	case !fieldNamePattern.MatchString(f.Name):
+		errs.Add("name", CodeInvalidFormat, "name must start with a letter and contain only a-z, 0-9 and _")
+	case reservedFieldNames[f.Name]:
+		errs.Add("name", CodeInvalidValue, fmt.Sprintf("name '%s' is reserved", f.Name))
+	}
+
+	switch f.Type {
+	case models.FieldTypeText, models.FieldTypeNumber, models.FieldTypeDate:
+		if len(f.Options) > 0 {
+			errs.Add("options", CodeInvalidValue, "options are only allowed for enum fields")
+		}
+	case models.FieldTypeEnum:
+		if len(f.Options) == 0 {
+			errs.Add("options", CodeRequired, "enum fields need at least one option")
+		}
+	case "":
+		errs.Add("type", CodeRequired, "type is required")
+	default:
+		errs.Add("type", CodeInvalidValue, "type must be one of: text, number, enum, date")
+	}
+
+	return errs.Err()
+}
+
+// validateCustomFields checks normalized custom field values against their definitions
+func validateCustomFields(values map[string]string, defs []*models.CustomField) Errors {
+	var errs Errors
+
+	byName := make(map[string]*models.CustomField, len(defs))
+	for _, def := range defs {
+		byName[def.Name] = def
+	}
+
+	// Sort names so errors come back in a stable order
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := values[name]
+		key := "custom_fields." + name
+		def, ok := byName[name]
+		if !ok {
+			errs.Add(key, CodeUnknownField, fmt.Sprintf("unknown custom field '%s'", name))
+			continue
+		}
+
+		switch def.Type {
+		case models.FieldTypeNumber:
+			if _, err := strconv.ParseFloat(value, 64); err != nil {
+				errs.Add(key, CodeInvalidFormat, fmt.Sprintf("%s must be a number", name))
+			}
+		case models.FieldTypeDate:
+			if !IsDate(value) {
+				errs.Add(key, CodeInvalidFormat, fmt.Sprintf("%s must be in YYYY-MM-DD format", name))
+			}
+		case models.FieldTypeEnum:
+			if !contains(def.Options, value) {
+				errs.Add(key, CodeInvalidValue, fmt.Sprintf("%s must be one of: %s", name, strings.Join(def.Options, ", ")))
+			}
+		default:
+			if utf8.RuneCountInString(value) > MaxCustomValueLength {
+				errs.Add(key, CodeTooLong, fmt.Sprintf("%s must be at most %d characters", name, MaxCustomValueLength))
+			}
+		}
+	}
+
+	return errs
+}
+
+// contains reports whether list includes s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeSavedSearch trims the name and query of a saved search
+func NormalizeSavedSearch(s *models.SavedSearch) {
+	s.Name = strings.TrimSpace(s.Name)
+	s.Query = strings.TrimSpace(s.Query)
+}
+
+// ValidateSavedSearch checks the name of a saved search and its structured filter;
+// filter errors are reported under filter.<field>
+func ValidateSavedSearch(s *models.SavedSearch) error {
+	var errs Errors
+
+	switch {
+	case s.Name == "":
+		errs.Add("name", CodeRequired, "name is required")
+	case utf8.RuneCountInString(s.Name) > MaxSavedSearchNameLength:
+		errs.Add("name", CodeTooLong, fmt.Sprintf("name must be at most %d characters", MaxSavedSearchNameLength))
+	}
+
+	filterErrs, _ := ValidateFilter(s.Filter).(Errors)
+	for _, e := range filterErrs {
+		e.Field = "filter." + e.Field
+		errs = append(errs, e)
+	}
+
+	return errs.Err()
+}
+
+// DateLayout converts a date format written with the tokens YYYY, YY, MM, M, MMM,
+// MMMM, DD and D, such as MM/DD/YYYY or D. MMMM YYYY, to a Go time layout. The
+// format must have a year, a month and a day.
+func DateLayout(format string) (string, error) {
+	var layout strings.Builder
+	var year, month, day bool
+
+	for rest := format; rest != ""; {
+		matched := false
+		for _, t := range dateTokens {
+			if strings.HasPrefix(rest, t.token) {
+				layout.WriteString(t.layout)
+				year = year || t.token[0] == 'Y'
+				month = month || t.token[0] == 'M'
+				day = day || t.token[0] == 'D'
+				rest = rest[len(t.token):]
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		r, size := utf8.DecodeRuneInString(rest)
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return "", fmt.Errorf("date format %q may only use YYYY, YY, MM, M, MMM, MMMM, DD and D between separators", format)
+		}
+		layout.WriteRune(r)
+		rest = rest[size:]
+	}
+
+	if !year || !month || !day {
+		return "", fmt.Errorf("date format %q must have a year, a month and a day", format)
+	}
+	return layout.String(), nil
+}
+
+// NormalizeImportProfile trims the name, encoding, date format, column mapping and
+// defaults of an import profile, and spells a tab delimiter as a tab
+func NormalizeImportProfile(p *models.ImportProfile) {
+	p.Name = strings.TrimSpace(p.Name)
+	p.Encoding = strings.ToLower(strings.TrimSpace(p.Encoding))
+	p.DateFormat = strings.TrimSpace(p.DateFormat)
+	p.Quote = strings.TrimSpace(p.Quote)
+	if p.Delimiter == `\t` || strings.EqualFold(p.Delimiter, "tab") {
+		p.Delimiter = "\t"
+	}
+
+	columns := make(map[string]string, len(p.Columns))
+	for header, target := range p.Columns {
+		if header = strings.TrimSpace(header); header != "" {
+			columns[header] = strings.TrimSpace(target)
+		}
+	}
+	p.Columns = columns
+
+	defaults := make(map[string]string, len(p.Defaults))
+	for target, value := range p.Defaults {
+		if value = strings.TrimSpace(value); value != "" {
+			defaults[strings.TrimSpace(target)] = value
+		}
+	}
+	p.Defaults = defaults
+}
+
+// ValidateImportProfile checks the name and reading settings of an import profile,
+// and that its columns and defaults name word fields or existing custom fields
+func ValidateImportProfile(p *models.ImportProfile, fields []*models.CustomField) error {
+	var errs Errors
+
+	switch {
+	case p.Name == "":
+		errs.Add("name", CodeRequired, "name is required")
+	case utf8.RuneCountInString(p.Name) > MaxImportProfileName:
+		errs.Add("name", CodeTooLong, fmt.Sprintf("name must be at most %d characters", MaxImportProfileName))
+	}
+
+	delimiter, _ := utf8.DecodeRuneInString(p.Delimiter)
+	if p.Delimiter != "" && (utf8.RuneCountInString(p.Delimiter) != 1 || delimiter == '\n' || delimiter == '\r') {
+		errs.Add("delimiter", CodeInvalidValue, "delimiter must be a single character")
+	}
+	quote, _ := utf8.DecodeRuneInString(p.Quote)
+	switch {
+	case p.Quote == "" || p.Quote == "none":
+	case utf8.RuneCountInString(p.Quote) != 1:
+		errs.Add("quote", CodeInvalidValue, `quote must be a single character or "none"`)
+	case p.Delimiter != "" && quote == delimiter, p.Delimiter == "" && quote == ',':
+		errs.Add("quote", CodeInvalidValue, "quote must differ from the delimiter")
+	}
+
+	if p.Encoding != "" && !contains(dsv.Encodings, p.Encoding) {
+		errs.Add("encoding", CodeInvalidValue, fmt.Sprintf("encoding must be one of: %s", strings.Join(dsv.Encodings, ", ")))
+	}
+	if p.DateFormat != "" {
+		if _, err := DateLayout(p.DateFormat); err != nil {
+			errs.Add("date_format", CodeInvalidFormat, err.Error())
+		}
+	}
+
+	isTarget := func(name string) bool {
+		return contains(ImportTargets, name) || slices.ContainsFunc(fields, func(f *models.CustomField) bool {
+			return f.Name == name
+		})
+	}
+
+	headers := make([]string, 0, len(p.Columns))
+	for header := range p.Columns {
+		headers = append(headers, header)
+	}
+	sort.Strings(headers)
+	for _, header := range headers {
+		if target := p.Columns[header]; target != "" && !isTarget(target) {
+			errs.Add("columns."+header, CodeUnknownField, fmt.Sprintf("'%s' is not a word field or custom field", target))
+		}
+	}
+
+	targets := make([]string, 0, len(p.Defaults))
+	for target := range p.Defaults {
+		targets = append(targets, target)
+	}
+	sort.Strings(targets)
+	for _, target := range targets {
+		switch {
+		case target == "word":
+			errs.Add("defaults.word", CodeInvalidValue, "the word cannot have a default")
+		case !isTarget(target):
+			errs.Add("defaults."+target, CodeUnknownField, fmt.Sprintf("'%s' is not a word field or custom field", target))
+		case target == "date_learned" && !IsDate(p.Defaults[target]):
+			errs.Add("defaults.date_learned", CodeInvalidFormat, "the default date_learned must be in YYYY-MM-DD format")
+		}
+	}
+
+	return errs.Err()
+}
@@ -0,0 +1,241 @@
+package validation
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/lehmann314159/vocabulator/internal/models"
+)
+
+func TestNormalizeTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want []string
+	}{
+		{
+			name: "trim and lowercase",
+			tags: []string{" Latin ", "LEGAL"},
+			want: []string{"latin", "legal"},
+		},
+		{
+			name: "drop empty and duplicate",
+			tags: []string{"latin", "", "  ", "Latin", "legal"},
+			want: []string{"latin", "legal"},
+		},
+		{
+			name: "nil input",
+			tags: nil,
+			want: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NormalizeTags(tt.tags)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NormalizeTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateWord(t *testing.T) {
+	tomorrow := Today().AddDate(0, 0, 1).Format(DateFormat)
+
+	tests := []struct {
+		name      string
+		word      models.Word
+		wantField string
+		wantCode  string
+	}{
+		{
+			name: "valid word",
+			word: models.Word{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15", PartOfSpeech: strPtr("adjective")},
+		},
+		{
+			name:      "missing word",
+			word:      models.Word{Source: "Book", DateLearned: "2024-01-15"},
+			wantField: "word",
+			wantCode:  CodeRequired,
+		},
+		{
+			name:      "word too long",
+			word:      models.Word{Word: strings.Repeat("a", MaxWordLength+1), Source: "Book", DateLearned: "2024-01-15"},
+			wantField: "word",
+			wantCode:  CodeTooLong,
+		},
+		{
+			name:      "bad date format",
+			word:      models.Word{Word: "ephemeral", Source: "Book", DateLearned: "01/15/2024"},
+			wantField: "date_learned",
+			wantCode:  CodeInvalidFormat,
+		},
+		{
+			name:      "future date",
+			word:      models.Word{Word: "ephemeral", Source: "Book", DateLearned: tomorrow},
+			wantField: "date_learned",
+			wantCode:  CodeFutureDate,
+		},
+		{
+			name:      "unknown part of speech",
+			word:      models.Word{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15", PartOfSpeech: strPtr("gerundive")},
+			wantField: "part_of_speech",
+			wantCode:  CodeInvalidValue,
+		},
+		{
+			name:      "tag too long",
+			word:      models.Word{Word: "ephemeral", Source: "Book", DateLearned: "2024-01-15", Tags: []string{strings.Repeat("t", MaxTagLength+1)}},
+			wantField: "tags",
+			wantCode:  CodeTooLong,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateWord(&tt.word, nil)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("ValidateWord() error = %v, want nil", err)
+				}
+				return
+			}
+
+			var errs Errors
+			if !errors.As(err, &errs) {
+				t.Fatalf("ValidateWord() error = %v, want Errors", err)
+			}
+			if errs[0].Field != tt.wantField || errs[0].Code != tt.wantCode {
+				t.Errorf("ValidateWord() = %s/%s, want %s/%s", errs[0].Field, errs[0].Code, tt.wantField, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestNormalizeWord(t *testing.T) {
+	w := &models.Word{
+		Word:         "  ephemeral ",
+		Source:       " Book",
+		PartOfSpeech: strPtr(" Adjective "),
+		Tags:         []string{"Nature", "nature "},
+	}
+	NormalizeWord(w)
+
+	if w.Word != "ephemeral" || w.Source != "Book" {
+		t.Errorf("NormalizeWord() did not trim: %q, %q", w.Word, w.Source)
+	}
+	if *w.PartOfSpeech != "adjective" {
+		t.Errorf("NormalizeWord() part_of_speech = %q, want adjective", *w.PartOfSpeech)
+	}
+	if !reflect.DeepEqual(w.Tags, []string{"nature"}) {
+		t.Errorf("NormalizeWord() tags = %v, want [nature]", w.Tags)
+	}
+}
+
+func TestValidateFilter(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    models.WordFilter
+		wantField string
+	}{
+		{name: "empty filter", filter: models.WordFilter{}},
+		{name: "valid dates and sort", filter: models.WordFilter{FromDate: "2024-01-01", ToDate: "2024-12-31", Sort: "-word"}},
+		{name: "bad from_date", filter: models.WordFilter{FromDate: "2024/01/01"}, wantField: "from_date"},
+		{name: "unknown sort", filter: models.WordFilter{Sort: "popularity"}, wantField: "sort"},
+		{name: "double prefix", filter: models.WordFilter{Sort: "--word"}, wantField: "sort"},
+		{name: "presence", filter: models.WordFilter{Has: []string{"pos"}, Missing: []string{"example", "tags"}}},
+		{name: "unknown presence", filter: models.WordFilter{Missing: []string{"audio"}}, wantField: "has"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateFilter(tt.filter)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("ValidateFilter() error = %v, want nil", err)
+				}
+				return
+			}
+			var errs Errors
+			if !errors.As(err, &errs) || !errs.Has(tt.wantField) {
+				t.Errorf("ValidateFilter() error = %v, want error for %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func TestDateLayout(t *testing.T) {
+	tests := []struct {
+		format  string
+		layout  string
+		wantErr bool
+	}{
+		{format: "YYYY-MM-DD", layout: "2006-01-02"},
+		{format: "MM/DD/YYYY", layout: "01/02/2006"},
+		{format: "D. MMMM YYYY", layout: "2. January 2006"},
+		{format: "DD-MMM-YY", layout: "02-Jan-06"},
+		{format: "M/D/YYYY", layout: "1/2/2006"},
+		{format: "MM/YYYY", wantErr: true},
+		{format: "DD.MM.YYYY hh:mm", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			layout, err := DateLayout(tt.format)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DateLayout() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if layout != tt.layout {
+				t.Errorf("DateLayout() = %q, want %q", layout, tt.layout)
+			}
+		})
+	}
+}
+
+func TestValidateImportProfile(t *testing.T) {
+	fields := []*models.CustomField{{Name: "cefr", Type: models.FieldTypeText}}
+
+	tests := []struct {
+		name      string
+		profile   models.ImportProfile
+		wantField string
+	}{
+		{name: "defaults only", profile: models.ImportProfile{Name: "Plain"}},
+		{name: "full profile", profile: models.ImportProfile{
+			Name: "Team sheet", Columns: map[string]string{"Term": "word", "Level": "cefr", "Notes": ""},
+			Delimiter: "tab", Quote: "'", Encoding: "latin-1", DateFormat: "MM/DD/YYYY",
+			Defaults: map[string]string{"source": "Team sheet", "date_learned": "2024-01-01"},
+		}},
+		{name: "missing name", profile: models.ImportProfile{}, wantField: "name"},
+		{name: "long delimiter", profile: models.ImportProfile{Name: "x", Delimiter: ";;"}, wantField: "delimiter"},
+		{name: "quote is delimiter", profile: models.ImportProfile{Name: "x", Delimiter: ";", Quote: ";"}, wantField: "quote"},
+		{name: "unknown encoding", profile: models.ImportProfile{Name: "x", Encoding: "ebcdic"}, wantField: "encoding"},
+		{name: "bad date format", profile: models.ImportProfile{Name: "x", DateFormat: "MM/YYYY"}, wantField: "date_format"},
+		{name: "unknown column target", profile: models.ImportProfile{Name: "x", Columns: map[string]string{"Level": "level"}}, wantField: "columns.Level"},
+		{name: "word default", profile: models.ImportProfile{Name: "x", Defaults: map[string]string{"word": "x"}}, wantField: "defaults.word"},
+		{name: "bad date default", profile: models.ImportProfile{Name: "x", Defaults: map[string]string{"date_learned": "03/15/2024"}}, wantField: "defaults.date_learned"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			NormalizeImportProfile(&tt.profile)
+			err := ValidateImportProfile(&tt.profile, fields)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Errorf("ValidateImportProfile() error = %v, want nil", err)
+				}
+				return
+			}
+			var errs Errors
+			if !errors.As(err, &errs) || !errs.Has(tt.wantField) {
+				t.Errorf("ValidateImportProfile() error = %v, want error for %s", err, tt.wantField)
+			}
+		})
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_word_field_values_field_value;
+DROP TABLE IF EXISTS word_field_values;
+DROP TABLE IF EXISTS custom_fields;
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_attachments_word_id;
+DROP TABLE IF EXISTS attachments;
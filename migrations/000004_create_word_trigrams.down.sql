@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_word_trigrams_word_id;
+DROP TABLE IF EXISTS word_trigrams;
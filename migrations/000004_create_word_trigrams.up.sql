@@ -0,0 +1,8 @@
+-- Trigram index for fuzzy word suggestions; rows are maintained by the application
+CREATE TABLE IF NOT EXISTS word_trigrams (
+    trigram TEXT NOT NULL,
+    word_id INTEGER NOT NULL REFERENCES words(id) ON DELETE CASCADE,
+    PRIMARY KEY (trigram, word_id)
+) WITHOUT ROWID;
+
+CREATE INDEX IF NOT EXISTS idx_word_trigrams_word_id ON word_trigrams(word_id);
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_words_difficulty;
+DROP INDEX IF EXISTS idx_words_word_length;
+DROP INDEX IF EXISTS idx_words_updated_at;
+DROP INDEX IF EXISTS idx_words_created_at;
+DROP INDEX IF EXISTS idx_words_word_nocase;
+ALTER TABLE words DROP COLUMN lapses;
+ALTER TABLE words DROP COLUMN reviews;
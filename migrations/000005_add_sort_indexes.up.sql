@@ -0,0 +1,12 @@
+-- Flash-card reviews: how often each word was reviewed and how often it was forgotten
+ALTER TABLE words ADD COLUMN reviews INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE words ADD COLUMN lapses INTEGER NOT NULL DEFAULT 0;
+
+-- Indexes backing the sort options on the word list
+CREATE INDEX IF NOT EXISTS idx_words_word_nocase ON words(word COLLATE NOCASE);
+CREATE INDEX IF NOT EXISTS idx_words_created_at ON words(created_at);
+CREATE INDEX IF NOT EXISTS idx_words_updated_at ON words(updated_at);
+CREATE INDEX IF NOT EXISTS idx_words_word_length ON words(length(word));
+
+-- Backs sort=difficulty; the expression must match sortColumns in the repository
+CREATE INDEX IF NOT EXISTS idx_words_difficulty ON words((lapses + 1) * 1000 / (reviews + 2));
@@ -0,0 +1,6 @@
+-- Dictionary lookups cached by word, so definitions can be shown and exported offline
+CREATE TABLE IF NOT EXISTS definitions (
+    word TEXT PRIMARY KEY COLLATE NOCASE,
+    data TEXT NOT NULL,
+    fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,8 @@
+-- Items already imported from external sources, such as Kindle lookups, so that
+-- importing the same file again only adds what is new
+CREATE TABLE IF NOT EXISTS imported_items (
+    origin TEXT NOT NULL,
+    item_key TEXT NOT NULL,
+    imported_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (origin, item_key)
+);
@@ -0,0 +1,13 @@
+-- Import profiles: how to read CSV files laid out differently from the export format
+CREATE TABLE IF NOT EXISTS import_profiles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL UNIQUE,
+    columns TEXT NOT NULL DEFAULT '{}',
+    delimiter TEXT NOT NULL DEFAULT '',
+    quote TEXT NOT NULL DEFAULT '',
+    encoding TEXT NOT NULL DEFAULT '',
+    date_format TEXT NOT NULL DEFAULT '',
+    defaults TEXT NOT NULL DEFAULT '{}',
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);